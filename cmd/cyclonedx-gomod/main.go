@@ -20,9 +20,11 @@ package main
 import (
 	"context"
 	"fmt"
-	"github.com/rs/zerolog"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/CycloneDX/cyclonedx-gomod/internal/cli"
@@ -30,7 +32,10 @@ import (
 )
 
 func main() {
-	err := cli.New().ParseAndRun(context.Background(), os.Args[1:])
+	// Abort running go commands when interrupted, instead of leaving them behind
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := cli.New().ParseAndRun(ctx, os.Args[1:])
+	stop()
 	if err != nil {
 		if _, ok := err.(*options.ValidationError); ok {
 			_, _ = fmt.Fprintln(os.Stderr, err)
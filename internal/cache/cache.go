@@ -0,0 +1,321 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+// Package cache provides a persistent, on-disk cache for results that are expensive to compute,
+// like module hashes, file hashes and license detection results.
+//
+// Contents of Go's module cache are immutable, so module hashes are keyed by the modules' coordinates.
+// Files are keyed by their path, and are only considered cached as long as their size and modification
+// time don't change. License detection results are keyed by the detector itself.
+//
+// All methods may be called on a nil *Cache, in which case nothing is cached.
+// Failures to read from or write to the cache are logged, but never fail the operation itself.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/rs/zerolog"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/gocmd"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/gomod"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/version"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect"
+)
+
+// formatVersion is incremented whenever the format of cache entries changes.
+const formatVersion = "v2"
+
+const (
+	namespaceFileHashes   = "file-hashes"
+	namespaceLicenses     = "licenses"
+	namespaceModuleHashes = "module-hashes"
+)
+
+// Cache is a persistent cache in a directory on disk.
+type Cache struct {
+	logger     zerolog.Logger
+	dir        string
+	gomodcache string
+}
+
+// DefaultDir returns the default cache directory, within the user's cache directory.
+func DefaultDir() (string, error) {
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(userCacheDir, "cyclonedx-gomod"), nil
+}
+
+// Open opens the cache in dir, creating the directory if necessary.
+// When dir is empty, caching is disabled and a nil *Cache is returned.
+func Open(ctx context.Context, logger zerolog.Logger, dir string) (*Cache, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	dir = filepath.Join(dir, formatVersion)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	env, err := gocmd.GetEnv(ctx, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine module cache directory: %w", err)
+	}
+
+	logger.Debug().Str("dir", dir).Msg("using cache")
+
+	return &Cache{
+		logger:     logger,
+		dir:        dir,
+		gomodcache: env["GOMODCACHE"],
+	}, nil
+}
+
+// ModuleHash returns the h1 hash of module, as calculated by gomod.Module.Hash.
+func (c *Cache) ModuleHash(module gomod.Module) (string, error) {
+	key, ok := c.moduleKey(module.Path, module.Version, module.Dir)
+	if !ok {
+		return module.Hash()
+	}
+
+	var h1 string
+	if c.get(namespaceModuleHashes, key, &h1) {
+		return h1, nil
+	}
+
+	h1, err := module.Hash()
+	if err != nil {
+		return "", err
+	}
+	c.put(namespaceModuleHashes, key, h1)
+
+	return h1, nil
+}
+
+// fileHashesEntry holds the hashes of a file, as well as the attributes
+// that were used to determine whether the file has changed since.
+type fileHashesEntry struct {
+	Size    int64                        `json:"size"`
+	ModTime int64                        `json:"modTime"`
+	Hashes  map[cdx.HashAlgorithm]string `json:"hashes"`
+}
+
+// FileHashes calculates hashes of the file at filePath, as sbom.CalculateFileHashes does.
+// Only hashes that haven't been cached for the file's current size and modification time are calculated.
+func (c *Cache) FileHashes(logger zerolog.Logger, filePath string, algos ...cdx.HashAlgorithm) ([]cdx.Hash, error) {
+	if c == nil || len(algos) == 0 {
+		return sbom.CalculateFileHashes(logger, filePath, algos...)
+	}
+
+	absFilePath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+	fileInfo, err := os.Stat(absFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry fileHashesEntry
+	if !c.get(namespaceFileHashes, absFilePath, &entry) ||
+		entry.Size != fileInfo.Size() || entry.ModTime != fileInfo.ModTime().UnixNano() {
+		entry = fileHashesEntry{
+			Size:    fileInfo.Size(),
+			ModTime: fileInfo.ModTime().UnixNano(),
+			Hashes:  make(map[cdx.HashAlgorithm]string),
+		}
+	}
+
+	missingAlgos := make([]cdx.HashAlgorithm, 0, len(algos))
+	for _, algo := range algos {
+		if _, ok := entry.Hashes[algo]; !ok {
+			missingAlgos = append(missingAlgos, algo)
+		}
+	}
+
+	if len(missingAlgos) > 0 {
+		hashes, err := sbom.CalculateFileHashes(logger, absFilePath, missingAlgos...)
+		if err != nil {
+			return nil, err
+		}
+		for _, hash := range hashes {
+			entry.Hashes[hash.Algorithm] = hash.Value
+		}
+		c.put(namespaceFileHashes, absFilePath, entry)
+	}
+
+	hashes := make([]cdx.Hash, 0, len(algos))
+	for _, algo := range algos {
+		hashes = append(hashes, cdx.Hash{Algorithm: algo, Value: entry.Hashes[algo]})
+	}
+
+	return hashes, nil
+}
+
+// LicenseDetector wraps detector, such that detected licenses are cached.
+// Detectors that don't implement licensedetect.CacheableDetector are returned as they are.
+func (c *Cache) LicenseDetector(detector licensedetect.Detector) licensedetect.Detector {
+	cacheableDetector, ok := detector.(licensedetect.CacheableDetector)
+	if c == nil || !ok {
+		return detector
+	}
+
+	return &licenseDetector{
+		cache:    c,
+		detector: cacheableDetector,
+	}
+}
+
+type licenseDetector struct {
+	cache    *Cache
+	detector licensedetect.CacheableDetector
+}
+
+// Detect implements the licensedetect.Detector interface.
+func (d licenseDetector) Detect(path, version, dir string) ([]cdx.License, error) {
+	return d.DetectContext(context.Background(), path, version, dir)
+}
+
+// DetectContext implements the licensedetect.ContextDetector interface.
+func (d licenseDetector) DetectContext(ctx context.Context, path, version, dir string) ([]cdx.License, error) {
+	licenses, _, err := d.DetectExpression(ctx, path, version, dir)
+	return licenses, err
+}
+
+// licensesEntry is a cached license detection result.
+type licensesEntry struct {
+	Licenses   []cdx.License `json:"licenses"`
+	Expression string        `json:"expression,omitempty"`
+}
+
+// DetectExpression implements the licensedetect.ExpressionDetector interface.
+func (d licenseDetector) DetectExpression(ctx context.Context, path, moduleVersion, dir string) ([]cdx.License, string, error) {
+	key, err := d.detector.CacheKey(path, moduleVersion, dir)
+	if err != nil {
+		d.cache.logger.Debug().Err(err).Str("module", path+"@"+moduleVersion).Msg("failed to determine cache key for licenses")
+		return licensedetect.DetectExpression(ctx, d.detector, path, moduleVersion, dir)
+	}
+
+	// Detection logic may change between releases, so results of other versions are not reused.
+	key = version.Info.Version + "|" + key
+
+	var entry licensesEntry
+	if d.cache.get(namespaceLicenses, key, &entry) {
+		return entry.Licenses, entry.Expression, nil
+	}
+
+	entry.Licenses, entry.Expression, err = licensedetect.DetectExpression(ctx, d.detector, path, moduleVersion, dir)
+	if err != nil {
+		return nil, "", err
+	}
+	d.cache.put(namespaceLicenses, key, entry)
+
+	return entry.Licenses, entry.Expression, nil
+}
+
+// moduleKey returns the key for a module in dir.
+// Only modules located in the module cache can be cached.
+func (c *Cache) moduleKey(path, version, dir string) (string, bool) {
+	if c == nil || c.gomodcache == "" || dir == "" || version == "" {
+		return "", false
+	}
+
+	relDir, err := filepath.Rel(c.gomodcache, dir)
+	if err != nil || !filepath.IsLocal(relDir) {
+		return "", false
+	}
+
+	return fmt.Sprintf("%s@%s|%s", path, version, filepath.ToSlash(relDir)), true
+}
+
+// entryPath returns the path of the file that holds the entry for key.
+func (c *Cache) entryPath(namespace, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:])
+
+	return filepath.Join(c.dir, namespace, name[:2], name+".json")
+}
+
+func (c *Cache) get(namespace, key string, value any) bool {
+	entryPath := c.entryPath(namespace, key)
+
+	data, err := os.ReadFile(entryPath)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			c.logger.Warn().Err(err).Str("entry", entryPath).Msg("failed to read cache entry")
+		}
+		return false
+	}
+
+	if err = json.Unmarshal(data, value); err != nil {
+		c.logger.Warn().Err(err).Str("entry", entryPath).Msg("failed to decode cache entry")
+		return false
+	}
+
+	return true
+}
+
+func (c *Cache) put(namespace, key string, value any) {
+	entryPath := c.entryPath(namespace, key)
+
+	if err := c.write(entryPath, value); err != nil {
+		c.logger.Warn().Err(err).Str("entry", entryPath).Msg("failed to write cache entry")
+	}
+}
+
+// write atomically writes value to entryPath, so that concurrent
+// readers never observe partially written entries.
+func (c *Cache) write(entryPath string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(entryPath), 0o755); err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(entryPath), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err = tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), entryPath)
+}
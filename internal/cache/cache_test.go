@@ -0,0 +1,267 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/gomod"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect"
+)
+
+// newTestCache returns a cache in a temporary directory,
+// with a temporary directory serving as module cache.
+func newTestCache(t *testing.T) *Cache {
+	return &Cache{
+		logger:     zerolog.Nop(),
+		dir:        t.TempDir(),
+		gomodcache: t.TempDir(),
+	}
+}
+
+func TestOpen(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		c, err := Open(context.Background(), zerolog.Nop(), "")
+		require.NoError(t, err)
+		require.Nil(t, c)
+	})
+
+	t.Run("Enabled", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "cache")
+
+		c, err := Open(context.Background(), zerolog.Nop(), dir)
+		require.NoError(t, err)
+		require.NotNil(t, c)
+		require.DirExists(t, filepath.Join(dir, formatVersion))
+		require.NotEmpty(t, c.gomodcache)
+	})
+}
+
+func TestCache_FileHashes(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "file.txt")
+	require.NoError(t, os.WriteFile(filePath, []byte("foo"), 0o600))
+
+	t.Run("Nil", func(t *testing.T) {
+		var c *Cache
+		hashes, err := c.FileHashes(zerolog.Nop(), filePath, cdx.HashAlgoSHA256)
+		require.NoError(t, err)
+		require.Equal(t, "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", hashes[0].Value)
+	})
+
+	t.Run("Cached", func(t *testing.T) {
+		c := newTestCache(t)
+
+		hashes, err := c.FileHashes(zerolog.Nop(), filePath, cdx.HashAlgoSHA256)
+		require.NoError(t, err)
+		require.Equal(t, []cdx.Hash{{Algorithm: cdx.HashAlgoSHA256, Value: "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"}}, hashes)
+
+		// Tamper with the entry to prove that hashes are read from the cache
+		var entry fileHashesEntry
+		require.True(t, c.get(namespaceFileHashes, filePath, &entry))
+		entry.Hashes[cdx.HashAlgoSHA256] = "cached"
+		c.put(namespaceFileHashes, filePath, entry)
+
+		hashes, err = c.FileHashes(zerolog.Nop(), filePath, cdx.HashAlgoSHA256, cdx.HashAlgoSHA1)
+		require.NoError(t, err)
+		require.Equal(t, []cdx.Hash{
+			{Algorithm: cdx.HashAlgoSHA256, Value: "cached"},
+			{Algorithm: cdx.HashAlgoSHA1, Value: "0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33"},
+		}, hashes)
+	})
+
+	t.Run("Modified", func(t *testing.T) {
+		c := newTestCache(t)
+
+		_, err := c.FileHashes(zerolog.Nop(), filePath, cdx.HashAlgoSHA256)
+		require.NoError(t, err)
+
+		var entry fileHashesEntry
+		require.True(t, c.get(namespaceFileHashes, filePath, &entry))
+		entry.Hashes[cdx.HashAlgoSHA256] = "cached"
+		c.put(namespaceFileHashes, filePath, entry)
+
+		modTime := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(filePath, modTime, modTime))
+
+		hashes, err := c.FileHashes(zerolog.Nop(), filePath, cdx.HashAlgoSHA256)
+		require.NoError(t, err)
+		require.Equal(t, "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", hashes[0].Value)
+	})
+
+	t.Run("UnsupportedAlgorithm", func(t *testing.T) {
+		c := newTestCache(t)
+
+		_, err := c.FileHashes(zerolog.Nop(), filePath, cdx.HashAlgoStreebog256)
+		require.ErrorContains(t, err, "unsupported hash algorithm")
+	})
+}
+
+func TestCache_ModuleHash(t *testing.T) {
+	newModule := func(t *testing.T, dir string) gomod.Module {
+		require.NoError(t, os.MkdirAll(dir, 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/foo\n"), 0o600))
+		return gomod.Module{Path: "example.com/foo", Version: "v1.0.0", Dir: dir}
+	}
+
+	t.Run("InModuleCache", func(t *testing.T) {
+		c := newTestCache(t)
+		module := newModule(t, filepath.Join(c.gomodcache, "example.com", "foo@v1.0.0"))
+
+		h1, err := c.ModuleHash(module)
+		require.NoError(t, err)
+		wantH1, err := module.Hash()
+		require.NoError(t, err)
+		require.Equal(t, wantH1, h1)
+
+		// Contents of the module cache are immutable, so changes are not expected to be detected
+		require.NoError(t, os.WriteFile(filepath.Join(module.Dir, "foo.go"), []byte("package foo\n"), 0o600))
+		h1, err = c.ModuleHash(module)
+		require.NoError(t, err)
+		require.Equal(t, wantH1, h1)
+	})
+
+	t.Run("OutsideModuleCache", func(t *testing.T) {
+		c := newTestCache(t)
+		module := newModule(t, t.TempDir())
+
+		h1, err := c.ModuleHash(module)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(filepath.Join(module.Dir, "foo.go"), []byte("package foo\n"), 0o600))
+		h1Modified, err := c.ModuleHash(module)
+		require.NoError(t, err)
+		require.NotEqual(t, h1, h1Modified)
+	})
+}
+
+type stubCacheableDetector struct {
+	calls      int
+	key        string
+	keyErr     error
+	licenses   []cdx.License
+	expression string
+}
+
+func (d *stubCacheableDetector) Detect(_, _, _ string) ([]cdx.License, error) {
+	d.calls++
+	return d.licenses, nil
+}
+
+func (d *stubCacheableDetector) DetectContext(_ context.Context, path, version, dir string) ([]cdx.License, error) {
+	return d.Detect(path, version, dir)
+}
+
+func (d *stubCacheableDetector) DetectExpression(_ context.Context, path, version, dir string) ([]cdx.License, string, error) {
+	licenses, err := d.Detect(path, version, dir)
+	return licenses, d.expression, err
+}
+
+func (d *stubCacheableDetector) CacheKey(_, _, _ string) (string, error) {
+	return d.key, d.keyErr
+}
+
+type stubDetector struct{}
+
+func (stubDetector) Detect(_, _, _ string) ([]cdx.License, error) {
+	return nil, nil
+}
+
+func TestCache_LicenseDetector(t *testing.T) {
+	licenses := []cdx.License{{ID: "Apache-2.0"}}
+
+	t.Run("Cached", func(t *testing.T) {
+		c := newTestCache(t)
+		stub := &stubCacheableDetector{key: "key", licenses: licenses}
+		detector := c.LicenseDetector(stub)
+
+		for range 2 {
+			detected, err := detector.Detect("example.com/foo", "v1.0.0", t.TempDir())
+			require.NoError(t, err)
+			require.Equal(t, licenses, detected)
+		}
+		require.Equal(t, 1, stub.calls)
+
+		stub.key = "otherKey"
+		_, err := detector.Detect("example.com/foo", "v1.0.0", t.TempDir())
+		require.NoError(t, err)
+		require.Equal(t, 2, stub.calls)
+	})
+
+	t.Run("Expression", func(t *testing.T) {
+		c := newTestCache(t)
+		stub := &stubCacheableDetector{key: "key", licenses: licenses, expression: "Apache-2.0 OR MIT"}
+		detector := c.LicenseDetector(stub)
+
+		for range 2 {
+			detected, expression, err := licensedetect.DetectExpression(context.Background(), detector, "example.com/foo", "v1.0.0", t.TempDir())
+			require.NoError(t, err)
+			require.Equal(t, licenses, detected)
+			require.Equal(t, "Apache-2.0 OR MIT", expression)
+		}
+		require.Equal(t, 1, stub.calls)
+	})
+
+	t.Run("CacheKeyError", func(t *testing.T) {
+		c := newTestCache(t)
+		stub := &stubCacheableDetector{keyErr: errors.New("test"), licenses: licenses}
+		detector := c.LicenseDetector(stub)
+
+		for range 2 {
+			detected, err := licensedetect.DetectContext(context.Background(), detector, "example.com/foo", "v1.0.0", t.TempDir())
+			require.NoError(t, err)
+			require.Equal(t, licenses, detected)
+		}
+		require.Equal(t, 2, stub.calls)
+	})
+
+	t.Run("NotCacheable", func(t *testing.T) {
+		c := newTestCache(t)
+		require.Equal(t, stubDetector{}, c.LicenseDetector(stubDetector{}))
+		require.Nil(t, c.LicenseDetector(nil))
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		var c *Cache
+		stub := &stubCacheableDetector{}
+		require.Same(t, stub, c.LicenseDetector(stub))
+	})
+}
+
+func TestCache_Get(t *testing.T) {
+	c := newTestCache(t)
+
+	var value string
+	require.False(t, c.get(namespaceLicenses, "key", &value))
+
+	c.put(namespaceLicenses, "key", "value")
+	require.True(t, c.get(namespaceLicenses, "key", &value))
+	require.Equal(t, "value", value)
+
+	// Corrupted entries are treated as missing
+	require.NoError(t, os.WriteFile(c.entryPath(namespaceLicenses, "key"), []byte("{"), 0o600))
+	require.False(t, c.get(namespaceLicenses, "key", &value))
+}
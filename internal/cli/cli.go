@@ -23,6 +23,9 @@ import (
 
 	appCmd "github.com/CycloneDX/cyclonedx-gomod/internal/cli/cmd/app"
 	binCmd "github.com/CycloneDX/cyclonedx-gomod/internal/cli/cmd/bin"
+	diffCmd "github.com/CycloneDX/cyclonedx-gomod/internal/cli/cmd/diff"
+	imageCmd "github.com/CycloneDX/cyclonedx-gomod/internal/cli/cmd/image"
+	mergeCmd "github.com/CycloneDX/cyclonedx-gomod/internal/cli/cmd/merge"
 	modCmd "github.com/CycloneDX/cyclonedx-gomod/internal/cli/cmd/mod"
 	versionCmd "github.com/CycloneDX/cyclonedx-gomod/internal/cli/cmd/version"
 	"github.com/peterbourgon/ff/v3/ffcli"
@@ -48,6 +51,12 @@ Multiple subcommands are offered, each targeting different use cases:
 
 - "bin" offers support for generating rudimentary SBOMs from binaries built with Go modules.
 
+- "image" does the same for all Go binaries in a container image.
+
+- "diff" compares two SBOMs generated by this tool, e.g. to review dependency updates.
+
+- "merge" combines SBOMs of multiple applications into a single product SBOM.
+
 Distributors of applications will typically use "app" and provide the resulting SBOMs
 alongside their application's binaries. This enables users to only consume SBOMs for
 artifacts that they actually use. For example, a Go module may include "server" and
@@ -64,6 +73,9 @@ cumbersome to deal with many SBOMs for the same product. Possible use cases are:
 		Subcommands: []*ffcli.Command{
 			appCmd.New(),
 			binCmd.New(),
+			diffCmd.New(),
+			imageCmd.New(),
+			mergeCmd.New(),
 			modCmd.New(),
 			versionCmd.New(),
 		},
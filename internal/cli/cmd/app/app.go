@@ -18,9 +18,12 @@
 package app
 
 import (
+	"cmp"
 	"context"
 	"flag"
 	"fmt"
+	"os"
+	"runtime"
 
 	"github.com/peterbourgon/ff/v3/ffcli"
 
@@ -44,28 +47,35 @@ func New() *ffcli.Command {
 		LongHelp: `Generate SBOMs for applications.
 
 In order to produce accurate SBOMs, build constraints must be configured
-via environment variables. These build constraints should mimic the ones passed
+via flags or environment variables. These build constraints should mimic the ones passed
 to the "go build" command for the application.
 
-Environment variables that act as build constraints are:
-  - GOARCH       The target architecture (386, amd64, etc.)
-  - GOOS         The target operating system (linux, windows, etc.)
-  - CGO_ENABLED  Whether or not CGO is enabled
-  - GOFLAGS      Flags that are passed to the Go command (e.g. build tags)
+Flags that act as build constraints are:
+  - -goarch  The target architecture (386, amd64, etc.), overrides GOARCH
+  - -goos    The target operating system (linux, windows, etc.), overrides GOOS
+  - -cgo     Whether or not CGO is enabled, overrides CGO_ENABLED
+  - -tags    Build tags, overrides -tags in GOFLAGS
+  - -env     Any other environment variable for the go command (e.g. GOFLAGS or GOEXPERIMENT)
 
 A complete overview of all environment variables can be found here:
   https://pkg.go.dev/cmd/go#hdr-Environment_variables
 
 Applicable build constraints are included as properties of the main component.
+When generating SBOMs for spec version 1.5 or newer, they are additionally recorded
+as a "go build" workflow in the SBOM's formulation.
 
 Because build constraints influence Go's module selection, an SBOM should be generated
-for each target in the build matrix.
+for each target in the build matrix. Alternatively, the -platforms flag may be used
+to generate a single SBOM for multiple GOOS/GOARCH combinations. Components are then
+annotated with the platforms they apply to, using the "cdx:gomod:build:platform" property.
 
 The -main flag should be used to specify the path to the application's main package.
 It must point to a directory within MODULE_PATH. If not set, MODULE_PATH is assumed.
 
 In order to not only include modules, but also the packages within them,
 the -packages flag can be used. Packages are represented as subcomponents of modules.
+With -package-deps, the dependency graph additionally includes dependencies between packages,
+as defined by their imports. This makes it possible to tell which package pulls in another.
 
 By passing -files, all files that would be included in a binary will be attached
 as subcomponents of their respective package. File versions follow the v0.0.0-SHORTHASH pattern,
@@ -74,6 +84,11 @@ Because files are subcomponents of packages, -files can only be used in conjunct
 When -paths option is additionally enabled, each file would have a property with
 a file path relative to its module root.
 
+Vulnerabilities can be reported by passing the path to a local copy of the Go vulnerability
+database (https://vuln.go.dev) via -vulndb. For each vulnerability, the "cdx:gomod:vuln:imported"
+property records whether any of the affected packages is imported by the application.
+Vulnerabilities of the standard library are only reported when -std is used.
+
 Licenses detected via -licenses flag will, per default, be reported as evidence.
 This is because it can not be guaranteed that the detected licenses are in fact correct.
 In case analysis software ingesting the BOM generated by this tool can not yet handle
@@ -82,11 +97,48 @@ For documentation on the respective fields of the CycloneDX specification, refer
   * https://cyclonedx.org/docs/1.4/json/#components_items_licenses
   * https://cyclonedx.org/docs/1.4/json/#components_items_evidence_licenses
 
+Every license file is reported as separate evidence, with the cdx:gomod:license:file
+and cdx:gomod:license:confidence properties. License files in subdirectories of a
+module are considered as well. When a module states how multiple licenses relate to
+each other, either using an SPDX license identifier or by offering a choice between
+them, the licenses are combined into an SPDX license expression, which is reported
+in the cdx:gomod:license:expression property. With -assert-licenses, the expression
+is reported as the component's license.
+
+Licenses that are detected incorrectly, or can't be detected at all, may be curated
+in a YAML file provided via -license-curations. Per default, .cyclonedx-gomod-licenses.yaml
+in the module directory is used if it exists. Curated licenses are reported as the
+component's concluded license, with a cdx:gomod:license:source property of "curation":
+
+  curations:
+    - module: github.com/acme/foo
+      versions: ">= v1.2.0, < v2.0.0"
+      license: Apache-2.0 OR MIT
+      comment: Dual-licensed, see README.md
+
+Licenses may be evaluated against a license policy, provided via -license-policy.
+The concluded or asserted licenses of every module are evaluated, falling back
+to the detected licenses. Of licenses combined with OR, one must be acceptable,
+while all licenses combined with AND, or detected in different files, must be.
+When an allow list is given, licenses that are not listed at all are denied.
+After the SBOM has been written, violations and warnings are reported on STDERR,
+and the command fails if there are any violations:
+
+  deny: [AGPL-*, GPL-*]
+  warn: [MPL-2.0]
+  allow: [Apache-2.0, BSD-*, MIT]
+  unknown: deny  # Components without license; allow, warn (default) or deny
+  exceptions:
+    - module: github.com/acme/foo
+      licenses: [GPL-3.0-only]
+      comment: Only used internally
+
 Examples:
-  $ GOARCH=arm64 GOOS=linux GOFLAGS="-tags=foo,bar" cyclonedx-gomod app -output linux-arm64.bom.xml
+  $ cyclonedx-gomod app -goos linux -goarch arm64 -tags foo,bar -output linux-arm64.bom.xml
+  $ cyclonedx-gomod app -platforms linux/amd64,darwin/arm64,windows/amd64 -output multi-platform.bom.xml
   $ cyclonedx-gomod app -json -output acme-app.bom.json -packages -files -licenses -main cmd/acme-app /usr/src/acme-module`,
 		FlagSet: fs,
-		Exec: func(_ context.Context, args []string) error {
+		Exec: func(ctx context.Context, args []string) error {
 			if len(args) > 1 {
 				return fmt.Errorf("too many arguments (expected 1, got %d)", len(args))
 			}
@@ -96,12 +148,12 @@ Examples:
 				options.ModuleDir = args[0]
 			}
 
-			return Exec(options)
+			return Exec(ctx, options)
 		},
 	}
 }
 
-func Exec(options Options) error {
+func Exec(ctx context.Context, options Options) error {
 	err := options.Validate()
 	if err != nil {
 		return err
@@ -114,20 +166,52 @@ func Exec(options Options) error {
 		licenseDetector = local.NewDetector(logger, float32(options.LicenseConfidenceThreshold))
 	}
 
-	generator, err := app.NewGenerator(options.ModuleDir,
+	licenseCurations, err := cliUtil.LoadLicenseCurations(logger, options.SBOMOptions, options.ModuleDir)
+	if err != nil {
+		return err
+	}
+
+	licensePolicy, err := cliUtil.LoadLicensePolicy(options.SBOMOptions)
+	if err != nil {
+		return err
+	}
+
+	platforms, err := options.ParsePlatforms()
+	if err != nil {
+		return err
+	}
+
+	generatorOptions := []app.Option{
 		app.WithLogger(logger),
+		app.WithCacheDir(cliUtil.CacheDir(logger, options.SBOMOptions)),
+		app.WithHashAlgorithms(options.HashAlgorithms...),
 		app.WithIncludeFiles(options.IncludeFiles),
+		app.WithIncludePackageDependencies(options.IncludePackageDeps),
 		app.WithIncludePackages(options.IncludePackages),
 		app.WithIncludePaths(options.IncludePaths),
 		app.WithIncludeStdlib(options.IncludeStd),
+		app.WithLicenseCurations(licenseCurations),
 		app.WithLicenseDetector(licenseDetector),
 		app.WithMainDir(options.Main),
-		app.WithShortPURLS(options.ShortPURLs))
+		app.WithPlatforms(platforms...),
+		app.WithGOOS(options.GOOS),
+		app.WithGOARCH(options.GOARCH),
+		app.WithBuildTags(options.ParseTags()...),
+		app.WithEnv(options.Env...),
+		app.WithParallelism(cmp.Or(options.Parallelism, runtime.GOMAXPROCS(0))),
+		app.WithShortPURLS(options.ShortPURLs),
+		app.WithVulnerabilityDatabase(options.VulnDB),
+	}
+	if options.CGOEnabled.value != nil {
+		generatorOptions = append(generatorOptions, app.WithCGOEnabled(*options.CGOEnabled.value))
+	}
+
+	generator, err := app.NewGenerator(options.ModuleDir, generatorOptions...)
 	if err != nil {
 		return err
 	}
 
-	bom, err := generator.Generate()
+	bom, err := generator.GenerateContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -144,5 +228,10 @@ func Exec(options Options) error {
 		sbom.AssertLicenses(bom)
 	}
 
-	return cliUtil.WriteBOM(bom, options.OutputOptions)
+	err = cliUtil.WriteBOM(bom, options.OutputOptions)
+	if err != nil {
+		return err
+	}
+
+	return cliUtil.CheckLicensePolicy(os.Stderr, bom, licensePolicy)
 }
@@ -18,17 +18,23 @@
 package app
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 
+	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/rs/zerolog"
 
 	"github.com/CycloneDX/cyclonedx-gomod/internal/cli/options"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/gomod"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/util"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/generate/app"
 )
 
 type Options struct {
@@ -36,11 +42,19 @@ type Options struct {
 	options.OutputOptions
 	options.SBOMOptions
 
-	IncludeFiles    bool
-	IncludePackages bool
-	IncludePaths    bool
-	Main            string
-	ModuleDir       string
+	CGOEnabled         optionalBool
+	Env                stringSlice
+	GOARCH             string
+	GOOS               string
+	IncludeFiles       bool
+	IncludePackageDeps bool
+	IncludePackages    bool
+	IncludePaths       bool
+	Main               string
+	ModuleDir          string
+	Platforms          string
+	Tags               string
+	VulnDB             string
 }
 
 func (o *Options) RegisterFlags(fs *flag.FlagSet) {
@@ -48,10 +62,18 @@ func (o *Options) RegisterFlags(fs *flag.FlagSet) {
 	o.OutputOptions.RegisterFlags(fs)
 	o.SBOMOptions.RegisterFlags(fs)
 
+	fs.Var(&o.CGOEnabled, "cgo", "Enable or disable cgo (default of the go command if not set)")
+	fs.Var(&o.Env, "env", "Additional `KEY=VALUE` environment variable for the go command, may be repeated")
+	fs.StringVar(&o.GOARCH, "goarch", "", "Target architecture (GOARCH of the go command if not set)")
+	fs.StringVar(&o.GOOS, "goos", "", "Target operating system (GOOS of the go command if not set)")
 	fs.BoolVar(&o.IncludeFiles, "files", false, "Include files")
+	fs.BoolVar(&o.IncludePackageDeps, "package-deps", false, "Include dependencies between packages")
 	fs.BoolVar(&o.IncludePackages, "packages", false, "Include packages")
 	fs.BoolVar(&o.IncludePaths, "paths", false, "Include file paths relative to their module root")
 	fs.StringVar(&o.Main, "main", "", "Path to the application's main package, relative to MODULE_PATH")
+	fs.StringVar(&o.Platforms, "platforms", "", "Comma-separated list of target platforms (GOOS/GOARCH) to include in a single SBOM")
+	fs.StringVar(&o.Tags, "tags", "", "Comma-separated list of build tags")
+	fs.StringVar(&o.VulnDB, "vulndb", "", "Path to a local copy of the Go vulnerability database to report vulnerabilities from")
 }
 
 func (o Options) Validate() error {
@@ -78,10 +100,39 @@ func (o Options) Validate() error {
 		errs = append(errs, fmt.Errorf("including files without including packages is not supported"))
 	}
 
+	if o.IncludePackageDeps && !o.IncludePackages {
+		errs = append(errs, fmt.Errorf("including package dependencies without including packages is not supported"))
+	}
+
+	// SPDX requires SHA-1 checksums for files, and package verification codes are derived from them
+	isSPDX := o.OutputFormat == options.OutputFormatSPDXJSON || o.OutputFormat == options.OutputFormatSPDXTV
+	if o.IncludeFiles && isSPDX && !slices.Contains(o.HashAlgorithms, cdx.HashAlgoSHA1) {
+		errs = append(errs, fmt.Errorf("hash algos: must include %s when including files with output format %s", cdx.HashAlgoSHA1, o.OutputFormat))
+	}
+
 	if o.IncludePaths && !o.IncludeFiles {
 		errs = append(errs, fmt.Errorf("including paths without including files is not supported"))
 	}
 
+	if _, err := o.ParsePlatforms(); err != nil {
+		errs = append(errs, fmt.Errorf("platforms: %w", err))
+	}
+	if o.Platforms != "" && (o.GOOS != "" || o.GOARCH != "") {
+		errs = append(errs, fmt.Errorf("platforms: can't be combined with -goos or -goarch"))
+	}
+
+	for _, e := range o.Env {
+		if !strings.Contains(e, "=") {
+			errs = append(errs, fmt.Errorf("env: \"%s\" is invalid, expected KEY=VALUE", e))
+		}
+	}
+
+	if o.VulnDB != "" {
+		if fileInfo, err := os.Stat(o.VulnDB); err != nil || !fileInfo.IsDir() {
+			errs = append(errs, fmt.Errorf("vulndb: \"%s\" is not a directory", o.VulnDB))
+		}
+	}
+
 	err := o.validateMain(o.Main, &errs)
 	if err != nil {
 		return err
@@ -94,6 +145,36 @@ func (o Options) Validate() error {
 	return nil
 }
 
+// ParsePlatforms parses the comma-separated list of platforms.
+func (o Options) ParsePlatforms() ([]app.Platform, error) {
+	if o.Platforms == "" {
+		return nil, nil
+	}
+
+	var platforms []app.Platform
+	for _, s := range strings.Split(o.Platforms, ",") {
+		platform, err := app.ParsePlatform(s)
+		if err != nil {
+			return nil, err
+		}
+		platforms = append(platforms, platform)
+	}
+
+	return platforms, nil
+}
+
+// ParseTags parses the comma-separated list of build tags.
+func (o Options) ParseTags() []string {
+	var tags []string
+	for _, tag := range strings.Split(o.Tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
 func (o Options) validateMain(mainPkgDir string, errs *[]error) error {
 	if filepath.IsAbs(mainPkgDir) {
 		*errs = append(*errs, fmt.Errorf("main: must be a relative path"))
@@ -123,7 +204,7 @@ func (o Options) validateMain(mainPkgDir string, errs *[]error) error {
 		return nil
 	}
 
-	pkg, err := gomod.LoadPackage(zerolog.Nop(), o.ModuleDir, o.Main)
+	pkg, err := gomod.LoadPackage(context.Background(), zerolog.Nop(), o.ModuleDir, o.Main)
 	if err != nil {
 		return fmt.Errorf("failed to load package: %w", err)
 	}
@@ -134,3 +215,46 @@ func (o Options) validateMain(mainPkgDir string, errs *[]error) error {
 
 	return nil
 }
+
+// optionalBool is a boolean flag that can be distinguished from not being set at all.
+type optionalBool struct {
+	value *bool
+}
+
+func (b *optionalBool) IsBoolFlag() bool {
+	return true
+}
+
+func (b *optionalBool) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+
+	b.value = &v
+	return nil
+}
+
+func (b *optionalBool) String() string {
+	if b == nil || b.value == nil {
+		return ""
+	}
+
+	return strconv.FormatBool(*b.value)
+}
+
+// stringSlice is a string flag that may be provided multiple times.
+type stringSlice []string
+
+func (s *stringSlice) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+func (s *stringSlice) String() string {
+	if s == nil {
+		return ""
+	}
+
+	return strings.Join(*s, ",")
+}
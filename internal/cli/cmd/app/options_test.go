@@ -18,6 +18,7 @@
 package app
 
 import (
+	"flag"
 	"os"
 	"path/filepath"
 	"testing"
@@ -37,6 +38,71 @@ func TestOptions_Validate(t *testing.T) {
 		require.Contains(t, err.Error(), "not supported")
 	})
 
+	t.Run("Package Dependencies without Packages", func(t *testing.T) {
+		var options Options
+		options.IncludePackageDeps = true
+
+		err := options.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "including package dependencies without including packages is not supported")
+	})
+
+	t.Run("Files With SPDX Without SHA-1", func(t *testing.T) {
+		var options Options
+		options.IncludeFiles = true
+		options.IncludePackages = true
+		options.OutputFormat = "spdx-json"
+		require.NoError(t, options.HashAlgorithms.Set("sha256"))
+
+		err := options.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "hash algos: must include SHA-1")
+
+		require.NoError(t, options.HashAlgorithms.Set("sha256,sha1"))
+		require.NotContains(t, options.Validate().Error(), "hash algos")
+
+		options.OutputFormat = "cyclonedx"
+		require.NoError(t, options.HashAlgorithms.Set(""))
+		require.NotContains(t, options.Validate().Error(), "hash algos")
+	})
+
+	t.Run("VulnDB Not A Directory", func(t *testing.T) {
+		var options Options
+		options.VulnDB = "./doesnotexist"
+
+		err := options.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "vulndb: \"./doesnotexist\" is not a directory")
+	})
+
+	t.Run("Invalid Platform", func(t *testing.T) {
+		var options Options
+		options.Platforms = "linux/amd64,darwin"
+
+		err := options.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "platforms: invalid platform \"darwin\"")
+	})
+
+	t.Run("Platforms With GOOS", func(t *testing.T) {
+		var options Options
+		options.Platforms = "linux/amd64"
+		options.GOOS = "windows"
+
+		err := options.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "can't be combined with -goos or -goarch")
+	})
+
+	t.Run("Invalid Env", func(t *testing.T) {
+		var options Options
+		options.Env = stringSlice{"GOOS=linux", "GOARCH"}
+
+		err := options.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "env: \"GOARCH\" is invalid")
+	})
+
 	t.Run("Main Isnt Subpath Of MODULE_PATH", func(t *testing.T) {
 		var options Options
 		options.ModuleDir = "/path/to/module"
@@ -107,3 +173,22 @@ func TestOptions_Validate(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestOptions_ParseTags(t *testing.T) {
+	require.Empty(t, Options{}.ParseTags())
+	require.Equal(t, []string{"foo", "bar"}, Options{Tags: "foo, bar,"}.ParseTags())
+}
+
+func TestOptions_RegisterFlags(t *testing.T) {
+	var options Options
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	options.RegisterFlags(fs)
+
+	require.Nil(t, options.CGOEnabled.value)
+
+	err := fs.Parse([]string{"-cgo=false", "-env", "GOPRIVATE=example.com", "-env", "GOEXPERIMENT=foo"})
+	require.NoError(t, err)
+	require.NotNil(t, options.CGOEnabled.value)
+	require.False(t, *options.CGOEnabled.value)
+	require.Equal(t, stringSlice{"GOPRIVATE=example.com", "GOEXPERIMENT=foo"}, options.Env)
+}
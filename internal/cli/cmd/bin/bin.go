@@ -18,12 +18,23 @@
 package bin
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/rs/zerolog"
 
 	cliUtil "github.com/CycloneDX/cyclonedx-gomod/internal/cli/util"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/gobinary"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom/merge"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom/policy"
 	"github.com/CycloneDX/cyclonedx-gomod/pkg/generate/bin"
 	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect"
 	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/local"
@@ -59,14 +70,94 @@ For documentation on the respective fields of the CycloneDX specification, refer
   * https://cyclonedx.org/docs/1.4/json/#components_items_licenses
   * https://cyclonedx.org/docs/1.4/json/#components_items_evidence_licenses
 
+Every license file is reported as separate evidence, with the cdx:gomod:license:file
+and cdx:gomod:license:confidence properties. License files in subdirectories of a
+module are considered as well. When a module states how multiple licenses relate to
+each other, either using an SPDX license identifier or by offering a choice between
+them, the licenses are combined into an SPDX license expression, which is reported
+in the cdx:gomod:license:expression property. With -assert-licenses, the expression
+is reported as the component's license.
+
+Licenses that are detected incorrectly, or can't be detected at all, may be curated
+in a YAML file provided via -license-curations. Per default, .cyclonedx-gomod-licenses.yaml
+in the working directory is used if it exists. Curated licenses are reported as the
+component's concluded license, with a cdx:gomod:license:source property of "curation":
+
+  curations:
+    - module: github.com/acme/foo
+      versions: ">= v1.2.0, < v2.0.0"
+      license: Apache-2.0 OR MIT
+      comment: Dual-licensed, see README.md
+
+Licenses may be evaluated against a license policy, provided via -license-policy.
+The concluded or asserted licenses of every module are evaluated, falling back
+to the detected licenses. Of licenses combined with OR, one must be acceptable,
+while all licenses combined with AND, or detected in different files, must be.
+When an allow list is given, licenses that are not listed at all are denied.
+After the SBOM has been written, violations and warnings are reported on STDERR,
+and the command fails if there are any violations:
+
+  deny: [AGPL-*, GPL-*]
+  warn: [MPL-2.0]
+  allow: [Apache-2.0, BSD-*, MIT]
+  unknown: deny  # Components without license; allow, warn (default) or deny
+  exceptions:
+    - module: github.com/acme/foo
+      licenses: [GPL-3.0-only]
+      comment: Only used internally
+
+In order to not only include modules, but also the packages within them,
+the -packages flag can be used. Packages are represented as subcomponents of modules.
+As build information doesn't list packages, they're read from the binary's symbol table,
+which only contains packages that were actually linked into the binary.
+
+Shared libraries that a binary is dynamically linked against, e.g. because it was built
+with cgo, are included as components with the cdx:gomod:binary:linked-library property.
+They're direct dependencies of the main component. As with any system library,
+their versions are unknown and depend on the system the binary is executed on.
+
+With -std, the Go toolchain that built the binary is included as well. It's represented
+as golang.org/toolchain application component (e.g. pkg:golang/golang.org/toolchain@go1.24.0).
+If the binary was built with a frozen snapshot of the Go Cryptographic Module (GOFIPS140=v1.0.0 etc.),
+the snapshot is included as golang.org/fips140 component with its respective version.
+Both are dependencies of the standard library component.
+
+Build settings embedded in the binary (build flags, GOOS, GOARCH, CGO_ENABLED, VCS information, etc.)
+are recorded as a "go build" workflow in the SBOM's formulation, which requires spec version 1.5 or newer.
+The workflow's output references the main component.
+
+BINARY_PATH may also point to a directory, or to a .tar, .tar.gz, .tgz or .zip archive.
+All Go binaries within it are analyzed, other files are skipped. Per default, a single
+SBOM is generated, with each binary nested as application component under a main component
+named after the directory or archive (override via -name). Modules used by multiple
+binaries are only included once. Binaries built from the same main module (e.g. for
+multiple platforms) remain distinct, with their path appended to their BOM reference.
+Alternatively, -output-dir writes one SBOM per binary to the given directory, mirroring
+the binaries' locations. In both cases, the location of a binary is recorded in the
+cdx:gomod:binary:path property. When -version is provided, it applies to the main
+modules of all binaries, as well as to the aggregated main component.
+
+If no SBOM can be generated for one of the binaries, the command fails. With -skip-failed,
+such binaries are skipped instead. Their locations are then recorded in cdx:gomod:binary:skipped
+properties of the aggregated SBOM's metadata, so that the gap remains visible.
+With -output-dir, no SBOM is written for skipped binaries.
+
+Binaries without module information, e.g. because they were built in GOPATH mode
+or with Go versions prior to 1.13, are analyzed using their symbol table instead.
+Modules are then inferred from the packages compiled into the binary, and the
+resulting components carry identity evidence with a confidence below 1.
+Module versions can only be inferred if the binary was built from the module cache.
+
 Please note that data embedded in binaries shouldn't be trusted,
 unless there's solid evidence that the binaries haven't been modified
 since they've been built.
 
-Example:
-  $ cyclonedx-gomod bin -json -output acme-app-v1.0.0.bom.json -version v1.0.0 ./acme-app`,
+Examples:
+  $ cyclonedx-gomod bin -json -output acme-app-v1.0.0.bom.json -version v1.0.0 ./acme-app
+  $ cyclonedx-gomod bin -json -output acme-v1.0.0.bom.json -version v1.0.0 ./dist
+  $ cyclonedx-gomod bin -json -output-dir ./sboms -version v1.0.0 ./acme_1.0.0_linux_amd64.tar.gz`,
 		FlagSet: fs,
-		Exec: func(_ context.Context, args []string) error {
+		Exec: func(ctx context.Context, args []string) error {
 			if len(args) > 1 {
 				return fmt.Errorf("too many arguments (expected 1, got %d)", len(args))
 			}
@@ -74,12 +165,12 @@ Example:
 				options.BinaryPath = args[0]
 			}
 
-			return Exec(options)
+			return Exec(ctx, options)
 		},
 	}
 }
 
-func Exec(options Options) error {
+func Exec(ctx context.Context, options Options) error {
 	err := options.Validate()
 	if err != nil {
 		return err
@@ -92,22 +183,207 @@ func Exec(options Options) error {
 		licenseDetector = local.NewDetector(logger, float32(options.LicenseConfidenceThreshold))
 	}
 
-	generator, err := bin.NewGenerator(options.BinaryPath,
+	licenseCurations, err := cliUtil.LoadLicenseCurations(logger, options.SBOMOptions, ".")
+	if err != nil {
+		return err
+	}
+
+	licensePolicy, err := cliUtil.LoadLicensePolicy(options.SBOMOptions)
+	if err != nil {
+		return err
+	}
+
+	generatorOptions := []bin.Option{
 		bin.WithLogger(logger),
+		bin.WithCacheDir(cliUtil.CacheDir(logger, options.SBOMOptions)),
+		bin.WithHashAlgorithms(options.HashAlgorithms...),
+		bin.WithIncludePackages(options.IncludePackages),
 		bin.WithIncludeStdlib(options.IncludeStd),
+		bin.WithLicenseCurations(licenseCurations),
 		bin.WithLicenseDetector(licenseDetector),
 		bin.WithVersionOverride(options.Version),
-		bin.WithShortPURLS(options.ShortPURLs))
+		bin.WithParallelism(cmp.Or(options.Parallelism, runtime.GOMAXPROCS(0))),
+		bin.WithShortPURLS(options.ShortPURLs),
+	}
+
+	isMultiBinary, err := options.IsMultiBinary()
+	if err != nil {
+		return err
+	}
+	if isMultiBinary {
+		return execMultiBinary(ctx, logger, options, generatorOptions, licensePolicy)
+	}
+
+	generator, err := bin.NewGenerator(options.BinaryPath, generatorOptions...)
+	if err != nil {
+		return err
+	}
+
+	bom, err := generator.GenerateContext(ctx)
 	if err != nil {
 		return err
 	}
 
-	bom, err := generator.Generate()
+	err = writeBOM(logger, bom, options, options.OutputFilePath)
 	if err != nil {
 		return err
 	}
 
-	err = cliUtil.SetSerialNumber(bom, options.SBOMOptions)
+	return cliUtil.CheckLicensePolicy(os.Stderr, bom, licensePolicy)
+}
+
+// PropertySkippedBinary records the location of a binary within a directory or archive,
+// for which no SBOM could be generated, and which is thus missing from the aggregated SBOM.
+const PropertySkippedBinary = "binary:skipped"
+
+// execMultiBinary generates SBOMs for all Go binaries in a directory or archive.
+// They are either written to the output directory individually, or merged into a single SBOM.
+//
+// When writing to the output directory, the license policy is evaluated for each SBOM,
+// so that all of them are written even if some of them violate the policy.
+//
+// Binaries for which no SBOM can be generated cause an error, unless options.SkipFailed is set.
+// Skipped binaries are recorded in the metadata of the aggregated SBOM.
+func execMultiBinary(ctx context.Context, logger zerolog.Logger, options Options, generatorOptions []bin.Option, licensePolicy *policy.Policy) error {
+	collection, err := gobinary.Find(logger, options.BinaryPath)
+	if err != nil {
+		return err
+	}
+	defer collection.Close()
+
+	if len(collection.Binaries) == 0 {
+		return fmt.Errorf("no go binaries found in %s", options.BinaryPath)
+	}
+
+	boms := make([]*cdx.BOM, 0, len(collection.Binaries))
+	paths := make([]string, 0, len(collection.Binaries))
+	written := 0
+	var skipped []string
+	var policyErrs []error
+	for _, binary := range collection.Binaries {
+		logger.Debug().
+			Str("path", binary.Path).
+			Msg("generating sbom for binary")
+
+		generator, err := bin.NewGenerator(binary.File, generatorOptions...)
+		if err != nil {
+			return err
+		}
+
+		bom, err := generator.GenerateContext(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			if !options.SkipFailed {
+				return fmt.Errorf("failed to generate sbom for %s: %w", binary.Path, err)
+			}
+
+			logger.Warn().
+				Err(err).
+				Str("path", binary.Path).
+				Msg("failed to generate sbom for binary, skipping it")
+			skipped = append(skipped, binary.Path)
+			continue
+		}
+
+		var properties []cdx.Property
+		if bom.Metadata.Properties != nil {
+			properties = *bom.Metadata.Properties
+		}
+		properties = append(properties, sbom.NewProperty("binary:path", binary.Path))
+		sbom.SortProperties(properties)
+		bom.Metadata.Properties = &properties
+
+		if options.OutputDir == "" {
+			boms = append(boms, bom)
+			paths = append(paths, binary.Path)
+			continue
+		}
+
+		outputFilePath := filepath.Join(options.OutputDir, filepath.FromSlash(binary.Path)) + options.outputFileExtension()
+		if err = os.MkdirAll(filepath.Dir(outputFilePath), 0o755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+		if err = writeBOM(logger, bom, options, outputFilePath); err != nil {
+			return err
+		}
+		written++
+
+		if err = cliUtil.CheckLicensePolicy(os.Stderr, bom, licensePolicy); err != nil {
+			policyErrs = append(policyErrs, fmt.Errorf("%s: %w", binary.Path, err))
+		}
+	}
+
+	if options.OutputDir != "" {
+		if written == 0 {
+			return fmt.Errorf("failed to generate sboms for any of the binaries in %s", options.BinaryPath)
+		}
+		return errors.Join(policyErrs...)
+	}
+	if len(boms) == 0 {
+		return fmt.Errorf("failed to generate sboms for any of the binaries in %s", options.BinaryPath)
+	}
+
+	disambiguateMainComponents(boms, paths)
+
+	product := cdx.Component{
+		Type:    cdx.ComponentTypeApplication,
+		Name:    options.Name,
+		Version: options.Version,
+	}
+	if product.Name == "" {
+		product.Name = gobinary.TrimArchiveExtension(filepath.Base(filepath.Clean(options.BinaryPath)))
+	}
+	product.BOMRef = product.Name
+	if product.Version != "" {
+		product.BOMRef += "@" + product.Version
+	}
+
+	bom, err := merge.BOMs(product, boms)
+	if err != nil {
+		return fmt.Errorf("failed to merge sboms: %w", err)
+	}
+
+	if len(skipped) > 0 {
+		var properties []cdx.Property
+		if bom.Metadata.Properties != nil {
+			properties = *bom.Metadata.Properties
+		}
+		for _, binaryPath := range skipped {
+			properties = append(properties, sbom.NewProperty(PropertySkippedBinary, binaryPath))
+		}
+		sbom.SortProperties(properties)
+		bom.Metadata.Properties = &properties
+	}
+
+	err = writeBOM(logger, bom, options, options.OutputFilePath)
+	if err != nil {
+		return err
+	}
+
+	return cliUtil.CheckLicensePolicy(os.Stderr, bom, licensePolicy)
+}
+
+// disambiguateMainComponents ensures that the main components of all BOMs have distinct BOM references.
+// Binaries built from the same main module (e.g. for multiple platforms) are distinct components,
+// yet they share the same BOM reference. The path of the binary is appended to their references.
+func disambiguateMainComponents(boms []*cdx.BOM, paths []string) {
+	refCounts := make(map[string]int)
+	for _, bom := range boms {
+		refCounts[bom.Metadata.Component.BOMRef]++
+	}
+
+	for i, bom := range boms {
+		ref := bom.Metadata.Component.BOMRef
+		if refCounts[ref] > 1 {
+			sbom.ReplaceBOMRef(bom, ref, ref+"|"+paths[i])
+		}
+	}
+}
+
+func writeBOM(logger zerolog.Logger, bom *cdx.BOM, options Options, outputFilePath string) error {
+	err := cliUtil.SetSerialNumber(bom, options.SBOMOptions)
 	if err != nil {
 		return fmt.Errorf("failed to set serial number: %w", err)
 	}
@@ -119,5 +395,8 @@ func Exec(options Options) error {
 		sbom.AssertLicenses(bom)
 	}
 
-	return cliUtil.WriteBOM(bom, options.OutputOptions)
+	outputOptions := options.OutputOptions
+	outputOptions.OutputFilePath = outputFilePath
+
+	return cliUtil.WriteBOM(bom, outputOptions)
 }
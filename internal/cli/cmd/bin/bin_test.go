@@ -0,0 +1,146 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package bin
+
+import (
+	"bytes"
+	"context"
+	"debug/elf"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cliUtil "github.com/CycloneDX/cyclonedx-gomod/internal/cli/util"
+)
+
+func newDistDir(t *testing.T) string {
+	distDir := filepath.Join(t.TempDir(), "dist")
+	for _, binary := range []string{"simple", "simple1.18"} {
+		content, err := os.ReadFile(filepath.Join("..", "..", "..", "..", "pkg", "generate", "testdata", binary))
+		require.NoError(t, err)
+
+		binaryDir := filepath.Join(distDir, binary+"_linux_amd64")
+		require.NoError(t, os.MkdirAll(binaryDir, 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(binaryDir, binary), content, 0o700))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(distDir, "checksums.txt"), []byte("foo"), 0o600))
+
+	return distDir
+}
+
+// addBrokenBinary adds a copy of a binary to distDir, of which the symbol table is corrupted.
+// Its build information is intact, but its packages can't be determined.
+func addBrokenBinary(t *testing.T, distDir string) {
+	content, err := os.ReadFile(filepath.Join("..", "..", "..", "..", "pkg", "generate", "testdata", "simple"))
+	require.NoError(t, err)
+
+	elfFile, err := elf.NewFile(bytes.NewReader(content))
+	require.NoError(t, err)
+	pclntab := elfFile.Section(".gopclntab")
+	require.NotNil(t, pclntab)
+	copy(content[pclntab.Offset:], make([]byte, 16))
+
+	binaryDir := filepath.Join(distDir, "broken_linux_amd64")
+	require.NoError(t, os.MkdirAll(binaryDir, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(binaryDir, "broken"), content, 0o700))
+}
+
+func TestExec_MultiBinary(t *testing.T) {
+	t.Run("Aggregated", func(t *testing.T) {
+		var options Options
+		options.BinaryPath = newDistDir(t)
+		options.OutputFilePath = filepath.Join(t.TempDir(), "bom.json")
+		options.OutputVersion = cdx.SpecVersion1_6.String()
+		options.UseJSON = true
+		options.Version = "v1.0.0"
+
+		require.NoError(t, Exec(context.Background(), options))
+
+		bom, err := cliUtil.ReadBOM(options.OutputFilePath)
+		require.NoError(t, err)
+		require.NotNil(t, bom.Metadata.Component)
+		assert.Equal(t, "dist@v1.0.0", bom.Metadata.Component.BOMRef)
+		assert.Equal(t, "dist", bom.Metadata.Component.Name)
+
+		// Both binaries are built from the same module, but must remain distinct
+		require.NotNil(t, bom.Metadata.Component.Components)
+		binaries := *bom.Metadata.Component.Components
+		require.Len(t, binaries, 2)
+		for i, binary := range []string{"simple1.18", "simple"} {
+			binaryPath := binary + "_linux_amd64/" + binary
+			assert.Equal(t, "pkg:golang/testmod-simple@v1.0.0?type=module|"+binaryPath, binaries[i].BOMRef)
+			assert.Equal(t, "v1.0.0", binaries[i].Version)
+			require.NotNil(t, binaries[i].Properties)
+			assert.Contains(t, *binaries[i].Properties, cdx.Property{Name: "cdx:gomod:binary:path", Value: binaryPath})
+		}
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		var options Options
+		options.BinaryPath = newDistDir(t)
+		options.IncludePackages = true
+		options.OutputFilePath = filepath.Join(t.TempDir(), "bom.json")
+		options.OutputVersion = cdx.SpecVersion1_6.String()
+		options.UseJSON = true
+		addBrokenBinary(t, options.BinaryPath)
+
+		err := Exec(context.Background(), options)
+		require.ErrorContains(t, err, "failed to generate sbom for broken_linux_amd64/broken")
+		require.NoFileExists(t, options.OutputFilePath)
+	})
+
+	t.Run("SkipFailed", func(t *testing.T) {
+		var options Options
+		options.BinaryPath = newDistDir(t)
+		options.IncludePackages = true
+		options.OutputFilePath = filepath.Join(t.TempDir(), "bom.json")
+		options.OutputVersion = cdx.SpecVersion1_6.String()
+		options.SkipFailed = true
+		options.UseJSON = true
+		addBrokenBinary(t, options.BinaryPath)
+
+		require.NoError(t, Exec(context.Background(), options))
+
+		bom, err := cliUtil.ReadBOM(options.OutputFilePath)
+		require.NoError(t, err)
+		require.NotNil(t, bom.Metadata.Component.Components)
+		require.Len(t, *bom.Metadata.Component.Components, 2)
+		require.NotNil(t, bom.Metadata.Properties)
+		assert.Contains(t, *bom.Metadata.Properties, cdx.Property{Name: "cdx:gomod:binary:skipped", Value: "broken_linux_amd64/broken"})
+	})
+
+	t.Run("OutputDir", func(t *testing.T) {
+		var options Options
+		options.BinaryPath = newDistDir(t)
+		options.OutputDir = t.TempDir()
+		options.OutputVersion = cdx.SpecVersion1_6.String()
+
+		require.NoError(t, Exec(context.Background(), options))
+
+		for _, binary := range []string{"simple", "simple1.18"} {
+			bom, err := cliUtil.ReadBOM(filepath.Join(options.OutputDir, binary+"_linux_amd64", binary+".bom.xml"))
+			require.NoError(t, err)
+			require.NotNil(t, bom.Metadata.Properties)
+			assert.Contains(t, *bom.Metadata.Properties, cdx.Property{Name: "cdx:gomod:binary:path", Value: binary + "_linux_amd64/" + binary})
+		}
+	})
+}
@@ -24,6 +24,7 @@ import (
 	"os"
 
 	"github.com/CycloneDX/cyclonedx-gomod/internal/cli/options"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/gobinary"
 )
 
 type Options struct {
@@ -31,8 +32,12 @@ type Options struct {
 	options.OutputOptions
 	options.SBOMOptions
 
-	BinaryPath string
-	Version    string
+	BinaryPath      string
+	IncludePackages bool
+	Name            string
+	OutputDir       string
+	SkipFailed      bool
+	Version         string
 }
 
 func (b *Options) RegisterFlags(fs *flag.FlagSet) {
@@ -40,6 +45,10 @@ func (b *Options) RegisterFlags(fs *flag.FlagSet) {
 	b.OutputOptions.RegisterFlags(fs)
 	b.SBOMOptions.RegisterFlags(fs)
 
+	fs.StringVar(&b.Name, "name", "", "Name of the main component when aggregating multiple binaries")
+	fs.StringVar(&b.OutputDir, "output-dir", "", "Write one SBOM per binary to this directory, instead of aggregating them")
+	fs.BoolVar(&b.IncludePackages, "packages", false, "Include packages")
+	fs.BoolVar(&b.SkipFailed, "skip-failed", false, "Skip binaries for which no SBOM can be generated, instead of failing")
 	fs.StringVar(&b.Version, "version", "", "Version of the main component")
 }
 
@@ -66,7 +75,7 @@ func (b Options) Validate() error {
 	if b.BinaryPath == "" {
 		errs = append(errs, fmt.Errorf("no binary path provided"))
 	} else {
-		fileInfo, err := os.Stat(b.BinaryPath)
+		_, err := os.Stat(b.BinaryPath)
 		if err != nil {
 			if errors.Is(err, os.ErrNotExist) {
 				errs = append(errs, fmt.Errorf("binary at %s does not exist", b.BinaryPath))
@@ -74,8 +83,28 @@ func (b Options) Validate() error {
 				return err
 			}
 		}
-		if fileInfo != nil && fileInfo.IsDir() {
-			errs = append(errs, fmt.Errorf("%s is a directory", b.BinaryPath))
+	}
+
+	if isMulti, err := b.IsMultiBinary(); err == nil && !isMulti {
+		if b.Name != "" {
+			errs = append(errs, fmt.Errorf("name: has no effect for a single binary"))
+		}
+		if b.OutputDir != "" {
+			errs = append(errs, fmt.Errorf("output-dir: has no effect for a single binary"))
+		}
+		if b.SkipFailed {
+			errs = append(errs, fmt.Errorf("skip-failed: has no effect for a single binary"))
+		}
+	}
+	if b.OutputDir != "" {
+		if b.OutputFilePath != "" && b.OutputFilePath != "-" {
+			errs = append(errs, fmt.Errorf("output: can't be used together with -output-dir"))
+		}
+		if b.Name != "" {
+			errs = append(errs, fmt.Errorf("name: has no effect with -output-dir"))
+		}
+		if b.SerialNumber != "" {
+			errs = append(errs, fmt.Errorf("serial: can't be used for multiple sboms"))
 		}
 	}
 
@@ -85,3 +114,30 @@ func (b Options) Validate() error {
 
 	return nil
 }
+
+// IsMultiBinary checks whether BinaryPath refers to a directory
+// or archive of binaries, rather than to a single binary.
+func (b Options) IsMultiBinary() (bool, error) {
+	fileInfo, err := os.Stat(b.BinaryPath)
+	if err != nil {
+		return false, err
+	}
+
+	return fileInfo.IsDir() || gobinary.IsArchive(b.BinaryPath), nil
+}
+
+// outputFileExtension returns the file extension for SBOMs written to OutputDir.
+func (b Options) outputFileExtension() string {
+	switch b.OutputFormat {
+	case options.OutputFormatSPDXJSON:
+		return ".spdx.json"
+	case options.OutputFormatSPDXTV:
+		return ".spdx"
+	}
+
+	if b.UseJSON {
+		return ".bom.json"
+	}
+
+	return ".bom.xml"
+}
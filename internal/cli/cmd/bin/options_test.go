@@ -20,7 +20,10 @@ package bin
 import (
 	"testing"
 
+	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/stretchr/testify/require"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/cli/options"
 )
 
 func TestBinOptions_Validate(t *testing.T) {
@@ -36,9 +39,48 @@ func TestBinOptions_Validate(t *testing.T) {
 	t.Run("BinaryPath Is Dir", func(t *testing.T) {
 		var binOptions Options
 		binOptions.BinaryPath = "./"
+		binOptions.OutputVersion = cdx.SpecVersion1_6.String()
+		binOptions.OutputDir = t.TempDir()
+
+		err := binOptions.Validate()
+		require.NoError(t, err)
+	})
+
+	t.Run("Multi Binary Options With Single Binary", func(t *testing.T) {
+		var binOptions Options
+		binOptions.BinaryPath = "./options.go"
+		binOptions.OutputVersion = cdx.SpecVersion1_6.String()
+		binOptions.Name = "acme"
+		binOptions.OutputDir = t.TempDir()
+		binOptions.SkipFailed = true
 
 		err := binOptions.Validate()
 		require.Error(t, err)
-		require.Contains(t, err.Error(), "is a directory")
+
+		var validationError *options.ValidationError
+		require.ErrorAs(t, err, &validationError)
+		require.Len(t, validationError.Errors, 4)
+		require.Contains(t, validationError.Errors[0].Error(), "name: has no effect for a single binary")
+		require.Contains(t, validationError.Errors[1].Error(), "output-dir: has no effect for a single binary")
+		require.Contains(t, validationError.Errors[2].Error(), "skip-failed: has no effect for a single binary")
+		require.Contains(t, validationError.Errors[3].Error(), "name: has no effect with -output-dir")
+	})
+
+	t.Run("OutputDir With Output", func(t *testing.T) {
+		var binOptions Options
+		binOptions.BinaryPath = "./"
+		binOptions.OutputVersion = cdx.SpecVersion1_6.String()
+		binOptions.OutputFilePath = "bom.xml"
+		binOptions.SerialNumber = "00000000-0000-0000-0000-000000000000"
+		binOptions.OutputDir = t.TempDir()
+
+		err := binOptions.Validate()
+		require.Error(t, err)
+
+		var validationError *options.ValidationError
+		require.ErrorAs(t, err, &validationError)
+		require.Len(t, validationError.Errors, 2)
+		require.Contains(t, validationError.Errors[0].Error(), "output: can't be used together with -output-dir")
+		require.Contains(t, validationError.Errors[1].Error(), "serial: can't be used for multiple sboms")
 	})
 }
@@ -0,0 +1,212 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package diff
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+
+	cliUtil "github.com/CycloneDX/cyclonedx-gomod/internal/cli/util"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom/diff"
+)
+
+type Options struct {
+	OutputFilePath string
+	UseJSON        bool
+
+	OldPath string
+	NewPath string
+}
+
+func (o *Options) RegisterFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&o.UseJSON, "json", false, "Output in JSON")
+	fs.StringVar(&o.OutputFilePath, "output", "-", "Output file path (or - for STDOUT)")
+}
+
+func New() *ffcli.Command {
+	fs := flag.NewFlagSet("cyclonedx-gomod diff", flag.ExitOnError)
+
+	var options Options
+	options.RegisterFlags(fs)
+
+	return &ffcli.Command{
+		Name:       "diff",
+		ShortHelp:  "Compare two SBOMs",
+		ShortUsage: "cyclonedx-gomod diff [FLAGS...] OLD NEW",
+		LongHelp: `Compare two SBOMs.
+
+Reports components that were added or removed, upgraded or downgraded,
+modules of which the replacement has changed, changes in (detected) licenses
+and cdx:gomod:* properties, as well as added and removed dependency edges.
+
+Components are matched by their identity, which for Go modules and packages
+is their PURL without version and platform qualifiers. Replaced modules are matched
+by the module they replace, as recorded in the cdx:gomod:module:replaces property.
+
+Both SBOMs may be in CycloneDX JSON or XML format. Use - to read one of them from STDIN.
+
+Examples:
+  $ cyclonedx-gomod diff old.bom.json new.bom.json
+  $ cyclonedx-gomod diff -json -output diff.json old.bom.xml new.bom.xml`,
+		FlagSet: fs,
+		Exec: func(_ context.Context, args []string) error {
+			if len(args) != 2 {
+				return fmt.Errorf("wrong number of arguments (expected 2, got %d)", len(args))
+			}
+			options.OldPath = args[0]
+			options.NewPath = args[1]
+
+			return Exec(options)
+		},
+	}
+}
+
+func Exec(options Options) error {
+	if options.OldPath == "-" && options.NewPath == "-" {
+		return fmt.Errorf("only one sbom can be read from stdin")
+	}
+
+	oldBOM, err := cliUtil.ReadBOM(options.OldPath)
+	if err != nil {
+		return fmt.Errorf("failed to read old sbom: %w", err)
+	}
+	newBOM, err := cliUtil.ReadBOM(options.NewPath)
+	if err != nil {
+		return fmt.Errorf("failed to read new sbom: %w", err)
+	}
+
+	result := diff.Compare(oldBOM, newBOM)
+
+	var outputWriter io.Writer
+	if options.OutputFilePath == "" || options.OutputFilePath == "-" {
+		outputWriter = os.Stdout
+	} else {
+		outputFile, err := os.Create(options.OutputFilePath)
+		if err != nil {
+			return fmt.Errorf("failed to create output file %s: %w", options.OutputFilePath, err)
+		}
+		defer outputFile.Close()
+		outputWriter = outputFile
+	}
+
+	if options.UseJSON {
+		enc := json.NewEncoder(outputWriter)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+
+	return writeText(outputWriter, result)
+}
+
+func writeText(writer io.Writer, result diff.Result) error {
+	if result.IsEmpty() {
+		_, err := fmt.Fprintln(writer, "No differences found.")
+		return err
+	}
+
+	var sb strings.Builder
+	section := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(title + ":\n")
+		for _, line := range lines {
+			sb.WriteString("  " + line + "\n")
+		}
+	}
+
+	section("Added components", mapLines(result.Added, func(c diff.Component) string { return "+ " + formatComponent(c) }))
+	section("Removed components", mapLines(result.Removed, func(c diff.Component) string { return "- " + formatComponent(c) }))
+	section("Upgraded components", mapLines(result.Upgraded, formatVersionChange))
+	section("Downgraded components", mapLines(result.Downgraded, formatVersionChange))
+	section("Changed versions", mapLines(result.Changed, formatVersionChange))
+	section("Changed replacements", mapLines(result.Replacements, func(c diff.ReplacementChange) string {
+		return fmt.Sprintf("%s: %s -> %s", c.Name, orNone(c.OldReplacement), orNone(c.NewReplacement))
+	}))
+	section("Changed licenses", mapLines(result.Licenses, func(c diff.LicenseChange) string {
+		return fmt.Sprintf("%s: %s -> %s", c.Name, orNone(strings.Join(c.OldLicenses, ", ")), orNone(strings.Join(c.NewLicenses, ", ")))
+	}))
+
+	var propertyLines []string
+	for _, change := range result.Properties {
+		propertyLines = append(propertyLines, change.Name+":")
+		for _, property := range change.Added {
+			propertyLines = append(propertyLines, fmt.Sprintf("  + %s=%s", property.Name, property.Value))
+		}
+		for _, property := range change.Removed {
+			propertyLines = append(propertyLines, fmt.Sprintf("  - %s=%s", property.Name, property.Value))
+		}
+	}
+	section("Changed properties", propertyLines)
+
+	section("Added dependencies", mapLines(result.AddedDependencies, func(d diff.Dependency) string {
+		return fmt.Sprintf("+ %s -> %s", d.Ref, d.DependsOn)
+	}))
+	section("Removed dependencies", mapLines(result.RemovedDependencies, func(d diff.Dependency) string {
+		return fmt.Sprintf("- %s -> %s", d.Ref, d.DependsOn)
+	}))
+
+	_, err := io.WriteString(writer, sb.String())
+	return err
+}
+
+func mapLines[T any](items []T, format func(T) string) []string {
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, format(item))
+	}
+
+	return lines
+}
+
+func formatComponent(c diff.Component) string {
+	line := c.Name
+	if c.Version != "" {
+		line += " " + c.Version
+	}
+	if c.Type == "package" {
+		line += " (package)"
+	}
+	if c.Replaces != "" {
+		line += " (replaces " + c.Replaces + ")"
+	}
+
+	return line
+}
+
+func formatVersionChange(c diff.VersionChange) string {
+	return fmt.Sprintf("%s %s -> %s", c.Name, orNone(c.OldVersion), orNone(c.NewVersion))
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+
+	return s
+}
@@ -0,0 +1,78 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package diff
+
+import (
+	"bytes"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom/diff"
+)
+
+func TestWriteText(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+
+		err := writeText(buf, diff.Result{})
+		require.NoError(t, err)
+		require.Equal(t, "No differences found.\n", buf.String())
+	})
+
+	t.Run("Changes", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+
+		err := writeText(buf, diff.Result{
+			Added: []diff.Component{
+				{Name: "a", Version: "v1.0.0"},
+				{Name: "a/pkg", Version: "v1.0.0", Type: "package"},
+			},
+			Upgraded:     []diff.VersionChange{{Name: "b", OldVersion: "v1.0.0", NewVersion: "v1.1.0"}},
+			Replacements: []diff.ReplacementChange{{Name: "c", NewReplacement: "fork/c@v1.0.1"}},
+			Licenses:     []diff.LicenseChange{{Name: "d", OldLicenses: []string{"MIT"}}},
+			Properties: []diff.PropertyChange{{
+				Name:  "main",
+				Added: []cdx.Property{{Name: "cdx:gomod:build:tag", Value: "foo"}},
+			}},
+			AddedDependencies: []diff.Dependency{{Ref: "pkg:golang/main?type=module", DependsOn: "pkg:golang/a?type=module"}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, `Added components:
+  + a v1.0.0
+  + a/pkg v1.0.0 (package)
+
+Upgraded components:
+  b v1.0.0 -> v1.1.0
+
+Changed replacements:
+  c: (none) -> fork/c@v1.0.1
+
+Changed licenses:
+  d: MIT -> (none)
+
+Changed properties:
+  main:
+    + cdx:gomod:build:tag=foo
+
+Added dependencies:
+  + pkg:golang/main?type=module -> pkg:golang/a?type=module
+`, buf.String())
+	})
+}
@@ -0,0 +1,144 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package image
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/peterbourgon/ff/v3/ffcli"
+
+	cliUtil "github.com/CycloneDX/cyclonedx-gomod/internal/cli/util"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/generate/image"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/local"
+)
+
+func New() *ffcli.Command {
+	fs := flag.NewFlagSet("cyclonedx-gomod image", flag.ExitOnError)
+
+	var options Options
+	options.RegisterFlags(fs)
+
+	return &ffcli.Command{
+		Name:       "image",
+		ShortHelp:  "Generate SBOMs for Go binaries in container images",
+		ShortUsage: "cyclonedx-gomod image [FLAGS...] IMAGE_PATH",
+		LongHelp: `Generate SBOMs for Go binaries in container images.
+
+IMAGE_PATH may point to a tarball written by "docker save" (optionally compressed
+with gzip), or to an OCI image layout, either as directory or tarball.
+Images are never pulled from a registry, and their layers are never executed.
+
+The layers of the image are applied in order, including whiteouts, and every
+executable in the resulting file system that contains Go build information is
+analyzed in the same way as with the "bin" command. The image becomes the main
+component of the SBOM, and each binary is nested under it as application component.
+The location of a binary is recorded in the cdx:gomod:image:path and
+cdx:gomod:image:layer:digest properties of its component. Modules used by multiple
+binaries are only included once. When the same binary is located at multiple
+paths, its component records all of them.
+
+For multi-platform images, the platform to analyze must be selected via -platform.
+
+All caveats of the "bin" command apply. Please refer to its documentation for details.
+
+Examples:
+  $ docker save -o acme-app.tar acme/app:v1.0.0
+  $ cyclonedx-gomod image -json -output acme-app.bom.json acme-app.tar
+  $ cyclonedx-gomod image -platform linux/arm64 -licenses -output acme-app.bom.xml ./oci-layout`,
+		FlagSet: fs,
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) > 1 {
+				return fmt.Errorf("too many arguments (expected 1, got %d)", len(args))
+			}
+			if len(args) == 1 {
+				options.ImagePath = args[0]
+			}
+
+			return Exec(ctx, options)
+		},
+	}
+}
+
+func Exec(ctx context.Context, options Options) error {
+	err := options.Validate()
+	if err != nil {
+		return err
+	}
+
+	logger := options.Logger()
+
+	var licenseDetector licensedetect.Detector
+	if options.ResolveLicenses {
+		licenseDetector = local.NewDetector(logger, float32(options.LicenseConfidenceThreshold))
+	}
+
+	licenseCurations, err := cliUtil.LoadLicenseCurations(logger, options.SBOMOptions, ".")
+	if err != nil {
+		return err
+	}
+
+	licensePolicy, err := cliUtil.LoadLicensePolicy(options.SBOMOptions)
+	if err != nil {
+		return err
+	}
+
+	generator, err := image.NewGenerator(options.ImagePath,
+		image.WithLogger(logger),
+		image.WithCacheDir(cliUtil.CacheDir(logger, options.SBOMOptions)),
+		image.WithHashAlgorithms(options.HashAlgorithms...),
+		image.WithIncludeStdlib(options.IncludeStd),
+		image.WithLicenseCurations(licenseCurations),
+		image.WithLicenseDetector(licenseDetector),
+		image.WithPlatform(options.Platform),
+		image.WithParallelism(cmp.Or(options.Parallelism, runtime.GOMAXPROCS(0))),
+		image.WithShortPURLS(options.ShortPURLs))
+	if err != nil {
+		return err
+	}
+
+	bom, err := generator.GenerateContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = cliUtil.SetSerialNumber(bom, options.SBOMOptions)
+	if err != nil {
+		return fmt.Errorf("failed to set serial number: %w", err)
+	}
+	err = cliUtil.AddCommonMetadata(logger, bom, options.SBOMOptions)
+	if err != nil {
+		return fmt.Errorf("failed to add common metadata: %w", err)
+	}
+	if options.AssertLicenses {
+		sbom.AssertLicenses(bom)
+	}
+
+	err = cliUtil.WriteBOM(bom, options.OutputOptions)
+	if err != nil {
+		return err
+	}
+
+	return cliUtil.CheckLicensePolicy(os.Stderr, bom, licensePolicy)
+}
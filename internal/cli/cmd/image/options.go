@@ -0,0 +1,89 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package image
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/cli/options"
+)
+
+type Options struct {
+	options.LogOptions
+	options.OutputOptions
+	options.SBOMOptions
+
+	ImagePath string
+	Platform  string
+}
+
+func (i *Options) RegisterFlags(fs *flag.FlagSet) {
+	i.LogOptions.RegisterFlags(fs)
+	i.OutputOptions.RegisterFlags(fs)
+	i.SBOMOptions.RegisterFlags(fs)
+
+	fs.StringVar(&i.Platform, "platform", "", "Platform to select from multi-platform images (os/arch[/variant])")
+}
+
+func (i Options) Validate() error {
+	errs := make([]error, 0)
+
+	if err := i.OutputOptions.Validate(); err != nil {
+		var verr *options.ValidationError
+		if errors.As(err, &verr) {
+			errs = append(errs, verr.Errors...)
+		} else {
+			return err
+		}
+	}
+	if err := i.SBOMOptions.Validate(); err != nil {
+		var verr *options.ValidationError
+		if errors.As(err, &verr) {
+			errs = append(errs, verr.Errors...)
+		} else {
+			return err
+		}
+	}
+
+	if i.ImagePath == "" {
+		errs = append(errs, fmt.Errorf("no image path provided"))
+	} else if _, err := os.Stat(i.ImagePath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("image at %s does not exist", i.ImagePath))
+		} else {
+			return err
+		}
+	}
+
+	if i.Platform != "" {
+		parts := strings.Split(i.Platform, "/")
+		if len(parts) < 2 || len(parts) > 3 || strings.Contains(i.Platform, "//") || strings.HasSuffix(i.Platform, "/") {
+			errs = append(errs, fmt.Errorf("platform: \"%s\" is invalid (expected os/arch[/variant])", i.Platform))
+		}
+	}
+
+	if len(errs) > 0 {
+		return &options.ValidationError{Errors: errs}
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package image
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageOptions_Validate(t *testing.T) {
+	t.Run("ImagePath Not Exists", func(t *testing.T) {
+		var imageOptions Options
+		imageOptions.ImagePath = "./doesNotExist"
+
+		err := imageOptions.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "does not exist")
+	})
+
+	t.Run("Invalid Platform", func(t *testing.T) {
+		for _, platform := range []string{"linux", "linux/", "linux//amd64", "linux/arm64/v8/extra"} {
+			var imageOptions Options
+			imageOptions.ImagePath = "./"
+			imageOptions.Platform = platform
+
+			err := imageOptions.Validate()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "is invalid (expected os/arch[/variant])", platform)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package merge
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/peterbourgon/ff/v3/ffcli"
+
+	cliUtil "github.com/CycloneDX/cyclonedx-gomod/internal/cli/util"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom/merge"
+)
+
+func New() *ffcli.Command {
+	fs := flag.NewFlagSet("cyclonedx-gomod merge", flag.ExitOnError)
+
+	var options Options
+	options.RegisterFlags(fs)
+
+	return &ffcli.Command{
+		Name:       "merge",
+		ShortHelp:  "Merge SBOMs into a product SBOM",
+		ShortUsage: "cyclonedx-gomod merge -name NAME [FLAGS...] SBOM_PATH...",
+		LongHelp: `Merge SBOMs into a product SBOM.
+
+Combines SBOMs generated by this tool, e.g. with "app" for multiple applications of a
+module, into a single SBOM. A new product component, as described by -name, -type and
+-version, becomes the main component. The main components of all merged SBOMs are
+nested under it, and the product depends on each of them. Metadata properties of
+the merged SBOMs (e.g. those describing binaries) are added to their main components.
+
+Components are deduplicated by their BOM reference, nested components (e.g. packages)
+and properties of duplicate components are combined. Dependency graphs, compositions
+and vulnerabilities are combined as well.
+
+Input SBOMs may be in CycloneDX JSON or XML format. Use - to read one of them from STDIN.
+
+Examples:
+  $ cyclonedx-gomod merge -name acme -version v1.2.3 -json -output bom.json client.bom.json server.bom.json`,
+		FlagSet: fs,
+		Exec: func(_ context.Context, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("no sboms to merge")
+			}
+			options.InputPaths = args
+
+			return Exec(options)
+		},
+	}
+}
+
+func Exec(options Options) error {
+	err := options.Validate()
+	if err != nil {
+		return err
+	}
+
+	logger := options.Logger()
+
+	boms := make([]*cdx.BOM, 0, len(options.InputPaths))
+	for _, path := range options.InputPaths {
+		logger.Debug().
+			Str("path", path).
+			Msg("reading sbom")
+
+		bom, err := cliUtil.ReadBOM(path)
+		if err != nil {
+			return err
+		}
+		boms = append(boms, bom)
+	}
+
+	product := cdx.Component{
+		BOMRef:  options.ProductName,
+		Type:    cdx.ComponentType(options.ProductType),
+		Name:    options.ProductName,
+		Version: options.ProductVersion,
+	}
+	if product.Version != "" {
+		product.BOMRef += "@" + product.Version
+	}
+
+	bom, err := merge.BOMs(product, boms)
+	if err != nil {
+		return fmt.Errorf("failed to merge sboms: %w", err)
+	}
+
+	err = cliUtil.SetSerialNumber(bom, options.SBOMOptions())
+	if err != nil {
+		return fmt.Errorf("failed to set serial number: %w", err)
+	}
+	err = cliUtil.AddCommonMetadata(logger, bom, options.SBOMOptions())
+	if err != nil {
+		return fmt.Errorf("failed to add common metadata: %w", err)
+	}
+
+	return cliUtil.WriteBOM(bom, options.OutputOptions)
+}
@@ -0,0 +1,128 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package merge
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/cli/options"
+)
+
+type Options struct {
+	options.LogOptions
+	options.OutputOptions
+
+	NoSerialNumber bool
+	NoTimestamp    bool
+	SerialNumber   string
+
+	ProductName    string
+	ProductType    string
+	ProductVersion string
+
+	InputPaths []string
+}
+
+func (m *Options) RegisterFlags(fs *flag.FlagSet) {
+	m.LogOptions.RegisterFlags(fs)
+	m.OutputOptions.RegisterFlags(fs)
+
+	fs.BoolVar(&m.NoSerialNumber, "noserial", false, "Omit serial number")
+	fs.BoolVar(&m.NoTimestamp, "notimestamp", false, "Omit timestamp")
+	fs.StringVar(&m.SerialNumber, "serial", "", "Serial number")
+	fs.StringVar(&m.ProductName, "name", "", "Name of the product component")
+	fs.StringVar(&m.ProductType, "type", "application", "Type of the product component")
+	fs.StringVar(&m.ProductVersion, "version", "", "Version of the product component")
+}
+
+// SBOMOptions returns the subset of options.SBOMOptions that applies to merged SBOMs.
+func (m Options) SBOMOptions() options.SBOMOptions {
+	return options.SBOMOptions{
+		NoSerialNumber: m.NoSerialNumber,
+		NoTimestamp:    m.NoTimestamp,
+		SerialNumber:   m.SerialNumber,
+	}
+}
+
+var allowedProductTypes = []cdx.ComponentType{
+	cdx.ComponentTypeApplication,
+	cdx.ComponentTypeFirmware,
+	cdx.ComponentTypeFramework,
+	cdx.ComponentTypePlatform,
+}
+
+func (m Options) Validate() error {
+	errs := make([]error, 0)
+
+	if err := m.OutputOptions.Validate(); err != nil {
+		var verr *options.ValidationError
+		if errors.As(err, &verr) {
+			errs = append(errs, verr.Errors...)
+		} else {
+			return err
+		}
+	}
+	if err := m.SBOMOptions().Validate(); err != nil {
+		var verr *options.ValidationError
+		if errors.As(err, &verr) {
+			errs = append(errs, verr.Errors...)
+		} else {
+			return err
+		}
+	}
+
+	if m.ProductName == "" {
+		errs = append(errs, fmt.Errorf("name: a product name is required"))
+	}
+
+	isAllowedProductType := false
+	for i := range allowedProductTypes {
+		if allowedProductTypes[i] == cdx.ComponentType(m.ProductType) {
+			isAllowedProductType = true
+			break
+		}
+	}
+	if !isAllowedProductType {
+		allowed := make([]string, len(allowedProductTypes))
+		for i := range allowedProductTypes {
+			allowed[i] = string(allowedProductTypes[i])
+		}
+		errs = append(errs, fmt.Errorf("product type: \"%s\" is invalid (allowed: %s)", m.ProductType, strings.Join(allowed, ",")))
+	}
+
+	stdinInputs := 0
+	for _, path := range m.InputPaths {
+		if path == "-" {
+			stdinInputs++
+		}
+	}
+	if stdinInputs > 1 {
+		errs = append(errs, fmt.Errorf("only one sbom can be read from stdin"))
+	}
+
+	if len(errs) > 0 {
+		return &options.ValidationError{Errors: errs}
+	}
+
+	return nil
+}
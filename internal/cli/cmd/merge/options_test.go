@@ -0,0 +1,57 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package merge
+
+import (
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/cli/options"
+)
+
+func TestOptions_Validate(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		var mergeOptions Options
+		mergeOptions.ProductName = "acme"
+		mergeOptions.ProductType = string(cdx.ComponentTypeApplication)
+		mergeOptions.OutputVersion = cdx.SpecVersion1_6.String()
+		mergeOptions.InputPaths = []string{"client.bom.json", "-"}
+
+		require.NoError(t, mergeOptions.Validate())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		var mergeOptions Options
+		mergeOptions.ProductType = "foobar"
+		mergeOptions.OutputVersion = cdx.SpecVersion1_6.String()
+		mergeOptions.InputPaths = []string{"-", "-"}
+
+		err := mergeOptions.Validate()
+		require.Error(t, err)
+
+		var validationError *options.ValidationError
+		require.ErrorAs(t, err, &validationError)
+
+		require.Len(t, validationError.Errors, 3)
+		require.Contains(t, validationError.Errors[0].Error(), "a product name is required")
+		require.Contains(t, validationError.Errors[1].Error(), "product type: \"foobar\" is invalid")
+		require.Contains(t, validationError.Errors[2].Error(), "only one sbom can be read from stdin")
+	})
+}
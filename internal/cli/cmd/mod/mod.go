@@ -18,9 +18,12 @@
 package mod
 
 import (
+	"cmp"
 	"context"
 	"flag"
 	"fmt"
+	"os"
+	"runtime"
 
 	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/peterbourgon/ff/v3/ffcli"
@@ -44,6 +47,9 @@ func New() *ffcli.Command {
 		ShortUsage: "cyclonedx-gomod mod [FLAGS...] [MODULE_PATH]",
 		LongHelp: `Generate SBOMs for modules.
 
+When MODULE_PATH is the root of a workspace (contains a go.work file), the SBOM
+describes the entire workspace. Every workspace module is then included as a component.
+
 Licenses detected via -licenses flag will, per default, be reported as evidence.
 This is because it can not be guaranteed that the detected licenses are in fact correct.
 In case analysis software ingesting the BOM generated by this tool can not yet handle
@@ -52,9 +58,60 @@ For documentation on the respective fields of the CycloneDX specification, refer
   * https://cyclonedx.org/docs/1.4/json/#components_items_licenses
   * https://cyclonedx.org/docs/1.4/json/#components_items_evidence_licenses
 
+Every license file is reported as separate evidence, with the cdx:gomod:license:file
+and cdx:gomod:license:confidence properties. License files in subdirectories of a
+module are considered as well. When a module states how multiple licenses relate to
+each other, either using an SPDX license identifier or by offering a choice between
+them, the licenses are combined into an SPDX license expression, which is reported
+in the cdx:gomod:license:expression property. With -assert-licenses, the expression
+is reported as the component's license.
+
+Licenses that are detected incorrectly, or can't be detected at all, may be curated
+in a YAML file provided via -license-curations. Per default, .cyclonedx-gomod-licenses.yaml
+in the module directory is used if it exists. Curated licenses are reported as the
+component's concluded license, with a cdx:gomod:license:source property of "curation":
+
+  curations:
+    - module: github.com/acme/foo
+      versions: ">= v1.2.0, < v2.0.0"
+      license: Apache-2.0 OR MIT
+      comment: Dual-licensed, see README.md
+
+Licenses may be evaluated against a license policy, provided via -license-policy.
+The concluded or asserted licenses of every module are evaluated, falling back
+to the detected licenses. Of licenses combined with OR, one must be acceptable,
+while all licenses combined with AND, or detected in different files, must be.
+When an allow list is given, licenses that are not listed at all are denied.
+After the SBOM has been written, violations and warnings are reported on STDERR,
+and the command fails if there are any violations:
+
+  deny: [AGPL-*, GPL-*]
+  warn: [MPL-2.0]
+  allow: [Apache-2.0, BSD-*, MIT]
+  unknown: deny  # Components without license; allow, warn (default) or deny
+  exceptions:
+    - module: github.com/acme/foo
+      licenses: [GPL-3.0-only]
+      comment: Only used internally
+
+With -offline, modules are read from go.mod and go.sum instead of being loaded
+with the go command, so that neither the module cache nor network access is needed.
+Requirements of dependencies are unknown in this mode, so the dependency graph only
+covers direct dependencies of the main module. This is reflected in the compositions
+of the SBOM. Test-only and tool-only modules can't be told apart in this mode either.
+They're always included, with the "required" scope like any other module, so the
+SBOM may list modules that aren't part of the module's runtime. -test and -tools
+can thus not be used together with -offline.
+
+Modules that are only required by tools, as declared by tool directives in go.mod,
+are not included per default. They can be included using the -tools flag, in which
+case they have the "excluded" scope, as they're not part of the module's runtime.
+Each of them has a cdx:gomod:tool property, naming the tool that requires it.
+
 Examples:
   $ cyclonedx-gomod mod -licenses -type library -json -output bom.json ./cyclonedx-go
-  $ cyclonedx-gomod mod -test -output bom.xml ./cyclonedx-go`,
+  $ cyclonedx-gomod mod -test -output bom.xml ./cyclonedx-go
+  $ cyclonedx-gomod mod -offline -json -output bom.json ./cyclonedx-go`,
 		FlagSet: fs,
 		Exec: func(ctx context.Context, args []string) error {
 			if len(args) > 1 {
@@ -66,12 +123,12 @@ Examples:
 				options.ModuleDir = args[0]
 			}
 
-			return Exec(options)
+			return Exec(ctx, options)
 		},
 	}
 }
 
-func Exec(options Options) error {
+func Exec(ctx context.Context, options Options) error {
 	err := options.Validate()
 	if err != nil {
 		return err
@@ -84,18 +141,33 @@ func Exec(options Options) error {
 		licenseDetector = local.NewDetector(logger, float32(options.LicenseConfidenceThreshold))
 	}
 
+	licenseCurations, err := cliUtil.LoadLicenseCurations(logger, options.SBOMOptions, options.ModuleDir)
+	if err != nil {
+		return err
+	}
+
+	licensePolicy, err := cliUtil.LoadLicensePolicy(options.SBOMOptions)
+	if err != nil {
+		return err
+	}
+
 	generator, err := mod.NewGenerator(options.ModuleDir,
 		mod.WithLogger(logger),
+		mod.WithCacheDir(cliUtil.CacheDir(logger, options.SBOMOptions)),
 		mod.WithComponentType(cdx.ComponentType(options.ComponentType)),
 		mod.WithIncludeStdlib(options.IncludeStd),
 		mod.WithIncludeTestModules(options.IncludeTest),
+		mod.WithIncludeToolModules(options.IncludeTools),
+		mod.WithLicenseCurations(licenseCurations),
 		mod.WithLicenseDetector(licenseDetector),
+		mod.WithOffline(options.Offline),
+		mod.WithParallelism(cmp.Or(options.Parallelism, runtime.GOMAXPROCS(0))),
 		mod.WithShortPURLS(options.ShortPURLs))
 	if err != nil {
 		return err
 	}
 
-	bom, err := generator.Generate()
+	bom, err := generator.GenerateContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -112,5 +184,10 @@ func Exec(options Options) error {
 		sbom.AssertLicenses(bom)
 	}
 
-	return cliUtil.WriteBOM(bom, options.OutputOptions)
+	err = cliUtil.WriteBOM(bom, options.OutputOptions)
+	if err != nil {
+		return err
+	}
+
+	return cliUtil.CheckLicensePolicy(os.Stderr, bom, licensePolicy)
 }
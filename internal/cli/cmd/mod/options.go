@@ -35,6 +35,8 @@ type Options struct {
 	ComponentType string
 	ModuleDir     string
 	IncludeTest   bool
+	IncludeTools  bool
+	Offline       bool
 }
 
 func (m *Options) RegisterFlags(fs *flag.FlagSet) {
@@ -44,6 +46,8 @@ func (m *Options) RegisterFlags(fs *flag.FlagSet) {
 
 	fs.StringVar(&m.ComponentType, "type", "application", "Type of the main component")
 	fs.BoolVar(&m.IncludeTest, "test", false, "Include test dependencies")
+	fs.BoolVar(&m.IncludeTools, "tools", false, "Include dependencies of tools")
+	fs.BoolVar(&m.Offline, "offline", false, "Read modules from go.mod and go.sum without invoking the go command")
 }
 
 var allowedComponentTypes = []cdx.ComponentType{
@@ -88,6 +92,13 @@ func (m Options) Validate() error {
 		errs = append(errs, fmt.Errorf("component type: \"%s\" is invalid (allowed: %s)", m.ComponentType, strings.Join(allowed, ",")))
 	}
 
+	if m.Offline && m.IncludeTest {
+		errs = append(errs, fmt.Errorf("test: has no effect in offline mode, as test dependencies can't be told apart"))
+	}
+	if m.Offline && m.IncludeTools {
+		errs = append(errs, fmt.Errorf("tools: has no effect in offline mode, as tool dependencies can't be told apart"))
+	}
+
 	if len(errs) > 0 {
 		return &options.ValidationError{Errors: errs}
 	}
@@ -41,4 +41,36 @@ func TestModOptions_Validate(t *testing.T) {
 		require.Len(t, validationError.Errors, 1)
 		require.Contains(t, validationError.Errors[0].Error(), "component type: \"foobar\" is invalid")
 	})
+	t.Run("OfflineWithTest", func(t *testing.T) {
+		var modOptions Options
+		modOptions.ComponentType = "application"
+		modOptions.OutputVersion = cdx.SpecVersion1_4.String()
+		modOptions.Offline = true
+		modOptions.IncludeTest = true
+
+		err := modOptions.Validate()
+		require.Error(t, err)
+
+		var validationError *options.ValidationError
+		require.ErrorAs(t, err, &validationError)
+
+		require.Len(t, validationError.Errors, 1)
+		require.Contains(t, validationError.Errors[0].Error(), "test: has no effect in offline mode")
+	})
+	t.Run("OfflineWithTools", func(t *testing.T) {
+		var modOptions Options
+		modOptions.ComponentType = "application"
+		modOptions.OutputVersion = cdx.SpecVersion1_4.String()
+		modOptions.Offline = true
+		modOptions.IncludeTools = true
+
+		err := modOptions.Validate()
+		require.Error(t, err)
+
+		var validationError *options.ValidationError
+		require.ErrorAs(t, err, &validationError)
+
+		require.Len(t, validationError.Errors, 1)
+		require.Contains(t, validationError.Errors[0].Error(), "tools: has no effect in offline mode")
+	})
 }
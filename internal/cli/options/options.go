@@ -29,7 +29,9 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/util"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/curation"
 	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/local"
 )
 
@@ -77,9 +79,17 @@ func (l LogOptions) Validate() error {
 	return nil // Nothing to validate
 }
 
+// Output formats supported by OutputOptions.
+const (
+	OutputFormatCycloneDX = "cyclonedx"
+	OutputFormatSPDXJSON  = "spdx-json"
+	OutputFormatSPDXTV    = "spdx-tv"
+)
+
 // OutputOptions provides options for customizing the output.
 type OutputOptions struct {
 	OutputFilePath    string
+	OutputFormat      string
 	OutputVersion     string
 	UseJSON           bool
 	DisableHTMLEscape bool
@@ -97,16 +107,40 @@ func (o *OutputOptions) RegisterFlags(fs *flag.FlagSet) {
 		cdx.SpecVersion1_0.String(),
 	}
 
+	formatChoices := []string{
+		OutputFormatCycloneDX,
+		OutputFormatSPDXJSON,
+		OutputFormatSPDXTV,
+	}
+
 	fs.BoolVar(&o.UseJSON, "json", false, "Output in JSON")
 	fs.StringVar(&o.OutputFilePath, "output", "-", "Output file path (or - for STDOUT)")
+	fs.StringVar(&o.OutputFormat, "format", OutputFormatCycloneDX,
+		fmt.Sprintf("Output format (%s)", strings.Join(formatChoices, ", ")))
 	fs.StringVar(&o.OutputVersion, "output-version", cdx.SpecVersion1_6.String(),
 		fmt.Sprintf("Output spec verson (%s)", strings.Join(versionChoices, ", ")))
 	fs.BoolVar(&o.DisableHTMLEscape, "disable-html-escape", false, "Disable HTML escaping in JSON output")
 }
 
 func (o OutputOptions) Validate() error {
+	errs := make([]error, 0)
+
 	if _, err := util.ParseSpecVersion(o.OutputVersion); err != nil {
-		return &ValidationError{Errors: []error{err}}
+		errs = append(errs, err)
+	}
+
+	switch o.OutputFormat {
+	case "", OutputFormatCycloneDX:
+	case OutputFormatSPDXJSON, OutputFormatSPDXTV:
+		if o.UseJSON {
+			errs = append(errs, fmt.Errorf("json: has no effect with output format %s", o.OutputFormat))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("output format: \"%s\" is invalid", o.OutputFormat))
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
 	}
 
 	return nil
@@ -115,10 +149,16 @@ func (o OutputOptions) Validate() error {
 // SBOMOptions provides options for customizing the SBOM.
 type SBOMOptions struct {
 	AssertLicenses             bool
+	CacheDir                   string
+	HashAlgorithms             HashAlgorithms
 	IncludeStd                 bool
 	LicenseConfidenceThreshold float64
+	LicenseCurations           string
+	LicensePolicy              string
+	NoCache                    bool
 	NoSerialNumber             bool
 	NoTimestamp                bool
+	Parallelism                int
 	ResolveLicenses            bool
 	SerialNumber               string
 	ShortPURLs                 bool
@@ -126,11 +166,22 @@ type SBOMOptions struct {
 
 func (s *SBOMOptions) RegisterFlags(fs *flag.FlagSet) {
 	fs.BoolVar(&s.AssertLicenses, "assert-licenses", false, "Assert detected licenses")
-	fs.BoolVar(&s.IncludeStd, "std", false, "Include Go standard library as component and dependency of the module")
+	fs.StringVar(&s.CacheDir, "cache-dir", "", "Directory to cache hashes and license detection results in (default cyclonedx-gomod in the user cache directory)")
+	// The default is assigned after registration and mentioned in the usage instead,
+	// as it would otherwise widen the flag column of the help text considerably.
+	fs.Var(&s.HashAlgorithms, "hash-algos", fmt.Sprintf("Comma-separated list of hash `algorithms` for files, binaries and the tool itself (default %s)",
+		HashAlgorithms(sbom.DefaultHashAlgorithms()).String()))
+	s.HashAlgorithms = sbom.DefaultHashAlgorithms()
+	fs.BoolVar(&s.IncludeStd, "std", false, "Include Go standard library and toolchain as component and dependency of the module")
 	fs.Float64Var(&s.LicenseConfidenceThreshold, "license-confidence-threshold", local.DefaultMinDetectionConfidence,
 		"Minimum confidence (0.0-1.0) required for a detected license to be included")
+	fs.StringVar(&s.LicenseCurations, "license-curations", "",
+		fmt.Sprintf("License curation file (default %s in the working or module directory, if present)", curation.DefaultFileName))
+	fs.StringVar(&s.LicensePolicy, "license-policy", "", "License policy file to evaluate licenses against, fails on violations")
+	fs.BoolVar(&s.NoCache, "no-cache", false, "Disable caching of hashes and license detection results")
 	fs.BoolVar(&s.NoSerialNumber, "noserial", false, "Omit serial number")
 	fs.BoolVar(&s.NoTimestamp, "notimestamp", false, "Omit timestamp")
+	fs.IntVar(&s.Parallelism, "parallelism", 0, "Number of modules to process concurrently (0 = number of CPUs)")
 	fs.BoolVar(&s.ResolveLicenses, "licenses", false, "Perform license detection")
 	fs.StringVar(&s.SerialNumber, "serial", "", "Serial number")
 	fs.BoolVar(&s.ShortPURLs, "short-purls", false, "Omit all qualifiers from PackageURLs")
@@ -143,10 +194,26 @@ func (s SBOMOptions) Validate() error {
 		errs = append(errs, fmt.Errorf("assertion of licenses has no effect without licenses detection"))
 	}
 
+	if s.LicenseCurations != "" && !s.ResolveLicenses {
+		errs = append(errs, fmt.Errorf("license curations have no effect without licenses detection"))
+	}
+
+	if s.LicensePolicy != "" && !s.ResolveLicenses {
+		errs = append(errs, fmt.Errorf("license policy has no effect without licenses detection"))
+	}
+
 	if s.LicenseConfidenceThreshold < 0 || s.LicenseConfidenceThreshold > 1 {
 		errs = append(errs, fmt.Errorf("license confidence threshold: must be between 0.0 and 1.0, got %v", s.LicenseConfidenceThreshold))
 	}
 
+	if s.NoCache && s.CacheDir != "" {
+		errs = append(errs, fmt.Errorf("cache dir: has no effect when caching is disabled"))
+	}
+
+	if s.Parallelism < 0 {
+		errs = append(errs, fmt.Errorf("parallelism: must not be negative, got %d", s.Parallelism))
+	}
+
 	// Serial numbers must be valid UUIDs
 	if !s.NoSerialNumber && s.SerialNumber != "" {
 		if _, err := uuid.Parse(s.SerialNumber); err != nil {
@@ -160,3 +227,38 @@ func (s SBOMOptions) Validate() error {
 
 	return nil
 }
+
+// HashAlgorithms is a flag that holds a comma-separated list of hash algorithms.
+// An empty list disables hashing.
+type HashAlgorithms []cdx.HashAlgorithm
+
+func (h *HashAlgorithms) Set(value string) error {
+	algos := make([]cdx.HashAlgorithm, 0)
+	for name := range strings.SplitSeq(value, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+
+		algo, err := sbom.ParseHashAlgorithm(name)
+		if err != nil {
+			return err
+		}
+		algos = append(algos, algo)
+	}
+
+	if err := sbom.ValidateHashAlgorithms(algos...); err != nil {
+		return err
+	}
+
+	*h = algos
+	return nil
+}
+
+func (h HashAlgorithms) String() string {
+	names := make([]string, 0, len(h))
+	for _, algo := range h {
+		names = append(names, string(algo))
+	}
+
+	return strings.Join(names, ",")
+}
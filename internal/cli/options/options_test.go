@@ -73,6 +73,36 @@ func TestSBOMOptions_Validate(t *testing.T) {
 		require.Contains(t, validationError.Errors[0].Error(), "has no effect")
 	})
 
+	t.Run("LicenseCurationsWithoutLicenseResolution", func(t *testing.T) {
+		var options SBOMOptions
+		options.LicenseCurations = "curations.yaml"
+		options.ResolveLicenses = false
+
+		err := options.Validate()
+		require.Error(t, err)
+
+		var validationError *ValidationError
+		require.ErrorAs(t, err, &validationError)
+
+		require.Len(t, validationError.Errors, 1)
+		require.Contains(t, validationError.Errors[0].Error(), "license curations")
+	})
+
+	t.Run("LicensePolicyWithoutLicenseResolution", func(t *testing.T) {
+		var options SBOMOptions
+		options.LicensePolicy = "policy.yaml"
+		options.ResolveLicenses = false
+
+		err := options.Validate()
+		require.Error(t, err)
+
+		var validationError *ValidationError
+		require.ErrorAs(t, err, &validationError)
+
+		require.Len(t, validationError.Errors, 1)
+		require.Contains(t, validationError.Errors[0].Error(), "license policy")
+	})
+
 	t.Run("InvalidSerialNumber", func(t *testing.T) {
 		var options SBOMOptions
 		options.SerialNumber = "foobar"
@@ -102,4 +132,62 @@ func TestSBOMOptions_Validate(t *testing.T) {
 			require.Contains(t, validationError.Errors[0].Error(), "license confidence threshold")
 		}
 	})
+
+	t.Run("CacheDirWithoutCache", func(t *testing.T) {
+		var options SBOMOptions
+		options.CacheDir = t.TempDir()
+		options.NoCache = true
+
+		err := options.Validate()
+		require.Error(t, err)
+
+		var validationError *ValidationError
+		require.ErrorAs(t, err, &validationError)
+
+		require.Len(t, validationError.Errors, 1)
+		require.Contains(t, validationError.Errors[0].Error(), "cache dir")
+	})
+
+	t.Run("NegativeParallelism", func(t *testing.T) {
+		var options SBOMOptions
+		options.Parallelism = -1
+
+		err := options.Validate()
+		require.Error(t, err)
+
+		var validationError *ValidationError
+		require.ErrorAs(t, err, &validationError)
+
+		require.Len(t, validationError.Errors, 1)
+		require.Contains(t, validationError.Errors[0].Error(), "parallelism")
+	})
+}
+
+func TestHashAlgorithms_Set(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		var algos HashAlgorithms
+		err := algos.Set("sha256, SHA3-512,blake3")
+		require.NoError(t, err)
+		require.Equal(t, HashAlgorithms{cdx.HashAlgoSHA256, cdx.HashAlgoSHA3_512, cdx.HashAlgoBlake3}, algos)
+		require.Equal(t, "SHA-256,SHA3-512,BLAKE3", algos.String())
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		algos := HashAlgorithms{cdx.HashAlgoSHA256}
+		err := algos.Set("")
+		require.NoError(t, err)
+		require.Empty(t, algos)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		var algos HashAlgorithms
+		err := algos.Set("SHA-256,CRC32")
+		require.ErrorContains(t, err, "unsupported hash algorithm: CRC32")
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		var algos HashAlgorithms
+		err := algos.Set("SHA-256,sha256")
+		require.ErrorContains(t, err, "duplicate hash algorithm")
+	})
 }
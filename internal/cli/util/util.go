@@ -18,18 +18,25 @@
 package util
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 
+	"github.com/CycloneDX/cyclonedx-gomod/internal/cache"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/cli/options"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom/policy"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom/spdx"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/util"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/curation"
 )
 
 func AddCommonMetadata(logger zerolog.Logger, bom *cdx.BOM, sbomOptions options.SBOMOptions) error {
@@ -37,7 +44,7 @@ func AddCommonMetadata(logger zerolog.Logger, bom *cdx.BOM, sbomOptions options.
 		bom.Metadata = &cdx.Metadata{}
 	}
 
-	tool, err := sbom.BuildToolMetadata(logger)
+	tool, err := sbom.BuildToolMetadata(logger, sbomOptions.HashAlgorithms...)
 	if err != nil {
 		return fmt.Errorf("failed to build tool metadata: %w", err)
 	}
@@ -52,6 +59,115 @@ func AddCommonMetadata(logger zerolog.Logger, bom *cdx.BOM, sbomOptions options.
 	return nil
 }
 
+// CacheDir returns the directory of the persistent cache according to the provided SBOMOptions.
+// An empty string is returned when caching is disabled.
+func CacheDir(logger zerolog.Logger, sbomOptions options.SBOMOptions) string {
+	if sbomOptions.NoCache {
+		return ""
+	}
+	if sbomOptions.CacheDir != "" {
+		return sbomOptions.CacheDir
+	}
+
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		logger.Debug().Err(err).Msg("no default cache directory available, caching is disabled")
+		return ""
+	}
+
+	return dir
+}
+
+// LoadLicenseCurations loads the license curations according to the provided SBOMOptions.
+//
+// When no curation file was specified explicitly, the default curation file
+// in dir is loaded if it exists. nil is returned when license detection is disabled,
+// or no curation file is available.
+func LoadLicenseCurations(logger zerolog.Logger, sbomOptions options.SBOMOptions, dir string) (*curation.Curations, error) {
+	if !sbomOptions.ResolveLicenses {
+		return nil, nil
+	}
+
+	path := sbomOptions.LicenseCurations
+	if path == "" {
+		path = filepath.Join(dir, curation.DefaultFileName)
+		if _, err := os.Stat(path); err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+	}
+
+	logger.Debug().Str("path", path).Msg("loading license curations")
+	curations, err := curation.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load license curations: %w", err)
+	}
+
+	return curations, nil
+}
+
+// LoadLicensePolicy loads the license policy according to the provided SBOMOptions.
+// nil is returned when no license policy was specified.
+func LoadLicensePolicy(sbomOptions options.SBOMOptions) (*policy.Policy, error) {
+	if sbomOptions.LicensePolicy == "" {
+		return nil, nil
+	}
+
+	licensePolicy, err := policy.Load(sbomOptions.LicensePolicy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load license policy: %w", err)
+	}
+
+	return licensePolicy, nil
+}
+
+// CheckLicensePolicy evaluates the licenses of bom against licensePolicy and writes a report of
+// all violations and warnings to writer. An error is returned when the policy is violated.
+// A nil licensePolicy is never violated.
+func CheckLicensePolicy(writer io.Writer, bom *cdx.BOM, licensePolicy *policy.Policy) error {
+	if licensePolicy == nil {
+		return nil
+	}
+
+	result := licensePolicy.Evaluate(bom)
+
+	var sb strings.Builder
+	section := func(title string, findings []policy.Finding) {
+		if len(findings) == 0 {
+			return
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(title + ":\n")
+		for _, finding := range findings {
+			line := finding.Name
+			if finding.Version != "" {
+				line += " " + finding.Version
+			}
+			if finding.Licenses != "" {
+				line += " (" + finding.Licenses + ")"
+			}
+			sb.WriteString("  " + line + ": " + strings.Join(finding.Reasons, ", ") + "\n")
+		}
+	}
+
+	section("License policy violations", result.Violations)
+	section("License policy warnings", result.Warnings)
+
+	if _, err := io.WriteString(writer, sb.String()); err != nil {
+		return fmt.Errorf("failed to write license policy report: %w", err)
+	}
+
+	if len(result.Violations) > 0 {
+		return fmt.Errorf("license policy violated by %d component(s)", len(result.Violations))
+	}
+
+	return nil
+}
+
 // SetSerialNumber sets the serial number of a given BOM according to the provided SBOMOptions.
 func SetSerialNumber(bom *cdx.BOM, sbomOptions options.SBOMOptions) error {
 	if sbomOptions.NoSerialNumber {
@@ -71,20 +187,41 @@ func SetSerialNumber(bom *cdx.BOM, sbomOptions options.SBOMOptions) error {
 	return nil
 }
 
-// WriteBOM writes the given bom according to the provided OutputOptions.
-func WriteBOM(bom *cdx.BOM, outputOptions options.OutputOptions) error {
-	var outputFormat cdx.BOMFileFormat
-	if outputOptions.UseJSON {
-		outputFormat = cdx.BOMFileFormatJSON
+// ReadBOM reads a CycloneDX BOM from the file at path, or from stdin if path is "-".
+// Both JSON and XML are supported, the format is determined from the file's content.
+func ReadBOM(path string) (*cdx.BOM, error) {
+	var (
+		content []byte
+		err     error
+	)
+	if path == "-" {
+		content, err = io.ReadAll(os.Stdin)
 	} else {
-		outputFormat = cdx.BOMFileFormatXML
+		content, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
 	}
 
-	outputVersion, err := util.ParseSpecVersion(outputOptions.OutputVersion)
+	format := cdx.BOMFileFormatJSON
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("<")) {
+		format = cdx.BOMFileFormatXML
+	}
+
+	var bom cdx.BOM
+	err = cdx.NewBOMDecoder(bytes.NewReader(content), format).Decode(&bom)
 	if err != nil {
-		return fmt.Errorf("failed to parse output version: %w", err)
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
 	}
 
+	return &bom, nil
+}
+
+// WriteBOM writes the given bom according to the provided OutputOptions.
+//
+// Depending on the output format, the BOM is either written as CycloneDX,
+// or converted to an SPDX document first.
+func WriteBOM(bom *cdx.BOM, outputOptions options.OutputOptions) error {
 	var outputWriter io.Writer
 	if outputOptions.OutputFilePath == "" || outputOptions.OutputFilePath == "-" {
 		outputWriter = os.Stdout
@@ -97,6 +234,33 @@ func WriteBOM(bom *cdx.BOM, outputOptions options.OutputOptions) error {
 		outputWriter = outputFile
 	}
 
+	switch outputOptions.OutputFormat {
+	case options.OutputFormatSPDXJSON, options.OutputFormatSPDXTV:
+		return writeSPDX(bom, outputWriter, outputOptions)
+	default:
+		return writeCycloneDX(bom, outputWriter, outputOptions)
+	}
+}
+
+func writeCycloneDX(bom *cdx.BOM, outputWriter io.Writer, outputOptions options.OutputOptions) error {
+	var outputFormat cdx.BOMFileFormat
+	if outputOptions.UseJSON {
+		outputFormat = cdx.BOMFileFormatJSON
+	} else {
+		outputFormat = cdx.BOMFileFormatXML
+	}
+
+	outputVersion, err := util.ParseSpecVersion(outputOptions.OutputVersion)
+	if err != nil {
+		return fmt.Errorf("failed to parse output version: %w", err)
+	}
+
+	if outputVersion < cdx.SpecVersion1_6 {
+		// cyclonedx-go only removes acknowledgements from licenses,
+		// but not from expressions.
+		removeExpressionAcknowledgements(bom)
+	}
+
 	encoder := cdx.NewBOMEncoder(outputWriter, outputFormat)
 	encoder.SetPretty(true)
 
@@ -110,3 +274,45 @@ func WriteBOM(bom *cdx.BOM, outputOptions options.OutputOptions) error {
 
 	return nil
 }
+
+func removeExpressionAcknowledgements(bom *cdx.BOM) {
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		removeComponentExpressionAcknowledgements(bom.Metadata.Component)
+	}
+	if bom.Components != nil {
+		for i := range *bom.Components {
+			removeComponentExpressionAcknowledgements(&(*bom.Components)[i])
+		}
+	}
+}
+
+func removeComponentExpressionAcknowledgements(c *cdx.Component) {
+	if c.Licenses != nil {
+		for i := range *c.Licenses {
+			(*c.Licenses)[i].Acknowledgement = nil
+		}
+	}
+	if c.Components != nil {
+		for i := range *c.Components {
+			removeComponentExpressionAcknowledgements(&(*c.Components)[i])
+		}
+	}
+}
+
+func writeSPDX(bom *cdx.BOM, outputWriter io.Writer, outputOptions options.OutputOptions) error {
+	doc, err := spdx.FromBOM(bom)
+	if err != nil {
+		return fmt.Errorf("failed to convert sbom to spdx: %w", err)
+	}
+
+	if outputOptions.OutputFormat == options.OutputFormatSPDXTV {
+		err = spdx.EncodeTagValue(outputWriter, doc)
+	} else {
+		err = spdx.EncodeJSON(outputWriter, doc, !outputOptions.DisableHTMLEscape)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode spdx document: %w", err)
+	}
+
+	return nil
+}
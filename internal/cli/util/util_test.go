@@ -18,10 +18,15 @@
 package util
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/CycloneDX/cyclonedx-go"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/cli/options"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom/policy"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/curation"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 )
@@ -85,3 +90,208 @@ func TestAddCommonMetadata(t *testing.T) {
 		require.Empty(t, bom.Metadata.Timestamp)
 	})
 }
+
+func TestCacheDir(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		t.Setenv("XDG_CACHE_HOME", "/tmp/xdg-cache")
+		t.Setenv("HOME", "/tmp/home")
+		t.Setenv("LocalAppData", "/tmp/localappdata")
+
+		dir := CacheDir(zerolog.Nop(), options.SBOMOptions{})
+		require.Equal(t, "cyclonedx-gomod", filepath.Base(dir))
+	})
+
+	t.Run("Custom", func(t *testing.T) {
+		require.Equal(t, "/tmp/cache", CacheDir(zerolog.Nop(), options.SBOMOptions{CacheDir: "/tmp/cache"}))
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		require.Empty(t, CacheDir(zerolog.Nop(), options.SBOMOptions{NoCache: true}))
+	})
+}
+
+func TestReadBOM(t *testing.T) {
+	t.Run("JSON", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bom.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"bomFormat":"CycloneDX","specVersion":"1.4","components":[{"type":"library","name":"foo"}]}`), 0o600))
+
+		bom, err := ReadBOM(path)
+		require.NoError(t, err)
+		require.NotNil(t, bom.Components)
+		require.Equal(t, "foo", (*bom.Components)[0].Name)
+	})
+
+	t.Run("XML", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bom")
+		require.NoError(t, os.WriteFile(path, []byte(`
+<?xml version="1.0" encoding="UTF-8"?>
+<bom xmlns="http://cyclonedx.org/schema/bom/1.4" version="1"><components><component type="library"><name>foo</name></component></components></bom>`), 0o600))
+
+		bom, err := ReadBOM(path)
+		require.NoError(t, err)
+		require.NotNil(t, bom.Components)
+		require.Equal(t, "foo", (*bom.Components)[0].Name)
+	})
+
+	t.Run("NotExists", func(t *testing.T) {
+		_, err := ReadBOM(filepath.Join(t.TempDir(), "bom.json"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestLoadLicenseCurations(t *testing.T) {
+	const content = `
+curations:
+  - module: github.com/foo/bar
+    license: MIT
+`
+
+	t.Run("LicensesDisabled", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, curation.DefaultFileName), []byte(content), 0o600))
+
+		curations, err := LoadLicenseCurations(zerolog.Nop(), options.SBOMOptions{}, dir)
+		require.NoError(t, err)
+		require.Nil(t, curations)
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, curation.DefaultFileName), []byte(content), 0o600))
+
+		curations, err := LoadLicenseCurations(zerolog.Nop(), options.SBOMOptions{ResolveLicenses: true}, dir)
+		require.NoError(t, err)
+		require.NotNil(t, curations)
+		require.Len(t, curations.Curations, 1)
+	})
+
+	t.Run("DefaultNotExists", func(t *testing.T) {
+		curations, err := LoadLicenseCurations(zerolog.Nop(), options.SBOMOptions{ResolveLicenses: true}, t.TempDir())
+		require.NoError(t, err)
+		require.Nil(t, curations)
+	})
+
+	t.Run("Custom", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "curations.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+		curations, err := LoadLicenseCurations(zerolog.Nop(), options.SBOMOptions{ResolveLicenses: true, LicenseCurations: path}, t.TempDir())
+		require.NoError(t, err)
+		require.NotNil(t, curations)
+		require.Len(t, curations.Curations, 1)
+	})
+
+	t.Run("CustomNotExists", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "curations.yaml")
+
+		_, err := LoadLicenseCurations(zerolog.Nop(), options.SBOMOptions{ResolveLicenses: true, LicenseCurations: path}, t.TempDir())
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestWriteBOM(t *testing.T) {
+	newBOM := func() *cyclonedx.BOM {
+		concluded := cyclonedx.LicenseAcknowledgementConcluded
+		return &cyclonedx.BOM{
+			Components: &[]cyclonedx.Component{
+				{
+					Type: cyclonedx.ComponentTypeLibrary,
+					Name: "foo",
+					Licenses: &cyclonedx.Licenses{
+						{Expression: "MIT OR Apache-2.0", Acknowledgement: &concluded},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("ExpressionAcknowledgement", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bom.json")
+
+		err := WriteBOM(newBOM(), options.OutputOptions{OutputFilePath: path, OutputVersion: "1.6", UseJSON: true})
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Contains(t, string(content), `"acknowledgement": "concluded"`)
+	})
+
+	t.Run("ExpressionAcknowledgementBefore1.6", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "bom.json")
+
+		err := WriteBOM(newBOM(), options.OutputOptions{OutputFilePath: path, OutputVersion: "1.5", UseJSON: true})
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Contains(t, string(content), `"expression": "MIT OR Apache-2.0"`)
+		require.NotContains(t, string(content), "acknowledgement")
+	})
+}
+
+func TestLoadLicensePolicy(t *testing.T) {
+	t.Run("None", func(t *testing.T) {
+		licensePolicy, err := LoadLicensePolicy(options.SBOMOptions{ResolveLicenses: true})
+		require.NoError(t, err)
+		require.Nil(t, licensePolicy)
+	})
+
+	t.Run("Custom", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "policy.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("deny: [GPL-3.0-only]\n"), 0o600))
+
+		licensePolicy, err := LoadLicensePolicy(options.SBOMOptions{ResolveLicenses: true, LicensePolicy: path})
+		require.NoError(t, err)
+		require.NotNil(t, licensePolicy)
+		require.Equal(t, []string{"GPL-3.0-only"}, licensePolicy.Deny)
+	})
+
+	t.Run("NotExists", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "policy.yaml")
+
+		_, err := LoadLicensePolicy(options.SBOMOptions{ResolveLicenses: true, LicensePolicy: path})
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestCheckLicensePolicy(t *testing.T) {
+	bom := &cyclonedx.BOM{
+		Components: &[]cyclonedx.Component{
+			{Name: "a", Version: "v1.0.0", Licenses: &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "MIT"}}}},
+			{Name: "b", Version: "v1.0.0", Licenses: &cyclonedx.Licenses{{Expression: "GPL-3.0-only AND MPL-2.0"}}},
+			{Name: "c", Version: "v1.0.0", Licenses: &cyclonedx.Licenses{{License: &cyclonedx.License{ID: "MPL-2.0"}}}},
+		},
+	}
+
+	t.Run("NoPolicy", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+
+		err := CheckLicensePolicy(buf, bom, nil)
+		require.NoError(t, err)
+		require.Empty(t, buf.String())
+	})
+
+	t.Run("Violations", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+
+		err := CheckLicensePolicy(buf, bom, &policy.Policy{Deny: []string{"GPL-*"}, Warn: []string{"MPL-2.0"}})
+		require.EqualError(t, err, "license policy violated by 1 component(s)")
+		require.Equal(t, `License policy violations:
+  b v1.0.0 (GPL-3.0-only AND MPL-2.0): GPL-3.0-only is denied, MPL-2.0 requires review
+
+License policy warnings:
+  c v1.0.0 (MPL-2.0): MPL-2.0 requires review
+`, buf.String())
+	})
+
+	t.Run("WarningsOnly", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+
+		err := CheckLicensePolicy(buf, bom, &policy.Policy{Warn: []string{"MPL-2.0"}})
+		require.NoError(t, err)
+		require.Equal(t, `License policy warnings:
+  b v1.0.0 (GPL-3.0-only AND MPL-2.0): MPL-2.0 requires review
+  c v1.0.0 (MPL-2.0): MPL-2.0 requires review
+`, buf.String())
+	})
+}
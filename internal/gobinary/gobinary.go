@@ -0,0 +1,321 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+// Package gobinary locates Go binaries in directories and archives,
+// and inspects the native libraries they link against.
+package gobinary
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"debug/buildinfo"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/gomod"
+)
+
+// Collection is a set of Go binaries found in a directory or archive.
+type Collection struct {
+	Binaries []Binary // Sorted by path
+
+	workDir string
+}
+
+// Binary is a Go binary within a directory or archive.
+type Binary struct {
+	Path string // Slash-separated path of the binary, relative to the directory or archive root
+	File string // Path of the binary on the local file system
+}
+
+// Close removes binaries that have been extracted from an archive.
+func (c *Collection) Close() error {
+	if c.workDir == "" {
+		return nil
+	}
+
+	return os.RemoveAll(c.workDir)
+}
+
+var archiveExtensions = []string{".tar.gz", ".tgz", ".tar", ".zip"}
+
+// IsArchive checks whether filePath has the extension of a supported archive format.
+func IsArchive(filePath string) bool {
+	return slices.ContainsFunc(archiveExtensions, func(ext string) bool {
+		return strings.HasSuffix(strings.ToLower(filePath), ext)
+	})
+}
+
+// TrimArchiveExtension removes the extension of a supported archive format from name.
+func TrimArchiveExtension(name string) string {
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+
+	return name
+}
+
+// Find locates all Go binaries in the directory or archive at searchPath.
+// Supported archive formats are tar (optionally compressed with gzip) and zip.
+// Binaries within archives are extracted to a temporary directory,
+// which is removed when the collection is closed.
+//
+// Archives within directories are not searched, because release
+// artifacts commonly contain the same binaries in both forms.
+func Find(logger zerolog.Logger, searchPath string) (*Collection, error) {
+	fileInfo, err := os.Stat(searchPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if fileInfo.IsDir() {
+		c := Collection{}
+		if err = c.findInDir(logger, searchPath); err != nil {
+			return nil, err
+		}
+		return &c, nil
+	}
+
+	if !IsArchive(searchPath) {
+		return nil, fmt.Errorf("%s is neither a directory nor a supported archive", searchPath)
+	}
+
+	workDir, err := os.MkdirTemp("", "cyclonedx-gomod-bin_*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create working directory: %w", err)
+	}
+
+	c := Collection{workDir: workDir}
+	if strings.HasSuffix(strings.ToLower(searchPath), ".zip") {
+		err = c.findInZip(logger, searchPath)
+	} else {
+		err = c.findInTar(logger, searchPath)
+	}
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to search %s: %w", searchPath, err)
+	}
+
+	return &c, nil
+}
+
+func (c *Collection) findInDir(logger zerolog.Logger, dir string) error {
+	err := filepath.WalkDir(dir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil // Symlinks would cause duplicates
+		}
+
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		magic, _ := bufio.NewReader(file).Peek(4)
+		file.Close()
+		if !IsExecutable(magic) || !IsGoBinary(logger, filePath) {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		c.add(logger, filepath.ToSlash(relPath), filePath)
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.sort()
+	return nil
+}
+
+func (c *Collection) findInTar(logger zerolog.Logger, archivePath string) error {
+	archiveFile, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer archiveFile.Close()
+
+	var reader io.Reader = archiveFile
+	if !strings.HasSuffix(strings.ToLower(archivePath), ".tar") {
+		gzipReader, err := gzip.NewReader(archiveFile)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	}
+
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if err = c.extract(logger, header.Name, tarReader); err != nil {
+			return err
+		}
+	}
+
+	c.sort()
+	return nil
+}
+
+func (c *Collection) findInZip(logger zerolog.Logger, archivePath string) error {
+	zipReader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer zipReader.Close()
+
+	for _, zipFile := range zipReader.File {
+		if !zipFile.Mode().IsRegular() {
+			continue
+		}
+
+		reader, err := zipFile.Open()
+		if err != nil {
+			return err
+		}
+		err = c.extract(logger, zipFile.Name, reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	c.sort()
+	return nil
+}
+
+// extract writes an archive entry to the working directory, if it is a Go binary.
+func (c *Collection) extract(logger zerolog.Logger, name string, reader io.Reader) error {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+
+	bufferedReader := bufio.NewReader(reader)
+	magic, _ := bufferedReader.Peek(4)
+	if !IsExecutable(magic) {
+		return nil
+	}
+
+	// Retain the file name, as it ends up in the SBOM
+	dir := filepath.Join(c.workDir, strconv.Itoa(len(c.Binaries)))
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		return err
+	}
+	filePath := filepath.Join(dir, path.Base(name))
+
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(file, bufferedReader); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to extract %s: %w", name, err)
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+
+	if !IsGoBinary(logger, filePath) {
+		return os.RemoveAll(dir)
+	}
+	c.add(logger, name, filePath)
+
+	return nil
+}
+
+func (c *Collection) add(logger zerolog.Logger, binaryPath, filePath string) {
+	logger.Debug().
+		Str("path", binaryPath).
+		Msg("found go binary")
+
+	c.Binaries = append(c.Binaries, Binary{
+		Path: binaryPath,
+		File: filePath,
+	})
+}
+
+func (c *Collection) sort() {
+	slices.SortFunc(c.Binaries, func(a, b Binary) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+}
+
+// IsGoBinary checks whether the file at filePath is a Go binary. Binaries without build information
+// are accepted as well if they contain the Go runtime, as their modules can still be inferred from their symbol table.
+func IsGoBinary(logger zerolog.Logger, filePath string) bool {
+	_, err := buildinfo.ReadFile(filePath)
+	if err == nil {
+		return true
+	}
+
+	if gomod.HasGoRuntime(filePath) {
+		logger.Debug().
+			Str("file", filePath).
+			Str("reason", err.Error()).
+			Msg("executable contains no build information, but go runtime symbols")
+		return true
+	}
+
+	logger.Debug().
+		Str("file", filePath).
+		Str("reason", err.Error()).
+		Msg("skipping executable")
+	return false
+}
+
+// IsExecutable checks whether magic identifies an ELF, PE or Mach-O file.
+func IsExecutable(magic []byte) bool {
+	switch {
+	case bytes.HasPrefix(magic, []byte("\x7fELF")),
+		bytes.HasPrefix(magic, []byte("MZ")),
+		bytes.HasPrefix(magic, []byte{0xfe, 0xed, 0xfa}),
+		bytes.HasPrefix(magic, []byte{0xce, 0xfa, 0xed, 0xfe}),
+		bytes.HasPrefix(magic, []byte{0xcf, 0xfa, 0xed, 0xfe}),
+		bytes.HasPrefix(magic, []byte{0xca, 0xfe, 0xba, 0xbe}):
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,159 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package gobinary
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"debug/buildinfo"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type file struct {
+	name    string
+	content []byte
+}
+
+func testFiles(t *testing.T) []file {
+	goBinary, err := os.ReadFile("../../pkg/generate/testdata/simple")
+	require.NoError(t, err)
+
+	return []file{
+		{"README.md", []byte("# README")},
+		{"linux_amd64/app", goBinary},
+		{"linux_amd64/libfoo.so", []byte("\x7fELF not a go binary")},
+		{"windows_amd64/app.exe", goBinary},
+	}
+}
+
+func requireCollection(t *testing.T, collection *Collection, extracted bool) {
+	require.Len(t, collection.Binaries, 2)
+	assert.Equal(t, "linux_amd64/app", collection.Binaries[0].Path)
+	assert.Equal(t, "app", filepath.Base(collection.Binaries[0].File))
+	assert.Equal(t, "windows_amd64/app.exe", collection.Binaries[1].Path)
+	assert.Equal(t, "app.exe", filepath.Base(collection.Binaries[1].File))
+
+	require.NoError(t, collection.Close())
+	for _, binary := range collection.Binaries {
+		if extracted {
+			assert.NoFileExists(t, binary.File)
+		} else {
+			assert.FileExists(t, binary.File)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	t.Run("Directory", func(t *testing.T) {
+		dir := t.TempDir()
+		for _, f := range testFiles(t) {
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(f.name)), 0o700))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, f.name), f.content, 0o700))
+		}
+		require.NoError(t, os.Symlink(filepath.Join(dir, "linux_amd64", "app"), filepath.Join(dir, "app")))
+
+		collection, err := Find(zerolog.Nop(), dir)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "linux_amd64", "app"), collection.Binaries[0].File)
+		requireCollection(t, collection, false)
+	})
+
+	t.Run("TarGz", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "dist.tar.gz")
+		archiveFile, err := os.Create(archivePath)
+		require.NoError(t, err)
+		gzipWriter := gzip.NewWriter(archiveFile)
+		tarWriter := tar.NewWriter(gzipWriter)
+		for _, f := range testFiles(t) {
+			require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: f.name, Mode: 0o755, Size: int64(len(f.content))}))
+			_, err = tarWriter.Write(f.content)
+			require.NoError(t, err)
+		}
+		require.NoError(t, tarWriter.Close())
+		require.NoError(t, gzipWriter.Close())
+		require.NoError(t, archiveFile.Close())
+
+		collection, err := Find(zerolog.Nop(), archivePath)
+		require.NoError(t, err)
+		requireCollection(t, collection, true)
+	})
+
+	t.Run("Zip", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "dist.zip")
+		archiveFile, err := os.Create(archivePath)
+		require.NoError(t, err)
+		zipWriter := zip.NewWriter(archiveFile)
+		for _, f := range testFiles(t) {
+			writer, err := zipWriter.Create(f.name)
+			require.NoError(t, err)
+			_, err = writer.Write(f.content)
+			require.NoError(t, err)
+		}
+		require.NoError(t, zipWriter.Close())
+		require.NoError(t, archiveFile.Close())
+
+		collection, err := Find(zerolog.Nop(), archivePath)
+		require.NoError(t, err)
+		requireCollection(t, collection, true)
+	})
+
+	t.Run("StrippedBuildInfo", func(t *testing.T) {
+		goBinary, err := os.ReadFile("../../pkg/generate/testdata/simple")
+		require.NoError(t, err)
+
+		// Remove the build information by destroying its magic
+		magic := []byte("\xff Go buildinf:")
+		require.Equal(t, 1, bytes.Count(goBinary, magic))
+		stripped := bytes.Replace(goBinary, magic, make([]byte, len(magic)), 1)
+
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "app"), stripped, 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "libfoo.so"), []byte("\x7fELF not a go binary"), 0o700))
+
+		_, err = buildinfo.ReadFile(filepath.Join(dir, "app"))
+		require.Error(t, err)
+
+		collection, err := Find(zerolog.Nop(), dir)
+		require.NoError(t, err)
+		require.Len(t, collection.Binaries, 1)
+		assert.Equal(t, "app", collection.Binaries[0].Path)
+		require.NoError(t, collection.Close())
+	})
+
+	t.Run("UnsupportedFile", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "dist.rar")
+		require.NoError(t, os.WriteFile(filePath, []byte("foo"), 0o600))
+
+		_, err := Find(zerolog.Nop(), filePath)
+		require.ErrorContains(t, err, "neither a directory nor a supported archive")
+	})
+}
+
+func TestTrimArchiveExtension(t *testing.T) {
+	assert.Equal(t, "acme_1.0.0_linux_amd64", TrimArchiveExtension("acme_1.0.0_linux_amd64.tar.gz"))
+	assert.Equal(t, "acme", TrimArchiveExtension("acme.ZIP"))
+	assert.Equal(t, "dist", TrimArchiveExtension("dist"))
+}
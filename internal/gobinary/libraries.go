@@ -0,0 +1,91 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package gobinary
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// LinkedLibraries returns the shared libraries that a binary is dynamically linked against.
+// Those are the DT_NEEDED entries of ELF files, the dylib load commands of Mach-O files,
+// and the imported DLLs of PE files. Statically linked binaries yield no libraries.
+func LinkedLibraries(filePath string) ([]string, error) {
+	var (
+		libraries []string
+		err       error
+	)
+
+	if elfFile, elfErr := elf.Open(filePath); elfErr == nil {
+		defer elfFile.Close()
+		libraries, err = elfFile.ImportedLibraries()
+	} else if machoFile, machoErr := macho.Open(filePath); machoErr == nil {
+		defer machoFile.Close()
+		libraries, err = machoFile.ImportedLibraries()
+	} else if fatFile, fatErr := macho.OpenFat(filePath); fatErr == nil {
+		defer fatFile.Close()
+		for _, arch := range fatFile.Arches {
+			archLibraries, archErr := arch.ImportedLibraries()
+			if archErr != nil {
+				err = archErr
+				break
+			}
+			libraries = append(libraries, archLibraries...)
+		}
+	} else if peFile, peErr := pe.Open(filePath); peErr == nil {
+		defer peFile.Close()
+		libraries, err = peImportedLibraries(peFile)
+	} else {
+		return nil, errors.New("unrecognized executable format")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read imported libraries: %w", err)
+	}
+
+	// DLL names are case-insensitive, and are referenced inconsistently
+	slices.SortFunc(libraries, func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+	libraries = slices.CompactFunc(libraries, strings.EqualFold)
+
+	return libraries, nil
+}
+
+// peImportedLibraries determines the DLLs imported by a PE file.
+// debug/pe doesn't implement ImportedLibraries, but imported symbols are
+// reported in "symbol:dll" notation.
+func peImportedLibraries(file *pe.File) ([]string, error) {
+	symbols, err := file.ImportedSymbols()
+	if err != nil {
+		return nil, err
+	}
+
+	var libraries []string
+	for _, symbol := range symbols {
+		if i := strings.LastIndex(symbol, ":"); i >= 0 {
+			libraries = append(libraries, symbol[i+1:])
+		}
+	}
+
+	return libraries, nil
+}
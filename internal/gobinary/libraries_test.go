@@ -0,0 +1,78 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package gobinary
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/testutil"
+)
+
+func TestLinkedLibraries(t *testing.T) {
+	t.Run("ELF", func(t *testing.T) {
+		libraries, err := LinkedLibraries("../../pkg/generate/testdata/simple1.18")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"libc.so.6", "libpthread.so.0"}, libraries)
+	})
+
+	t.Run("ELF Static", func(t *testing.T) {
+		libraries, err := LinkedLibraries("../../pkg/generate/testdata/simple")
+		require.NoError(t, err)
+		assert.Empty(t, libraries)
+	})
+
+	t.Run("MachO", func(t *testing.T) {
+		libraries, err := LinkedLibraries(crossCompile(t, "darwin"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/usr/lib/libSystem.B.dylib"}, libraries)
+	})
+
+	t.Run("PE", func(t *testing.T) {
+		libraries, err := LinkedLibraries(crossCompile(t, "windows"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"kernel32.dll"}, libraries)
+	})
+
+	t.Run("NotAnExecutable", func(t *testing.T) {
+		_, err := LinkedLibraries("./libraries.go")
+		require.EqualError(t, err, "unrecognized executable format")
+	})
+}
+
+// crossCompile builds a minimal Go program for the given OS.
+func crossCompile(t *testing.T, goos string) string {
+	testutil.SkipIfShort(t)
+
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o600))
+
+	binaryPath := filepath.Join(tmpDir, "app")
+	cmd := exec.Command("go", "build", "-o", binaryPath, "main.go")
+	cmd.Dir = tmpDir
+	cmd.Env = append(os.Environ(), "GOOS="+goos, "GOARCH=amd64", "CGO_ENABLED=0", "GOFLAGS=")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	return binaryPath
+}
@@ -19,20 +19,22 @@ package gocmd
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"regexp"
+	"time"
 
 	"github.com/rs/zerolog"
 )
 
 // GetVersion returns the version of Go in the environment.
-func GetVersion(logger zerolog.Logger) (string, error) {
+func GetVersion(ctx context.Context, logger zerolog.Logger) (string, error) {
 	buf := new(bytes.Buffer)
-	err := executeGoCommand(logger, []string{"version"}, withStdout(buf))
+	err := executeGoCommand(ctx, logger, []string{"version"}, withStdout(buf))
 	if err != nil {
 		return "", err
 	}
@@ -56,38 +58,46 @@ func ParseVersion(s string) (string, error) {
 
 // GetEnv executes `go env -json` and returns the result as a map.
 // See https://pkg.go.dev/cmd/go#hdr-Print_Go_environment_information.
-func GetEnv(logger zerolog.Logger) (map[string]string, error) {
+//
+// env may contain additional "key=value" pairs that will be passed to the go command.
+func GetEnv(ctx context.Context, logger zerolog.Logger, env ...string) (map[string]string, error) {
 	buf := new(bytes.Buffer)
-	err := executeGoCommand(logger, []string{"env", "-json"}, withStdout(buf)) //nolint:goconst
+	err := executeGoCommand(ctx, logger, []string{"env", "-json"}, withEnv(env...), withStdout(buf)) //nolint:goconst
 	if err != nil {
 		return nil, err
 	}
 
-	var env map[string]string
-	err = json.NewDecoder(buf).Decode(&env)
+	var goEnv map[string]string
+	err = json.NewDecoder(buf).Decode(&goEnv)
 	if err != nil {
 		return nil, err
 	}
 
-	return env, nil
+	return goEnv, nil
 }
 
 // ListModule executes `go list -json -m` and writes the output to a given writer.
 // See https://golang.org/ref/mod#go-list-m
-func ListModule(logger zerolog.Logger, moduleDir string, writer io.Writer) error {
-	return executeGoCommand(logger, []string{"list", "-mod", "readonly", "-json", "-m"}, withDir(moduleDir), withStdout(writer)) //nolint:goconst
+//
+// Workspace mode is disabled, because the go command would
+// otherwise list all modules of the workspace.
+func ListModule(ctx context.Context, logger zerolog.Logger, moduleDir string, writer io.Writer) error {
+	return executeGoCommand(ctx, logger, []string{"list", "-mod", "readonly", "-json", "-m"}, //nolint:goconst
+		withDir(moduleDir),
+		withEnv("GOWORK=off"),
+		withStdout(writer))
 }
 
 // ListModules executes `go list -json -m all` and writes the output to a given writer.
 // See https://golang.org/ref/mod#go-list-m
-func ListModules(logger zerolog.Logger, moduleDir string, writer io.Writer) error {
-	return executeGoCommand(logger, []string{"list", "-mod", "readonly", "-json", "-m", "all"}, withDir(moduleDir), withStdout(writer))
+func ListModules(ctx context.Context, logger zerolog.Logger, moduleDir string, writer io.Writer) error {
+	return executeGoCommand(ctx, logger, []string{"list", "-mod", "readonly", "-json", "-m", "all"}, withDir(moduleDir), withStdout(writer))
 }
 
 // ListPackage executes `go list -json -e <PATTERN>` and writes the output to a given writer.
 // See https://golang.org/cmd/go/#hdr-List_packages_or_modules.
-func ListPackage(logger zerolog.Logger, moduleDir, packagePattern string, writer io.Writer) error {
-	return executeGoCommand(logger, []string{"list", "-json", "-e", packagePattern},
+func ListPackage(ctx context.Context, logger zerolog.Logger, moduleDir, packagePattern string, writer io.Writer) error {
+	return executeGoCommand(ctx, logger, []string{"list", "-json", "-e", packagePattern},
 		withDir(moduleDir),
 		withStdout(writer),
 		withStderr(newLoggerWriter(logger))) // reports download status
@@ -95,9 +105,31 @@ func ListPackage(logger zerolog.Logger, moduleDir, packagePattern string, writer
 
 // ListPackages executes `go list -deps -json <PATTERN>` and writes the output to a given writer.
 // See https://golang.org/cmd/go/#hdr-List_packages_or_modules.
-func ListPackages(logger zerolog.Logger, moduleDir, packagePattern string, writer io.Writer) error {
-	return executeGoCommand(logger, []string{"list", "-deps", "-json", packagePattern},
+//
+// env may contain additional "key=value" pairs that will be passed to the go command.
+func ListPackages(ctx context.Context, logger zerolog.Logger, moduleDir, packagePattern string, writer io.Writer, env ...string) error {
+	return executeGoCommand(ctx, logger, []string{"list", "-deps", "-json", packagePattern},
 		withDir(moduleDir),
+		withEnv(env...),
+		withStdout(writer),
+		withStderr(newLoggerWriter(logger)), // reports download status
+	)
+}
+
+// ListPackageGraph executes `go list -e -deps -json <PATTERNS...>` and writes the output to a given writer.
+// When includeTests is true, the -test flag is added, so that test packages and their dependencies are listed as well.
+// See https://golang.org/cmd/go/#hdr-List_packages_or_modules.
+//
+// env may contain additional "key=value" pairs that will be passed to the go command.
+func ListPackageGraph(ctx context.Context, logger zerolog.Logger, moduleDir string, packagePatterns []string, includeTests bool, writer io.Writer, env ...string) error {
+	args := []string{"list", "-e", "-deps", "-json"}
+	if includeTests {
+		args = append(args, "-test")
+	}
+
+	return executeGoCommand(ctx, logger, append(args, packagePatterns...),
+		withDir(moduleDir),
+		withEnv(env...),
 		withStdout(writer),
 		withStderr(newLoggerWriter(logger)), // reports download status
 	)
@@ -105,21 +137,21 @@ func ListPackages(logger zerolog.Logger, moduleDir, packagePattern string, write
 
 // ListVendoredModules executes `go mod vendor -v` and writes the output to a given writer.
 // See https://golang.org/ref/mod#go-mod-vendor.
-func ListVendoredModules(logger zerolog.Logger, moduleDir string, writer io.Writer) error {
-	return executeGoCommand(logger, []string{"mod", "vendor", "-v", "-e"}, withDir(moduleDir), withStderr(writer)) //nolint:goconst
+func ListVendoredModules(ctx context.Context, logger zerolog.Logger, moduleDir string, writer io.Writer) error {
+	return executeGoCommand(ctx, logger, []string{"mod", "vendor", "-v", "-e"}, withDir(moduleDir), withStderr(writer)) //nolint:goconst
 }
 
 // GetModuleGraph executes `go mod graph` and writes the output to a given writer.
 // See https://golang.org/ref/mod#go-mod-graph.
-func GetModuleGraph(logger zerolog.Logger, moduleDir string, writer io.Writer) error {
-	return executeGoCommand(logger, []string{"mod", "graph"}, withDir(moduleDir),
+func GetModuleGraph(ctx context.Context, logger zerolog.Logger, moduleDir string, writer io.Writer) error {
+	return executeGoCommand(ctx, logger, []string{"mod", "graph"}, withDir(moduleDir),
 		withStdout(writer), withStderr(newLoggerWriter(logger)))
 }
 
 // ModWhy executes `go mod why -m -vendor` and writes the output to a given writer.
 // See https://golang.org/ref/mod#go-mod-why.
-func ModWhy(logger zerolog.Logger, moduleDir string, modules []string, writer io.Writer) error {
-	return executeGoCommand(logger,
+func ModWhy(ctx context.Context, logger zerolog.Logger, moduleDir string, modules []string, writer io.Writer) error {
+	return executeGoCommand(ctx, logger,
 		append([]string{"mod", "why", "-m", "-vendor"}, modules...),
 		withDir(moduleDir),
 		withStdout(writer),
@@ -129,14 +161,14 @@ func ModWhy(logger zerolog.Logger, moduleDir string, modules []string, writer io
 
 // LoadBuildInfo executes `go version -m` and writes the output to a given writer.
 // See https://golang.org/ref/mod#go-version-m.
-func LoadBuildInfo(logger zerolog.Logger, binaryPath string, writer io.Writer) error {
-	return executeGoCommand(logger, []string{"version", "-m", binaryPath}, withStdout(writer))
+func LoadBuildInfo(ctx context.Context, logger zerolog.Logger, binaryPath string, writer io.Writer) error {
+	return executeGoCommand(ctx, logger, []string{"version", "-m", binaryPath}, withStdout(writer))
 }
 
 // DownloadModules executes `go mod download -json` and writes the output to the given writers.
 // See https://golang.org/ref/mod#go-mod-download.
-func DownloadModules(logger zerolog.Logger, modules []string, stdout, stderr io.Writer) error {
-	return executeGoCommand(logger,
+func DownloadModules(ctx context.Context, logger zerolog.Logger, modules []string, stdout, stderr io.Writer) error {
+	return executeGoCommand(ctx, logger,
 		append([]string{"mod", "download", "-json"}, modules...),
 		withDir(os.TempDir()), // `mod download` modifies go.sum when executed in moduleDir
 		withStdout(stdout),
@@ -144,6 +176,9 @@ func DownloadModules(logger zerolog.Logger, modules []string, stdout, stderr io.
 	)
 }
 
+// commandWaitDelay is the time to wait for I/O to complete after a command has been killed.
+const commandWaitDelay = 5 * time.Second
+
 type commandOption func(*exec.Cmd)
 
 func withDir(dir string) commandOption {
@@ -152,6 +187,20 @@ func withDir(dir string) commandOption {
 	}
 }
 
+// withEnv adds the given "key=value" pairs to the environment of the command.
+// The command still inherits the environment of the current process.
+func withEnv(env ...string) commandOption {
+	return func(c *exec.Cmd) {
+		if len(env) == 0 {
+			return
+		}
+		if c.Env == nil {
+			c.Env = os.Environ()
+		}
+		c.Env = append(c.Env, env...)
+	}
+}
+
 func withStderr(writer io.Writer) commandOption {
 	return func(c *exec.Cmd) {
 		c.Stderr = writer
@@ -164,8 +213,11 @@ func withStdout(writer io.Writer) commandOption {
 	}
 }
 
-func executeGoCommand(logger zerolog.Logger, args []string, options ...commandOption) error {
-	cmd := exec.Command("go", args...)
+func executeGoCommand(ctx context.Context, logger zerolog.Logger, args []string, options ...commandOption) error {
+	cmd := exec.CommandContext(ctx, "go", args...)
+	// Don't block indefinitely on child processes of the go command (e.g. git)
+	// that keep the output pipes open after the context has been cancelled.
+	cmd.WaitDelay = commandWaitDelay
 
 	for _, option := range options {
 		option(cmd)
@@ -178,6 +230,10 @@ func executeGoCommand(logger zerolog.Logger, args []string, options ...commandOp
 
 	err := cmd.Run()
 	if err != nil {
+		if ctx.Err() != nil {
+			// The error returned by Run (e.g. "signal: killed") is not meaningful in this case
+			return fmt.Errorf("command `%s` aborted: %w", cmd.String(), ctx.Err())
+		}
 		return fmt.Errorf("command `%s` failed: %w", cmd.String(), err)
 	}
 
@@ -19,6 +19,7 @@ package gocmd
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"runtime"
 	"strings"
@@ -30,7 +31,7 @@ import (
 )
 
 func TestGetVersion(t *testing.T) {
-	version, err := GetVersion(zerolog.Nop())
+	version, err := GetVersion(context.Background(), zerolog.Nop())
 	require.NoError(t, err)
 	require.Equal(t, runtime.Version(), version)
 }
@@ -56,7 +57,7 @@ func TestParseVersion(t *testing.T) {
 }
 
 func TestGetEnv(t *testing.T) {
-	env, err := GetEnv(zerolog.Nop())
+	env, err := GetEnv(context.Background(), zerolog.Nop())
 	require.NoError(t, err)
 
 	require.Contains(t, env, "CGO_ENABLED")
@@ -68,7 +69,7 @@ func TestGetEnv(t *testing.T) {
 
 func TestListModule(t *testing.T) {
 	buf := new(bytes.Buffer)
-	err := ListModule(zerolog.Nop(), "../../", buf)
+	err := ListModule(context.Background(), zerolog.Nop(), "../../", buf)
 	require.NoError(t, err)
 
 	mod := make(map[string]interface{})
@@ -80,7 +81,7 @@ func TestListModule(t *testing.T) {
 
 func TestListModules(t *testing.T) {
 	buf := new(bytes.Buffer)
-	err := ListModules(zerolog.Nop(), "../../", buf)
+	err := ListModules(context.Background(), zerolog.Nop(), "../../", buf)
 	require.NoError(t, err)
 
 	mod := make(map[string]interface{})
@@ -93,7 +94,7 @@ func TestListModules(t *testing.T) {
 
 func TestGetModuleGraph(t *testing.T) {
 	buf := new(bytes.Buffer)
-	err := GetModuleGraph(zerolog.Nop(), "../../", buf)
+	err := GetModuleGraph(context.Background(), zerolog.Nop(), "../../", buf)
 	require.NoError(t, err)
 
 	assert.Equal(t, 0, strings.Index(buf.String(), "github.com/CycloneDX/cyclonedx-gomod"))
@@ -101,11 +102,21 @@ func TestGetModuleGraph(t *testing.T) {
 
 func TestModWhy(t *testing.T) {
 	buf := new(bytes.Buffer)
-	err := ModWhy(zerolog.Nop(), "../../", []string{"github.com/CycloneDX/cyclonedx-go"}, buf)
+	err := ModWhy(context.Background(), zerolog.Nop(), "../../", []string{"github.com/CycloneDX/cyclonedx-go"}, buf)
 	require.NoError(t, err)
 
 	require.Equal(t, `# github.com/CycloneDX/cyclonedx-go
-github.com/CycloneDX/cyclonedx-gomod/internal/cli/cmd/mod
+github.com/CycloneDX/cyclonedx-gomod/internal/cache
 github.com/CycloneDX/cyclonedx-go
 `, buf.String())
 }
+
+func TestExecuteGoCommand(t *testing.T) {
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := GetVersion(ctx, zerolog.Nop())
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -20,6 +20,7 @@ package gomod
 import (
 	"debug/buildinfo"
 	"fmt"
+	"runtime/debug"
 
 	"github.com/CycloneDX/cyclonedx-gomod/internal/gocmd"
 )
@@ -82,13 +83,20 @@ func LoadBuildInfo(binaryPath string) (*BuildInfo, error) {
 		buildInfo.Deps = deps
 	}
 
-	settings := make(map[string]string)
-	for _, setting := range stdBuildInfo.Settings {
-		settings[setting.Key] = setting.Value
+	buildInfo.Settings = buildSettings(stdBuildInfo.Settings)
+
+	return &buildInfo, nil
+}
+
+func buildSettings(stdSettings []debug.BuildSetting) map[string]string {
+	if len(stdSettings) == 0 {
+		return nil
 	}
-	if len(settings) > 0 {
-		buildInfo.Settings = settings
+
+	settings := make(map[string]string, len(stdSettings))
+	for _, setting := range stdSettings {
+		settings[setting.Key] = setting.Value
 	}
 
-	return &buildInfo, nil
+	return settings
 }
@@ -19,6 +19,7 @@ package gomod
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
@@ -45,12 +46,12 @@ func (m ModuleDownload) Coordinates() string {
 	return m.Path + "@" + m.Version
 }
 
-func Download(logger zerolog.Logger, modules []Module) ([]ModuleDownload, error) {
+func Download(ctx context.Context, logger zerolog.Logger, modules []Module) ([]ModuleDownload, error) {
 	var downloads []ModuleDownload
 	chunks := chunkModules(modules, 20)
 
 	for _, chunk := range chunks {
-		chunkDownloads, err := downloadInternal(logger, chunk)
+		chunkDownloads, err := downloadInternal(ctx, logger, chunk)
 		if err != nil {
 			return nil, err
 		}
@@ -61,7 +62,7 @@ func Download(logger zerolog.Logger, modules []Module) ([]ModuleDownload, error)
 	return downloads, nil
 }
 
-func downloadInternal(logger zerolog.Logger, modules []Module) ([]ModuleDownload, error) {
+func downloadInternal(ctx context.Context, logger zerolog.Logger, modules []Module) ([]ModuleDownload, error) {
 	stdoutBuf := new(bytes.Buffer)
 	stderrBuf := new(bytes.Buffer)
 
@@ -70,8 +71,13 @@ func downloadInternal(logger zerolog.Logger, modules []Module) ([]ModuleDownload
 		coordinates[i] = modules[i].Coordinates()
 	}
 
-	err := gocmd.DownloadModules(logger, coordinates, stdoutBuf, stderrBuf)
+	err := gocmd.DownloadModules(ctx, logger, coordinates, stdoutBuf, stderrBuf)
 	if err != nil {
+		if ctx.Err() != nil {
+			// Output of an aborted download is incomplete
+			return nil, err
+		}
+
 		// `go mod download` will exit with code 1 if *any* of the
 		// module downloads failed. Download errors are reported for
 		// each module separately via the .Error field (written to STDOUT).
@@ -18,6 +18,7 @@
 package gomod
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"strings"
@@ -45,7 +46,7 @@ func TestDownload(t *testing.T) {
 
 		os.Setenv("GOMODCACHE", tmpDir)
 
-		downloads, err := Download(zerolog.Nop(), []Module{
+		downloads, err := Download(context.Background(), zerolog.Nop(), []Module{
 			{
 				Path:    "github.com/CycloneDX/cyclonedx-go",
 				Version: "v0.4.0",
@@ -59,7 +60,7 @@ func TestDownload(t *testing.T) {
 	})
 
 	t.Run("Error", func(t *testing.T) {
-		downloads, err := Download(zerolog.Nop(), []Module{
+		downloads, err := Download(context.Background(), zerolog.Nop(), []Module{
 			{
 				Path:    "doesnotexist",
 				Version: "v0.0.0",
@@ -18,9 +18,15 @@
 package gomod
 
 import (
-	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"maps"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/rs/zerolog"
@@ -28,106 +34,356 @@ import (
 	"github.com/CycloneDX/cyclonedx-gomod/internal/gocmd"
 )
 
-// FilterModules queries `go mod why` with all provided modules to determine whether or not
-// they're required by the main module. Modules required by the main module are returned in
-// a new slice.
+// FilterModules determines which of the provided modules are required by the main modules,
+// and returns them in a new slice. Main modules are always retained.
 //
-// Unless includeTest is true, test-only dependencies are not included in the returned slice.
-// Test-only modules will have the TestOnly field set to true.
+// To do so, the import graph of all packages in the main modules, their tests and their
+// dependencies is loaded using `go list -e -deps -test -json`, and traversed in memory.
+// Like with `go mod why -m -vendor`, modules are retained regardless of the platform
+// that imports them: the graph is loaded for the platform the go command targets,
+// as well as for all first-class ports, with cgo enabled, and the imports are merged.
 //
-// Note that this method doesn't work when replacements have already been applied to the module slice.
-// Consider a go.mod file containing the following lines:
+// Unless includeTest is true, test-only dependencies are not included in the returned slice.
+// Test-only modules are those that are only reachable via tests of main module packages.
+// They will have the TestOnly field set to true.
 //
-//	require golang.org/x/crypto v0.0.0-xxx-xxx
-//	replace golang.org/x/crypto => github.com/ProtonMail/go-crypto v0.0.0-xxx-xxx
+// Likewise, unless includeTools is true, dependencies that are only required by tools
+// (as declared by tool directives of the main modules) are not included in the returned slice.
+// Tool-only modules will have the Tool field set to the tool package that requires them.
 //
-// Querying `go mod why -m` with `golang.org/x/crypto` yields the expected result, querying it with
-// `github.com/ProtonMail/go-crypto` will always yield `(main module does not need github.com/ProtonMail/go-crypto)`.
-// See:
-//   - https://github.com/golang/go/issues/30720
-//   - https://github.com/golang/go/issues/26904
-func FilterModules(logger zerolog.Logger, moduleDir string, modules []Module, includeTest bool) ([]Module, error) {
+// Packages are attributed to modules by the module information reported by the go command,
+// which refers to replaced modules rather than their replacements. Replacements must thus
+// not have been applied to the module slice yet.
+func FilterModules(ctx context.Context, logger zerolog.Logger, moduleDir string, modules []Module, includeTest, includeTools bool) ([]Module, error) {
 	logger.Debug().
 		Str("moduleDir", moduleDir).
 		Int("moduleCount", len(modules)).
 		Bool("includeTest", includeTest).
+		Bool("includeTools", includeTools).
 		Msg("filtering modules")
 
-	buf := new(bytes.Buffer)
-	filtered := make([]Module, 0)
-	chunks := chunkModules(modules, 20)
+	toolPkgs, err := readToolPackages(moduleDir, modules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tool directives: %w", err)
+	}
+
+	patterns, err := mainModulePatterns(moduleDir, modules)
+	if err != nil {
+		return nil, err
+	}
+
+	platforms, err := filterPlatforms(ctx, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := newPackageGraph(modules)
+	mainPkgs := make(map[string]struct{})
+	testPkgs := make(map[string]struct{})
+	for _, platform := range platforms {
+		env := []string{"GOOS=" + platform[0], "GOARCH=" + platform[1], "CGO_ENABLED=1"}
+
+		pkgs, err := graph.load(ctx, logger, moduleDir, patterns, true, env...)
+		if err != nil {
+			return nil, err
+		}
+
+		// Packages that were matched by the patterns are either packages of the main modules,
+		// or variants of them (and their test main packages) that were compiled for tests.
+		for _, pkg := range pkgs {
+			switch {
+			case pkg.DepOnly:
+			case pkg.ForTest != "" || strings.HasSuffix(pkg.ImportPath, ".test"):
+				testPkgs[pkg.ImportPath] = struct{}{}
+			default:
+				mainPkgs[pkg.ImportPath] = struct{}{}
+			}
+		}
 
-	for _, chunk := range chunks {
-		paths := make([]string, len(chunk))
-		for i := range chunk {
-			paths[i] = chunk[i].Path
+		// Tools are loaded without tests, as those are not required to build them.
+		if len(toolPkgs) > 0 {
+			if _, err = graph.load(ctx, logger, moduleDir, toolPkgs, false, env...); err != nil {
+				return nil, err
+			}
 		}
+	}
+
+	required, err := graph.reachableModules(ctx, slices.Collect(maps.Keys(mainPkgs)))
+	if err != nil {
+		return nil, err
+	}
+	requiredByTests, err := graph.reachableModules(ctx, slices.Collect(maps.Keys(testPkgs)))
+	if err != nil {
+		return nil, err
+	}
 
-		if err := gocmd.ModWhy(logger, moduleDir, paths, buf); err != nil {
+	// Record the first tool that requires a module, in the order tools are declared in
+	requiredByTools := make(map[string]string)
+	for _, toolPkg := range toolPkgs {
+		toolModules, err := graph.reachableModules(ctx, []string{toolPkg})
+		if err != nil {
 			return nil, err
 		}
+		for modPath := range toolModules {
+			if _, ok := requiredByTools[modPath]; !ok {
+				requiredByTools[modPath] = toolPkg
+			}
+		}
+	}
 
-		for modPath, modPkgs := range parseModWhy(buf) {
-			if len(modPkgs) == 0 {
+	filtered := make([]Module, 0, len(modules))
+	for _, module := range modules {
+		_, isRequired := required[module.Path]
+		_, isRequiredByTests := requiredByTests[module.Path]
+		tool := requiredByTools[module.Path]
+
+		switch {
+		case module.Main || isRequired:
+		case isRequiredByTests:
+			if !includeTest {
 				logger.Debug().
-					Str("module", modPath).
-					Str("reason", "not needed").
+					Str("module", module.Path).
+					Str("reason", "test only").
 					Msg("filtering module")
 				continue
 			}
-
-			// If the shortest package path contains test nodes, this is a test-only dependency.
-			testOnly := false
-			for _, pkg := range modPkgs {
-				if strings.HasSuffix(pkg, ".test") {
-					testOnly = true
-					break
-				}
-			}
-			if !includeTest && testOnly {
+			module.TestOnly = true
+		case tool != "":
+			if !includeTools {
 				logger.Debug().
-					Str("module", modPath).
-					Str("reason", "test only").
+					Str("module", module.Path).
+					Str("reason", "tool only").
 					Msg("filtering module")
 				continue
 			}
+			module.Tool = tool
+		default:
+			logger.Debug().
+				Str("module", module.Path).
+				Str("reason", "not needed").
+				Msg("filtering module")
+			continue
+		}
+
+		filtered = append(filtered, module)
+	}
+
+	return filtered, nil
+}
+
+// readToolPackages collects the packages declared by tool directives
+// of the module in moduleDir, and of the main modules among modules.
+// The latter is necessary for workspaces, where moduleDir doesn't contain a go.mod file.
+func readToolPackages(moduleDir string, modules []Module) ([]string, error) {
+	moduleDirs := []string{moduleDir}
+	for i := range modules {
+		if modules[i].Main && modules[i].Dir != "" {
+			moduleDirs = append(moduleDirs, modules[i].Dir)
+		}
+	}
+
+	toolPkgs := make([]string, 0)
+	for _, dir := range moduleDirs {
+		if !IsModule(dir) {
+			continue
+		}
+
+		modFile, err := ReadModFile(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, tool := range modFile.Tool {
+			if !slices.Contains(toolPkgs, tool.Path) {
+				toolPkgs = append(toolPkgs, tool.Path)
+			}
+		}
+	}
+
+	return toolPkgs, nil
+}
+
+// firstClassPorts are the GOOS/GOARCH combinations the Go project considers first-class.
+// See https://go.dev/wiki/PortingPolicy#first-class-ports.
+var firstClassPorts = [][2]string{
+	{"darwin", "amd64"},
+	{"darwin", "arm64"},
+	{"linux", "386"},
+	{"linux", "amd64"},
+	{"linux", "arm"},
+	{"linux", "arm64"},
+	{"windows", "386"},
+	{"windows", "amd64"},
+}
 
-			for i := range chunk {
-				if chunk[i].Path == modPath {
-					mod := chunk[i]
-					mod.TestOnly = testOnly
-					filtered = append(filtered, mod)
+// filterPlatforms returns the GOOS/GOARCH combinations to load the package graph for.
+// The platform the go command targets comes first, followed by all other first-class ports.
+func filterPlatforms(ctx context.Context, logger zerolog.Logger) ([][2]string, error) {
+	goEnv, err := gocmd.GetEnv(ctx, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get go environment: %w", err)
+	}
+
+	platforms := [][2]string{{goEnv["GOOS"], goEnv["GOARCH"]}}
+	for _, port := range firstClassPorts {
+		if port != platforms[0] {
+			platforms = append(platforms, port)
+		}
+	}
+
+	return platforms, nil
+}
+
+// mainModulePatterns returns package patterns, matching all packages of the main modules.
+// The main module of moduleDir is always included, as the list of vendored modules doesn't contain it.
+func mainModulePatterns(moduleDir string, modules []Module) ([]string, error) {
+	dirs := make([]string, 0)
+	if IsModule(moduleDir) {
+		dirs = append(dirs, moduleDir)
+	}
+	for _, module := range modules {
+		if module.Main && module.Dir != "" {
+			dirs = append(dirs, module.Dir)
+		}
+	}
+
+	patterns := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		pattern := filepath.Join(absDir, "...")
+		if !slices.Contains(patterns, pattern) {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	return patterns, nil
+}
+
+// packageGraph is an import graph of packages, as listed by `go list -deps`.
+type packageGraph struct {
+	modules []Module // sorted by path length in descending order
+	nodes   map[string]packageNode
+}
+
+type packageNode struct {
+	module  string // path of the module providing the package, empty for the standard library and unknown packages
+	imports []string
+}
+
+func newPackageGraph(modules []Module) *packageGraph {
+	graph := packageGraph{
+		modules: slices.Clone(modules),
+		nodes:   make(map[string]packageNode),
+	}
+
+	slices.SortStableFunc(graph.modules, func(a, b Module) int {
+		return len(b.Path) - len(a.Path)
+	})
+
+	return &graph
+}
+
+// load lists the packages matching patterns and all of their dependencies, adds them to the graph,
+// and returns them. When includeTests is true, test packages are listed as well.
+// Imports of packages that are already part of the graph are merged with the existing ones.
+//
+// env may contain additional "key=value" pairs that will be passed to the go command.
+func (g *packageGraph) load(ctx context.Context, logger zerolog.Logger, moduleDir string, patterns []string, includeTests bool, env ...string) ([]Package, error) {
+	buf := new(bytes.Buffer)
+	if err := gocmd.ListPackageGraph(ctx, logger, moduleDir, patterns, includeTests, buf, env...); err != nil {
+		return nil, fmt.Errorf("failed to list packages: %w", err)
+	}
+
+	pkgs := make([]Package, 0)
+	decoder := json.NewDecoder(buf)
+	for {
+		var pkg Package
+		if err := decoder.Decode(&pkg); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse `go list` output: %w", err)
+		}
+
+		node := packageNode{imports: pkg.Imports}
+		switch {
+		case pkg.Standard:
+		case pkg.Module != nil:
+			node.module = pkg.Module.Path
+		default:
+			// Packages that failed to load, e.g. because their module is missing, lack module information
+			node.module = g.resolve(pkg.ImportPath)
+		}
+		if pkg.Error != nil {
+			logger.Debug().
+				Str("package", pkg.ImportPath).
+				Str("module", node.module).
+				Str("error", pkg.Error.Err).
+				Msg("failed to load package")
+		}
+
+		if existing, ok := g.nodes[pkg.ImportPath]; ok {
+			for _, imp := range existing.imports {
+				if !slices.Contains(node.imports, imp) {
+					node.imports = append(node.imports, imp)
 				}
 			}
+			if node.module == "" {
+				node.module = existing.module
+			}
 		}
 
-		buf.Reset()
+		g.nodes[pkg.ImportPath] = node
+		pkgs = append(pkgs, pkg)
 	}
 
-	return filtered, nil
+	return pkgs, nil
 }
 
-// parseModWhy parses the output of `go mod why`,
-// populating a map with module paths as keys and a list of packages as values.
-func parseModWhy(reader io.Reader) map[string][]string {
-	modPkgs := make(map[string][]string)
-	currentModPath := ""
+// resolve determines the path of the module that provides the package importPath,
+// based on the paths of the known modules. An empty string is returned if none of them does.
+func (g *packageGraph) resolve(importPath string) string {
+	for _, module := range g.modules {
+		if relPath, ok := strings.CutPrefix(importPath, module.Path); ok && (relPath == "" || relPath[0] == '/') {
+			return module.Path
+		}
+	}
+
+	return ""
+}
 
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "(main module does not need ") {
-			continue
+// reachableModules returns the paths of all modules that provide
+// packages which are reachable from the given import paths.
+func (g *packageGraph) reachableModules(ctx context.Context, importPaths []string) (map[string]struct{}, error) {
+	modules := make(map[string]struct{})
+	visited := make(map[string]struct{})
+	queue := slices.Clone(importPaths)
+
+	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
 
-		if strings.HasPrefix(line, "#") {
-			currentModPath = strings.TrimSpace(strings.TrimPrefix(line, "#"))
-			modPkgs[currentModPath] = make([]string, 0)
+		importPath := queue[0]
+		queue = queue[1:]
+
+		if _, ok := visited[importPath]; ok {
 			continue
 		}
+		visited[importPath] = struct{}{}
 
-		modPkgs[currentModPath] = append(modPkgs[currentModPath], line)
+		node, ok := g.nodes[importPath]
+		if !ok {
+			continue
+		}
+		if node.module != "" {
+			modules[node.module] = struct{}{}
+		}
+		queue = append(queue, node.imports...)
 	}
 
-	return modPkgs
+	return modules, nil
 }
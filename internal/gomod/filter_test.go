@@ -18,31 +18,156 @@
 package gomod
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestParseModWhy(t *testing.T) {
-	modWhyOutput := `
-# github.com/stretchr/testify
-github.com/CycloneDX/cyclonedx-gomod
-github.com/CycloneDX/cyclonedx-gomod.test
-github.com/stretchr/testify/assert
+func TestFilterModules(t *testing.T) {
+	// Resolve all modules locally, so that the go command never reaches out to the network
+	t.Setenv("GOFLAGS", "-mod=readonly")
+	t.Setenv("GOPROXY", "off")
+	t.Setenv("GOWORK", "off")
+	t.Setenv("GOOS", "linux")
 
-# github.com/CycloneDX/cyclonedx-go
-(main module does not need module github.com/CycloneDX/cyclonedx-go)
+	rootDir := t.TempDir()
+	writeFiles := func(files map[string]string) {
+		for name, content := range files {
+			filePath := filepath.Join(rootDir, filepath.FromSlash(name))
+			require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0o755))
+			require.NoError(t, os.WriteFile(filePath, []byte(content), 0o600))
+		}
+	}
 
-# bazil.org/fuse
-(main module does not need to vendor module bazil.org/fuse)
-`
+	deps := []string{"ignored", "lib", "lib/sub", "testlib", "tool", "toollib", "transitive", "unused", "winlib"}
+	goMod := "module example.com/app\n\ngo 1.24\n\ntool example.com/tool/cmd/tool\n"
+	for _, dep := range deps {
+		dir := strings.ReplaceAll(dep, "/", "")
+		goMod += fmt.Sprintf("\nrequire example.com/%s v0.0.0\n\nreplace example.com/%s => ../%s\n", dep, dep, dir)
+		writeFiles(map[string]string{dir + "/go.mod": fmt.Sprintf("module example.com/%s\n\ngo 1.24\n", dep)})
+	}
 
-	modulePkgs := parseModWhy(strings.NewReader(modWhyOutput))
-	require.Len(t, modulePkgs, 3)
+	writeFiles(map[string]string{
+		"app/go.mod":                 goMod,
+		"app/main.go":                "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/lib\"\n)\n\nfunc main() { fmt.Println(lib.Name) }\n",
+		"app/main_windows.go":        "//go:build windows\n\npackage main\n\nimport _ \"example.com/winlib\"\n",
+		"app/gen.go":                 "//go:build ignore\n\npackage main\n\nimport _ \"example.com/ignored\"\n",
+		"app/main_test.go":           "package main\n\nimport _ \"example.com/testlib\"\n",
+		"app/internal/foo/foo.go":    "package foo\n\nimport _ \"example.com/lib/sub\"\n",
+		"app/testdata/data.go":       "package data\n\nimport _ \"example.com/unused\"\n",
+		"lib/lib.go":                 "package lib\n\nimport _ \"example.com/transitive\"\n\nconst Name = \"lib\"\n",
+		"lib/lib_test.go":            "package lib\n\nimport _ \"example.com/unused\"\n",
+		"libsub/sub.go":              "package sub\n",
+		"transitive/transitive.go":   "package transitive\n",
+		"testlib/testlib.go":         "package testlib\n",
+		"winlib/winlib.go":           "package winlib\n",
+		"ignored/ignored.go":         "package ignored\n",
+		"tool/cmd/tool/main.go":      "package main\n\nimport _ \"example.com/toollib\"\n\nfunc main() {}\n",
+		"tool/cmd/tool/main_test.go": "package main\n\nimport _ \"example.com/unused\"\n",
+		"toollib/toollib.go":         "package toollib\n",
+		"unused/unused.go":           "package unused\n",
+	})
 
-	assert.Len(t, modulePkgs["github.com/stretchr/testify"], 3)
-	assert.Len(t, modulePkgs["github.com/CycloneDX/cyclonedx-go"], 0)
-	assert.Len(t, modulePkgs["bazil.org/fuse"], 0)
+	modules := []Module{{Path: "example.com/app", Dir: filepath.Join(rootDir, "app"), Main: true}}
+	for _, dep := range deps {
+		modules = append(modules, Module{
+			Path:    "example.com/" + dep,
+			Version: "v0.0.0",
+			Replace: &Module{Path: "../" + strings.ReplaceAll(dep, "/", ""), Dir: filepath.Join(rootDir, strings.ReplaceAll(dep, "/", ""))},
+		})
+	}
+
+	modulePaths := func(modules []Module) []string {
+		paths := make([]string, len(modules))
+		for i := range modules {
+			paths[i] = modules[i].Path
+		}
+		return paths
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		filtered, err := FilterModules(context.Background(), zerolog.Nop(), filepath.Join(rootDir, "app"), modules, false, false)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"example.com/app",
+			"example.com/lib",
+			"example.com/lib/sub",
+			"example.com/transitive",
+			"example.com/winlib",
+		}, modulePaths(filtered))
+
+		for _, module := range filtered {
+			assert.False(t, module.TestOnly)
+			assert.Empty(t, module.Tool)
+		}
+	})
+
+	t.Run("IncludeTestAndTools", func(t *testing.T) {
+		filtered, err := FilterModules(context.Background(), zerolog.Nop(), filepath.Join(rootDir, "app"), modules, true, true)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"example.com/app",
+			"example.com/lib",
+			"example.com/lib/sub",
+			"example.com/testlib",
+			"example.com/tool",
+			"example.com/toollib",
+			"example.com/transitive",
+			"example.com/winlib",
+		}, modulePaths(filtered))
+
+		assert.True(t, filtered[3].TestOnly)
+		assert.Empty(t, filtered[3].Tool)
+		assert.False(t, filtered[4].TestOnly)
+		assert.Equal(t, "example.com/tool/cmd/tool", filtered[4].Tool)
+		assert.Equal(t, "example.com/tool/cmd/tool", filtered[5].Tool)
+	})
+
+	t.Run("OtherPlatform", func(t *testing.T) {
+		// Modules imported only on other platforms are retained, regardless of the targeted platform
+		t.Setenv("GOOS", "freebsd")
+
+		filtered, err := FilterModules(context.Background(), zerolog.Nop(), filepath.Join(rootDir, "app"), modules, false, false)
+		require.NoError(t, err)
+		require.Contains(t, modulePaths(filtered), "example.com/winlib")
+		require.NotContains(t, modulePaths(filtered), "example.com/ignored")
+	})
+
+	t.Run("NotDownloaded", func(t *testing.T) {
+		writeFiles(map[string]string{
+			"app/internal/bar/bar.go": "package bar\n\nimport _ \"example.com/undownloaded/pkg\"\n",
+		})
+		t.Cleanup(func() {
+			require.NoError(t, os.RemoveAll(filepath.Join(rootDir, "app", "internal", "bar")))
+		})
+
+		filtered, err := FilterModules(context.Background(), zerolog.Nop(), filepath.Join(rootDir, "app"),
+			append(slices.Clone(modules), Module{Path: "example.com/undownloaded"}), false, false)
+		require.NoError(t, err)
+		require.Contains(t, modulePaths(filtered), "example.com/undownloaded")
+	})
+}
+
+func TestReadToolPackages(t *testing.T) {
+	moduleDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(moduleDir, "go.mod"), []byte("module example.com/app\n\ngo 1.24\n\ntool example.com/gen/cmd/gen\n"), 0o600))
+
+	// Other main modules, e.g. of a workspace
+	otherModuleDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(otherModuleDir, "go.mod"), []byte("module example.com/lib\n\ngo 1.24\n\ntool golang.org/x/tools/cmd/stringer\n"), 0o600))
+
+	toolPkgs, err := readToolPackages(moduleDir, []Module{
+		{Path: "example.com/lib", Dir: otherModuleDir, Main: true},
+		{Path: "example.com/dep", Dir: t.TempDir()},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"example.com/gen/cmd/gen", "golang.org/x/tools/cmd/stringer"}, toolPkgs)
 }
@@ -20,6 +20,7 @@ package gomod
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"slices"
@@ -31,14 +32,14 @@ import (
 	"github.com/CycloneDX/cyclonedx-gomod/internal/gocmd"
 )
 
-func ApplyModuleGraph(logger zerolog.Logger, moduleDir string, modules []Module) error {
+func ApplyModuleGraph(ctx context.Context, logger zerolog.Logger, moduleDir string, modules []Module) error {
 	logger.Debug().
 		Str("moduleDir", moduleDir).
 		Int("moduleCount", len(modules)).
 		Msg("applying module graph")
 
 	buf := new(bytes.Buffer)
-	err := gocmd.GetModuleGraph(logger, moduleDir, buf)
+	err := gocmd.GetModuleGraph(ctx, logger, moduleDir, buf)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,247 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package gomod
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+// ReadModFile parses the go.mod file of the module in moduleDir.
+func ReadModFile(moduleDir string) (*modfile.File, error) {
+	modFilePath := filepath.Join(moduleDir, "go.mod")
+
+	data, err := os.ReadFile(modFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return modfile.Parse(modFilePath, data, nil)
+}
+
+// ReadSumFile parses the go.sum file of the module in moduleDir,
+// and returns the module hashes it contains, keyed by module coordinates.
+// Hashes of go.mod files are omitted. A missing go.sum file is not considered an error,
+// as modules without dependencies don't have one.
+func ReadSumFile(moduleDir string) (map[string]string, error) {
+	sums := make(map[string]string)
+
+	sumFile, err := os.Open(filepath.Join(moduleDir, "go.sum"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return sums, nil
+		}
+		return nil, err
+	}
+	defer sumFile.Close()
+
+	scanner := bufio.NewScanner(sumFile)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("go.sum:%d: malformed line", lineNo)
+		}
+		if strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+
+		sums[fields[0]+"@"+fields[1]] = fields[2]
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return sums, nil
+}
+
+// ModulesFromModFile assembles the main module and the modules it requires from its parsed
+// go.mod file and the hashes of its go.sum file. Unlike LoadModules, it doesn't invoke the go command.
+//
+// The main module is the first element of the returned slice. It depends on all requirements
+// that are not marked as indirect. Dependencies of other modules can't be determined
+// without their go.mod files, and are thus left empty. Replacements are applied, with local
+// replacements being resolved relative to moduleDir.
+func ModulesFromModFile(logger zerolog.Logger, moduleDir string, modFile *modfile.File, sums map[string]string) ([]Module, error) {
+	if modFile.Module == nil {
+		return nil, fmt.Errorf("go.mod has no module directive")
+	}
+
+	modules := make([]Module, 0, len(modFile.Require)+1)
+	modules = append(modules, Module{
+		Path: modFile.Module.Mod.Path,
+		Main: true,
+		Dir:  moduleDir,
+	})
+
+	excluded := make(map[string]bool, len(modFile.Exclude))
+	for _, exclude := range modFile.Exclude {
+		excluded[exclude.Mod.String()] = true
+	}
+
+	for _, require := range modFile.Require {
+		if excluded[require.Mod.String()] {
+			// The go command would select the next higher version, which we can't know.
+			logger.Warn().
+				Str("module", require.Mod.String()).
+				Msg("required module version is excluded")
+		}
+
+		module := Module{
+			Path:     require.Mod.Path,
+			Version:  require.Mod.Version,
+			Indirect: require.Indirect,
+			Sum:      sums[require.Mod.String()],
+		}
+
+		if replace := findReplace(modFile, module); replace != nil {
+			module.Replace = &Module{
+				Path:    replace.New.Path,
+				Version: replace.New.Version,
+			}
+			if replace.New.Version == "" {
+				err := resolveLocalReplacementFromModFile(logger, moduleDir, module.Replace)
+				if err != nil {
+					return nil, fmt.Errorf("resolving local module %s failed: %w", replace.New.Path, err)
+				}
+			} else {
+				module.Replace.Sum = sums[replace.New.String()]
+			}
+		}
+
+		modules = append(modules, module)
+	}
+
+	sortModules(modules[1:])
+
+	// Only now that the slice won't be modified anymore, pointers to its elements can be taken
+	for i := 1; i < len(modules); i++ {
+		if !modules[i].Indirect {
+			modules[0].Dependencies = append(modules[0].Dependencies, &modules[i])
+		}
+	}
+
+	return modules, nil
+}
+
+// findReplace returns the replace directive that applies to module, if any.
+// Replacements of a specific version take precedence over those for all versions.
+func findReplace(modFile *modfile.File, module Module) *modfile.Replace {
+	var match *modfile.Replace
+	for _, replace := range modFile.Replace {
+		if replace.Old.Path != module.Path {
+			continue
+		}
+		if replace.Old.Version == module.Version {
+			return replace
+		}
+		if replace.Old.Version == "" {
+			match = replace
+		}
+	}
+
+	return match
+}
+
+func resolveLocalReplacementFromModFile(logger zerolog.Logger, mainModuleDir string, module *Module) error {
+	localModuleDir := module.Path
+	if !filepath.IsAbs(localModuleDir) {
+		localModuleDir = filepath.Join(mainModuleDir, localModuleDir)
+	}
+
+	logger.Debug().
+		Str("moduleDir", localModuleDir).
+		Msg("resolving local replacement module")
+
+	localModFile, err := ReadModFile(localModuleDir)
+	if err != nil {
+		return err
+	}
+	if localModFile.Module == nil {
+		return fmt.Errorf("go.mod has no module directive")
+	}
+
+	module.Path = localModFile.Module.Mod.Path
+	module.Dir = localModuleDir
+	module.Local = true
+
+	version, err := GetModuleVersion(logger, localModuleDir)
+	if err == nil {
+		module.Version = version
+	} else {
+		logger.Warn().
+			Err(err).
+			Str("module", module.Path).
+			Str("moduleDir", localModuleDir).
+			Msg("failed to resolve version of local module")
+	}
+
+	return nil
+}
+
+// IsRetracted reports whether version of the module described by modFile
+// is retracted by one of its retract directives.
+func IsRetracted(modFile *modfile.File, version string) bool {
+	if !semver.IsValid(version) {
+		return false
+	}
+
+	for _, retract := range modFile.Retract {
+		if semver.Compare(version, retract.Low) >= 0 && semver.Compare(version, retract.High) <= 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// StdlibModuleFromModFile returns a standard library module with the Go version
+// that modFile requires, i.e. the version of its toolchain directive or, if absent, its go directive.
+// Note that this is the minimum version required to build the module, not necessarily
+// the version that's actually used. The toolchain of the same version is attached as dependency.
+// Nil is returned if modFile declares neither.
+func StdlibModuleFromModFile(modFile *modfile.File) *Module {
+	var version string
+	switch {
+	case modFile.Toolchain != nil:
+		version = modFile.Toolchain.Name
+	case modFile.Go != nil:
+		version = "go" + modFile.Go.Version
+	default:
+		return nil
+	}
+
+	toolchain := ToolchainModule(version)
+
+	return &Module{
+		Path:         StdlibModulePath,
+		Version:      version,
+		Local:        true,
+		Dependencies: []*Module{&toolchain},
+	}
+}
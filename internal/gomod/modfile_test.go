@@ -0,0 +1,143 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package gomod
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSumFile(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "go.sum"), []byte(`github.com/google/uuid v1.2.0 h1:qJYtXnJRWmpe7m/3XlyhrsLrEURqHRM2kxzoxXqyUDs=
+github.com/google/uuid v1.2.0/go.mod h1:TIyPZe4MgqvfeYDBFedMoGGpEw/LqOeaOT+nhxU+yHo=
+
+`), 0o600))
+
+		sums, err := ReadSumFile(tmpDir)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			"github.com/google/uuid@v1.2.0": "h1:qJYtXnJRWmpe7m/3XlyhrsLrEURqHRM2kxzoxXqyUDs=",
+		}, sums)
+	})
+
+	t.Run("NotExists", func(t *testing.T) {
+		sums, err := ReadSumFile(t.TempDir())
+		require.NoError(t, err)
+		require.Empty(t, sums)
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "go.sum"), []byte("github.com/google/uuid v1.2.0\n"), 0o600))
+
+		_, err := ReadSumFile(tmpDir)
+		require.EqualError(t, err, "go.sum:1: malformed line")
+	})
+}
+
+func TestModulesFromModFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte(`module example.com/main
+
+go 1.21
+
+require (
+	example.com/b v1.0.0
+	example.com/a v1.1.0 // indirect
+)
+
+require example.com/local v0.0.0
+
+exclude example.com/a v1.1.0
+
+replace example.com/b v1.0.0 => example.com/fork/b v1.0.1
+
+replace example.com/local => ./local
+
+retract v1.0.0
+`), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(tmpDir, "local"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "local", "go.mod"), []byte("module example.com/local\n"), 0o600))
+
+	modFile, err := ReadModFile(tmpDir)
+	require.NoError(t, err)
+
+	modules, err := ModulesFromModFile(zerolog.Nop(), tmpDir, modFile, map[string]string{
+		"example.com/a@v1.1.0":      "h1:a",
+		"example.com/fork/b@v1.0.1": "h1:b",
+	})
+	require.NoError(t, err)
+	require.Len(t, modules, 4)
+
+	assert.Equal(t, "example.com/main", modules[0].Path)
+	assert.True(t, modules[0].Main)
+	assert.Equal(t, tmpDir, modules[0].Dir)
+
+	assert.Equal(t, "example.com/a", modules[1].Path)
+	assert.True(t, modules[1].Indirect, "excluded modules are retained")
+	assert.Equal(t, "h1:a", modules[1].Sum)
+
+	assert.Equal(t, "example.com/b", modules[2].Path)
+	require.NotNil(t, modules[2].Replace)
+	assert.Equal(t, "example.com/fork/b@v1.0.1", modules[2].Replace.Coordinates())
+	assert.Equal(t, "h1:b", modules[2].Replace.Sum)
+
+	assert.Equal(t, "example.com/local", modules[3].Path)
+	require.NotNil(t, modules[3].Replace)
+	assert.Equal(t, "example.com/local", modules[3].Replace.Path)
+	assert.Equal(t, filepath.Join(tmpDir, "local"), modules[3].Replace.Dir)
+	assert.True(t, modules[3].Replace.Local)
+
+	// Indirect requirements are not direct dependencies of the main module
+	require.Len(t, modules[0].Dependencies, 2)
+	assert.Equal(t, "example.com/b", modules[0].Dependencies[0].Path)
+	assert.Equal(t, "example.com/local", modules[0].Dependencies[1].Path)
+
+	assert.True(t, IsRetracted(modFile, "v1.0.0"))
+	assert.False(t, IsRetracted(modFile, "v1.0.1"))
+	assert.False(t, IsRetracted(modFile, ""))
+}
+
+func TestStdlibModuleFromModFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module example.com/main\n\ngo 1.21.0\n\ntoolchain go1.22.1\n"), 0o600))
+
+	modFile, err := ReadModFile(tmpDir)
+	require.NoError(t, err)
+
+	module := StdlibModuleFromModFile(modFile)
+	require.NotNil(t, module)
+	require.Equal(t, "std@go1.22.1", module.Coordinates())
+	require.Len(t, module.Dependencies, 1)
+	require.Equal(t, "pkg:golang/golang.org/toolchain@go1.22.1", module.Dependencies[0].BOMRef())
+
+	modFile.Toolchain = nil
+	module = StdlibModuleFromModFile(modFile)
+	require.NotNil(t, module)
+	require.Equal(t, "std@go1.21.0", module.Coordinates())
+
+	modFile.Go = nil
+	require.Nil(t, StdlibModuleFromModFile(modFile))
+}
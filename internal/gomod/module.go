@@ -19,6 +19,7 @@ package gomod
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -45,12 +46,16 @@ type Module struct {
 	Indirect bool    // is this module only an indirect dependency of main module?
 	Dir      string  // directory holding files for this module, if any
 
-	Dependencies []*Module `json:"-"` // modules this module depends on
-	Local        bool      `json:"-"` // is this a local module?
-	Packages     []Package `json:"-"` // packages in this module
-	Sum          string    `json:"-"` // checksum for path, version (as in go.sum)
-	TestOnly     bool      `json:"-"` // is this module only required for tests?
-	Vendored     bool      `json:"-"` // is this a vendored module?
+	Dependencies []*Module  `json:"-"` // modules this module depends on
+	Inference    *Inference `json:"-"` // how the module was inferred, if it was
+	Local        bool       `json:"-"` // is this a local module?
+	Packages     []Package  `json:"-"` // packages in this module
+	Sum          string     `json:"-"` // checksum for path, version (as in go.sum)
+	TestOnly     bool       `json:"-"` // is this module only required for tests?
+	Tool         string     `json:"-"` // tool package that requires this module, if it's only required by tools
+	Toolchain    bool       `json:"-"` // is this the Go toolchain, rather than the standard library?
+	Vendored     bool       `json:"-"` // is this a vendored module?
+	Workspace    bool       `json:"-"` // is this a workspace module other than the main module?
 }
 
 func (m Module) Coordinates() string {
@@ -71,6 +76,10 @@ func (m Module) Hash() (string, error) {
 }
 
 func (m Module) BOMRef() string {
+	if m.Toolchain {
+		return m.ShortPackageURL()
+	}
+
 	return fmt.Sprintf("pkg:golang/%s?type=module", m.Coordinates())
 }
 
@@ -80,13 +89,29 @@ var (
 )
 
 func (m Module) PackageURL() string {
+	if m.Toolchain {
+		return m.ShortPackageURL()
+	}
+
 	envOnce.Do(func() {
-		envMap, _ = gocmd.GetEnv(zerolog.Nop())
+		envMap, _ = gocmd.GetEnv(context.Background(), zerolog.Nop())
 	})
 
 	return fmt.Sprintf("pkg:golang/%s?goarch=%s&goos=%s&type=module", m.Coordinates(), envMap["GOARCH"], envMap["GOOS"])
 }
 
+// PlatformIndependentPackageURL returns a package URL without goos and goarch qualifiers.
+// Unlike PackageURL, it doesn't require the go command.
+func (m Module) PlatformIndependentPackageURL() string {
+	if m.Toolchain {
+		return m.ShortPackageURL()
+	}
+
+	return fmt.Sprintf("pkg:golang/%s?type=module", m.Coordinates())
+}
+
+// ShortPackageURL returns a package URL without qualifiers.
+// Package URLs of the toolchain never have qualifiers, as the golang type doesn't define any for it.
 func (m Module) ShortPackageURL() string {
 	return fmt.Sprintf("pkg:golang/%s", m.Coordinates())
 }
@@ -99,13 +124,13 @@ func IsModule(dir string) bool {
 // ErrNoModule indicates that a given path is not a valid Go module
 var ErrNoModule = errors.New("not a go module")
 
-func LoadModule(logger zerolog.Logger, moduleDir string) (*Module, error) {
+func LoadModule(ctx context.Context, logger zerolog.Logger, moduleDir string) (*Module, error) {
 	logger.Debug().
 		Str("moduleDir", moduleDir).
 		Msg("loading module")
 
 	buf := new(bytes.Buffer)
-	err := gocmd.ListModule(logger, moduleDir, buf)
+	err := gocmd.ListModule(ctx, logger, moduleDir, buf)
 	if err != nil {
 		return nil, fmt.Errorf("listing module failed: %w", err)
 	}
@@ -119,18 +144,19 @@ func LoadModule(logger zerolog.Logger, moduleDir string) (*Module, error) {
 	return &module, nil
 }
 
-func LoadModules(logger zerolog.Logger, moduleDir string, includeTest bool) ([]Module, error) {
+func LoadModules(ctx context.Context, logger zerolog.Logger, moduleDir string, includeTest, includeTools bool) ([]Module, error) {
 	logger.Debug().
 		Str("moduleDir", moduleDir).
 		Bool("includeTest", includeTest).
+		Bool("includeTools", includeTools).
 		Msg("loading modules")
 
-	if !IsModule(moduleDir) {
+	if !IsModule(moduleDir) && !IsWorkspace(moduleDir) {
 		return nil, ErrNoModule
 	}
 
 	buf := new(bytes.Buffer)
-	err := gocmd.ListModules(logger, moduleDir, buf)
+	err := gocmd.ListModules(ctx, logger, moduleDir, buf)
 	if err != nil {
 		return nil, fmt.Errorf("listing modules failed: %w", err)
 	}
@@ -140,12 +166,12 @@ func LoadModules(logger zerolog.Logger, moduleDir string, includeTest bool) ([]M
 		return nil, fmt.Errorf("parsing modules failed: %w", err)
 	}
 
-	modules, err = FilterModules(logger, moduleDir, modules, includeTest)
+	modules, err = FilterModules(ctx, logger, moduleDir, modules, includeTest, includeTools)
 	if err != nil {
 		return nil, fmt.Errorf("filtering modules failed: %w", err)
 	}
 
-	err = ResolveLocalReplacements(logger, moduleDir, modules)
+	err = ResolveLocalReplacements(ctx, logger, moduleDir, modules)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve local replacements: %w", err)
 	}
@@ -194,7 +220,7 @@ func sortModules(modules []Module) {
 }
 
 // ResolveLocalReplacements tries to resolve paths and versions for local replacement modules.
-func ResolveLocalReplacements(logger zerolog.Logger, mainModuleDir string, modules []Module) error {
+func ResolveLocalReplacements(ctx context.Context, logger zerolog.Logger, mainModuleDir string, modules []Module) error {
 	for i, module := range modules {
 		if module.Replace == nil {
 			// Only replacements can be local
@@ -208,7 +234,11 @@ func ResolveLocalReplacements(logger zerolog.Logger, mainModuleDir string, modul
 		}
 
 		var localModuleDir string
-		if filepath.IsAbs(module.Replace.Path) {
+		if module.Replace.Dir != "" && !module.Vendored && IsModule(module.Replace.Dir) {
+			// The go command already resolved the path for us. This is the only reliable option
+			// for replacements declared in go.work, or in other modules of a workspace.
+			localModuleDir = module.Replace.Dir
+		} else if filepath.IsAbs(module.Replace.Path) {
 			localModuleDir = modules[i].Replace.Path
 		} else { // Replacement path is relative to main module
 			localModuleDir = filepath.Join(mainModuleDir, modules[i].Replace.Path)
@@ -221,7 +251,7 @@ func ResolveLocalReplacements(logger zerolog.Logger, mainModuleDir string, modul
 			continue
 		}
 
-		err := resolveLocalReplacement(logger, localModuleDir, module.Replace)
+		err := resolveLocalReplacement(ctx, logger, localModuleDir, module.Replace)
 		if err != nil {
 			return fmt.Errorf("resolving local module %s failed: %w", module.Replace.Coordinates(), err)
 		}
@@ -230,12 +260,12 @@ func ResolveLocalReplacements(logger zerolog.Logger, mainModuleDir string, modul
 	return nil
 }
 
-func resolveLocalReplacement(logger zerolog.Logger, localModuleDir string, module *Module) error {
+func resolveLocalReplacement(ctx context.Context, logger zerolog.Logger, localModuleDir string, module *Module) error {
 	logger.Debug().
 		Str("moduleDir", localModuleDir).
 		Msg("resolving local replacement module")
 
-	localModule, err := LoadModule(logger, localModuleDir)
+	localModule, err := LoadModule(ctx, logger, localModuleDir)
 	if err != nil {
 		return err
 	}
@@ -19,6 +19,7 @@ package gomod
 
 import (
 	"bytes"
+	"context"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -82,7 +83,7 @@ func TestModule_BOMRef(t *testing.T) {
 
 func TestModule_PackageURL(t *testing.T) {
 	// To get value from "go env -json", cannot just use t.GetEnv() might return ""
-	envMap, _ = gocmd.GetEnv(zerolog.Nop())
+	envMap, _ = gocmd.GetEnv(context.Background(), zerolog.Nop())
 	goos := envMap["GOOS"]
 	goarch := envMap["GOARCH"]
 
@@ -192,7 +193,7 @@ func TestResolveLocalReplacementPreservesResolvedDirectory(t *testing.T) {
 		},
 	}
 
-	require.NoError(t, ResolveLocalReplacements(zerolog.Nop(), mainModuleDir, modules))
+	require.NoError(t, ResolveLocalReplacements(context.Background(), zerolog.Nop(), mainModuleDir, modules))
 	require.Equal(t, "example.com/org/project/deploy", modules[0].Replace.Path)
 	require.Equal(t, replacementDir, modules[0].Replace.Dir)
 	require.True(t, modules[0].Replace.Local)
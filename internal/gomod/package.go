@@ -19,6 +19,7 @@ package gomod
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -34,11 +35,14 @@ import (
 
 // See https://golang.org/cmd/go/#hdr-List_packages_or_modules
 type Package struct {
-	Dir        string  // directory containing package sources
-	ImportPath string  // import path of package in dir
-	Name       string  // package name
-	Standard   bool    // is this package part of the standard Go library?
-	Module     *Module // info about package's containing module, if any (can be nil)
+	Dir        string   // directory containing package sources
+	ImportPath string   // import path of package in dir
+	Name       string   // package name
+	Standard   bool     // is this package part of the standard Go library?
+	Module     *Module  // info about package's containing module, if any (can be nil)
+	Imports    []string // import paths used by this package
+	ForTest    string   // package is only for use in named test
+	DepOnly    bool     // package is only a dependency, not explicitly listed
 
 	GoFiles      []string // .go source files (excluding CgoFiles, TestGoFiles, XTestGoFiles)
 	CgoFiles     []string // .go source files that import "C"
@@ -56,6 +60,11 @@ type Package struct {
 	Error *PackageError // error loading package
 }
 
+// BOMRef returns the BOM reference of the package, given the module it belongs to.
+func (p Package) BOMRef(module Module) string {
+	return fmt.Sprintf("pkg:golang/%s@%s?type=package", p.ImportPath, module.Version)
+}
+
 type PackageError struct {
 	Err string
 }
@@ -64,14 +73,14 @@ func (pe PackageError) Error() string {
 	return pe.Err
 }
 
-func LoadPackage(logger zerolog.Logger, moduleDir, packagePattern string) (*Package, error) {
+func LoadPackage(ctx context.Context, logger zerolog.Logger, moduleDir, packagePattern string) (*Package, error) {
 	logger.Debug().
 		Str("moduleDir", moduleDir).
 		Str("packagePattern", packagePattern).
 		Msg("loading package")
 
 	buf := new(bytes.Buffer)
-	err := gocmd.ListPackage(logger, moduleDir, toRelativePackagePath(packagePattern), buf)
+	err := gocmd.ListPackage(ctx, logger, moduleDir, toRelativePackagePath(packagePattern), buf)
 	if err != nil {
 		return nil, err
 	}
@@ -89,9 +98,14 @@ func LoadPackage(logger zerolog.Logger, moduleDir, packagePattern string) (*Pack
 	return &pkg, nil
 }
 
-func LoadModulesFromPackages(logger zerolog.Logger, moduleDir, packagePattern string) ([]Module, error) {
+// LoadModulesFromPackages loads all modules that provide packages matching packagePattern,
+// including those of their dependencies.
+//
+// env may contain additional "key=value" pairs for the go command, e.g. to select a target platform.
+func LoadModulesFromPackages(ctx context.Context, logger zerolog.Logger, moduleDir, packagePattern string, env ...string) ([]Module, error) {
 	logger.Debug().
 		Str("moduleDir", moduleDir).
+		Strs("env", env).
 		Msg("loading modules")
 
 	if !IsModule(moduleDir) {
@@ -99,7 +113,7 @@ func LoadModulesFromPackages(logger zerolog.Logger, moduleDir, packagePattern st
 	}
 
 	buf := new(bytes.Buffer)
-	err := gocmd.ListPackages(logger, moduleDir, toRelativePackagePath(packagePattern), buf)
+	err := gocmd.ListPackages(ctx, logger, moduleDir, toRelativePackagePath(packagePattern), buf, env...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list packages for pattern \"%s\": %w", packagePattern, err)
 	}
@@ -109,12 +123,12 @@ func LoadModulesFromPackages(logger zerolog.Logger, moduleDir, packagePattern st
 		return nil, fmt.Errorf("failed to parse `go list` output: %w", err)
 	}
 
-	modules, err := convertPackagesToModules(logger, moduleDir, pkgMap)
+	modules, err := convertPackagesToModules(ctx, logger, moduleDir, pkgMap)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert packages to modules: %w", err)
 	}
 
-	err = ResolveLocalReplacements(logger, moduleDir, modules)
+	err = ResolveLocalReplacements(ctx, logger, moduleDir, modules)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve local replacements: %w", err)
 	}
@@ -168,7 +182,7 @@ func parsePackages(logger zerolog.Logger, reader io.Reader) (map[string][]Packag
 	return pkgsMap, nil
 }
 
-func convertPackagesToModules(logger zerolog.Logger, mainModuleDir string, pkgsMap map[string][]Package) ([]Module, error) {
+func convertPackagesToModules(ctx context.Context, logger zerolog.Logger, mainModuleDir string, pkgsMap map[string][]Package) ([]Module, error) {
 	modules := make([]Module, 0, len(pkgsMap))
 	isVendoring := IsVendoring(mainModuleDir)
 
@@ -183,7 +197,7 @@ func convertPackagesToModules(logger zerolog.Logger, mainModuleDir string, pkgsM
 		)
 
 		if coordinates == StdlibModulePath {
-			module, err = LoadStdlibModule(logger)
+			module, err = LoadStdlibModule(ctx, logger)
 			if err != nil {
 				return nil, fmt.Errorf("failed to load stdlib module: %w", err)
 			}
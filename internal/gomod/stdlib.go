@@ -18,10 +18,16 @@
 package gomod
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/rs/zerolog"
+	"golang.org/x/mod/semver"
+	"golang.org/x/mod/sumdb/dirhash"
 
 	"github.com/CycloneDX/cyclonedx-gomod/internal/gocmd"
 )
@@ -29,9 +35,20 @@ import (
 // StdlibModulePath defines the path used for Go's standard library module.
 const StdlibModulePath = "std"
 
+// ToolchainModulePath defines the path used for the Go toolchain module.
+// It's the path under which the go command distributes toolchains.
+// See https://go.dev/doc/toolchain#download.
+const ToolchainModulePath = "golang.org/toolchain"
+
+// FIPS140ModulePath defines the path of the Go Cryptographic Module.
+// See https://go.dev/doc/security/fips140.
+const FIPS140ModulePath = "golang.org/fips140"
+
 // LoadStdlibModule loads the standard library module.
-func LoadStdlibModule(logger zerolog.Logger) (*Module, error) {
-	env, err := gocmd.GetEnv(logger)
+// The toolchain it's part of and, if GOFIPS140 selects a frozen snapshot of the
+// Go Cryptographic Module, that snapshot are attached as its dependencies.
+func LoadStdlibModule(ctx context.Context, logger zerolog.Logger) (*Module, error) {
+	env, err := gocmd.GetEnv(ctx, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get go env: %w", err)
 	}
@@ -41,12 +58,12 @@ func LoadStdlibModule(logger zerolog.Logger) (*Module, error) {
 		return nil, fmt.Errorf("failed to determine GOROOT")
 	}
 
-	module, err := LoadModule(logger, filepath.Join(goroot, "src"))
+	module, err := LoadModule(ctx, logger, filepath.Join(goroot, "src"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to load stdlib module: %w", err)
 	}
 
-	module.Version, err = gocmd.GetVersion(logger)
+	module.Version, err = gocmd.GetVersion(ctx, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to determine go version: %w", err)
 	}
@@ -54,5 +71,135 @@ func LoadStdlibModule(logger zerolog.Logger) (*Module, error) {
 	module.Local = true
 	module.Main = false
 
+	toolchain := ToolchainModule(module.Version)
+	toolchain.Dir = goroot
+	module.Dependencies = []*Module{&toolchain}
+
+	fips140, err := loadFIPS140Module(logger, goroot, env["GOFIPS140"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fips140 module: %w", err)
+	}
+	if fips140 != nil {
+		module.Dependencies = append(module.Dependencies, fips140)
+	}
+
+	return module, nil
+}
+
+// NewStdlibModule creates a standard library module for the Go version goVersion,
+// as recorded in the build information of binaries. Like with LoadStdlibModule, the toolchain
+// and the snapshot of the Go Cryptographic Module selected by gofips140 are attached as dependencies.
+func NewStdlibModule(goVersion, gofips140 string) Module {
+	module := Module{
+		Path:    StdlibModulePath,
+		Version: goVersion,
+		Local:   true,
+	}
+
+	toolchain := ToolchainModule(goVersion)
+	module.Dependencies = []*Module{&toolchain}
+
+	if fips140 := FIPS140Module(gofips140); fips140 != nil {
+		module.Dependencies = append(module.Dependencies, fips140)
+	}
+
+	return module
+}
+
+// ToolchainModule creates a module for the Go toolchain of version goVersion.
+// It's identified by ToolchainModulePath, so that it can't be mistaken for the standard library.
+// Experiments that may be part of goVersion (e.g. "go1.22.1 X:boringcrypto") are removed.
+func ToolchainModule(goVersion string) Module {
+	if !strings.HasPrefix(goVersion, "devel ") {
+		goVersion, _, _ = strings.Cut(goVersion, " ")
+	}
+
+	return Module{
+		Path:      ToolchainModulePath,
+		Version:   goVersion,
+		Local:     true,
+		Toolchain: true,
+	}
+}
+
+// FIPS140Module creates a module for the snapshot of the Go Cryptographic Module
+// that is selected by the resolved value of GOFIPS140, as recorded in the build information
+// of binaries. Nil is returned when no snapshot is in use, i.e. if GOFIPS140 is unset, "off",
+// or "latest", with the latter referring to the module's source in the standard library.
+func FIPS140Module(gofips140 string) *Module {
+	if !semver.IsValid(gofips140) {
+		return nil
+	}
+
+	return &Module{
+		Path:    FIPS140ModulePath,
+		Version: gofips140,
+		Local:   true,
+	}
+}
+
+// loadFIPS140Module loads the snapshot of the Go Cryptographic Module that gofips140 selects.
+// Aliases such as "certified" are resolved using the files in GOROOT/lib/fips140,
+// which also holds the module zips that are used to calculate the module's checksum.
+func loadFIPS140Module(logger zerolog.Logger, goroot, gofips140 string) (*Module, error) {
+	if gofips140 == "" || gofips140 == "off" || gofips140 == "latest" {
+		return nil, nil
+	}
+
+	fipsDir := filepath.Join(goroot, "lib", "fips140")
+
+	version, err := resolveFIPS140Version(fipsDir, gofips140)
+	if err != nil {
+		return nil, err
+	}
+
+	module := FIPS140Module(version)
+	if module == nil {
+		return nil, fmt.Errorf("invalid fips140 module version: %s", version)
+	}
+
+	module.Sum, err = dirhash.HashZip(filepath.Join(fipsDir, version+".zip"), dirhash.Hash1)
+	if err != nil {
+		logger.Warn().Err(err).Str("version", version).Msg("failed to calculate fips140 module checksum")
+	}
+
 	return module, nil
 }
+
+// resolveFIPS140Version resolves GOFIPS140 aliases, using the first
+// non-comment line of the respective text file in fipsDir.
+func resolveFIPS140Version(fipsDir, gofips140 string) (string, error) {
+	file, err := os.Open(filepath.Join(fipsDir, gofips140+".txt"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return gofips140, nil // Not an alias
+		}
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line, nil
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", fmt.Errorf("no version found for alias %s", gofips140)
+}
+
+// StdlibModules returns the standard library module stdlib,
+// followed by the modules it depends on, i.e. its toolchain and the Go Cryptographic Module.
+func StdlibModules(stdlib Module) []Module {
+	modules := make([]Module, 0, len(stdlib.Dependencies)+1)
+	modules = append(modules, stdlib)
+	for _, dependency := range stdlib.Dependencies {
+		modules = append(modules, *dependency)
+	}
+
+	return modules
+}
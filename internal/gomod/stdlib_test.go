@@ -18,6 +18,10 @@
 package gomod
 
 import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/rs/zerolog"
@@ -25,7 +29,7 @@ import (
 )
 
 func TestLoadStdlibModule(t *testing.T) {
-	module, err := LoadStdlibModule(zerolog.Nop())
+	module, err := LoadStdlibModule(context.Background(), zerolog.Nop())
 	require.NoError(t, err)
 	require.Equal(t, "std", module.Path)
 	require.Regexp(t, `^go\d\.`, module.Version)
@@ -33,10 +37,87 @@ func TestLoadStdlibModule(t *testing.T) {
 	require.Nil(t, module.Replace)
 	require.NotEmpty(t, module.Dir)
 	require.False(t, module.Indirect)
-	require.Empty(t, module.Dependencies)
+	require.NotEmpty(t, module.Dependencies)
+	require.True(t, module.Dependencies[0].Toolchain)
+	require.Equal(t, module.Version, module.Dependencies[0].Version)
 	require.True(t, module.Local)
 	require.False(t, module.Main)
 	require.Empty(t, module.Packages)
 	require.False(t, module.TestOnly)
 	require.False(t, module.Vendored)
 }
+
+func TestNewStdlibModule(t *testing.T) {
+	t.Run("WithoutFIPS140", func(t *testing.T) {
+		module := NewStdlibModule("go1.22.1 X:boringcrypto", "")
+		require.Equal(t, "std@go1.22.1 X:boringcrypto", module.Coordinates())
+		require.Len(t, module.Dependencies, 1)
+		require.Equal(t, "pkg:golang/golang.org/toolchain@go1.22.1", module.Dependencies[0].BOMRef())
+		require.Equal(t, "pkg:golang/golang.org/toolchain@go1.22.1", module.Dependencies[0].ShortPackageURL())
+	})
+
+	t.Run("WithFIPS140", func(t *testing.T) {
+		module := NewStdlibModule("go1.24.0", "v1.0.0-c2097c7c")
+		require.Len(t, module.Dependencies, 2)
+		require.Equal(t, "golang.org/fips140@v1.0.0-c2097c7c", module.Dependencies[1].Coordinates())
+
+		modules := StdlibModules(module)
+		require.Len(t, modules, 3)
+		require.Equal(t, "pkg:golang/std@go1.24.0?type=module", modules[0].BOMRef())
+		require.Equal(t, "pkg:golang/golang.org/toolchain@go1.24.0", modules[1].BOMRef())
+		require.Equal(t, "pkg:golang/golang.org/fips140@v1.0.0-c2097c7c?type=module", modules[2].BOMRef())
+	})
+}
+
+func TestFIPS140Module(t *testing.T) {
+	require.Nil(t, FIPS140Module(""))
+	require.Nil(t, FIPS140Module("off"))
+	require.Nil(t, FIPS140Module("latest"))
+
+	module := FIPS140Module("v1.0.0")
+	require.NotNil(t, module)
+	require.Equal(t, "golang.org/fips140@v1.0.0", module.Coordinates())
+}
+
+func TestLoadFIPS140Module(t *testing.T) {
+	goroot := t.TempDir()
+	fipsDir := filepath.Join(goroot, "lib", "fips140")
+	require.NoError(t, os.MkdirAll(fipsDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(fipsDir, "certified.txt"), []byte("# comment\n\nv1.0.0-c2097c7c\n"), 0o600))
+
+	zipFile, err := os.Create(filepath.Join(fipsDir, "v1.0.0-c2097c7c.zip"))
+	require.NoError(t, err)
+	zipWriter := zip.NewWriter(zipFile)
+	fileWriter, err := zipWriter.Create("golang.org/fips140@v1.0.0-c2097c7c/fips140/v1.0.0/sha256/sha256.go")
+	require.NoError(t, err)
+	_, err = fileWriter.Write([]byte("package sha256\n"))
+	require.NoError(t, err)
+	require.NoError(t, zipWriter.Close())
+	require.NoError(t, zipFile.Close())
+
+	t.Run("Off", func(t *testing.T) {
+		module, err := loadFIPS140Module(zerolog.Nop(), goroot, "off")
+		require.NoError(t, err)
+		require.Nil(t, module)
+	})
+
+	t.Run("Alias", func(t *testing.T) {
+		module, err := loadFIPS140Module(zerolog.Nop(), goroot, "certified")
+		require.NoError(t, err)
+		require.NotNil(t, module)
+		require.Equal(t, "golang.org/fips140@v1.0.0-c2097c7c", module.Coordinates())
+		require.Regexp(t, `^h1:`, module.Sum)
+	})
+
+	t.Run("Version", func(t *testing.T) {
+		module, err := loadFIPS140Module(zerolog.Nop(), goroot, "v1.0.0-c2097c7c")
+		require.NoError(t, err)
+		require.NotNil(t, module)
+		require.Equal(t, "golang.org/fips140@v1.0.0-c2097c7c", module.Coordinates())
+	})
+
+	t.Run("InvalidVersion", func(t *testing.T) {
+		_, err := loadFIPS140Module(zerolog.Nop(), goroot, "foo")
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,590 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package gomod
+
+import (
+	"bytes"
+	"debug/buildinfo"
+	"debug/elf"
+	"debug/gosym"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/module"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/gocmd"
+)
+
+// Inference describes how a module was inferred, for modules that were
+// not read from authoritative sources like go.mod files or build information.
+type Inference struct {
+	Confidence float32 // confidence in the module's identity, between 0 and 1
+	Method     string  // how the module was inferred
+}
+
+var (
+	// The module cache contains the escaped path and version of a module,
+	// so both can be recovered from the source file paths with reasonable confidence.
+	inferenceModuleCache = Inference{Confidence: 0.7, Method: "module path and version from source file paths in symbol table"}
+
+	// Only the module path can be guessed from import paths, the version is unknown.
+	inferenceImportPath = Inference{Confidence: 0.3, Method: "module path from package import paths in symbol table"}
+
+	// Last resort for the main module, when nothing else is known about it.
+	inferenceFileName = Inference{Confidence: 0.1, Method: "binary file name"}
+)
+
+// InferBuildInfo infers build information from the symbol table of a Go binary.
+//
+// It's meant as fallback for binaries that don't contain module information,
+// e.g. because they were built in GOPATH mode or with a Go version prior to 1.13.
+// Modules are derived from the source file paths and import paths of the packages
+// that were compiled into the binary. Because this is inherently imprecise,
+// all resulting modules are annotated with an Inference.
+func InferBuildInfo(binaryPath string) (*BuildInfo, error) {
+	table, err := readSymbolTable(binaryPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read symbol table: %w", err)
+	}
+
+	var buildInfo BuildInfo
+
+	// Binaries without module information may still carry the Go version,
+	// the main package path and build settings.
+	if stdBuildInfo, err := buildinfo.ReadFile(binaryPath); err == nil {
+		buildInfo.Path = stdBuildInfo.Path
+		buildInfo.GoVersion, _ = gocmd.ParseVersion(stdBuildInfo.GoVersion)
+		buildInfo.Settings = buildSettings(stdBuildInfo.Settings)
+	}
+
+	pkgDirs, mainDir := collectPackageDirs(table)
+	buildInfo.Main, buildInfo.Deps = inferModules(pkgDirs, mainDir, buildInfo.Path)
+	if buildInfo.Main.Path == "" {
+		buildInfo.Main.Path = filepath.Base(binaryPath)
+		buildInfo.Main.Inference = &inferenceFileName
+	}
+
+	if len(buildInfo.Deps) > 0 {
+		// Like with regular build information, all deps are considered direct dependencies of main
+		buildInfo.Main.Dependencies = make([]*Module, len(buildInfo.Deps))
+		for i := range buildInfo.Deps {
+			buildInfo.Main.Dependencies[i] = &buildInfo.Deps[i]
+		}
+		sortDependencies(buildInfo.Main.Dependencies)
+	}
+
+	return &buildInfo, nil
+}
+
+// ApplyLinkedPackages assigns the packages that were linked into a Go binary
+// to the modules they belong to, as determined from the binary's symbol table.
+//
+// The symbol table refers to the main package as "main", so its import path has to be
+// provided via mainPkg. Standard library packages are assigned to the std module,
+// if modules contains one. Packages that don't belong to any module are skipped.
+func ApplyLinkedPackages(binaryPath, mainPkg string, modules []Module) error {
+	table, err := readSymbolTable(binaryPath)
+	if err != nil {
+		return fmt.Errorf("failed to read symbol table: %w", err)
+	}
+
+	pkgDirs, _ := collectPackageDirs(table)
+	gorootSrc := findGorootSrc(pkgDirs)
+	if mainPkg != "" && mainPkg != "command-line-arguments" {
+		pkgDirs[mainPkg] = nil
+	}
+
+	stdIndex := slices.IndexFunc(modules, func(m Module) bool { return m.Path == StdlibModulePath })
+
+	for pkg, dirs := range pkgDirs {
+		importPath := trimVendorPrefix(pkg)
+
+		// Known modules take precedence over the stdlib heuristic,
+		// as it can't tell apart some packages of local modules.
+		moduleIndex := -1
+		for i := range modules {
+			if i == stdIndex || (importPath != modules[i].Path && !strings.HasPrefix(importPath, modules[i].Path+"/")) {
+				continue
+			}
+			if moduleIndex < 0 || len(modules[i].Path) > len(modules[moduleIndex].Path) {
+				moduleIndex = i
+			}
+		}
+
+		standard := false
+		if moduleIndex < 0 {
+			if stdIndex < 0 || !(strings.HasPrefix(pkg, "vendor/") || isStdlibPackage(pkg, dirs, gorootSrc)) {
+				continue
+			}
+			moduleIndex = stdIndex
+			standard = true
+		}
+
+		if slices.ContainsFunc(modules[moduleIndex].Packages, func(p Package) bool { return p.ImportPath == importPath }) {
+			continue // Vendored multiple times in GOPATH mode
+		}
+		modules[moduleIndex].Packages = append(modules[moduleIndex].Packages, Package{
+			ImportPath: importPath,
+			Standard:   standard,
+		})
+	}
+
+	for i := range modules {
+		slices.SortFunc(modules[i].Packages, func(a, b Package) int {
+			return strings.Compare(a.ImportPath, b.ImportPath)
+		})
+	}
+
+	return nil
+}
+
+// HasGoRuntime checks whether the symbol table of the binary at binaryPath contains the Go runtime.
+// This allows identifying Go binaries of which the build information has been removed.
+func HasGoRuntime(binaryPath string) bool {
+	table, err := readSymbolTable(binaryPath)
+	if err != nil {
+		return false
+	}
+
+	return table.LookupFunc("runtime.main") != nil
+}
+
+// readSymbolTable reads the pclntab of an ELF, Mach-O or PE binary.
+func readSymbolTable(binaryPath string) (*gosym.Table, error) {
+	var (
+		pclntab   []byte
+		textStart uint64
+		err       error
+	)
+
+	if elfFile, elfErr := elf.Open(binaryPath); elfErr == nil {
+		defer elfFile.Close()
+		pclntab, textStart, err = readELFSymbolTable(elfFile)
+	} else if machoFile, machoErr := macho.Open(binaryPath); machoErr == nil {
+		defer machoFile.Close()
+		pclntab, textStart, err = readMachOSymbolTable(machoFile)
+	} else if peFile, peErr := pe.Open(binaryPath); peErr == nil {
+		defer peFile.Close()
+		pclntab, textStart, err = readPESymbolTable(peFile)
+	} else {
+		return nil, errors.New("unrecognized executable format")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := gosym.NewTable(nil, gosym.NewLineTable(pclntab, textStart))
+	if err != nil {
+		return nil, err
+	}
+	if len(table.Funcs) == 0 {
+		return nil, errors.New("symbol table contains no functions")
+	}
+
+	return table, nil
+}
+
+func readELFSymbolTable(file *elf.File) ([]byte, uint64, error) {
+	text := file.Section(".text")
+	if text == nil {
+		return nil, 0, errors.New("no .text section")
+	}
+
+	if section := file.Section(".gopclntab"); section != nil {
+		data, err := section.Data()
+		return data, text.Addr, err
+	}
+
+	// Position independent executables keep the pclntab in .data.rel.ro
+	for _, section := range file.Sections {
+		if section.Type != elf.SHT_PROGBITS || section.Flags&elf.SHF_EXECINSTR != 0 {
+			continue
+		}
+		data, err := section.Data()
+		if err != nil {
+			continue
+		}
+		if pclntab := findPclntab(data); pclntab != nil {
+			return pclntab, text.Addr, nil
+		}
+	}
+
+	return nil, 0, errors.New("no pclntab found")
+}
+
+func readMachOSymbolTable(file *macho.File) ([]byte, uint64, error) {
+	text := file.Section("__text")
+	if text == nil {
+		return nil, 0, errors.New("no __text section")
+	}
+
+	section := file.Section("__gopclntab")
+	if section == nil {
+		return nil, 0, errors.New("no __gopclntab section")
+	}
+	data, err := section.Data()
+
+	return data, text.Addr, err
+}
+
+func readPESymbolTable(file *pe.File) ([]byte, uint64, error) {
+	var imageBase uint64
+	switch header := file.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		imageBase = uint64(header.ImageBase)
+	case *pe.OptionalHeader64:
+		imageBase = header.ImageBase
+	}
+
+	text := file.Section(".text")
+	if text == nil {
+		return nil, 0, errors.New("no .text section")
+	}
+
+	// PE files have no dedicated section for the pclntab,
+	// and symbols that would point to it are usually stripped.
+	for _, name := range []string{".rdata", ".data"} {
+		section := file.Section(name)
+		if section == nil {
+			continue
+		}
+		data, err := section.Data()
+		if err != nil {
+			continue
+		}
+		if pclntab := findPclntab(data); pclntab != nil {
+			return pclntab, imageBase + uint64(text.VirtualAddress), nil
+		}
+	}
+
+	return nil, 0, errors.New("no pclntab found")
+}
+
+// pclntabMagics are the magic numbers of the pclntab formats known to debug/gosym,
+// from Go 1.20+ down to Go 1.2.
+var pclntabMagics = []uint32{0xfffffff1, 0xfffffff0, 0xfffffffa, 0xfffffffb}
+
+// findPclntab searches data for the header of a pclntab.
+// The returned slice starts at the header and extends to the end of data.
+func findPclntab(data []byte) []byte {
+	for _, magic := range pclntabMagics {
+		for _, byteOrder := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+			var header [6]byte
+			byteOrder.PutUint32(header[:], magic)
+
+			for offset := 0; ; {
+				i := bytes.Index(data[offset:], header[:])
+				if i < 0 {
+					break
+				}
+				offset += i
+				if isPclntabHeader(data[offset:]) {
+					return data[offset:]
+				}
+				offset++
+			}
+		}
+	}
+
+	return nil
+}
+
+// isPclntabHeader checks the instruction size quantum and pointer size of a pclntab header.
+func isPclntabHeader(data []byte) bool {
+	if len(data) < 8 {
+		return false
+	}
+
+	quantum, ptrSize := data[6], data[7]
+
+	return (quantum == 1 || quantum == 2 || quantum == 4) && (ptrSize == 4 || ptrSize == 8)
+}
+
+// collectPackageDirs collects the source directories of all packages in table,
+// as well as the source directory of the main package.
+func collectPackageDirs(table *gosym.Table) (map[string][]string, string) {
+	pkgDirs := make(map[string][]string)
+	mainDir := ""
+
+	for i := range table.Funcs {
+		fn := &table.Funcs[i]
+
+		file, _, _ := table.PCToLine(fn.Entry)
+		if file == "" || file == "<autogenerated>" {
+			continue
+		}
+		dir := path.Dir(strings.ReplaceAll(file, `\`, "/"))
+
+		if fn.Name == "main.main" {
+			mainDir = dir
+			continue
+		}
+
+		pkg := fn.PackageName()
+		if pkg == "" || pkg == "main" || strings.Contains(pkg, ":") {
+			continue
+		}
+		if unescaped, err := url.PathUnescape(pkg); err == nil {
+			pkg = unescaped // Dots in the last path element are escaped in symbol names
+		}
+
+		if !slices.Contains(pkgDirs[pkg], dir) {
+			pkgDirs[pkg] = append(pkgDirs[pkg], dir)
+		}
+	}
+
+	return pkgDirs, mainDir
+}
+
+// inferModules infers the main module and its dependencies from the packages in a binary.
+func inferModules(pkgDirs map[string][]string, mainDir, mainPkg string) (*Module, []Module) {
+	gorootSrc := findGorootSrc(pkgDirs)
+
+	modules := make(map[string]*Module)
+	localRoots := make(map[string]string) // module root directory -> module path
+
+	addModule := func(mod Module, inference *Inference) {
+		if _, ok := modules[mod.Path]; !ok {
+			mod.Inference = inference
+			modules[mod.Path] = &mod
+		}
+	}
+
+	// Packages in the module cache reveal their module directly.
+	var unresolved []string
+	for pkg, dirs := range pkgDirs {
+		if strings.HasPrefix(pkg, "vendor/") || isStdlibPackage(pkg, dirs, gorootSrc) {
+			continue
+		}
+		if mod, ok := moduleFromDirs(trimVendorPrefix(pkg), dirs); ok {
+			addModule(mod, &inferenceModuleCache)
+		} else {
+			unresolved = append(unresolved, pkg)
+		}
+	}
+
+	// Remaining packages are assigned to modules based on their import path,
+	// starting with the shortest so that sub packages end up in the same module.
+	slices.SortFunc(unresolved, func(a, b string) int {
+		return len(a) - len(b)
+	})
+	for _, pkg := range unresolved {
+		importPath := trimVendorPrefix(pkg)
+		modPath, root := inferModulePath(importPath, pkgDirs[pkg], modules)
+		addModule(Module{Path: modPath}, &inferenceImportPath)
+		if root != "" {
+			localRoots[root] = modPath
+		}
+	}
+
+	// Determine the module containing the main package
+	if mainPkg == "command-line-arguments" {
+		mainPkg = ""
+	}
+	if mainPkg == "" && mainDir != "." && !path.IsAbs(mainDir) && !strings.Contains(mainDir, ":") {
+		mainPkg = mainDir // Paths are relative to the module cache or GOPATH when built with -trimpath
+	}
+
+	var main *Module
+	if mod, ok := moduleFromDirs(mainPkg, []string{mainDir}); ok && mainDir != "" {
+		mod.Inference = &inferenceModuleCache
+		main = &mod
+	} else if mainPkg != "" {
+		modPath, _ := inferModulePath(mainPkg, []string{mainDir}, modules)
+		main = &Module{Path: modPath, Inference: &inferenceImportPath}
+	} else {
+		main = &Module{}
+		for root, modPath := range localRoots {
+			if mainDir == root || strings.HasPrefix(mainDir, root+"/") {
+				main = &Module{Path: modPath, Inference: &inferenceImportPath}
+				break
+			}
+		}
+	}
+	main.Main = true
+	if mod, ok := modules[main.Path]; ok && main.Path != "" {
+		if main.Version == "" {
+			main.Version = mod.Version
+		}
+		delete(modules, main.Path)
+	}
+
+	deps := make([]Module, 0, len(modules))
+	for _, mod := range modules {
+		deps = append(deps, *mod)
+	}
+	slices.SortFunc(deps, func(a, b Module) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return main, deps
+}
+
+// findGorootSrc determines the GOROOT/src directory of the Go installation
+// that built a binary, based on the source directory of the runtime package.
+func findGorootSrc(pkgDirs map[string][]string) string {
+	for _, dir := range pkgDirs["runtime"] {
+		if dir == "runtime" || strings.HasSuffix(dir, "/runtime") {
+			return strings.TrimSuffix(dir, "runtime")
+		}
+	}
+
+	return ""
+}
+
+// isStdlibPackage determines whether a package is part of the standard library.
+// Standard library import paths don't have a dot in their first element,
+// but neither do packages of local modules or GOPATH projects. Those can
+// be told apart if the binary was built without -trimpath.
+func isStdlibPackage(pkg string, dirs []string, gorootSrc string) bool {
+	first, _, _ := strings.Cut(pkg, "/")
+	if strings.Contains(first, ".") {
+		return false
+	}
+
+	if gorootSrc == "" {
+		return true
+	}
+	for _, dir := range dirs {
+		if strings.HasPrefix(dir, gorootSrc) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// trimVendorPrefix removes the vendor directory prefix from
+// import paths of packages that were vendored in GOPATH mode.
+func trimVendorPrefix(pkg string) string {
+	if i := strings.LastIndex(pkg, "/vendor/"); i >= 0 {
+		return pkg[i+len("/vendor/"):]
+	}
+
+	return pkg
+}
+
+// moduleFromDirs attempts to recover the module of a package from its source directories,
+// which contain the escaped module path and version when located in the module cache.
+// An empty pkg skips the check whether the package actually belongs to the module.
+func moduleFromDirs(pkg string, dirs []string) (Module, bool) {
+	for _, dir := range dirs {
+		if i := strings.LastIndex(dir, "/pkg/mod/"); i >= 0 {
+			dir = dir[i+len("/pkg/mod/"):]
+		}
+
+		elems := strings.Split(dir, "/")
+		for i, elem := range elems {
+			escapedName, escapedVersion, ok := strings.Cut(elem, "@")
+			if !ok {
+				continue
+			}
+
+			// Paths are only escaped in the module cache, but not when trimmed with -trimpath
+			modPath := path.Join(append(elems[:i:i], escapedName)...)
+			if unescaped, err := module.UnescapePath(modPath); err == nil {
+				modPath = unescaped
+			}
+			version := escapedVersion
+			if unescaped, err := module.UnescapeVersion(version); err == nil {
+				version = unescaped
+			}
+			if err := module.Check(modPath, version); err != nil {
+				continue
+			}
+			if pkg != "" && pkg != modPath && !strings.HasPrefix(pkg, modPath+"/") {
+				continue
+			}
+
+			return Module{Path: modPath, Version: version}, true
+		}
+	}
+
+	return Module{}, false
+}
+
+// inferModulePath infers the module path of a package that could not be located in the module cache.
+// Known modules take precedence, followed by the directory layout for packages with absolute source paths.
+// The returned root is the module's directory, if it could be determined.
+func inferModulePath(pkg string, dirs []string, modules map[string]*Module) (modPath string, root string) {
+	for knownPath := range modules {
+		if (pkg == knownPath || strings.HasPrefix(pkg, knownPath+"/")) && len(knownPath) > len(modPath) {
+			modPath = knownPath
+		}
+	}
+	if modPath != "" {
+		return modPath, ""
+	}
+
+	elems := strings.Split(pkg, "/")
+	n := min(minModulePathElems(elems), len(elems))
+
+	// The trailing elements of the source directory that match the import path
+	// are the package's location within the module. E.g. for github.com/acme/app/internal/foo
+	// in /src/app/internal/foo, the module path is github.com/acme/app.
+	for _, dir := range dirs {
+		if !path.IsAbs(dir) {
+			continue
+		}
+		dirElems := strings.Split(dir, "/")
+		common := 0
+		for common < len(elems) && common < len(dirElems)-1 &&
+			elems[len(elems)-1-common] == dirElems[len(dirElems)-1-common] {
+			common++
+		}
+
+		n = max(n, len(elems)-common)
+		root = path.Join("/", path.Join(dirElems[:len(dirElems)-(len(elems)-n)]...))
+		break
+	}
+
+	return path.Join(elems[:n]...), root
+}
+
+var majorVersionSuffixRegex = regexp.MustCompile(`^v[2-9]\d*$|^v1\d+$`)
+
+// minModulePathElems estimates the number of elements in the module path
+// of an import path, based on the conventions of popular hosting providers.
+func minModulePathElems(elems []string) int {
+	var n int
+	switch {
+	case !strings.Contains(elems[0], "."):
+		return 1
+	case elems[0] == "github.com" || elems[0] == "gitlab.com" || elems[0] == "bitbucket.org" || elems[0] == "golang.org":
+		n = 3
+	case elems[0] == "gopkg.in" && len(elems) > 1 && !strings.Contains(elems[1], "."):
+		n = 3
+	default:
+		n = 2
+	}
+
+	if len(elems) > n && majorVersionSuffixRegex.MatchString(elems[n]) {
+		n++
+	}
+
+	return n
+}
@@ -0,0 +1,204 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package gomod
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/testutil"
+)
+
+func TestInferBuildInfo(t *testing.T) {
+	t.Run("ModuleCache", func(t *testing.T) {
+		buildInfo, err := InferBuildInfo("../../pkg/generate/testdata/simple")
+		require.NoError(t, err)
+
+		assert.Equal(t, "go1.16.7", buildInfo.GoVersion)
+		assert.Equal(t, "testmod-simple", buildInfo.Path)
+		assert.Equal(t, "testmod-simple", buildInfo.Main.Path)
+		assert.True(t, buildInfo.Main.Main)
+		require.Len(t, buildInfo.Deps, 1)
+		assert.Equal(t, "github.com/google/uuid", buildInfo.Deps[0].Path)
+		assert.Equal(t, "v1.2.0", buildInfo.Deps[0].Version)
+		assert.Equal(t, &inferenceModuleCache, buildInfo.Deps[0].Inference)
+		require.Len(t, buildInfo.Main.Dependencies, 1)
+		assert.Equal(t, &buildInfo.Deps[0], buildInfo.Main.Dependencies[0])
+	})
+
+	t.Run("GOPATH", func(t *testing.T) {
+		testutil.SkipIfShort(t)
+
+		binaryPath := testutil.BuildGOPATHBinary(t, map[string]string{
+			"example.com/lib/greet/greet.go":  "package greet\n\n//go:noinline\nfunc Hello() string { return \"hello\" }\n",
+			"example.com/app/cmd/app/main.go": "package main\n\nimport \"example.com/lib/greet\"\n\nfunc main() { println(greet.Hello()) }\n",
+		}, "example.com/app/cmd/app")
+
+		buildInfo, err := InferBuildInfo(binaryPath)
+		require.NoError(t, err)
+
+		assert.Equal(t, "example.com/app/cmd/app", buildInfo.Path)
+		assert.Equal(t, "example.com/app", buildInfo.Main.Path)
+		assert.Empty(t, buildInfo.Main.Version)
+		assert.Equal(t, &inferenceImportPath, buildInfo.Main.Inference)
+		require.Len(t, buildInfo.Deps, 1)
+		assert.Equal(t, "example.com/lib", buildInfo.Deps[0].Path)
+		assert.Empty(t, buildInfo.Deps[0].Version)
+		assert.Equal(t, &inferenceImportPath, buildInfo.Deps[0].Inference)
+	})
+
+	t.Run("NotAnExecutable", func(t *testing.T) {
+		_, err := InferBuildInfo("./symtab.go")
+		require.ErrorContains(t, err, "unrecognized executable format")
+	})
+}
+
+func TestApplyLinkedPackages(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		modules := []Module{
+			{Path: "testmod-simple", Main: true},
+			{Path: "github.com/google/uuid", Version: "v1.2.0"},
+			{Path: StdlibModulePath, Version: "go1.16.7"},
+		}
+
+		err := ApplyLinkedPackages("../../pkg/generate/testdata/simple", "testmod-simple", modules)
+		require.NoError(t, err)
+
+		assert.Equal(t, []Package{{ImportPath: "testmod-simple"}}, modules[0].Packages)
+		assert.Equal(t, []Package{{ImportPath: "github.com/google/uuid"}}, modules[1].Packages)
+		assert.Contains(t, modules[2].Packages, Package{ImportPath: "fmt", Standard: true})
+		assert.Contains(t, modules[2].Packages, Package{ImportPath: "runtime", Standard: true})
+		assert.NotContains(t, modules[2].Packages, Package{ImportPath: "github.com/google/uuid", Standard: true})
+	})
+
+	t.Run("WithoutStdlib", func(t *testing.T) {
+		modules := []Module{
+			{Path: "testmod-simple", Main: true},
+		}
+
+		err := ApplyLinkedPackages("../../pkg/generate/testdata/simple", "command-line-arguments", modules)
+		require.NoError(t, err)
+		assert.Empty(t, modules[0].Packages)
+	})
+}
+
+func TestInferModules(t *testing.T) {
+	t.Run("Trimpath", func(t *testing.T) {
+		main, deps := inferModules(map[string][]string{
+			"runtime":                        {"runtime"},
+			"vendor/golang.org/x/net/idna":   {"vendor/golang.org/x/net/idna"},
+			"github.com/acme/app/internal/x": {"github.com/acme/app/internal/x"},
+			"github.com/acme/lib/v2/foo":     {"github.com/acme/lib/v2@v2.1.0/foo"},
+			"github.com/acme/lib/v2":         {"github.com/acme/lib/v2@v2.1.0"},
+		}, "github.com/acme/app/cmd/app", "")
+
+		assert.Equal(t, "github.com/acme/app", main.Path)
+		assert.True(t, main.Main)
+		assert.Equal(t, []Module{
+			{Path: "github.com/acme/lib/v2", Version: "v2.1.0", Inference: &inferenceModuleCache},
+		}, deps)
+	})
+
+	t.Run("LocalModule", func(t *testing.T) {
+		main, deps := inferModules(map[string][]string{
+			"runtime":                                  {"/usr/local/go/src/runtime"},
+			"github.com/acme/app/internal/x":           {"/home/dev/app/internal/x"},
+			"github.com/Acme/lib/foo":                  {"/home/dev/go/pkg/mod/github.com/!acme/lib@v1.0.0/foo"},
+			"github.com/acme/app/vendor/example.com/y": {"/home/dev/app/vendor/example.com/y"},
+		}, "/home/dev/app/cmd/app", "")
+
+		assert.Equal(t, "github.com/acme/app", main.Path)
+		assert.Equal(t, &inferenceImportPath, main.Inference)
+		assert.Equal(t, []Module{
+			{Path: "example.com/y", Inference: &inferenceImportPath},
+			{Path: "github.com/Acme/lib", Version: "v1.0.0", Inference: &inferenceModuleCache},
+		}, deps)
+	})
+
+	t.Run("UnknownMain", func(t *testing.T) {
+		main, deps := inferModules(map[string][]string{}, "/build/main.go", "command-line-arguments")
+
+		assert.Empty(t, main.Path)
+		assert.True(t, main.Main)
+		assert.Empty(t, deps)
+	})
+}
+
+func TestModuleFromDirs(t *testing.T) {
+	testCases := []struct {
+		pkg     string
+		dir     string
+		module  Module
+		matched bool
+	}{
+		{"github.com/CycloneDX/cyclonedx-go", "/root/go/pkg/mod/github.com/!cyclone!d!x/cyclonedx-go@v0.11.0", Module{Path: "github.com/CycloneDX/cyclonedx-go", Version: "v0.11.0"}, true},
+		{"github.com/CycloneDX/cyclonedx-go", "github.com/CycloneDX/cyclonedx-go@v0.11.0", Module{Path: "github.com/CycloneDX/cyclonedx-go", Version: "v0.11.0"}, true},
+		{"gopkg.in/yaml.v3", "gopkg.in/yaml.v3@v3.0.1", Module{Path: "gopkg.in/yaml.v3", Version: "v3.0.1"}, true},
+		{"golang.org/x/mod/semver", "C:/Users/dev@corp/go/pkg/mod/golang.org/x/mod@v0.20.0/semver", Module{Path: "golang.org/x/mod", Version: "v0.20.0"}, true},
+		{"", "example.com/foo@v1.0.0/cmd/foo", Module{Path: "example.com/foo", Version: "v1.0.0"}, true},
+		{"example.com/bar", "example.com/foo@v1.0.0", Module{}, false},
+		{"example.com/foo", "/home/dev@corp/foo", Module{}, false},
+		{"example.com/foo", "example.com/foo@latest", Module{}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.dir, func(t *testing.T) {
+			module, matched := moduleFromDirs(tc.pkg, []string{tc.dir})
+			assert.Equal(t, tc.matched, matched)
+			assert.Equal(t, tc.module, module)
+		})
+	}
+}
+
+func TestInferModulePath(t *testing.T) {
+	testCases := []struct {
+		pkg     string
+		dir     string
+		modPath string
+		root    string
+	}{
+		{"github.com/acme/app/internal/x", "github.com/acme/app/internal/x", "github.com/acme/app", ""},
+		{"github.com/acme/app/v3/internal/x", "github.com/acme/app/v3/internal/x", "github.com/acme/app/v3", ""},
+		{"gopkg.in/yaml.v3", "gopkg.in/yaml.v3", "gopkg.in/yaml.v3", ""},
+		{"gopkg.in/acme/lib.v1/x", "gopkg.in/acme/lib.v1/x", "gopkg.in/acme/lib.v1", ""},
+		{"example.com/app/internal/x", "/src/app/internal/x", "example.com/app", "/src/app"},
+		{"github.com/acme/app/internal/x", "/src/app/internal/x", "github.com/acme/app", "/src/app"},
+		{"example.com/app/internal/x", "/gopath/src/example.com/app/internal/x", "example.com/app", "/gopath/src/example.com/app"},
+		{"myapp/internal/x", "/gopath/src/myapp/internal/x", "myapp", "/gopath/src/myapp"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pkg+" in "+tc.dir, func(t *testing.T) {
+			modPath, root := inferModulePath(tc.pkg, []string{tc.dir}, nil)
+			assert.Equal(t, tc.modPath, modPath)
+			assert.Equal(t, tc.root, root)
+		})
+	}
+
+	t.Run("KnownModule", func(t *testing.T) {
+		modPath, root := inferModulePath("example.com/app/internal/x", []string{"/src/x"}, map[string]*Module{
+			"example.com/app":          {Path: "example.com/app"},
+			"example.com/app/internal": {Path: "example.com/app/internal"},
+			"example.com/application":  {Path: "example.com/application"},
+		})
+		assert.Equal(t, "example.com/app/internal", modPath)
+		assert.Empty(t, root)
+	})
+}
@@ -20,6 +20,7 @@ package gomod
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -40,7 +41,7 @@ func IsVendoring(moduleDir string) bool {
 
 var ErrNotVendoring = errors.New("the module is not vendoring its dependencies")
 
-func GetVendoredModules(logger zerolog.Logger, moduleDir string, includeTest bool) ([]Module, error) {
+func GetVendoredModules(ctx context.Context, logger zerolog.Logger, moduleDir string, includeTest, includeTools bool) ([]Module, error) {
 	if !IsModule(moduleDir) {
 		return nil, ErrNoModule
 	}
@@ -51,10 +52,11 @@ func GetVendoredModules(logger zerolog.Logger, moduleDir string, includeTest boo
 	logger.Debug().
 		Str("moduleDir", moduleDir).
 		Bool("includeTest", includeTest).
+		Bool("includeTools", includeTools).
 		Msg("loading vendored modules")
 
 	buf := new(bytes.Buffer)
-	err := gocmd.ListVendoredModules(logger, moduleDir, buf)
+	err := gocmd.ListVendoredModules(ctx, logger, moduleDir, buf)
 	if err != nil {
 		return nil, fmt.Errorf("listing vendored modules failed: %w", err)
 	}
@@ -64,18 +66,18 @@ func GetVendoredModules(logger zerolog.Logger, moduleDir string, includeTest boo
 		return nil, fmt.Errorf("parsing vendored modules failed: %w", err)
 	}
 
-	modules, err = FilterModules(logger, moduleDir, modules, includeTest)
+	modules, err = FilterModules(ctx, logger, moduleDir, modules, includeTest, includeTools)
 	if err != nil {
 		return nil, fmt.Errorf("filtering modules failed: %w", err)
 	}
 
-	err = ResolveLocalReplacements(logger, moduleDir, modules)
+	err = ResolveLocalReplacements(ctx, logger, moduleDir, modules)
 	if err != nil {
 		return nil, fmt.Errorf("resolving local modules failed: %w", err)
 	}
 
 	// Main module is not included in vendored module list, so we have to get it separately
-	mainModule, err := LoadModule(logger, moduleDir)
+	mainModule, err := LoadModule(ctx, logger, moduleDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get main module: %w", err)
 	}
@@ -0,0 +1,171 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package gomod
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/mod/modfile"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/util"
+)
+
+// Workspace represents a Go workspace, as defined by a go.work file.
+// See https://go.dev/ref/mod#workspaces
+type Workspace struct {
+	Dir string   // absolute directory containing the go.work file
+	Use []string // absolute directories of the modules in the workspace
+}
+
+// IsWorkspace determines whether dir is the root of a Go workspace.
+//
+// Like the go command, workspaces are ignored when GOWORK is set to "off".
+func IsWorkspace(dir string) bool {
+	if os.Getenv("GOWORK") == "off" {
+		return false
+	}
+
+	return util.FileExists(filepath.Join(dir, "go.work"))
+}
+
+// ErrNoWorkspace indicates that a given path is not a valid Go workspace.
+var ErrNoWorkspace = errors.New("not a go workspace")
+
+// LoadWorkspace parses the go.work file in workspaceDir.
+func LoadWorkspace(logger zerolog.Logger, workspaceDir string) (*Workspace, error) {
+	logger.Debug().
+		Str("workspaceDir", workspaceDir).
+		Msg("loading workspace")
+
+	if !IsWorkspace(workspaceDir) {
+		return nil, ErrNoWorkspace
+	}
+
+	workspaceDirAbs, err := filepath.Abs(workspaceDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make workspaceDir absolute: %w", err)
+	}
+
+	goWorkPath := filepath.Join(workspaceDirAbs, "go.work")
+	goWork, err := os.ReadFile(goWorkPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.work: %w", err)
+	}
+
+	workFile, err := modfile.ParseWork(goWorkPath, goWork, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go.work: %w", err)
+	}
+
+	workspace := Workspace{
+		Dir: workspaceDirAbs,
+		Use: make([]string, 0, len(workFile.Use)),
+	}
+	for _, use := range workFile.Use {
+		useDir := filepath.FromSlash(use.Path)
+		if !filepath.IsAbs(useDir) {
+			useDir = filepath.Join(workspaceDirAbs, useDir)
+		}
+
+		if !IsModule(useDir) {
+			return nil, fmt.Errorf("workspace module %s: %w", use.Path, ErrNoModule)
+		}
+
+		workspace.Use = append(workspace.Use, filepath.Clean(useDir))
+	}
+
+	return &workspace, nil
+}
+
+// MarkWorkspaceModules sets the Workspace flag for all main modules, except the one located in mainModuleDir.
+//
+// Within a workspace, the go command reports all workspace modules as main modules.
+// If mainModuleDir is empty, all of them are considered to be workspace modules.
+func MarkWorkspaceModules(modules []Module, mainModuleDir string) {
+	for i := range modules {
+		if modules[i].Main && (mainModuleDir == "" || !util.IsSameDir(modules[i].Dir, mainModuleDir)) {
+			modules[i].Workspace = true
+		}
+	}
+}
+
+// ResolveWorkspaceModuleVersions tries to determine the versions of all workspace modules.
+// Only works for modules residing in Git repositories.
+//
+// Like for the main module, this must happen after the module graph has been applied,
+// because `go mod graph` refers to workspace modules without version.
+func ResolveWorkspaceModuleVersions(logger zerolog.Logger, modules []Module) {
+	for i := range modules {
+		if !modules[i].Workspace {
+			continue
+		}
+
+		version, err := GetModuleVersion(logger, modules[i].Dir)
+		if err != nil {
+			// Not fatal, see resolveLocalReplacement
+			logger.Warn().
+				Err(err).
+				Str("module", modules[i].Path).
+				Msg("failed to resolve version of workspace module")
+			continue
+		}
+
+		modules[i].Version = version
+	}
+}
+
+// PruneUnreachableModules returns a new slice containing only those modules
+// that are (directly or transitively) reachable from root via their dependencies.
+// root itself is always included.
+//
+// Dependencies must have been applied to the modules prior to calling this function.
+func PruneUnreachableModules(modules []Module, root *Module) []Module {
+	reachable := make(map[string]struct{})
+
+	var visit func(*Module)
+	visit = func(m *Module) {
+		if _, seen := reachable[m.Coordinates()]; seen {
+			return
+		}
+		reachable[m.Coordinates()] = struct{}{}
+
+		for _, dep := range m.Dependencies {
+			visit(dep)
+		}
+	}
+	visit(root)
+
+	pruned := make([]Module, 0, len(reachable))
+	for i := range modules {
+		coordinates := modules[i].Coordinates()
+		if modules[i].Replace != nil {
+			// Dependencies point to the replacement
+			coordinates = modules[i].Replace.Coordinates()
+		}
+
+		if _, ok := reachable[coordinates]; ok {
+			pruned = append(pruned, modules[i])
+		}
+	}
+
+	return pruned
+}
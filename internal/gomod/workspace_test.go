@@ -0,0 +1,112 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package gomod
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsWorkspace(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.False(t, IsWorkspace(tmpDir))
+
+	err := os.WriteFile(filepath.Join(tmpDir, "go.work"), []byte("go 1.21\n"), 0o600)
+	require.NoError(t, err)
+	require.True(t, IsWorkspace(tmpDir))
+
+	t.Setenv("GOWORK", "off")
+	require.False(t, IsWorkspace(tmpDir))
+}
+
+func TestLoadWorkspace(t *testing.T) {
+	t.Run("NoWorkspace", func(t *testing.T) {
+		_, err := LoadWorkspace(zerolog.Nop(), t.TempDir())
+		require.ErrorIs(t, err, ErrNoWorkspace)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "go.work"), []byte("go 1.21\n\nuse (\n\t./a\n\t./b\n)\n"), 0o600))
+		for _, dir := range []string{"a", "b"} {
+			require.NoError(t, os.Mkdir(filepath.Join(tmpDir, dir), 0o700))
+			require.NoError(t, os.WriteFile(filepath.Join(tmpDir, dir, "go.mod"), []byte("module example.com/"+dir+"\n"), 0o600))
+		}
+
+		workspace, err := LoadWorkspace(zerolog.Nop(), tmpDir)
+		require.NoError(t, err)
+		require.Equal(t, tmpDir, workspace.Dir)
+		require.Equal(t, []string{filepath.Join(tmpDir, "a"), filepath.Join(tmpDir, "b")}, workspace.Use)
+	})
+
+	t.Run("UseWithoutModule", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "go.work"), []byte("go 1.21\n\nuse ./a\n"), 0o600))
+
+		_, err := LoadWorkspace(zerolog.Nop(), tmpDir)
+		require.ErrorIs(t, err, ErrNoModule)
+	})
+}
+
+func TestMarkWorkspaceModules(t *testing.T) {
+	tmpDir := t.TempDir()
+	modules := []Module{
+		{Path: "a", Main: true, Dir: filepath.Join(tmpDir, "a")},
+		{Path: "b", Main: true, Dir: tmpDir},
+		{Path: "c", Version: "v1.0.0"},
+	}
+	require.NoError(t, os.Mkdir(modules[0].Dir, 0o700))
+
+	MarkWorkspaceModules(modules, tmpDir)
+	require.True(t, modules[0].Workspace)
+	require.False(t, modules[1].Workspace)
+	require.False(t, modules[2].Workspace)
+
+	MarkWorkspaceModules(modules, "")
+	require.True(t, modules[1].Workspace)
+	require.False(t, modules[2].Workspace)
+}
+
+func TestPruneUnreachableModules(t *testing.T) {
+	modules := []Module{
+		{Path: "a", Main: true},
+		{Path: "b", Main: true},
+		{Path: "c", Version: "v1.0.0"},
+		{Path: "d", Version: "v1.0.0", Replace: &Module{Path: "../d"}},
+		{Path: "e", Version: "v1.0.0"},
+	}
+	modules[0].Dependencies = []*Module{&modules[1], modules[3].Replace}
+	modules[1].Dependencies = []*Module{&modules[2]}
+	modules[4].Dependencies = []*Module{&modules[2]}
+
+	pruned := PruneUnreachableModules(modules, &modules[0])
+	require.Len(t, pruned, 4)
+	require.Equal(t, "a", pruned[0].Path)
+	require.Equal(t, "b", pruned[1].Path)
+	require.Equal(t, "c", pruned[2].Path)
+	require.Equal(t, "d", pruned[3].Path)
+
+	pruned = PruneUnreachableModules(modules, &modules[1])
+	require.Len(t, pruned, 2)
+	require.Equal(t, "b", pruned[0].Path)
+	require.Equal(t, "c", pruned[1].Path)
+}
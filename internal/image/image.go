@@ -0,0 +1,341 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+// Package image extracts Go binaries from container images.
+//
+// Supported are tarballs written by "docker save" (optionally compressed with gzip),
+// as well as OCI image layouts, either as directory or tarball.
+package image
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/gobinary"
+)
+
+// Image is a container image of which the Go binaries have been extracted.
+type Image struct {
+	Name     string   // Name of the image, e.g. "docker.io/library/alpine"
+	Tag      string   // Tag of the image, if known
+	Digest   string   // Digest of the image manifest, if known
+	Platform string   // Platform of the image in os/arch[/variant] notation, if known
+	Binaries []Binary // Go binaries in the image's file system, sorted by path
+
+	workDir string
+}
+
+// Binary is a Go binary within an image.
+type Binary struct {
+	Path        string // Absolute path of the binary within the image
+	LayerDigest string // Digest of the layer that contains the binary
+	File        string // Path of the extracted binary on the local file system
+}
+
+// Extract opens the image at imagePath and extracts all Go binaries
+// from the file system that results from applying its layers.
+//
+// If the image is available for multiple platforms, platform
+// (in os/arch[/variant] notation) selects the one to extract from.
+//
+// The extracted binaries are removed when the image is closed.
+func Extract(logger zerolog.Logger, imagePath, platform string) (*Image, error) {
+	workDir, err := os.MkdirTemp("", "cyclonedx-gomod-image_*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create working directory: %w", err)
+	}
+
+	img := Image{workDir: workDir}
+	if err = img.extract(logger, imagePath, platform); err != nil {
+		img.Close()
+		return nil, err
+	}
+
+	return &img, nil
+}
+
+// Close removes all extracted binaries.
+func (i *Image) Close() error {
+	return os.RemoveAll(i.workDir)
+}
+
+func (i *Image) extract(logger zerolog.Logger, imagePath, platform string) error {
+	s, closeStore, err := openStore(imagePath, i.workDir)
+	if err != nil {
+		return fmt.Errorf("failed to open image: %w", err)
+	}
+	defer closeStore()
+
+	m, err := readManifest(s, platform)
+	if err != nil {
+		return err
+	}
+
+	i.Name, i.Tag = nameAndTag(m.reference, imagePath)
+	i.Digest = m.digest
+	i.Platform = m.platform
+
+	e := extractor{
+		logger:   logger,
+		dir:      filepath.Join(i.workDir, "binaries"),
+		binaries: make(map[string]Binary),
+	}
+	if err = os.Mkdir(e.dir, 0o700); err != nil {
+		return err
+	}
+
+	for _, l := range m.layers {
+		logger.Debug().
+			Str("layer", l.digest).
+			Msg("scanning layer")
+
+		if err = e.scanLayer(s, l); err != nil {
+			return fmt.Errorf("failed to scan layer %s: %w", l.digest, err)
+		}
+	}
+
+	i.Binaries = make([]Binary, 0, len(e.binaries))
+	for _, binaryPath := range slices.Sorted(maps.Keys(e.binaries)) {
+		i.Binaries = append(i.Binaries, e.binaries[binaryPath])
+	}
+
+	return nil
+}
+
+// nameAndTag determines name and tag of an image from its reference.
+// When the image has no name, it is named after the file or directory it was read from.
+func nameAndTag(reference, imagePath string) (string, string) {
+	if strings.ContainsAny(reference, ":/") {
+		return splitReference(reference)
+	}
+
+	name := filepath.Base(filepath.Clean(imagePath))
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar"} {
+		if trimmed, ok := strings.CutSuffix(name, ext); ok {
+			name = trimmed
+			break
+		}
+	}
+
+	// A reference without name is just a tag
+	return name, reference
+}
+
+// extractor maintains the Go binaries of an image's file system
+// while its layers are being applied.
+type extractor struct {
+	logger   zerolog.Logger
+	dir      string
+	binaries map[string]Binary
+	count    int
+}
+
+func (e *extractor) scanLayer(s store, l layer) error {
+	blob, err := s.open(l.name)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	reader, err := decompress(blob)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	var (
+		added    = make(map[string]Binary)
+		replaced []string // Paths that hide the contents of lower layers, including children
+		opaque   []string // Directories of which the contents of lower layers are hidden
+	)
+
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		name := path.Clean("/" + header.Name)
+		dir, base := path.Split(name)
+
+		if base == ".wh..wh..opq" {
+			opaque = append(opaque, path.Clean(dir))
+			continue
+		} else if whiteout, ok := strings.CutPrefix(base, ".wh."); ok {
+			replaced = append(replaced, path.Join(dir, whiteout))
+			continue
+		}
+
+		if header.Typeflag == tar.TypeDir {
+			continue
+		}
+		replaced = append(replaced, name)
+		if binary, ok := added[name]; ok {
+			e.remove(binary)
+			delete(added, name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeReg:
+			if header.FileInfo().Mode()&0o111 == 0 {
+				continue
+			}
+
+			binary, err := e.extract(tarReader, name, l.digest)
+			if err != nil {
+				return err
+			}
+			if binary != nil {
+				added[name] = *binary
+			}
+		case tar.TypeLink:
+			target, ok := added[path.Clean("/"+header.Linkname)]
+			if !ok {
+				continue
+			}
+
+			binary, err := e.link(target, name)
+			if err != nil {
+				return err
+			}
+			added[name] = *binary
+		}
+	}
+
+	for binaryPath, binary := range e.binaries {
+		isHidden := slices.ContainsFunc(replaced, func(p string) bool { return isWithin(binaryPath, p, true) }) ||
+			slices.ContainsFunc(opaque, func(p string) bool { return isWithin(binaryPath, p, false) })
+		if isHidden {
+			e.logger.Debug().
+				Str("path", binaryPath).
+				Str("layer", l.digest).
+				Msg("binary removed by layer")
+			e.remove(binary)
+			delete(e.binaries, binaryPath)
+		}
+	}
+	for binaryPath, binary := range added {
+		e.binaries[binaryPath] = binary
+	}
+
+	return nil
+}
+
+// extract writes the file to the working directory, if it is a Go binary.
+func (e *extractor) extract(reader io.Reader, name, layerDigest string) (*Binary, error) {
+	bufferedReader := bufio.NewReader(reader)
+	magic, _ := bufferedReader.Peek(4)
+	if !gobinary.IsExecutable(magic) {
+		return nil, nil
+	}
+
+	file, err := e.newFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if err = writeFile(file, bufferedReader); err != nil {
+		return nil, fmt.Errorf("failed to extract %s: %w", name, err)
+	}
+
+	if !gobinary.IsGoBinary(e.logger.With().Str("path", name).Logger(), file) {
+		os.RemoveAll(filepath.Dir(file))
+		return nil, nil
+	}
+
+	e.logger.Debug().
+		Str("path", name).
+		Msg("found go binary")
+
+	return &Binary{
+		Path:        name,
+		LayerDigest: layerDigest,
+		File:        file,
+	}, nil
+}
+
+// link makes a hard link of target available under name.
+func (e *extractor) link(target Binary, name string) (*Binary, error) {
+	file, err := e.newFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.Link(target.File, file); err != nil {
+		return nil, fmt.Errorf("failed to link %s: %w", name, err)
+	}
+
+	return &Binary{
+		Path:        name,
+		LayerDigest: target.LayerDigest,
+		File:        file,
+	}, nil
+}
+
+// newFile returns a unique path for a binary, while retaining its file name.
+func (e *extractor) newFile(name string) (string, error) {
+	e.count++
+	dir := filepath.Join(e.dir, strconv.Itoa(e.count))
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, path.Base(name)), nil
+}
+
+func (e *extractor) remove(binary Binary) {
+	os.RemoveAll(filepath.Dir(binary.File))
+}
+
+// isWithin checks whether p is a child of dir, or dir itself when inclusive is set.
+func isWithin(p, dir string, inclusive bool) bool {
+	if p == dir {
+		return inclusive
+	}
+
+	return strings.HasPrefix(p, strings.TrimSuffix(dir, "/")+"/")
+}
+
+// decompress detects the compression of a layer.
+func decompress(reader io.Reader) (io.ReadCloser, error) {
+	bufferedReader := bufio.NewReader(reader)
+	magic, _ := bufferedReader.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(bufferedReader)
+	case bytes.HasPrefix(magic, zstdMagic):
+		return nil, fmt.Errorf("zstd compressed layers are not supported")
+	}
+
+	return io.NopCloser(bufferedReader), nil
+}
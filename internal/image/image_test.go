@@ -0,0 +1,292 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type tarFile struct {
+	name     string
+	mode     int64
+	content  []byte
+	typeflag byte
+	linkname string
+}
+
+func buildTar(t *testing.T, files ...tarFile) []byte {
+	buf := new(bytes.Buffer)
+	tarWriter := tar.NewWriter(buf)
+	for _, file := range files {
+		header := tar.Header{
+			Name:     file.name,
+			Mode:     file.mode,
+			Size:     int64(len(file.content)),
+			Typeflag: file.typeflag,
+			Linkname: file.linkname,
+		}
+		if header.Typeflag == 0 {
+			header.Typeflag = tar.TypeReg
+		}
+		if header.Typeflag != tar.TypeReg {
+			header.Size = 0
+		}
+		require.NoError(t, tarWriter.WriteHeader(&header))
+		if header.Size > 0 {
+			_, err := tarWriter.Write(file.content)
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, tarWriter.Close())
+
+	return buf.Bytes()
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	buf := new(bytes.Buffer)
+	gzipWriter := gzip.NewWriter(buf)
+	_, err := gzipWriter.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+
+	return buf.Bytes()
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	return data
+}
+
+func readGoBinary(t *testing.T) []byte {
+	binary, err := os.ReadFile("../../pkg/generate/testdata/simple")
+	require.NoError(t, err)
+
+	return binary
+}
+
+func TestExtract(t *testing.T) {
+	goBinary := readGoBinary(t)
+
+	// Go binaries without build information are identified by their symbol table
+	magic := []byte("\xff Go buildinf:")
+	strippedGoBinary := bytes.Replace(goBinary, magic, make([]byte, len(magic)), 1)
+
+	layer1 := buildTar(t,
+		tarFile{name: "usr/bin/", typeflag: tar.TypeDir, mode: 0o755},
+		tarFile{name: "usr/bin/app", mode: 0o755, content: goBinary},
+		tarFile{name: "usr/bin/removed", mode: 0o755, content: goBinary},
+		tarFile{name: "usr/bin/sh", mode: 0o755, content: []byte("\x7fELF not really")},
+		tarFile{name: "usr/bin/script", mode: 0o755, content: []byte("#!/bin/sh\n")},
+		tarFile{name: "etc/not-executable", mode: 0o644, content: goBinary},
+		tarFile{name: "opt/tools/tool", mode: 0o755, content: goBinary},
+		tarFile{name: "opt/other", mode: 0o755, content: goBinary},
+	)
+	layer2 := buildTar(t,
+		tarFile{name: "usr/bin/.wh.removed"},
+		tarFile{name: "opt/tools/.wh..wh..opq"},
+		tarFile{name: "opt/other", mode: 0o755, content: []byte("replaced")},
+		tarFile{name: "usr/local/bin/app2", mode: 0o755, content: goBinary},
+		tarFile{name: "usr/local/bin/app3", typeflag: tar.TypeLink, linkname: "usr/local/bin/app2"},
+		tarFile{name: "usr/local/bin/stripped", mode: 0o755, content: strippedGoBinary},
+	)
+
+	archive := buildTar(t,
+		tarFile{name: "manifest.json", mode: 0o644, content: mustJSON(t, []dockerManifest{{
+			Config:   "config.json",
+			RepoTags: []string{"example.com/acme/app:v1.0.0"},
+			Layers:   []string{"layer1/layer.tar", "layer2/layer.tar"},
+		}})},
+		tarFile{name: "config.json", mode: 0o644, content: []byte(`{"os":"linux","architecture":"amd64","rootfs":{"diff_ids":["sha256:aaa","sha256:bbb"]}}`)},
+		tarFile{name: "layer1/layer.tar", mode: 0o644, content: layer1},
+		tarFile{name: "layer2/layer.tar", mode: 0o644, content: layer2},
+	)
+
+	requireImage := func(t *testing.T, img *Image) {
+		assert.Equal(t, "example.com/acme/app", img.Name)
+		assert.Equal(t, "v1.0.0", img.Tag)
+		assert.Empty(t, img.Digest)
+		assert.Equal(t, "linux/amd64", img.Platform)
+
+		require.Len(t, img.Binaries, 4)
+		assert.Equal(t, "/usr/bin/app", img.Binaries[0].Path)
+		assert.Equal(t, "sha256:aaa", img.Binaries[0].LayerDigest)
+		assert.Equal(t, "/usr/local/bin/app2", img.Binaries[1].Path)
+		assert.Equal(t, "sha256:bbb", img.Binaries[1].LayerDigest)
+		assert.Equal(t, "/usr/local/bin/app3", img.Binaries[2].Path)
+		assert.Equal(t, "sha256:bbb", img.Binaries[2].LayerDigest)
+		assert.Equal(t, "/usr/local/bin/stripped", img.Binaries[3].Path)
+		assert.Equal(t, "sha256:bbb", img.Binaries[3].LayerDigest)
+
+		for _, binary := range img.Binaries {
+			assert.Equal(t, filepath.Base(binary.Path), filepath.Base(binary.File))
+			assert.FileExists(t, binary.File)
+		}
+
+		require.NoError(t, img.Close())
+		for _, binary := range img.Binaries {
+			assert.NoFileExists(t, binary.File)
+		}
+	}
+
+	t.Run("DockerArchive", func(t *testing.T) {
+		imagePath := filepath.Join(t.TempDir(), "image.tar")
+		require.NoError(t, os.WriteFile(imagePath, archive, 0o600))
+
+		img, err := Extract(zerolog.Nop(), imagePath, "")
+		require.NoError(t, err)
+		requireImage(t, img)
+	})
+
+	t.Run("CompressedDockerArchive", func(t *testing.T) {
+		imagePath := filepath.Join(t.TempDir(), "image.tar.gz")
+		require.NoError(t, os.WriteFile(imagePath, gzipBytes(t, archive), 0o600))
+
+		img, err := Extract(zerolog.Nop(), imagePath, "")
+		require.NoError(t, err)
+		requireImage(t, img)
+	})
+
+	t.Run("PlatformMismatch", func(t *testing.T) {
+		imagePath := filepath.Join(t.TempDir(), "image.tar")
+		require.NoError(t, os.WriteFile(imagePath, archive, 0o600))
+
+		_, err := Extract(zerolog.Nop(), imagePath, "linux/arm64")
+		require.ErrorContains(t, err, "image is not available for platform linux/arm64 (available: linux/amd64)")
+	})
+
+	t.Run("NotAnImage", func(t *testing.T) {
+		_, err := Extract(zerolog.Nop(), t.TempDir(), "")
+		require.ErrorContains(t, err, "neither a docker archive nor an oci image layout")
+	})
+}
+
+func TestExtract_OCILayout(t *testing.T) {
+	goBinary := readGoBinary(t)
+	layoutDir := t.TempDir()
+
+	writeBlob := func(data []byte) string {
+		sum := sha256.Sum256(data)
+		encoded := hex.EncodeToString(sum[:])
+
+		require.NoError(t, os.MkdirAll(filepath.Join(layoutDir, "blobs", "sha256"), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(layoutDir, "blobs", "sha256", encoded), data, 0o600))
+
+		return "sha256:" + encoded
+	}
+
+	writeManifest := func(binaryPath string) string {
+		layerDigest := writeBlob(gzipBytes(t, buildTar(t, tarFile{name: binaryPath, mode: 0o755, content: goBinary})))
+		configDigest := writeBlob([]byte(`{}`))
+
+		return writeBlob(mustJSON(t, ociBlob{
+			MediaType: "application/vnd.oci.image.manifest.v1+json",
+			Config:    descriptor{MediaType: "application/vnd.oci.image.config.v1+json", Digest: configDigest},
+			Layers:    []descriptor{{MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Digest: layerDigest}},
+		}))
+	}
+
+	amd64Digest := writeManifest("app-amd64")
+	arm64Digest := writeManifest("app-arm64")
+	indexDigest := writeBlob(mustJSON(t, ociBlob{
+		MediaType: "application/vnd.oci.image.index.v1+json",
+		Manifests: []descriptor{
+			{
+				MediaType: "application/vnd.oci.image.manifest.v1+json",
+				Digest:    amd64Digest,
+				Platform:  &ociPlatform{OS: "linux", Architecture: "amd64"},
+			},
+			{
+				MediaType: "application/vnd.oci.image.manifest.v1+json",
+				Digest:    arm64Digest,
+				Platform:  &ociPlatform{OS: "linux", Architecture: "arm64", Variant: "v8"},
+			},
+			{
+				MediaType:   "application/vnd.oci.image.manifest.v1+json",
+				Digest:      writeBlob([]byte(`{}`)),
+				Platform:    &ociPlatform{OS: "unknown", Architecture: "unknown"},
+				Annotations: map[string]string{annotationRefType: "attestation-manifest"},
+			},
+		},
+	}))
+	require.NoError(t, os.WriteFile(filepath.Join(layoutDir, "oci-layout"), []byte(`{"imageLayoutVersion":"1.0.0"}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(layoutDir, "index.json"), mustJSON(t, ociBlob{
+		Manifests: []descriptor{{
+			MediaType:   "application/vnd.oci.image.index.v1+json",
+			Digest:      indexDigest,
+			Annotations: map[string]string{annotationRefName: "v2.0.0"},
+		}},
+	}), 0o600))
+
+	t.Run("Platform", func(t *testing.T) {
+		img, err := Extract(zerolog.Nop(), layoutDir, "linux/arm64")
+		require.NoError(t, err)
+		defer img.Close()
+
+		assert.Equal(t, filepath.Base(layoutDir), img.Name)
+		assert.Equal(t, "v2.0.0", img.Tag)
+		assert.Equal(t, arm64Digest, img.Digest)
+		assert.Equal(t, "linux/arm64/v8", img.Platform)
+		require.Len(t, img.Binaries, 1)
+		assert.Equal(t, "/app-arm64", img.Binaries[0].Path)
+	})
+
+	t.Run("MultiplePlatforms", func(t *testing.T) {
+		_, err := Extract(zerolog.Nop(), layoutDir, "")
+		require.ErrorContains(t, err, "image is available for multiple platforms (linux/amd64, linux/arm64/v8)")
+	})
+
+	t.Run("UnknownPlatform", func(t *testing.T) {
+		_, err := Extract(zerolog.Nop(), layoutDir, "windows/amd64")
+		require.ErrorContains(t, err, "image is not available for platform windows/amd64")
+	})
+}
+
+func TestNameAndTag(t *testing.T) {
+	for _, tc := range []struct {
+		reference string
+		imagePath string
+		name      string
+		tag       string
+	}{
+		{"docker.io/library/alpine:3.20", "image.tar", "docker.io/library/alpine", "3.20"},
+		{"localhost:5000/app", "image.tar", "localhost:5000/app", ""},
+		{"example.com/app:v1@sha256:abc", "image.tar", "example.com/app", "v1"},
+		{"v1", "/tmp/app.tar.gz", "app", "v1"},
+		{"", "/tmp/layout/", "layout", ""},
+	} {
+		t.Run(tc.reference, func(t *testing.T) {
+			name, tag := nameAndTag(tc.reference, tc.imagePath)
+			assert.Equal(t, tc.name, name)
+			assert.Equal(t, tc.tag, tag)
+		})
+	}
+}
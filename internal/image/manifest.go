@@ -0,0 +1,365 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package image
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"path"
+	"strings"
+)
+
+// manifest describes the image that has been selected for extraction.
+type manifest struct {
+	reference string // Image reference, e.g. "docker.io/library/alpine:3.20"
+	digest    string // Digest of the image manifest, if known
+	platform  string // Platform of the image, if known
+	layers    []layer
+}
+
+type layer struct {
+	name   string // Name of the layer blob within the store
+	digest string
+}
+
+const (
+	annotationImageName = "io.containerd.image.name"
+	annotationRefName   = "org.opencontainers.image.ref.name"
+	annotationRefType   = "vnd.docker.reference.type"
+)
+
+// readManifest reads the manifest of a "docker save" archive or an OCI image layout.
+// If the image contains manifests for multiple platforms, platform selects one of them.
+func readManifest(s store, platform string) (*manifest, error) {
+	m, err := readDockerManifest(s)
+	if errors.Is(err, fs.ErrNotExist) {
+		m, err = readOCIManifest(s, platform)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("neither a docker archive nor an oci image layout: %w", err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if platform != "" && m.platform != "" && !platformMatches(m.platform, platform) {
+		return nil, fmt.Errorf("image is not available for platform %s (available: %s)", platform, m.platform)
+	}
+
+	return m, nil
+}
+
+type dockerManifest struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+type imageConfig struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant"`
+	RootFS       struct {
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+}
+
+func readDockerManifest(s store) (*manifest, error) {
+	var dockerManifests []dockerManifest
+	if err := readJSON(s, "manifest.json", &dockerManifests); err != nil {
+		return nil, err
+	}
+	if len(dockerManifests) != 1 {
+		return nil, fmt.Errorf("expected archive to contain exactly one image, but found %d", len(dockerManifests))
+	}
+	dm := dockerManifests[0]
+
+	var config imageConfig
+	if err := readJSON(s, dm.Config, &config); err != nil {
+		return nil, fmt.Errorf("failed to read image config: %w", err)
+	}
+
+	m := manifest{
+		platform: config.platform(),
+		layers:   make([]layer, 0, len(dm.Layers)),
+	}
+	if len(dm.RepoTags) > 0 {
+		m.reference = dm.RepoTags[0]
+	}
+
+	for i, name := range dm.Layers {
+		l := layer{name: name}
+		if digest, ok := digestFromBlobName(name); ok {
+			l.digest = digest
+		} else if i < len(config.RootFS.DiffIDs) {
+			// Layers of legacy archives are uncompressed, so their digest equals the diff ID
+			l.digest = config.RootFS.DiffIDs[i]
+		}
+		m.layers = append(m.layers, l)
+	}
+
+	return &m, nil
+}
+
+type ociPlatform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant"`
+}
+
+func (p ociPlatform) String() string {
+	platform := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		platform += "/" + p.Variant
+	}
+
+	return platform
+}
+
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Platform    *ociPlatform      `json:"platform"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// ociBlob covers both image indexes and image manifests.
+type ociBlob struct {
+	MediaType string       `json:"mediaType"`
+	Manifests []descriptor `json:"manifests"`
+	Config    descriptor   `json:"config"`
+	Layers    []descriptor `json:"layers"`
+}
+
+func readOCIManifest(s store, platform string) (*manifest, error) {
+	var index ociBlob
+	if err := readJSON(s, "index.json", &index); err != nil {
+		return nil, err
+	}
+
+	candidates, err := collectManifests(s, index.Manifests, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := selectManifest(candidates, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	blobName, err := blobNameFromDigest(selected.descriptor.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	var imageManifest ociBlob
+	if err = readJSON(s, blobName, &imageManifest); err != nil {
+		return nil, fmt.Errorf("failed to read image manifest: %w", err)
+	}
+
+	m := manifest{
+		reference: imageReference(selected.descriptor.Annotations),
+		digest:    selected.descriptor.Digest,
+		platform:  selected.platform,
+		layers:    make([]layer, 0, len(imageManifest.Layers)),
+	}
+
+	if m.platform == "" {
+		configName, err := blobNameFromDigest(imageManifest.Config.Digest)
+		if err != nil {
+			return nil, err
+		}
+
+		var config imageConfig
+		if err = readJSON(s, configName, &config); err != nil {
+			return nil, fmt.Errorf("failed to read image config: %w", err)
+		}
+		m.platform = config.platform()
+	}
+
+	for _, layerDescriptor := range imageManifest.Layers {
+		name, err := blobNameFromDigest(layerDescriptor.Digest)
+		if err != nil {
+			return nil, err
+		}
+		m.layers = append(m.layers, layer{name: name, digest: layerDescriptor.Digest})
+	}
+
+	return &m, nil
+}
+
+type manifestCandidate struct {
+	descriptor descriptor
+	platform   string
+}
+
+// collectManifests resolves (nested) image indexes to the image manifests they reference.
+// Annotations of indexes are inherited by the manifests within them.
+func collectManifests(s store, descriptors []descriptor, annotations map[string]string, depth int) ([]manifestCandidate, error) {
+	if depth > 5 {
+		return nil, fmt.Errorf("image indexes are nested too deeply")
+	}
+
+	var candidates []manifestCandidate
+	for _, d := range descriptors {
+		if d.Annotations[annotationRefType] == "attestation-manifest" {
+			continue
+		}
+
+		merged := maps.Clone(annotations)
+		if merged == nil {
+			merged = make(map[string]string)
+		}
+		maps.Copy(merged, d.Annotations)
+		d.Annotations = merged
+
+		switch d.MediaType {
+		case "application/vnd.oci.image.index.v1+json", "application/vnd.docker.distribution.manifest.list.v2+json":
+			blobName, err := blobNameFromDigest(d.Digest)
+			if err != nil {
+				return nil, err
+			}
+
+			var index ociBlob
+			if err = readJSON(s, blobName, &index); err != nil {
+				return nil, fmt.Errorf("failed to read image index: %w", err)
+			}
+
+			nested, err := collectManifests(s, index.Manifests, d.Annotations, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			candidates = append(candidates, nested...)
+		case "application/vnd.oci.image.manifest.v1+json", "application/vnd.docker.distribution.manifest.v2+json":
+			candidate := manifestCandidate{descriptor: d}
+			if d.Platform != nil {
+				if d.Platform.OS == "unknown" {
+					continue // Attestations and other non-runnable artifacts
+				}
+				candidate.platform = d.Platform.String()
+			}
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	return candidates, nil
+}
+
+func selectManifest(candidates []manifestCandidate, platform string) (*manifestCandidate, error) {
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no image manifest found")
+	}
+
+	available := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		available = append(available, candidate.platform)
+	}
+
+	// The platform of a single manifest is verified against the image config later
+	if len(candidates) == 1 {
+		return &candidates[0], nil
+	} else if platform == "" {
+		return nil, fmt.Errorf("image is available for multiple platforms (%s), please select one", strings.Join(available, ", "))
+	}
+
+	for i, candidate := range candidates {
+		if platformMatches(candidate.platform, platform) {
+			return &candidates[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("image is not available for platform %s (available: %s)", platform, strings.Join(available, ", "))
+}
+
+// platformMatches checks whether platform selects the candidate platform.
+// The variant may be omitted, e.g. "linux/arm64" selects "linux/arm64/v8".
+func platformMatches(candidate, platform string) bool {
+	return candidate == platform || strings.HasPrefix(candidate, platform+"/")
+}
+
+// imageReference determines the image reference from the annotations of a manifest.
+// The reference name annotation may either be a complete reference, or just the tag.
+func imageReference(annotations map[string]string) string {
+	if name := annotations[annotationImageName]; name != "" {
+		return name
+	}
+
+	return annotations[annotationRefName]
+}
+
+func (c imageConfig) platform() string {
+	if c.OS == "" || c.Architecture == "" {
+		return ""
+	}
+
+	return ociPlatform{OS: c.OS, Architecture: c.Architecture, Variant: c.Variant}.String()
+}
+
+// blobNameFromDigest returns the name of the blob with the given digest in an OCI image layout.
+func blobNameFromDigest(digest string) (string, error) {
+	algorithm, encoded, ok := strings.Cut(digest, ":")
+	if !ok || algorithm == "" || encoded == "" || strings.ContainsAny(digest, "/\\") {
+		return "", fmt.Errorf("invalid digest \"%s\"", digest)
+	}
+
+	return path.Join("blobs", algorithm, encoded), nil
+}
+
+// digestFromBlobName is the inverse of blobNameFromDigest.
+func digestFromBlobName(name string) (string, bool) {
+	parts := strings.Split(cleanName(name), "/")
+	if len(parts) != 3 || parts[0] != "blobs" {
+		return "", false
+	}
+
+	return parts[1] + ":" + parts[2], true
+}
+
+func readJSON(s store, name string, v any) error {
+	reader, err := s.open(name)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// splitReference splits an image reference into name and tag.
+// Digests are not considered to be part of the name.
+func splitReference(reference string) (string, string) {
+	reference, _, _ = strings.Cut(reference, "@")
+	if i := strings.LastIndex(reference, ":"); i > strings.LastIndex(reference, "/") {
+		return reference[:i], reference[i+1:]
+	}
+
+	return reference, ""
+}
@@ -0,0 +1,198 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package image
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// store provides access to the files of an image archive or layout.
+type store interface {
+	// open opens the file with the given slash-separated name.
+	open(name string) (io.ReadCloser, error)
+}
+
+// openStore opens the image archive or layout at imagePath.
+// Compressed archives are extracted to workDir.
+func openStore(imagePath, workDir string) (store, func() error, error) {
+	fileInfo, err := os.Stat(imagePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if fileInfo.IsDir() {
+		return dirStore(imagePath), func() error { return nil }, nil
+	}
+
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	magic, err := bufio.NewReader(file).Peek(len(gzipMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		file.Close()
+		return nil, nil, err
+	}
+	if _, err = file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	if bytes.Equal(magic, gzipMagic) {
+		defer file.Close()
+
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer gzipReader.Close()
+
+		archiveDir := filepath.Join(workDir, "archive")
+		if err = extractTar(gzipReader, archiveDir); err != nil {
+			return nil, nil, fmt.Errorf("failed to extract archive: %w", err)
+		}
+
+		return dirStore(archiveDir), func() error { return nil }, nil
+	}
+
+	tarStore, err := newTarStore(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	return tarStore, file.Close, nil
+}
+
+// dirStore is a store backed by a directory, e.g. an OCI image layout.
+type dirStore string
+
+func (d dirStore) open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(string(d), filepath.FromSlash(cleanName(name))))
+}
+
+// tarStore is a store backed by an uncompressed tar archive, e.g. as written by "docker save".
+// Instead of extracting the archive, the offsets of all files are recorded,
+// so that they can be read from the archive directly.
+type tarStore struct {
+	file    *os.File
+	entries map[string]tarEntry
+}
+
+type tarEntry struct {
+	offset int64
+	size   int64
+}
+
+func newTarStore(file *os.File) (*tarStore, error) {
+	s := tarStore{
+		file:    file,
+		entries: make(map[string]tarEntry),
+	}
+
+	tarReader := tar.NewReader(file)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// tar.Reader doesn't buffer, the file is positioned at the start of the entry's content.
+		offset, err := file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		s.entries[cleanName(header.Name)] = tarEntry{offset: offset, size: header.Size}
+	}
+
+	return &s, nil
+}
+
+func (s tarStore) open(name string) (io.ReadCloser, error) {
+	entry, ok := s.entries[cleanName(name)]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+	}
+
+	return io.NopCloser(io.NewSectionReader(s.file, entry.offset, entry.size)), nil
+}
+
+// extractTar extracts all regular files of a tar archive to dir.
+func extractTar(reader io.Reader, dir string) error {
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		filePath := filepath.Join(dir, filepath.FromSlash(cleanName(header.Name)))
+		if err = os.MkdirAll(filepath.Dir(filePath), 0o700); err != nil {
+			return err
+		}
+		if err = writeFile(filePath, tarReader); err != nil {
+			return err
+		}
+	}
+}
+
+func writeFile(filePath string, reader io.Reader) error {
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(file, reader); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// cleanName normalizes the name of an archive entry.
+// Leading slashes and path traversals are removed.
+func cleanName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
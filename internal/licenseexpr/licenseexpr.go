@@ -0,0 +1,248 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+// Package licenseexpr provides parsing of SPDX license expressions.
+//
+// See https://spdx.github.io/spdx-spec/v2.3/SPDX-license-expressions/ for the specification.
+package licenseexpr
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Expression is a node of a parsed SPDX license expression.
+// It is either a License, an And or an Or.
+type Expression interface {
+	fmt.Stringer
+
+	// Licenses returns the licenses referenced by the expression, in order of their appearance.
+	Licenses() []License
+}
+
+// License is a simple expression, referencing a single license, optionally with an exception.
+type License struct {
+	// ID is the license identifier, e.g. "MIT", "GPL-2.0-or-later", or "LicenseRef-Acme".
+	ID string
+
+	// Exception is the identifier of the exception that was added using WITH, if any.
+	Exception string
+}
+
+// Licenses implements the Expression interface.
+func (l License) Licenses() []License {
+	return []License{l}
+}
+
+// String implements the fmt.Stringer interface.
+func (l License) String() string {
+	if l.Exception != "" {
+		return l.ID + " WITH " + l.Exception
+	}
+
+	return l.ID
+}
+
+// IsRef checks whether the license is a user defined license reference,
+// rather than a license of the SPDX license list.
+func (l License) IsRef() bool {
+	return strings.HasPrefix(l.ID, "LicenseRef-") || strings.HasPrefix(l.ID, "DocumentRef-")
+}
+
+// And is a conjunction of expressions, all of which apply.
+type And []Expression
+
+// Licenses implements the Expression interface.
+func (a And) Licenses() []License {
+	return collectLicenses(a)
+}
+
+// String implements the fmt.Stringer interface.
+func (a And) String() string {
+	return join(a, " AND ")
+}
+
+// Or is a disjunction of expressions, one of which may be chosen.
+type Or []Expression
+
+// Licenses implements the Expression interface.
+func (o Or) Licenses() []License {
+	return collectLicenses(o)
+}
+
+// String implements the fmt.Stringer interface.
+func (o Or) String() string {
+	return join(o, " OR ")
+}
+
+func collectLicenses(expressions []Expression) []License {
+	var licenses []License
+	for _, expression := range expressions {
+		licenses = append(licenses, expression.Licenses()...)
+	}
+
+	return licenses
+}
+
+func join(expressions []Expression, separator string) string {
+	terms := make([]string, len(expressions))
+	for i, expression := range expressions {
+		terms[i] = expression.String()
+		if _, ok := expression.(License); !ok {
+			terms[i] = "(" + terms[i] + ")"
+		}
+	}
+
+	return strings.Join(terms, separator)
+}
+
+// tokenRegex matches the tokens of license expressions, which are parentheses and identifiers.
+// Identifiers may contain a colon, in order to allow for DocumentRef-*:LicenseRef-* references.
+var tokenRegex = regexp.MustCompile(`\(|\)|[A-Za-z0-9.+:-]+`)
+
+// Parse parses an SPDX license expression.
+//
+// Operators are matched case-insensitively. The validity of license and exception
+// identifiers is not checked, as long as they're syntactically valid.
+func Parse(expression string) (Expression, error) {
+	tokens := tokenRegex.FindAllString(expression, -1)
+	if strings.Join(tokens, "") != strings.Join(strings.Fields(expression), "") {
+		return nil, fmt.Errorf("invalid license expression %q: contains invalid characters", expression)
+	}
+
+	p := parser{tokens: tokens}
+	parsed, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid license expression %q: %w", expression, err)
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("invalid license expression %q: unexpected %q", expression, p.tokens[p.pos])
+	}
+
+	return parsed, nil
+}
+
+type parser struct {
+	tokens []string
+	pos    int
+}
+
+// next returns the next token, or an empty string if there are no more tokens.
+func (p *parser) next() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+
+	return p.tokens[p.pos]
+}
+
+func (p *parser) nextIsOperator(operator string) bool {
+	return strings.EqualFold(p.next(), operator)
+}
+
+// parseOr parses expressions joined by OR, which has the lowest precedence.
+func (p *parser) parseOr() (Expression, error) {
+	var terms Or
+	for {
+		term, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+
+		if !p.nextIsOperator("OR") {
+			break
+		}
+		p.pos++
+	}
+
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+
+	return terms, nil
+}
+
+// parseAnd parses expressions joined by AND.
+func (p *parser) parseAnd() (Expression, error) {
+	var terms And
+	for {
+		term, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+
+		if !p.nextIsOperator("AND") {
+			break
+		}
+		p.pos++
+	}
+
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+
+	return terms, nil
+}
+
+// parseTerm parses a parenthesized expression, or a license with an optional exception.
+func (p *parser) parseTerm() (Expression, error) {
+	token := p.next()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case token == "(":
+		p.pos++
+		expression, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return expression, nil
+	case !isIdentifier(token):
+		return nil, fmt.Errorf("unexpected %q", token)
+	}
+	p.pos++
+
+	license := License{ID: token}
+	if p.nextIsOperator("WITH") {
+		p.pos++
+		if exception := p.next(); isIdentifier(exception) {
+			license.Exception = exception
+			p.pos++
+		} else {
+			return nil, fmt.Errorf("missing exception after WITH")
+		}
+	}
+
+	return license, nil
+}
+
+// isIdentifier checks whether token is a license or exception identifier, rather than an operator or a parenthesis.
+func isIdentifier(token string) bool {
+	switch strings.ToUpper(token) {
+	case "", "(", ")", "AND", "OR", "WITH":
+		return false
+	}
+
+	return true
+}
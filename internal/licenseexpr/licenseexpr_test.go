@@ -0,0 +1,78 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package licenseexpr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		testCases := map[string]Expression{
+			"MIT": License{ID: "MIT"},
+			"GPL-2.0-or-later WITH Classpath-exception-2.0": License{ID: "GPL-2.0-or-later", Exception: "Classpath-exception-2.0"},
+			"Apache-2.0 OR MIT":                             Or{License{ID: "Apache-2.0"}, License{ID: "MIT"}},
+			"Apache-2.0 AND MIT OR BSD-3-Clause":            Or{And{License{ID: "Apache-2.0"}, License{ID: "MIT"}}, License{ID: "BSD-3-Clause"}},
+			"Apache-2.0 AND (MIT or BSD-3-Clause)":          And{License{ID: "Apache-2.0"}, Or{License{ID: "MIT"}, License{ID: "BSD-3-Clause"}}},
+			"((LicenseRef-Acme))":                           License{ID: "LicenseRef-Acme"},
+			"DocumentRef-foo:LicenseRef-bar":                License{ID: "DocumentRef-foo:LicenseRef-bar"},
+		}
+
+		for input, want := range testCases {
+			t.Run(input, func(t *testing.T) {
+				got, err := Parse(input)
+				require.NoError(t, err)
+				require.Equal(t, want, got)
+			})
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, input := range []string{
+			"",
+			"MIT OR",
+			"AND MIT",
+			"MIT Apache-2.0",
+			"(MIT OR Apache-2.0",
+			"MIT OR Apache-2.0)",
+			"GPL-2.0-only WITH",
+			"MIT, Apache-2.0",
+		} {
+			t.Run(input, func(t *testing.T) {
+				_, err := Parse(input)
+				require.Error(t, err)
+			})
+		}
+	})
+}
+
+func TestExpression_String(t *testing.T) {
+	expression, err := Parse("(MIT or Apache-2.0) AND GPL-2.0-only WITH Classpath-exception-2.0")
+	require.NoError(t, err)
+	require.Equal(t, "(MIT OR Apache-2.0) AND GPL-2.0-only WITH Classpath-exception-2.0", expression.String())
+}
+
+func TestExpression_Licenses(t *testing.T) {
+	expression, err := Parse("MIT OR (Apache-2.0 AND LicenseRef-Acme)")
+	require.NoError(t, err)
+	require.Equal(t, []License{{ID: "MIT"}, {ID: "Apache-2.0"}, {ID: "LicenseRef-Acme"}}, expression.Licenses())
+	require.False(t, expression.Licenses()[0].IsRef())
+	require.True(t, expression.Licenses()[2].IsRef())
+}
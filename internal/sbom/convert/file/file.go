@@ -20,30 +20,22 @@ package file
 import (
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/rs/zerolog"
 
+	"github.com/CycloneDX/cyclonedx-gomod/internal/cache"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/gomod"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
 )
 
-type Option func(logger zerolog.Logger, absFilePath, relFilePath string, component *cdx.Component) error
-
-func WithHashes(algos ...cdx.HashAlgorithm) Option {
-	return func(logger zerolog.Logger, abs, _ string, component *cdx.Component) error {
-		hashes, err := sbom.CalculateFileHashes(logger, abs, algos...)
-		if err != nil {
-			return err
-		}
-
-		component.Hashes = &hashes
-		return nil
-	}
-}
-
-func ToComponent(logger zerolog.Logger, absFilePath, relFilePath string, pathEnabled bool, module gomod.Module, options ...Option) (*cdx.Component, error) {
+// ToComponent converts a file to a component, with hashes calculated using hashAlgos.
+//
+// The component's version is derived from the file's SHA-1 hash. It is calculated in the same pass
+// as the requested hashes, but only included in the component's hashes if it was requested.
+func ToComponent(logger zerolog.Logger, c *cache.Cache, absFilePath, relFilePath string, pathEnabled bool, module gomod.Module, hashAlgos ...cdx.HashAlgorithm) (*cdx.Component, error) {
 	logger.Debug().
 		Str("file", absFilePath).
 		Msg("converting file to component")
@@ -54,11 +46,22 @@ func ToComponent(logger zerolog.Logger, absFilePath, relFilePath string, pathEna
 		Scope: cdx.ScopeRequired,
 	}
 
-	hashes, err := sbom.CalculateFileHashes(logger, absFilePath, cdx.HashAlgoSHA1)
+	algos := hashAlgos
+	if !slices.Contains(algos, cdx.HashAlgoSHA1) {
+		algos = append(slices.Clip(algos), cdx.HashAlgoSHA1)
+	}
+	hashes, err := c.FileHashes(logger, absFilePath, algos...)
 	if err != nil {
 		return nil, err
 	}
-	component.Version = fmt.Sprintf("v0.0.0-%s", hashes[0].Value[:12])
+
+	sha1Index := slices.IndexFunc(hashes, func(hash cdx.Hash) bool { return hash.Algorithm == cdx.HashAlgoSHA1 })
+	component.Version = fmt.Sprintf("v0.0.0-%s", hashes[sha1Index].Value[:12])
+
+	hashes = hashes[:len(hashAlgos)] // Requested hashes come first
+	if len(hashes) > 0 {
+		component.Hashes = &hashes
+	}
 
 	if pathEnabled {
 		if component.Properties == nil {
@@ -75,12 +78,5 @@ func ToComponent(logger zerolog.Logger, absFilePath, relFilePath string, pathEna
 		*component.Properties = append(*component.Properties, sbom.NewProperty("file:path", trimmedPath))
 	}
 
-	for _, option := range options {
-		err = option(logger, absFilePath, relFilePath, &component)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	return &component, nil
 }
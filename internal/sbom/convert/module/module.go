@@ -18,25 +18,60 @@
 package module
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/rs/zerolog"
 
+	"github.com/CycloneDX/cyclonedx-gomod/internal/cache"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/gomod"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/licenseexpr"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
 	pkgConv "github.com/CycloneDX/cyclonedx-gomod/internal/sbom/convert/pkg"
 	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/curation"
 )
 
 type Option func(zerolog.Logger, gomod.Module, *cdx.Component) error
 
+// PropertyLicenseSource records where the licenses of a component originate from,
+// which is either LicenseSourceCuration or LicenseSourceDetection.
+const PropertyLicenseSource = "license:source"
+
+const (
+	LicenseSourceCuration  = "curation"
+	LicenseSourceDetection = "detection"
+)
+
 // WithLicenses attempts to detect licenses for the module using a provided license detector
 // and attach them to the component's license evidence.
-func WithLicenses(detector licensedetect.Detector) Option {
+// If the detector is able to combine the detected licenses into an SPDX license expression,
+// the expression is recorded in the sbom.PropertyLicenseExpression property. Like the evidence,
+// it only becomes the component's license when licenses are asserted (see sbom.AssertLicenses).
+//
+// Curations take precedence over detection: if a curation applies to the module,
+// its license is attached to the component as concluded license instead.
+// Detected licenses are still reported as evidence in that case.
+// Detection is aborted when ctx is canceled.
+func WithLicenses(ctx context.Context, detector licensedetect.Detector, curations *curation.Curations) Option {
 	return func(logger zerolog.Logger, module gomod.Module, component *cdx.Component) error {
+		curated, isCurated := curations.Lookup(module.Path, module.Version)
+		if isCurated {
+			logger.Debug().
+				Str("module", module.Coordinates()).
+				Str("license", curated.License).
+				Msg("applying license curation")
+
+			component.Licenses = &cdx.Licenses{concludedLicense(curated.License)}
+			addProperty(component, sbom.NewProperty(PropertyLicenseSource, LicenseSourceCuration))
+		}
+
 		if detector == nil {
 			logger.Debug().
 				Str("module", module.Coordinates()).
@@ -46,16 +81,18 @@ func WithLicenses(detector licensedetect.Detector) Option {
 		}
 
 		if module.Dir == "" {
-			logger.Warn().
-				Str("module", module.Coordinates()).
-				Str("reason", "module not in cache").
-				Msg("can't resolve module license")
+			if !isCurated {
+				logger.Warn().
+					Str("module", module.Coordinates()).
+					Str("reason", "module not in cache").
+					Msg("can't resolve module license")
+			}
 			return nil
 		}
 
-		detectedLicenses, err := detector.Detect(module.Path, module.Version, module.Dir)
+		detectedLicenses, expression, err := licensedetect.DetectExpression(ctx, detector, module.Path, module.Version, module.Dir)
 		if err != nil {
-			return fmt.Errorf("failed to detect licenses for %s: %v", module.Coordinates(), err)
+			return fmt.Errorf("failed to detect licenses for %s: %w", module.Coordinates(), err)
 		}
 
 		if len(detectedLicenses) > 0 {
@@ -64,10 +101,18 @@ func WithLicenses(detector licensedetect.Detector) Option {
 				componentLicenses[i] = cdx.LicenseChoice{License: &detectedLicenses[i]}
 			}
 
-			component.Evidence = &cdx.Evidence{
-				Licenses: &componentLicenses,
+			if component.Evidence == nil {
+				component.Evidence = &cdx.Evidence{}
 			}
-		} else {
+			component.Evidence.Licenses = &componentLicenses
+
+			if !isCurated {
+				if expression != "" {
+					addProperty(component, sbom.NewProperty(sbom.PropertyLicenseExpression, expression))
+				}
+				addProperty(component, sbom.NewProperty(PropertyLicenseSource, LicenseSourceDetection))
+			}
+		} else if !isCurated {
 			logger.Warn().Str("module", module.Coordinates()).Msg("no licenses detected")
 		}
 
@@ -75,6 +120,37 @@ func WithLicenses(detector licensedetect.Detector) Option {
 	}
 }
 
+// concludedLicense converts a curated license to a license choice.
+// Single licenses of the SPDX license list are represented by their ID,
+// all other licenses by an expression.
+func concludedLicense(license string) cdx.LicenseChoice {
+	acknowledgement := cdx.LicenseAcknowledgementConcluded
+
+	if parsed, err := licenseexpr.Parse(license); err == nil {
+		if simple, ok := parsed.(licenseexpr.License); ok && simple.Exception == "" && !simple.IsRef() {
+			return cdx.LicenseChoice{
+				License: &cdx.License{
+					ID:              simple.ID,
+					Acknowledgement: acknowledgement,
+				},
+			}
+		}
+	}
+
+	return cdx.LicenseChoice{
+		Expression:      license,
+		Acknowledgement: &acknowledgement,
+	}
+}
+
+func addProperty(component *cdx.Component, property cdx.Property) {
+	if component.Properties == nil {
+		component.Properties = &[]cdx.Property{property}
+	} else {
+		*component.Properties = append(*component.Properties, property)
+	}
+}
+
 // WithComponentType overrides the type of the component.
 func WithComponentType(ctype cdx.ComponentType) Option {
 	return func(_ zerolog.Logger, _ gomod.Module, component *cdx.Component) error {
@@ -83,7 +159,9 @@ func WithComponentType(ctype cdx.ComponentType) Option {
 	}
 }
 
-func WithModuleHashes() Option {
+// WithModuleHashes calculates the hash of the module's directory, as it would be recorded in go.sum.
+// Hashes of modules in the module cache are cached in c.
+func WithModuleHashes(c *cache.Cache) Option {
 	return func(logger zerolog.Logger, module gomod.Module, component *cdx.Component) error {
 		if module.Main {
 			// We currently don't have an accurate way of hashing the main module, as it may contain
@@ -100,8 +178,8 @@ func WithModuleHashes() Option {
 			return nil
 		}
 
-		if module.Path == gomod.StdlibModulePath {
-			// There are no module hashes published for the standard library.
+		if module.Path == gomod.StdlibModulePath || module.Toolchain {
+			// There are no module hashes published for the standard library and toolchain.
 			logger.Debug().Str("module", module.Coordinates()).Msg("not calculating hash for stdlib module")
 			return nil
 		}
@@ -113,7 +191,7 @@ func WithModuleHashes() Option {
 		}
 
 		logger.Debug().Str("module", module.Coordinates()).Msg("calculating module hash")
-		h1, err := module.Hash()
+		h1, err := c.ModuleHash(module)
 		if err != nil {
 			return fmt.Errorf("failed to calculate module hash: %w", err)
 		}
@@ -154,6 +232,17 @@ func WithPackages(enabled bool, options ...pkgConv.Option) Option {
 	}
 }
 
+// WithPlatformIndependentPURL configures the component to use a PURL without goos and goarch qualifiers.
+func WithPlatformIndependentPURL(enabled bool) Option {
+	return func(_ zerolog.Logger, module gomod.Module, component *cdx.Component) error {
+		if enabled && component.PackageURL == "" {
+			component.PackageURL = module.PlatformIndependentPackageURL()
+		}
+
+		return nil
+	}
+}
+
 // WithScope overrides the scope of the component.
 func WithScope(scope cdx.Scope) Option {
 	return func(_ zerolog.Logger, _ gomod.Module, component *cdx.Component) error {
@@ -185,11 +274,35 @@ func WithShortPURL(enabled bool) Option {
 	}
 }
 
+// PropertyReplaces names the module that a component replaces, in "path@version" notation.
+const PropertyReplaces = "module:replaces"
+
+// PropertyTool names the tool package that requires a component's module,
+// for modules that are only required by tools.
+const PropertyTool = "tool"
+
 // ToComponent converts a gomod.Module to a CycloneDX component.
 // The component can be further customized using options, before it's returned.
 func ToComponent(logger zerolog.Logger, module gomod.Module, options ...Option) (*cdx.Component, error) {
 	if module.Replace != nil {
-		return ToComponent(logger, *module.Replace, options...)
+		// Packages are associated with the replaced module, not with its replacement
+		replacement := *module.Replace
+		if len(replacement.Packages) == 0 {
+			replacement.Packages = module.Packages
+		}
+		if replacement.Tool == "" {
+			replacement.Tool = module.Tool
+		}
+
+		component, err := ToComponent(logger, replacement, options...)
+		if err != nil {
+			return nil, err
+		}
+
+		// Record the replaced module, so that replacements can be told apart from regular modules
+		addProperty(component, sbom.NewProperty(PropertyReplaces, module.Coordinates()))
+
+		return component, nil
 	}
 
 	logger.Debug().
@@ -197,21 +310,33 @@ func ToComponent(logger zerolog.Logger, module gomod.Module, options ...Option)
 		Msg("converting module to component")
 
 	component := cdx.Component{
-		BOMRef:     module.BOMRef(),
-		Type:       cdx.ComponentTypeLibrary,
-		Name:       module.Path,
-		Version:    module.Version,
-		PackageURL: module.PackageURL(),
+		BOMRef:  module.BOMRef(),
+		Type:    cdx.ComponentTypeLibrary,
+		Name:    module.Path,
+		Version: module.Version,
+	}
+	if module.Toolchain {
+		component.Type = cdx.ComponentTypeApplication
 	}
 
-	if !module.Main { // Main component can't have a scope
+	// Main component can't have a scope, but other modules of a workspace are regular dependencies
+	if !module.Main || module.Workspace {
 		if module.TestOnly {
 			component.Scope = cdx.ScopeOptional
+		} else if module.Tool != "" {
+			// Tools are used during development, but aren't part of the module's runtime
+			component.Scope = cdx.ScopeExcluded
 		} else {
 			component.Scope = cdx.ScopeRequired
 		}
 	}
 
+	if module.Tool != "" {
+		component.Properties = &[]cdx.Property{
+			sbom.NewProperty(PropertyTool, module.Tool),
+		}
+	}
+
 	if module.Sum != "" && strings.HasPrefix(module.Sum, "h1:") {
 		h1Bytes, err := base64.StdEncoding.DecodeString(module.Sum[3:])
 		if err != nil {
@@ -236,25 +361,91 @@ func ToComponent(logger zerolog.Logger, module gomod.Module, options ...Option)
 		}
 	}
 
+	if module.Inference != nil {
+		component.Evidence = buildInferenceEvidence(*module.Inference)
+	}
+
 	for _, option := range options {
 		if err := option(logger, module, &component); err != nil {
 			return nil, err
 		}
 	}
 
+	// Determining the platform qualifiers requires the go command,
+	// so only do so if no option provided a package URL already.
+	if component.PackageURL == "" {
+		component.PackageURL = module.PackageURL()
+	}
+
 	return &component, nil
 }
 
+// buildInferenceEvidence builds identity evidence for a component whose module was inferred,
+// so that it can't be mistaken for a module that was read from authoritative sources.
+// Modules are currently only inferred from binaries, hence the technique.
+func buildInferenceEvidence(inference gomod.Inference) *cdx.Evidence {
+	return &cdx.Evidence{
+		Identity: &cdx.EvidenceIdentityChoice{
+			Identities: &[]cdx.EvidenceIdentity{
+				{
+					Field:      cdx.EvidenceIdentityFieldTypePURL,
+					Confidence: &inference.Confidence,
+					Methods: &[]cdx.EvidenceIdentityMethod{
+						{
+							Technique:  cdx.EvidenceIdentityTechniqueBinaryAnalysis,
+							Confidence: &inference.Confidence,
+							Value:      inference.Method,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 // ToComponents converts a slice of gomod.Module to a slice of CycloneDX components.
-func ToComponents(logger zerolog.Logger, modules []gomod.Module, options ...Option) ([]cdx.Component, error) {
-	components := make([]cdx.Component, 0, len(modules))
+//
+// Options like WithLicenses and WithModuleHashes scan entire module directories,
+// so up to parallelism modules are converted concurrently. Components are returned
+// in the order of their modules. Once a conversion fails, modules that have not been
+// started yet are skipped, and the first error in module order is returned.
+func ToComponents(logger zerolog.Logger, modules []gomod.Module, parallelism int, options ...Option) ([]cdx.Component, error) {
+	components := make([]cdx.Component, len(modules))
+	errs := make([]error, len(modules))
+
+	var (
+		failed  atomic.Bool
+		indexes = make(chan int)
+		wg      sync.WaitGroup
+	)
+	for range max(min(parallelism, len(modules)), 1) {
+		wg.Go(func() {
+			for i := range indexes {
+				if failed.Load() {
+					continue
+				}
+
+				component, err := ToComponent(logger, modules[i], options...)
+				if err != nil {
+					errs[i] = err
+					failed.Store(true)
+					continue
+				}
+				components[i] = *component
+			}
+		})
+	}
 
 	for i := range modules {
-		component, err := ToComponent(logger, modules[i], options...)
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	for _, err := range errs {
 		if err != nil {
 			return nil, err
 		}
-		components = append(components, *component)
 	}
 
 	return components, nil
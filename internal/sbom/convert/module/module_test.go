@@ -19,9 +19,12 @@ package module
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	cdx "github.com/CycloneDX/cyclonedx-go"
@@ -32,11 +35,14 @@ import (
 
 	"github.com/CycloneDX/cyclonedx-gomod/internal/gocmd"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/gomod"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/curation"
 )
 
 type stubLicenseDetector struct {
-	Err      error
-	Licenses []cdx.License
+	Err        error
+	Licenses   []cdx.License
+	Expression string
 }
 
 func (d stubLicenseDetector) Detect(_, _, _ string) ([]cdx.License, error) {
@@ -47,6 +53,19 @@ func (d stubLicenseDetector) Detect(_, _, _ string) ([]cdx.License, error) {
 	return d.Licenses, nil
 }
 
+func (d stubLicenseDetector) DetectContext(_ context.Context, path, version, dir string) ([]cdx.License, error) {
+	return d.Detect(path, version, dir)
+}
+
+func (d stubLicenseDetector) DetectExpression(_ context.Context, path, version, dir string) ([]cdx.License, string, error) {
+	licenses, err := d.Detect(path, version, dir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return licenses, d.Expression, nil
+}
+
 func TestWithLicenses(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		component := cdx.Component{}
@@ -58,11 +77,36 @@ func TestWithLicenses(t *testing.T) {
 			},
 		}
 
-		err := WithLicenses(detector)(zerolog.Nop(), gomod.Module{Dir: t.TempDir()}, &component)
+		err := WithLicenses(context.Background(), detector, nil)(zerolog.Nop(), gomod.Module{Dir: t.TempDir()}, &component)
 		require.NoError(t, err)
 		require.NotNil(t, component.Evidence)
 		require.NotNil(t, component.Evidence.Licenses)
 		require.Len(t, *component.Evidence.Licenses, 1)
+		require.Nil(t, component.Licenses)
+		require.Equal(t, &[]cdx.Property{{Name: "cdx:gomod:license:source", Value: "detection"}}, component.Properties)
+	})
+
+	t.Run("Expression", func(t *testing.T) {
+		component := cdx.Component{}
+		detector := &stubLicenseDetector{
+			Licenses: []cdx.License{
+				{
+					ID: "Apache-2.0",
+				},
+				{
+					ID: "MIT",
+				},
+			},
+			Expression: "Apache-2.0 OR MIT",
+		}
+
+		err := WithLicenses(context.Background(), detector, nil)(zerolog.Nop(), gomod.Module{Dir: t.TempDir()}, &component)
+		require.NoError(t, err)
+		require.NotNil(t, component.Evidence)
+		require.NotNil(t, component.Evidence.Licenses)
+		require.Len(t, *component.Evidence.Licenses, 2)
+		require.Nil(t, component.Licenses, "detected licenses must only be reported as evidence")
+		require.Contains(t, *component.Properties, cdx.Property{Name: "cdx:gomod:license:expression", Value: "Apache-2.0 OR MIT"})
 	})
 
 	t.Run("NoLicenseFound", func(t *testing.T) {
@@ -71,7 +115,7 @@ func TestWithLicenses(t *testing.T) {
 			Licenses: []cdx.License{},
 		}
 
-		err := WithLicenses(detector)(zerolog.Nop(), gomod.Module{Dir: t.TempDir()}, &component)
+		err := WithLicenses(context.Background(), detector, nil)(zerolog.Nop(), gomod.Module{Dir: t.TempDir()}, &component)
 		require.NoError(t, err)
 		require.Nil(t, component.Evidence)
 	})
@@ -80,7 +124,7 @@ func TestWithLicenses(t *testing.T) {
 		component := cdx.Component{}
 		detector := &stubLicenseDetector{}
 
-		err := WithLicenses(detector)(zerolog.Nop(), gomod.Module{Dir: ""}, &component)
+		err := WithLicenses(context.Background(), detector, nil)(zerolog.Nop(), gomod.Module{Dir: ""}, &component)
 		require.NoError(t, err)
 		require.Nil(t, component.Evidence)
 	})
@@ -91,15 +135,85 @@ func TestWithLicenses(t *testing.T) {
 			Err: errors.New("test"),
 		}
 
-		err := WithLicenses(detector)(zerolog.Nop(), gomod.Module{Dir: t.TempDir()}, &component)
+		err := WithLicenses(context.Background(), detector, nil)(zerolog.Nop(), gomod.Module{Dir: t.TempDir()}, &component)
 		require.Error(t, err)
 		require.Nil(t, component.Evidence)
 	})
 
+	t.Run("Canceled", func(t *testing.T) {
+		component := cdx.Component{}
+		detector := &stubLicenseDetector{
+			Licenses: []cdx.License{
+				{
+					ID: "Apache-2.0",
+				},
+			},
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := WithLicenses(ctx, detector, nil)(zerolog.Nop(), gomod.Module{Dir: t.TempDir()}, &component)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Nil(t, component.Evidence)
+	})
+
+	t.Run("Curated", func(t *testing.T) {
+		component := cdx.Component{}
+		detector := &stubLicenseDetector{
+			Licenses: []cdx.License{
+				{
+					ID: "Apache-2.0",
+				},
+				{
+					ID: "MIT",
+				},
+			},
+			Expression: "Apache-2.0 AND MIT",
+		}
+		curations := &curation.Curations{
+			Curations: []curation.Curation{{Module: "github.com/acme", License: "Apache-2.0 OR MIT"}},
+		}
+
+		err := WithLicenses(context.Background(), detector, curations)(zerolog.Nop(), gomod.Module{Path: "github.com/acme/foo", Dir: t.TempDir()}, &component)
+		require.NoError(t, err)
+		require.NotNil(t, component.Evidence)
+		require.NotNil(t, component.Evidence.Licenses)
+		require.Len(t, *component.Evidence.Licenses, 2)
+
+		acknowledgement := cdx.LicenseAcknowledgementConcluded
+		require.Equal(t, &cdx.Licenses{{Expression: "Apache-2.0 OR MIT", Acknowledgement: &acknowledgement}}, component.Licenses)
+		require.Equal(t, &[]cdx.Property{{Name: "cdx:gomod:license:source", Value: "curation"}}, component.Properties)
+	})
+
+	t.Run("CuratedWithoutDetection", func(t *testing.T) {
+		component := cdx.Component{}
+		curations := &curation.Curations{
+			Curations: []curation.Curation{{Module: "github.com/acme/foo", License: "MIT"}},
+		}
+
+		err := WithLicenses(context.Background(), nil, curations)(zerolog.Nop(), gomod.Module{Path: "github.com/acme/foo"}, &component)
+		require.NoError(t, err)
+		require.Nil(t, component.Evidence)
+		require.Equal(t, &cdx.Licenses{{License: &cdx.License{ID: "MIT", Acknowledgement: cdx.LicenseAcknowledgementConcluded}}}, component.Licenses)
+	})
+
+	t.Run("NotCurated", func(t *testing.T) {
+		component := cdx.Component{}
+		curations := &curation.Curations{
+			Curations: []curation.Curation{{Module: "github.com/acme/foo", License: "MIT"}},
+		}
+
+		err := WithLicenses(context.Background(), nil, curations)(zerolog.Nop(), gomod.Module{Path: "github.com/acme/bar"}, &component)
+		require.NoError(t, err)
+		require.Nil(t, component.Licenses)
+		require.Nil(t, component.Properties)
+	})
+
 	t.Run("Disabled", func(t *testing.T) {
 		component := cdx.Component{}
 
-		err := WithLicenses(nil)(zerolog.Nop(), gomod.Module{Dir: t.TempDir()}, &component)
+		err := WithLicenses(context.Background(), nil, nil)(zerolog.Nop(), gomod.Module{Dir: t.TempDir()}, &component)
 		require.NoError(t, err)
 		require.Nil(t, component.Evidence)
 	})
@@ -127,7 +241,7 @@ func TestWithModuleHashes(t *testing.T) {
 	component := new(cdx.Component)
 
 	// Calculate hashes
-	err = WithModuleHashes()(zerolog.Nop(), module, component)
+	err = WithModuleHashes(nil)(zerolog.Nop(), module, component)
 	require.NoError(t, err)
 	require.NotNil(t, component.Hashes)
 
@@ -145,7 +259,7 @@ func TestWithModuleHashesWithoutDirectory(t *testing.T) {
 	}
 	component := new(cdx.Component)
 
-	err := WithModuleHashes()(zerolog.Nop(), module, component)
+	err := WithModuleHashes(nil)(zerolog.Nop(), module, component)
 	require.NoError(t, err)
 	require.Nil(t, component.Hashes)
 }
@@ -159,6 +273,18 @@ func TestWithComponentType(t *testing.T) {
 	require.Equal(t, cdx.ComponentTypeContainer, component.Type)
 }
 
+func TestWithPlatformIndependentPURL(t *testing.T) {
+	module := gomod.Module{
+		Path:    "github.com/google/uuid",
+		Version: "v1.2.0",
+	}
+	component := cdx.Component{}
+
+	err := WithPlatformIndependentPURL(true)(zerolog.Nop(), module, &component)
+	require.NoError(t, err)
+	require.Equal(t, "pkg:golang/github.com/google/uuid@v1.2.0?type=module", component.PackageURL)
+}
+
 func TestWithScope(t *testing.T) {
 	module := gomod.Module{}
 	component := cdx.Component{}
@@ -194,7 +320,7 @@ func TestWithTestScope(t *testing.T) {
 
 func TestToComponent(t *testing.T) {
 	// To get value from "go env -json", cannot just use t.GetEnv() might return ""
-	envMap, _ := gocmd.GetEnv(zerolog.Nop())
+	envMap, _ := gocmd.GetEnv(context.Background(), zerolog.Nop())
 	goos := envMap["GOOS"]
 	goarch := envMap["GOARCH"]
 
@@ -276,6 +402,30 @@ func TestToComponent(t *testing.T) {
 		require.Equal(t, "versionReplace", component.Version)
 		require.Equal(t, "pkg:golang/pathReplace@versionReplace?"+qualifiers.String(), component.PackageURL)
 		require.Equal(t, cdx.ScopeRequired, component.Scope)
+		require.NotNil(t, component.Properties)
+		require.Equal(t, []cdx.Property{{Name: "cdx:gomod:module:replaces", Value: "path@version"}}, *component.Properties)
+	})
+
+	t.Run("With Replace And Packages", func(t *testing.T) {
+		module := gomod.Module{
+			Path:    "path",
+			Version: "version",
+			Replace: &gomod.Module{
+				Path:    "pathReplace",
+				Version: "versionReplace",
+			},
+			Packages: []gomod.Package{
+				{ImportPath: "path/pkg"},
+			},
+		}
+
+		component, err := ToComponent(zerolog.Nop(), module, WithPackages(true))
+		require.NoError(t, err)
+		require.NotNil(t, component)
+
+		require.NotNil(t, component.Components)
+		require.Len(t, *component.Components, 1)
+		require.Equal(t, "pkg:golang/path/pkg@versionReplace?type=package", (*component.Components)[0].BOMRef)
 	})
 
 	t.Run("WithSum", func(t *testing.T) {
@@ -293,6 +443,136 @@ func TestToComponent(t *testing.T) {
 		require.Equal(t, cdx.HashAlgoSHA256, (*component.Hashes)[0].Algorithm)
 		require.Equal(t, "a8962d5e72515a6a5eee6ff75e5ca1aec2eb11446a1d1336931ce8c57ab2503b", (*component.Hashes)[0].Value)
 	})
+
+	t.Run("WithInference", func(t *testing.T) {
+		module := gomod.Module{
+			Path:    "path",
+			Version: "version",
+			Inference: &gomod.Inference{
+				Confidence: 0.3,
+				Method:     "method",
+			},
+		}
+
+		component, err := ToComponent(zerolog.Nop(), module)
+		require.NoError(t, err)
+		require.NotNil(t, component)
+
+		confidence := float32(0.3)
+		require.NotNil(t, component.Evidence)
+		require.Equal(t, &cdx.EvidenceIdentityChoice{
+			Identities: &[]cdx.EvidenceIdentity{
+				{
+					Field:      cdx.EvidenceIdentityFieldTypePURL,
+					Confidence: &confidence,
+					Methods: &[]cdx.EvidenceIdentityMethod{
+						{
+							Technique:  cdx.EvidenceIdentityTechniqueBinaryAnalysis,
+							Confidence: &confidence,
+							Value:      "method",
+						},
+					},
+				},
+			},
+		}, component.Evidence.Identity)
+	})
+
+	t.Run("WithInferenceAndAssertedLicenses", func(t *testing.T) {
+		module := gomod.Module{
+			Path:    "path",
+			Version: "version",
+			Dir:     t.TempDir(),
+			Inference: &gomod.Inference{
+				Confidence: 0.3,
+				Method:     "method",
+			},
+		}
+		detector := &stubLicenseDetector{Licenses: []cdx.License{{ID: "MIT"}}}
+
+		component, err := ToComponent(zerolog.Nop(), module, WithLicenses(context.Background(), detector, nil))
+		require.NoError(t, err)
+		require.NotNil(t, component)
+
+		bom := &cdx.BOM{Components: &[]cdx.Component{*component}}
+		sbom.AssertLicenses(bom)
+
+		asserted := (*bom.Components)[0]
+		require.Equal(t, &cdx.Licenses{{License: &cdx.License{ID: "MIT"}}}, asserted.Licenses)
+		require.NotNil(t, asserted.Evidence, "identity evidence of inferred modules must be retained")
+		require.NotNil(t, asserted.Evidence.Identity)
+		require.Nil(t, asserted.Evidence.Licenses)
+	})
+
+	t.Run("ToolOnly", func(t *testing.T) {
+		module := gomod.Module{
+			Path:    "path",
+			Version: "version",
+			Tool:    "example.com/gen/cmd/gen",
+		}
+
+		component, err := ToComponent(zerolog.Nop(), module)
+		require.NoError(t, err)
+		require.NotNil(t, component)
+
+		require.Equal(t, cdx.ScopeExcluded, component.Scope)
+		require.NotNil(t, component.Properties)
+		require.Equal(t, []cdx.Property{{Name: "cdx:gomod:tool", Value: "example.com/gen/cmd/gen"}}, *component.Properties)
+	})
+
+	t.Run("Toolchain", func(t *testing.T) {
+		module := gomod.ToolchainModule("go1.22.1")
+
+		component, err := ToComponent(zerolog.Nop(), module, WithShortPURL(true))
+		require.NoError(t, err)
+		require.NotNil(t, component)
+
+		require.Equal(t, "pkg:golang/golang.org/toolchain@go1.22.1", component.BOMRef)
+		require.Equal(t, cdx.ComponentTypeApplication, component.Type)
+		require.Equal(t, "golang.org/toolchain", component.Name)
+		require.Equal(t, "go1.22.1", component.Version)
+		require.Equal(t, "pkg:golang/golang.org/toolchain@go1.22.1", component.PackageURL)
+	})
+}
+
+func TestToComponents(t *testing.T) {
+	modules := make([]gomod.Module, 20)
+	for i := range modules {
+		modules[i] = gomod.Module{Path: fmt.Sprintf("example.com/module%d", i), Version: "v1.0.0"}
+	}
+
+	t.Run("Order", func(t *testing.T) {
+		for _, parallelism := range []int{0, 1, 4, 100} {
+			components, err := ToComponents(zerolog.Nop(), modules, parallelism)
+			require.NoError(t, err)
+			require.Len(t, components, len(modules))
+
+			for i, component := range components {
+				require.Equal(t, modules[i].Path, component.Name)
+			}
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		failFor := func(paths ...string) Option {
+			return func(_ zerolog.Logger, module gomod.Module, _ *cdx.Component) error {
+				if slices.Contains(paths, module.Path) {
+					return fmt.Errorf("failed for %s", module.Path)
+				}
+				return nil
+			}
+		}
+
+		components, err := ToComponents(zerolog.Nop(), modules, 4, failFor(modules[7].Path, modules[13].Path))
+		require.Error(t, err)
+		require.Nil(t, components)
+		require.Contains(t, err.Error(), modules[7].Path)
+	})
+
+	t.Run("NoModules", func(t *testing.T) {
+		components, err := ToComponents(zerolog.Nop(), nil, 4)
+		require.NoError(t, err)
+		require.Empty(t, components)
+	})
 }
 
 func TestResolveVCSURL(t *testing.T) {
@@ -24,13 +24,14 @@ import (
 	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/rs/zerolog"
 
+	"github.com/CycloneDX/cyclonedx-gomod/internal/cache"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/gomod"
 	fileConv "github.com/CycloneDX/cyclonedx-gomod/internal/sbom/convert/file"
 )
 
 type Option func(zerolog.Logger, gomod.Package, gomod.Module, *cdx.Component) error
 
-func WithFiles(enabled bool, pathEnabled bool) Option {
+func WithFiles(enabled bool, pathEnabled bool, c *cache.Cache, hashAlgos ...cdx.HashAlgorithm) Option {
 	return func(logger zerolog.Logger, pkg gomod.Package, module gomod.Module, component *cdx.Component) error {
 		if !enabled {
 			return nil
@@ -55,17 +56,12 @@ func WithFiles(enabled bool, pathEnabled bool) Option {
 		for _, file := range files {
 			fileComponent, err := fileConv.ToComponent(
 				logger,
+				c,
 				filepath.Join(pkg.Dir, file),
 				file,
 				pathEnabled,
 				module,
-				fileConv.WithHashes(
-					cdx.HashAlgoMD5,
-					cdx.HashAlgoSHA1,
-					cdx.HashAlgoSHA256,
-					cdx.HashAlgoSHA384,
-					cdx.HashAlgoSHA512,
-				),
+				hashAlgos...,
 			)
 			if err != nil {
 				return err
@@ -99,6 +95,7 @@ func ToComponent(logger zerolog.Logger, pkg gomod.Package, module gomod.Module,
 		Msg("converting package to component")
 
 	component := cdx.Component{
+		BOMRef:     pkg.BOMRef(module),
 		Type:       cdx.ComponentTypeLibrary,
 		Name:       pkg.ImportPath,
 		Version:    module.Version,
@@ -38,6 +38,7 @@ func TestToComponent(t *testing.T) {
 
 	c, err := ToComponent(zerolog.Nop(), p, m)
 	require.NoError(t, err)
+	require.Equal(t, "pkg:golang/packagePath@moduleVersion?type=package", c.BOMRef)
 	require.Equal(t, "packagePath", c.Name)
 	require.Equal(t, "moduleVersion", c.Version)
 	require.Equal(t, "pkg:golang/packagePath@moduleVersion?type=package", c.PackageURL)
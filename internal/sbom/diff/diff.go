@@ -0,0 +1,444 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+// Package diff compares SBOMs generated by cyclonedx-gomod.
+package diff
+
+import (
+	"go/version"
+	"slices"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+	"golang.org/x/mod/semver"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
+	modConv "github.com/CycloneDX/cyclonedx-gomod/internal/sbom/convert/module"
+)
+
+// Result holds the differences between two SBOMs.
+//
+// Components are matched by their identity, which for Go modules and packages
+// is their path and type (as per the "type" qualifier of their PURL).
+// Replaced modules are matched by the path of the module they replace.
+type Result struct {
+	Added               []Component         `json:"added,omitempty"`
+	Removed             []Component         `json:"removed,omitempty"`
+	Upgraded            []VersionChange     `json:"upgraded,omitempty"`
+	Downgraded          []VersionChange     `json:"downgraded,omitempty"`
+	Changed             []VersionChange     `json:"changed,omitempty"` // Version changes that can't be ordered
+	Replacements        []ReplacementChange `json:"replacements,omitempty"`
+	Licenses            []LicenseChange     `json:"licenses,omitempty"`
+	Properties          []PropertyChange    `json:"properties,omitempty"`
+	AddedDependencies   []Dependency        `json:"addedDependencies,omitempty"`
+	RemovedDependencies []Dependency        `json:"removedDependencies,omitempty"`
+}
+
+// Component describes a component that was added or removed.
+type Component struct {
+	Name       string `json:"name"`
+	Version    string `json:"version,omitempty"`
+	Type       string `json:"type,omitempty"`     // "module" or "package" for Go components
+	Replaces   string `json:"replaces,omitempty"` // Replaced module in "path@version" notation
+	PackageURL string `json:"purl,omitempty"`
+}
+
+// VersionChange describes a component of which the version has changed.
+type VersionChange struct {
+	Name       string `json:"name"`
+	OldVersion string `json:"oldVersion"`
+	NewVersion string `json:"newVersion"`
+}
+
+// ReplacementChange describes a module of which the replacement has changed.
+// Replacements are in "path@version" notation, an empty replacement means that the module is not replaced.
+type ReplacementChange struct {
+	Name           string `json:"name"`
+	OldReplacement string `json:"oldReplacement,omitempty"`
+	NewReplacement string `json:"newReplacement,omitempty"`
+}
+
+// LicenseChange describes a component of which the licenses have changed.
+// Both asserted and detected licenses (evidence) are considered.
+type LicenseChange struct {
+	Name        string   `json:"name"`
+	OldLicenses []string `json:"oldLicenses"`
+	NewLicenses []string `json:"newLicenses"`
+}
+
+// PropertyChange describes a component of which cdx:gomod:* properties were added or removed.
+type PropertyChange struct {
+	Name    string         `json:"name"`
+	Added   []cdx.Property `json:"added,omitempty"`
+	Removed []cdx.Property `json:"removed,omitempty"`
+}
+
+// Dependency describes a dependency edge between two components,
+// which are referred to by their identity.
+type Dependency struct {
+	Ref       string `json:"ref"`
+	DependsOn string `json:"dependsOn"`
+}
+
+// IsEmpty reports whether no differences were found.
+func (r Result) IsEmpty() bool {
+	return len(r.Added) == 0 &&
+		len(r.Removed) == 0 &&
+		len(r.Upgraded) == 0 &&
+		len(r.Downgraded) == 0 &&
+		len(r.Changed) == 0 &&
+		len(r.Replacements) == 0 &&
+		len(r.Licenses) == 0 &&
+		len(r.Properties) == 0 &&
+		len(r.AddedDependencies) == 0 &&
+		len(r.RemovedDependencies) == 0
+}
+
+// Compare determines the differences between oldBOM and newBOM.
+//
+// The main component and all (nested) components are considered,
+// with the exception of files.
+func Compare(oldBOM, newBOM *cdx.BOM) Result {
+	oldIndex, newIndex := newComponentIndex(oldBOM), newComponentIndex(newBOM)
+
+	var result Result
+	for identity, newEntry := range newIndex.entries {
+		oldEntry, ok := oldIndex.entries[identity]
+		if !ok {
+			result.Added = append(result.Added, newEntry.toComponent())
+			continue
+		}
+		result.compare(oldEntry, newEntry)
+	}
+	for identity, oldEntry := range oldIndex.entries {
+		if _, ok := newIndex.entries[identity]; !ok {
+			result.Removed = append(result.Removed, oldEntry.toComponent())
+		}
+	}
+
+	oldEdges, newEdges := oldIndex.dependencyEdges(oldBOM), newIndex.dependencyEdges(newBOM)
+	for edge := range newEdges {
+		if _, ok := oldEdges[edge]; !ok {
+			result.AddedDependencies = append(result.AddedDependencies, edge)
+		}
+	}
+	for edge := range oldEdges {
+		if _, ok := newEdges[edge]; !ok {
+			result.RemovedDependencies = append(result.RemovedDependencies, edge)
+		}
+	}
+
+	result.sort()
+
+	return result
+}
+
+func (r *Result) compare(oldEntry, newEntry componentEntry) {
+	name := newEntry.name()
+
+	oldReplacement, newReplacement := oldEntry.replacement(), newEntry.replacement()
+	if oldReplacement != newReplacement {
+		r.Replacements = append(r.Replacements, ReplacementChange{
+			Name:           name,
+			OldReplacement: oldReplacement,
+			NewReplacement: newReplacement,
+		})
+	} else if oldEntry.kind != "package" && oldEntry.component.Version != newEntry.component.Version {
+		// Package versions follow the version of their module, reporting them as well would be redundant
+		change := VersionChange{
+			Name:       name,
+			OldVersion: oldEntry.component.Version,
+			NewVersion: newEntry.component.Version,
+		}
+		cmp, ok := compareVersions(change.OldVersion, change.NewVersion)
+		switch {
+		case !ok:
+			r.Changed = append(r.Changed, change)
+		case cmp < 0:
+			r.Upgraded = append(r.Upgraded, change)
+		case cmp > 0:
+			r.Downgraded = append(r.Downgraded, change)
+		}
+	}
+
+	oldLicenses, newLicenses := componentLicenses(oldEntry.component), componentLicenses(newEntry.component)
+	if !slices.Equal(oldLicenses, newLicenses) {
+		r.Licenses = append(r.Licenses, LicenseChange{
+			Name:        name,
+			OldLicenses: oldLicenses,
+			NewLicenses: newLicenses,
+		})
+	}
+
+	oldProperties, newProperties := componentProperties(oldEntry.component), componentProperties(newEntry.component)
+	change := PropertyChange{Name: name}
+	for _, property := range newProperties {
+		if !slices.Contains(oldProperties, property) {
+			change.Added = append(change.Added, property)
+		}
+	}
+	for _, property := range oldProperties {
+		if !slices.Contains(newProperties, property) {
+			change.Removed = append(change.Removed, property)
+		}
+	}
+	if len(change.Added) > 0 || len(change.Removed) > 0 {
+		r.Properties = append(r.Properties, change)
+	}
+}
+
+func (r *Result) sort() {
+	slices.SortFunc(r.Added, compareComponents)
+	slices.SortFunc(r.Removed, compareComponents)
+	slices.SortFunc(r.Upgraded, func(a, b VersionChange) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(r.Downgraded, func(a, b VersionChange) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(r.Changed, func(a, b VersionChange) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(r.Replacements, func(a, b ReplacementChange) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(r.Licenses, func(a, b LicenseChange) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(r.Properties, func(a, b PropertyChange) int { return strings.Compare(a.Name, b.Name) })
+	slices.SortFunc(r.AddedDependencies, compareDependencies)
+	slices.SortFunc(r.RemovedDependencies, compareDependencies)
+}
+
+func compareComponents(a, b Component) int {
+	if a.Name == b.Name {
+		return strings.Compare(a.Type, b.Type)
+	}
+
+	return strings.Compare(a.Name, b.Name)
+}
+
+func compareDependencies(a, b Dependency) int {
+	if a.Ref == b.Ref {
+		return strings.Compare(a.DependsOn, b.DependsOn)
+	}
+
+	return strings.Compare(a.Ref, b.Ref)
+}
+
+// compareVersions compares semantic versions, or Go versions as used for the standard library.
+// If the versions can't be ordered, false is returned.
+func compareVersions(oldVersion, newVersion string) (int, bool) {
+	switch {
+	case semver.IsValid(oldVersion) && semver.IsValid(newVersion):
+		return semver.Compare(oldVersion, newVersion), true
+	case version.IsValid(oldVersion) && version.IsValid(newVersion):
+		return version.Compare(oldVersion, newVersion), true
+	}
+
+	return 0, false
+}
+
+func componentLicenses(component cdx.Component) []string {
+	var licenses []string
+
+	collect := func(choices *cdx.Licenses) {
+		if choices == nil {
+			return
+		}
+		for _, choice := range *choices {
+			switch {
+			case choice.Expression != "":
+				licenses = append(licenses, choice.Expression)
+			case choice.License != nil && choice.License.ID != "":
+				licenses = append(licenses, choice.License.ID)
+			case choice.License != nil && choice.License.Name != "":
+				licenses = append(licenses, choice.License.Name)
+			}
+		}
+	}
+
+	collect(component.Licenses)
+	if component.Evidence != nil {
+		collect(component.Evidence.Licenses)
+	}
+
+	slices.Sort(licenses)
+	return slices.Compact(licenses)
+}
+
+// componentProperties returns all cdx:gomod:* properties of component,
+// except for the replaced module, which is covered by ReplacementChange.
+func componentProperties(component cdx.Component) []cdx.Property {
+	if component.Properties == nil {
+		return nil
+	}
+
+	var properties []cdx.Property
+	for _, property := range *component.Properties {
+		if strings.HasPrefix(property.Name, sbom.PropertyPrefix+":") && property.Name != replacesPropertyName {
+			properties = append(properties, property)
+		}
+	}
+
+	return properties
+}
+
+var replacesPropertyName = sbom.NewProperty(modConv.PropertyReplaces, "").Name
+
+type componentEntry struct {
+	component cdx.Component
+	kind      string // Value of the PURL's type qualifier
+	replaces  string // Replaced module in "path@version" notation
+}
+
+func (e componentEntry) name() string {
+	if e.replaces != "" {
+		path, _, _ := strings.Cut(e.replaces, "@")
+		return path
+	}
+	if e.component.Group != "" {
+		return e.component.Group + "/" + e.component.Name
+	}
+
+	return e.component.Name
+}
+
+// replacement returns the coordinates of the component if it replaces another module.
+func (e componentEntry) replacement() string {
+	if e.replaces == "" {
+		return ""
+	}
+	if e.component.Version == "" {
+		return e.component.Name
+	}
+
+	return e.component.Name + "@" + e.component.Version
+}
+
+func (e componentEntry) toComponent() Component {
+	return Component{
+		Name:       e.component.Name,
+		Version:    e.component.Version,
+		Type:       e.kind,
+		Replaces:   e.replaces,
+		PackageURL: e.component.PackageURL,
+	}
+}
+
+type componentIndex struct {
+	entries map[string]componentEntry // identity -> entry
+	refs    map[string]string         // bom-ref -> identity
+}
+
+func newComponentIndex(bom *cdx.BOM) componentIndex {
+	index := componentIndex{
+		entries: make(map[string]componentEntry),
+		refs:    make(map[string]string),
+	}
+
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		index.add(*bom.Metadata.Component)
+	}
+	if bom.Components != nil {
+		for _, component := range *bom.Components {
+			index.add(component)
+		}
+	}
+
+	return index
+}
+
+func (i *componentIndex) add(component cdx.Component) {
+	if component.Type != cdx.ComponentTypeFile {
+		entry := componentEntry{component: component}
+		if component.Properties != nil {
+			for _, property := range *component.Properties {
+				if property.Name == replacesPropertyName {
+					entry.replaces = property.Value
+				}
+			}
+		}
+
+		identity := entry.identity()
+		if _, ok := i.entries[identity]; !ok {
+			i.entries[identity] = entry
+		}
+		if component.BOMRef != "" {
+			i.refs[component.BOMRef] = identity
+		}
+	}
+
+	if component.Components != nil {
+		for _, nested := range *component.Components {
+			i.add(nested)
+		}
+	}
+}
+
+// identity determines the version-independent identity of the entry's component,
+// and sets its kind along the way.
+//
+// For Go components, this is their PURL without version and platform qualifiers,
+// e.g. pkg:golang/github.com/foo/bar?type=module.
+func (e *componentEntry) identity() string {
+	purl, err := packageurl.FromString(e.component.PackageURL)
+	if err != nil {
+		return e.name()
+	}
+
+	e.kind = purl.Qualifiers.Map()["type"]
+
+	path := purl.Name
+	if purl.Namespace != "" {
+		path = purl.Namespace + "/" + purl.Name
+	}
+	if purl.Type == packageurl.TypeGolang && e.replaces != "" {
+		path = e.name()
+	}
+
+	identity := "pkg:" + purl.Type + "/" + path
+	if e.kind != "" {
+		identity += "?type=" + e.kind
+	}
+
+	return identity
+}
+
+// dependencyEdges returns the dependency edges of bom, with bom-refs being resolved to identities.
+func (i componentIndex) dependencyEdges(bom *cdx.BOM) map[Dependency]struct{} {
+	edges := make(map[Dependency]struct{})
+	if bom.Dependencies == nil {
+		return edges
+	}
+
+	for _, dependency := range *bom.Dependencies {
+		if dependency.Dependencies == nil {
+			continue
+		}
+		for _, dependsOn := range *dependency.Dependencies {
+			edge := Dependency{
+				Ref:       i.resolveRef(dependency.Ref),
+				DependsOn: i.resolveRef(dependsOn),
+			}
+			if edge.Ref != edge.DependsOn {
+				edges[edge] = struct{}{}
+			}
+		}
+	}
+
+	return edges
+}
+
+func (i componentIndex) resolveRef(ref string) string {
+	if identity, ok := i.refs[ref]; ok {
+		return identity
+	}
+
+	return ref
+}
@@ -0,0 +1,191 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package diff
+
+import (
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func moduleComponent(path, version string, properties ...cdx.Property) cdx.Component {
+	component := cdx.Component{
+		BOMRef:     "pkg:golang/" + path + "@" + version + "?type=module",
+		Type:       cdx.ComponentTypeLibrary,
+		Name:       path,
+		Version:    version,
+		PackageURL: "pkg:golang/" + path + "@" + version + "?goarch=amd64&goos=linux&type=module",
+	}
+	if len(properties) > 0 {
+		component.Properties = &properties
+	}
+
+	return component
+}
+
+func bomOf(main cdx.Component, components []cdx.Component, dependencies []cdx.Dependency) *cdx.BOM {
+	return &cdx.BOM{
+		Metadata:     &cdx.Metadata{Component: &main},
+		Components:   &components,
+		Dependencies: &dependencies,
+	}
+}
+
+func TestCompare(t *testing.T) {
+	t.Run("Identical", func(t *testing.T) {
+		bom := bomOf(moduleComponent("main", "v1.0.0"), []cdx.Component{moduleComponent("a", "v1.0.0")}, nil)
+
+		result := Compare(bom, bom)
+		require.True(t, result.IsEmpty())
+	})
+
+	t.Run("AddedAndRemoved", func(t *testing.T) {
+		oldBOM := bomOf(moduleComponent("main", "v1.0.0"), []cdx.Component{moduleComponent("a", "v1.0.0")}, nil)
+		newBOM := bomOf(moduleComponent("main", "v1.0.0"), []cdx.Component{moduleComponent("b", "v1.0.0")}, nil)
+
+		result := Compare(oldBOM, newBOM)
+		require.Len(t, result.Added, 1)
+		assert.Equal(t, Component{Name: "b", Version: "v1.0.0", Type: "module", PackageURL: "pkg:golang/b@v1.0.0?goarch=amd64&goos=linux&type=module"}, result.Added[0])
+		require.Len(t, result.Removed, 1)
+		assert.Equal(t, "a", result.Removed[0].Name)
+	})
+
+	t.Run("Versions", func(t *testing.T) {
+		oldBOM := bomOf(moduleComponent("main", "v1.0.0"), []cdx.Component{
+			moduleComponent("a", "v1.0.0"),
+			moduleComponent("b", "v1.2.0"),
+			moduleComponent("c", "v0.0.0-20210101000000-abcdefabcdef"),
+			moduleComponent("std", "go1.21.5"),
+			moduleComponent("local", "(devel)"),
+		}, nil)
+		newBOM := bomOf(moduleComponent("main", "v1.0.0"), []cdx.Component{
+			moduleComponent("a", "v1.1.0"),
+			moduleComponent("b", "v1.1.0"),
+			moduleComponent("c", "v0.0.0-20220101000000-abcdefabcdef"),
+			moduleComponent("std", "go1.22.0"),
+			moduleComponent("local", "(latest)"),
+		}, nil)
+
+		result := Compare(oldBOM, newBOM)
+		assert.Equal(t, []VersionChange{
+			{Name: "a", OldVersion: "v1.0.0", NewVersion: "v1.1.0"},
+			{Name: "c", OldVersion: "v0.0.0-20210101000000-abcdefabcdef", NewVersion: "v0.0.0-20220101000000-abcdefabcdef"},
+			{Name: "std", OldVersion: "go1.21.5", NewVersion: "go1.22.0"},
+		}, result.Upgraded)
+		assert.Equal(t, []VersionChange{{Name: "b", OldVersion: "v1.2.0", NewVersion: "v1.1.0"}}, result.Downgraded)
+		assert.Equal(t, []VersionChange{{Name: "local", OldVersion: "(devel)", NewVersion: "(latest)"}}, result.Changed)
+		assert.Empty(t, result.Added)
+		assert.Empty(t, result.Removed)
+	})
+
+	t.Run("Replacements", func(t *testing.T) {
+		oldBOM := bomOf(moduleComponent("main", "v1.0.0"), []cdx.Component{
+			moduleComponent("a", "v1.0.0"),
+			moduleComponent("fork/b", "v1.0.1", cdx.Property{Name: "cdx:gomod:module:replaces", Value: "b@v1.0.0"}),
+		}, nil)
+		newBOM := bomOf(moduleComponent("main", "v1.0.0"), []cdx.Component{
+			moduleComponent("fork/a", "v1.0.1", cdx.Property{Name: "cdx:gomod:module:replaces", Value: "a@v1.0.0"}),
+			moduleComponent("b", "v1.1.0"),
+		}, nil)
+
+		result := Compare(oldBOM, newBOM)
+		assert.Equal(t, []ReplacementChange{
+			{Name: "a", NewReplacement: "fork/a@v1.0.1"},
+			{Name: "b", OldReplacement: "fork/b@v1.0.1"},
+		}, result.Replacements)
+		assert.Empty(t, result.Added)
+		assert.Empty(t, result.Removed)
+		assert.Empty(t, result.Upgraded)
+		assert.Empty(t, result.Properties)
+	})
+
+	t.Run("Licenses", func(t *testing.T) {
+		oldComponent := moduleComponent("a", "v1.0.0")
+		oldComponent.Evidence = &cdx.Evidence{Licenses: &cdx.Licenses{{License: &cdx.License{ID: "MIT"}}}}
+		newComponent := moduleComponent("a", "v1.0.0")
+		newComponent.Licenses = &cdx.Licenses{{License: &cdx.License{ID: "Apache-2.0"}}}
+
+		result := Compare(
+			bomOf(cdx.Component{Name: "main"}, []cdx.Component{oldComponent}, nil),
+			bomOf(cdx.Component{Name: "main"}, []cdx.Component{newComponent}, nil))
+		assert.Equal(t, []LicenseChange{{Name: "a", OldLicenses: []string{"MIT"}, NewLicenses: []string{"Apache-2.0"}}}, result.Licenses)
+	})
+
+	t.Run("Properties", func(t *testing.T) {
+		oldBOM := bomOf(moduleComponent("main", "v1.0.0",
+			cdx.Property{Name: "cdx:gomod:build:tag", Value: "foo"},
+			cdx.Property{Name: "other", Value: "value"}), nil, nil)
+		newBOM := bomOf(moduleComponent("main", "v1.0.0",
+			cdx.Property{Name: "cdx:gomod:build:tag", Value: "bar"}), nil, nil)
+
+		result := Compare(oldBOM, newBOM)
+		assert.Equal(t, []PropertyChange{{
+			Name:    "main",
+			Added:   []cdx.Property{{Name: "cdx:gomod:build:tag", Value: "bar"}},
+			Removed: []cdx.Property{{Name: "cdx:gomod:build:tag", Value: "foo"}},
+		}}, result.Properties)
+	})
+
+	t.Run("Packages", func(t *testing.T) {
+		oldComponent := moduleComponent("a", "v1.0.0")
+		oldComponent.Components = &[]cdx.Component{{
+			BOMRef:     "pkg:golang/a/pkg@v1.0.0?type=package",
+			Type:       cdx.ComponentTypeLibrary,
+			Name:       "a/pkg",
+			Version:    "v1.0.0",
+			PackageURL: "pkg:golang/a/pkg@v1.0.0?type=package",
+			Components: &[]cdx.Component{{Type: cdx.ComponentTypeFile, Name: "pkg.go"}},
+		}}
+		newComponent := moduleComponent("a", "v1.1.0")
+		newComponent.Components = &[]cdx.Component{{
+			BOMRef:     "pkg:golang/a/pkg@v1.1.0?type=package",
+			Type:       cdx.ComponentTypeLibrary,
+			Name:       "a/pkg",
+			Version:    "v1.1.0",
+			PackageURL: "pkg:golang/a/pkg@v1.1.0?type=package",
+		}}
+
+		result := Compare(
+			bomOf(cdx.Component{Name: "main"}, []cdx.Component{oldComponent}, nil),
+			bomOf(cdx.Component{Name: "main"}, []cdx.Component{newComponent}, nil))
+		assert.Equal(t, []VersionChange{{Name: "a", OldVersion: "v1.0.0", NewVersion: "v1.1.0"}}, result.Upgraded)
+		assert.Empty(t, result.Added)
+		assert.Empty(t, result.Removed)
+	})
+
+	t.Run("Dependencies", func(t *testing.T) {
+		oldBOM := bomOf(moduleComponent("main", "v1.0.0"),
+			[]cdx.Component{moduleComponent("a", "v1.0.0"), moduleComponent("b", "v1.0.0")},
+			[]cdx.Dependency{
+				{Ref: "pkg:golang/main@v1.0.0?type=module", Dependencies: &[]string{"pkg:golang/a@v1.0.0?type=module"}},
+				{Ref: "pkg:golang/a@v1.0.0?type=module", Dependencies: &[]string{"pkg:golang/b@v1.0.0?type=module"}},
+			})
+		newBOM := bomOf(moduleComponent("main", "v1.0.0"),
+			[]cdx.Component{moduleComponent("a", "v1.1.0"), moduleComponent("b", "v1.0.0")},
+			[]cdx.Dependency{
+				{Ref: "pkg:golang/main@v1.0.0?type=module", Dependencies: &[]string{"pkg:golang/a@v1.1.0?type=module", "pkg:golang/b@v1.0.0?type=module"}},
+				{Ref: "pkg:golang/a@v1.1.0?type=module"},
+			})
+
+		result := Compare(oldBOM, newBOM)
+		assert.Equal(t, []Dependency{{Ref: "pkg:golang/main?type=module", DependsOn: "pkg:golang/b?type=module"}}, result.AddedDependencies)
+		assert.Equal(t, []Dependency{{Ref: "pkg:golang/a?type=module", DependsOn: "pkg:golang/b?type=module"}}, result.RemovedDependencies)
+	})
+}
@@ -0,0 +1,132 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package sbom
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+)
+
+// BuildFormula builds a formula describing the go build invocation
+// that produced the component referenced by componentRef.
+//
+// settings holds the build settings in the notation of debug/buildinfo:
+// Keys starting with a dash are command line flags, upper case keys are
+// environment variables, and all others (e.g. vcs.revision) are metadata
+// about the build. target is the package that was built.
+func BuildFormula(componentRef, target string, settings map[string]string) cdx.Formula {
+	var (
+		args       = []string{"go", "build"}
+		parameters []cdx.Parameter
+		envVars    cdx.EnvironmentVariables
+	)
+
+	for _, key := range slices.Sorted(maps.Keys(settings)) {
+		value := settings[key]
+
+		switch {
+		case strings.HasPrefix(key, "-"):
+			if value == "true" {
+				args = append(args, key)
+			} else {
+				args = append(args, key+"="+quoteArg(value))
+			}
+			parameters = append(parameters, cdx.Parameter{Name: key, Value: value})
+		case isEnvVarName(key):
+			envVars = append(envVars, cdx.EnvironmentVariableChoice{
+				Property: &cdx.Property{Name: key, Value: value},
+			})
+		default:
+			parameters = append(parameters, cdx.Parameter{Name: key, Value: value})
+		}
+	}
+	if target != "" {
+		args = append(args, target)
+	}
+
+	var inputs []cdx.TaskInput
+	if len(parameters) > 0 {
+		inputs = append(inputs, cdx.TaskInput{Parameters: &parameters})
+	}
+	if len(envVars) > 0 {
+		inputs = append(inputs, cdx.TaskInput{EnvironmentVars: &envVars})
+	}
+
+	ref := GoBuildWorkflowRef(componentRef, settings["GOOS"], settings["GOARCH"])
+	workflow := cdx.Workflow{
+		BOMRef:    ref,
+		UID:       ref,
+		Name:      "go build",
+		TaskTypes: &[]cdx.TaskType{cdx.TaskTypeBuild},
+		Steps: &[]cdx.TaskStep{
+			{
+				Name:     "go build",
+				Commands: &[]cdx.TaskCommand{{Executed: strings.Join(args, " ")}},
+			},
+		},
+		Outputs: &[]cdx.TaskOutput{
+			{
+				Type:     cdx.TaskOutputTypeArtifact,
+				Resource: &cdx.ResourceReferenceChoice{Ref: componentRef},
+			},
+		},
+	}
+	if len(inputs) > 0 {
+		workflow.Inputs = &inputs
+	}
+
+	return cdx.Formula{
+		Workflows: &[]cdx.Workflow{workflow},
+	}
+}
+
+// GoBuildWorkflowRef returns the BOM reference of the workflow that built the component
+// referenced by componentRef. The platform is part of the reference, so that
+// builds of the same component for different platforms can be told apart.
+func GoBuildWorkflowRef(componentRef, goos, goarch string) string {
+	if goos == "" || goarch == "" {
+		return componentRef + "|go-build"
+	}
+
+	return componentRef + "|go-build:" + goos + "/" + goarch
+}
+
+// isEnvVarName determines whether a build setting key refers to an environment variable.
+func isEnvVarName(key string) bool {
+	for i, r := range key {
+		if (r < 'A' || r > 'Z') && r != '_' && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+
+	return key != ""
+}
+
+// quoteArg quotes command line arguments containing spaces or quotes,
+// the same way "go version -m" does.
+func quoteArg(arg string) string {
+	if strings.ContainsAny(arg, " \t\r\n\"`") {
+		return strconv.Quote(arg)
+	}
+
+	return arg
+}
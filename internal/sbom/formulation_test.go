@@ -0,0 +1,85 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package sbom
+
+import (
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildFormula(t *testing.T) {
+	formula := BuildFormula("pkg:golang/example.com/app@v1.0.0?type=module", "example.com/app/cmd/app", map[string]string{
+		"-buildmode":     "exe",
+		"-ldflags":       "-s -w",
+		"-trimpath":      "true",
+		"CGO_ENABLED":    "0",
+		"DefaultGODEBUG": "tlsrsakex=1",
+		"GOAMD64":        "v1",
+		"GOARCH":         "amd64",
+		"GOOS":           "linux",
+		"vcs.revision":   "cafebabe",
+	})
+
+	require.NotNil(t, formula.Workflows)
+	require.Len(t, *formula.Workflows, 1)
+	workflow := (*formula.Workflows)[0]
+
+	require.Equal(t, "pkg:golang/example.com/app@v1.0.0?type=module|go-build:linux/amd64", workflow.BOMRef)
+	require.Equal(t, workflow.BOMRef, workflow.UID)
+	require.Equal(t, []cdx.TaskType{cdx.TaskTypeBuild}, *workflow.TaskTypes)
+	require.Equal(t, []cdx.TaskStep{
+		{
+			Name: "go build",
+			Commands: &[]cdx.TaskCommand{
+				{Executed: `go build -buildmode=exe -ldflags="-s -w" -trimpath example.com/app/cmd/app`},
+			},
+		},
+	}, *workflow.Steps)
+	require.Equal(t, []cdx.TaskInput{
+		{
+			Parameters: &[]cdx.Parameter{
+				{Name: "-buildmode", Value: "exe"},
+				{Name: "-ldflags", Value: "-s -w"},
+				{Name: "-trimpath", Value: "true"},
+				{Name: "DefaultGODEBUG", Value: "tlsrsakex=1"},
+				{Name: "vcs.revision", Value: "cafebabe"},
+			},
+		},
+		{
+			EnvironmentVars: &cdx.EnvironmentVariables{
+				{Property: &cdx.Property{Name: "CGO_ENABLED", Value: "0"}},
+				{Property: &cdx.Property{Name: "GOAMD64", Value: "v1"}},
+				{Property: &cdx.Property{Name: "GOARCH", Value: "amd64"}},
+				{Property: &cdx.Property{Name: "GOOS", Value: "linux"}},
+			},
+		},
+	}, *workflow.Inputs)
+	require.Equal(t, []cdx.TaskOutput{
+		{
+			Type:     cdx.TaskOutputTypeArtifact,
+			Resource: &cdx.ResourceReferenceChoice{Ref: "pkg:golang/example.com/app@v1.0.0?type=module"},
+		},
+	}, *workflow.Outputs)
+}
+
+func TestGoBuildWorkflowRef(t *testing.T) {
+	require.Equal(t, "main|go-build:linux/arm64", GoBuildWorkflowRef("main", "linux", "arm64"))
+	require.Equal(t, "main|go-build", GoBuildWorkflowRef("main", "", ""))
+}
@@ -0,0 +1,384 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+// Package merge combines multiple SBOMs into a single one.
+package merge
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/vuln"
+)
+
+// BOMs merges the given BOMs into a single BOM describing product.
+//
+// The main component of every BOM is nested under product, which becomes the main
+// component of the merged BOM. Metadata properties of a BOM are added to its main component.
+// Other components are deduplicated by their BOM reference,
+// with their nested components and properties being combined. Dependency graphs,
+// compositions and vulnerabilities are combined as well.
+//
+// Because BOM references must be unique within a BOM, a component is only included once,
+// even if it is nested under multiple components (e.g. a package of the main module used by multiple applications).
+func BOMs(product cdx.Component, boms []*cdx.BOM) (*cdx.BOM, error) {
+	m := merger{
+		componentMap:   make(map[string]*mergedComponent),
+		edges:          make(map[string][]string),
+		compositionMap: make(map[cdx.CompositionAggregate]*cdx.Composition),
+		vulnMap:        make(map[string]*mergedVulnerability),
+	}
+
+	for i, bom := range boms {
+		if bom.Metadata == nil || bom.Metadata.Component == nil {
+			return nil, fmt.Errorf("sbom #%d has no main component", i+1)
+		}
+		m.add(*bom)
+	}
+
+	return m.bom(product), nil
+}
+
+type merger struct {
+	applications   []*mergedComponent
+	components     []*mergedComponent
+	componentMap   map[string]*mergedComponent
+	dependants     []string
+	edges          map[string][]string // dependant -> dependencies
+	compositions   []cdx.CompositionAggregate
+	compositionMap map[cdx.CompositionAggregate]*cdx.Composition
+	vulns          []*mergedVulnerability
+	vulnMap        map[string]*mergedVulnerability
+	formulation    []cdx.Formula
+}
+
+type mergedComponent struct {
+	component  cdx.Component
+	children   []*mergedComponent
+	childMap   map[string]*mergedComponent
+	properties []cdx.Property
+}
+
+func (m *merger) add(bom cdx.BOM) {
+	application := newMergedComponent(*bom.Metadata.Component)
+	application.merge(*bom.Metadata.Component)
+	if bom.Metadata.Properties != nil {
+		// Properties of the BOM itself (e.g. of the binary it was generated from)
+		// would otherwise be lost, as the merged BOM has metadata of its own.
+		application.mergeProperties(*bom.Metadata.Properties)
+		sbom.SortProperties(application.properties)
+	}
+	m.applications = append(m.applications, application)
+
+	if bom.Components != nil {
+		for _, component := range *bom.Components {
+			key := componentKey(component)
+			merged, ok := m.componentMap[key]
+			if !ok {
+				merged = newMergedComponent(component)
+				m.componentMap[key] = merged
+				m.components = append(m.components, merged)
+			}
+			merged.merge(component)
+		}
+	}
+
+	if bom.Dependencies != nil {
+		for _, dependency := range *bom.Dependencies {
+			var dependsOn []string
+			if dependency.Dependencies != nil {
+				dependsOn = *dependency.Dependencies
+			}
+			m.addDependencies(dependency.Ref, dependsOn...)
+		}
+	}
+
+	if bom.Compositions != nil {
+		for _, composition := range *bom.Compositions {
+			m.addComposition(composition)
+		}
+	}
+
+	if bom.Vulnerabilities != nil {
+		for _, vulnerability := range *bom.Vulnerabilities {
+			merged, ok := m.vulnMap[vulnerability.ID]
+			if !ok {
+				merged = &mergedVulnerability{vulnerability: vulnerability}
+				m.vulnMap[vulnerability.ID] = merged
+				m.vulns = append(m.vulns, merged)
+			}
+			merged.merge(vulnerability)
+		}
+	}
+
+	if bom.Formulation != nil {
+		m.formulation = append(m.formulation, *bom.Formulation...)
+	}
+}
+
+func (m *merger) addDependencies(ref string, dependsOn ...string) {
+	dependencies, ok := m.edges[ref]
+	if !ok {
+		m.dependants = append(m.dependants, ref)
+	}
+	for _, dependencyRef := range dependsOn {
+		if !slices.Contains(dependencies, dependencyRef) {
+			dependencies = append(dependencies, dependencyRef)
+		}
+	}
+	m.edges[ref] = dependencies
+}
+
+// addComposition merges composition with a previously added composition of the same aggregate.
+// A signature can't be retained, as it would no longer be valid.
+func (m *merger) addComposition(composition cdx.Composition) {
+	merged, ok := m.compositionMap[composition.Aggregate]
+	if !ok {
+		merged = &cdx.Composition{Aggregate: composition.Aggregate}
+		m.compositionMap[composition.Aggregate] = merged
+		m.compositions = append(m.compositions, composition.Aggregate)
+	}
+
+	merged.Assemblies = unionRefs(merged.Assemblies, composition.Assemblies)
+	merged.Dependencies = unionRefs(merged.Dependencies, composition.Dependencies)
+	merged.Vulnerabilities = unionRefs(merged.Vulnerabilities, composition.Vulnerabilities)
+}
+
+func unionRefs(refs, other *[]cdx.BOMReference) *[]cdx.BOMReference {
+	if other == nil {
+		return refs
+	}
+	if refs == nil {
+		refs = &[]cdx.BOMReference{}
+	}
+	for _, ref := range *other {
+		if !slices.Contains(*refs, ref) {
+			*refs = append(*refs, ref)
+		}
+	}
+
+	return refs
+}
+
+// bom assembles the merged BOM.
+func (m *merger) bom(product cdx.Component) *cdx.BOM {
+	seenRefs := map[string]bool{product.BOMRef: true}
+
+	applications := make([]cdx.Component, 0, len(m.applications))
+	applicationRefs := make([]string, 0, len(m.applications))
+	for _, application := range m.applications {
+		if component, ok := application.build(seenRefs); ok {
+			applications = append(applications, component)
+			applicationRefs = append(applicationRefs, component.BOMRef)
+		}
+	}
+	product.Components = &applications
+
+	slices.SortStableFunc(m.components, func(a, b *mergedComponent) int {
+		return strings.Compare(a.component.Name, b.component.Name)
+	})
+
+	components := make([]cdx.Component, 0, len(m.components))
+	for _, component := range m.components {
+		if built, ok := component.build(seenRefs); ok {
+			components = append(components, built)
+		}
+	}
+
+	m.dependants = append([]string{product.BOMRef}, m.dependants...)
+	m.edges[product.BOMRef] = applicationRefs
+
+	dependencies := make([]cdx.Dependency, 0, len(m.dependants))
+	for _, ref := range m.dependants {
+		dependsOn := slices.Clone(m.edges[ref])
+		slices.Sort(dependsOn)
+
+		dependency := cdx.Dependency{Ref: ref}
+		if len(dependsOn) > 0 {
+			dependency.Dependencies = &dependsOn
+		}
+		dependencies = append(dependencies, dependency)
+	}
+
+	bom := cdx.NewBOM()
+	bom.Metadata = &cdx.Metadata{
+		Component: &product,
+	}
+	bom.Components = &components
+	bom.Dependencies = &dependencies
+
+	if len(m.compositions) > 0 {
+		compositions := make([]cdx.Composition, 0, len(m.compositions))
+		for _, aggregate := range m.compositions {
+			compositions = append(compositions, *m.compositionMap[aggregate])
+		}
+		bom.Compositions = &compositions
+	}
+
+	if len(m.vulns) > 0 {
+		slices.SortStableFunc(m.vulns, func(a, b *mergedVulnerability) int {
+			return strings.Compare(a.vulnerability.ID, b.vulnerability.ID)
+		})
+
+		vulnerabilities := make([]cdx.Vulnerability, 0, len(m.vulns))
+		for _, vulnerability := range m.vulns {
+			vulnerabilities = append(vulnerabilities, vulnerability.build())
+		}
+		bom.Vulnerabilities = &vulnerabilities
+	}
+
+	if len(m.formulation) > 0 {
+		bom.Formulation = &m.formulation
+	}
+
+	return bom
+}
+
+func newMergedComponent(component cdx.Component) *mergedComponent {
+	return &mergedComponent{
+		component: component,
+		childMap:  make(map[string]*mergedComponent),
+	}
+}
+
+// merge adds the properties and nested components of component that are not yet known.
+func (c *mergedComponent) merge(component cdx.Component) {
+	if component.Properties != nil {
+		c.mergeProperties(*component.Properties)
+	}
+
+	if component.Components == nil {
+		return
+	}
+
+	for _, child := range *component.Components {
+		key := componentKey(child)
+		merged, ok := c.childMap[key]
+		if !ok {
+			merged = newMergedComponent(child)
+			c.childMap[key] = merged
+			c.children = append(c.children, merged)
+		}
+		merged.merge(child)
+	}
+}
+
+func (c *mergedComponent) mergeProperties(properties []cdx.Property) {
+	for _, property := range properties {
+		if !slices.Contains(c.properties, property) {
+			c.properties = append(c.properties, property)
+		}
+	}
+}
+
+// build returns the merged component, including all of its (merged) children.
+// Components of which the BOM reference has already been seen are omitted.
+func (c *mergedComponent) build(seenRefs map[string]bool) (cdx.Component, bool) {
+	component := c.component
+	if component.BOMRef != "" {
+		if seenRefs[component.BOMRef] {
+			return cdx.Component{}, false
+		}
+		seenRefs[component.BOMRef] = true
+	}
+
+	component.Properties = nil
+	if len(c.properties) > 0 {
+		properties := slices.Clone(c.properties)
+		component.Properties = &properties
+	}
+
+	component.Components = nil
+	if len(c.children) > 0 {
+		slices.SortStableFunc(c.children, func(a, b *mergedComponent) int {
+			return strings.Compare(a.component.Name, b.component.Name)
+		})
+
+		children := make([]cdx.Component, 0, len(c.children))
+		for _, child := range c.children {
+			if built, ok := child.build(seenRefs); ok {
+				children = append(children, built)
+			}
+		}
+		if len(children) > 0 {
+			component.Components = &children
+		}
+	}
+
+	return component, true
+}
+
+type mergedVulnerability struct {
+	vulnerability cdx.Vulnerability
+	affects       []cdx.Affects
+	properties    []cdx.Property
+	affected      bool // whether the vulnerability affects any of the applications
+}
+
+func (v *mergedVulnerability) merge(vulnerability cdx.Vulnerability) {
+	if vulnerability.Affects != nil {
+		for _, affects := range *vulnerability.Affects {
+			if !slices.ContainsFunc(v.affects, func(a cdx.Affects) bool { return a.Ref == affects.Ref }) {
+				v.affects = append(v.affects, affects)
+			}
+		}
+	}
+	if vulnerability.Properties != nil {
+		v.properties = append(v.properties, *vulnerability.Properties...)
+	}
+	if vulnerability.Analysis == nil {
+		v.affected = true
+	}
+}
+
+// build returns the merged vulnerability. It's only considered not to
+// affect the product, if that's the case for all applications.
+func (v *mergedVulnerability) build() cdx.Vulnerability {
+	vulnerability := v.vulnerability
+
+	properties := slices.Clone(v.properties)
+	if v.affected {
+		properties = slices.DeleteFunc(properties, func(property cdx.Property) bool {
+			return property == sbom.NewProperty(vuln.PropertyImported, "false")
+		})
+		vulnerability.Analysis = nil
+	}
+	sbom.SortProperties(properties)
+	properties = slices.Compact(properties)
+	if len(properties) > 0 {
+		vulnerability.Properties = &properties
+	}
+
+	affects := slices.Clone(v.affects)
+	vulnerability.Affects = &affects
+
+	return vulnerability
+}
+
+// componentKey returns the key by which components are identified when merging.
+// Packages and files may not have a BOM reference, but their names are unique within their parent.
+func componentKey(component cdx.Component) string {
+	if component.BOMRef != "" {
+		return component.BOMRef
+	}
+
+	return component.Name
+}
@@ -0,0 +1,193 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package merge
+
+import (
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBOMs(t *testing.T) {
+	product := cdx.Component{BOMRef: "acme@v1.0.0", Type: cdx.ComponentTypeApplication, Name: "acme", Version: "v1.0.0"}
+
+	client := &cdx.BOM{
+		Metadata: &cdx.Metadata{
+			Component: &cdx.Component{
+				BOMRef: "pkg:golang/acme@v1.0.0?type=module#cmd/client",
+				Name:   "acme",
+				Components: &[]cdx.Component{
+					{BOMRef: "pkg:golang/acme/internal/shared@v1.0.0?type=package", Name: "acme/internal/shared"},
+					{BOMRef: "pkg:golang/acme/cmd/client@v1.0.0?type=package", Name: "acme/cmd/client"},
+				},
+			},
+		},
+		Components: &[]cdx.Component{
+			{
+				BOMRef:     "pkg:golang/a@v1.0.0?type=module",
+				Name:       "a",
+				Properties: &[]cdx.Property{{Name: "cdx:gomod:build:platform", Value: "linux/amd64"}},
+				Components: &[]cdx.Component{{BOMRef: "pkg:golang/a/foo@v1.0.0?type=package", Name: "a/foo"}},
+			},
+		},
+		Dependencies: &[]cdx.Dependency{
+			{Ref: "pkg:golang/acme@v1.0.0?type=module#cmd/client", Dependencies: &[]string{"pkg:golang/a@v1.0.0?type=module"}},
+			{Ref: "pkg:golang/a@v1.0.0?type=module"},
+		},
+		Compositions: &[]cdx.Composition{
+			{Aggregate: cdx.CompositionAggregateComplete, Dependencies: &[]cdx.BOMReference{"pkg:golang/acme@v1.0.0?type=module#cmd/client"}},
+		},
+		Vulnerabilities: &[]cdx.Vulnerability{
+			{
+				ID:         "GO-0000-0001",
+				Affects:    &[]cdx.Affects{{Ref: "pkg:golang/a@v1.0.0?type=module"}},
+				Properties: &[]cdx.Property{{Name: "cdx:gomod:vuln:imported", Value: "false"}},
+				Analysis:   &cdx.VulnerabilityAnalysis{State: cdx.IASNotAffected},
+			},
+		},
+	}
+
+	server := &cdx.BOM{
+		Metadata: &cdx.Metadata{
+			Component: &cdx.Component{
+				BOMRef: "pkg:golang/acme@v1.0.0?type=module#cmd/server",
+				Name:   "acme",
+				Components: &[]cdx.Component{
+					{BOMRef: "pkg:golang/acme/internal/shared@v1.0.0?type=package", Name: "acme/internal/shared"},
+					{BOMRef: "pkg:golang/acme/cmd/server@v1.0.0?type=package", Name: "acme/cmd/server"},
+				},
+				Properties: &[]cdx.Property{{Name: "cdx:gomod:build:env:GOOS", Value: "linux"}},
+			},
+			Properties: &[]cdx.Property{{Name: "cdx:gomod:binary:name", Value: "server"}},
+		},
+		Components: &[]cdx.Component{
+			{
+				BOMRef:     "pkg:golang/a@v1.0.0?type=module",
+				Name:       "a",
+				Properties: &[]cdx.Property{{Name: "cdx:gomod:build:platform", Value: "linux/arm64"}},
+				Components: &[]cdx.Component{{BOMRef: "pkg:golang/a/bar@v1.0.0?type=package", Name: "a/bar"}},
+			},
+			{BOMRef: "pkg:golang/b@v1.0.0?type=module", Name: "b"},
+		},
+		Dependencies: &[]cdx.Dependency{
+			{Ref: "pkg:golang/acme@v1.0.0?type=module#cmd/server", Dependencies: &[]string{"pkg:golang/a@v1.0.0?type=module"}},
+			{Ref: "pkg:golang/a@v1.0.0?type=module", Dependencies: &[]string{"pkg:golang/b@v1.0.0?type=module"}},
+			{Ref: "pkg:golang/b@v1.0.0?type=module"},
+		},
+		Compositions: &[]cdx.Composition{
+			{Aggregate: cdx.CompositionAggregateComplete, Dependencies: &[]cdx.BOMReference{"pkg:golang/acme@v1.0.0?type=module#cmd/server"}},
+			{Aggregate: cdx.CompositionAggregateUnknown, Dependencies: &[]cdx.BOMReference{"pkg:golang/b@v1.0.0?type=module"}},
+		},
+		Vulnerabilities: &[]cdx.Vulnerability{
+			{
+				ID:         "GO-0000-0001",
+				Affects:    &[]cdx.Affects{{Ref: "pkg:golang/a@v1.0.0?type=module"}},
+				Properties: &[]cdx.Property{{Name: "cdx:gomod:vuln:imported", Value: "true"}},
+			},
+		},
+	}
+
+	bom, err := BOMs(product, []*cdx.BOM{client, server})
+	require.NoError(t, err)
+
+	t.Run("Product", func(t *testing.T) {
+		require.NotNil(t, bom.Metadata)
+		require.NotNil(t, bom.Metadata.Component)
+		assert.Equal(t, "acme@v1.0.0", bom.Metadata.Component.BOMRef)
+
+		require.NotNil(t, bom.Metadata.Component.Components)
+		applications := *bom.Metadata.Component.Components
+		require.Len(t, applications, 2)
+		assert.Equal(t, "pkg:golang/acme@v1.0.0?type=module#cmd/client", applications[0].BOMRef)
+		assert.Equal(t, []cdx.Component{
+			{BOMRef: "pkg:golang/acme/cmd/client@v1.0.0?type=package", Name: "acme/cmd/client"},
+			{BOMRef: "pkg:golang/acme/internal/shared@v1.0.0?type=package", Name: "acme/internal/shared"},
+		}, *applications[0].Components)
+		assert.Equal(t, "pkg:golang/acme@v1.0.0?type=module#cmd/server", applications[1].BOMRef)
+		assert.Equal(t, []cdx.Component{
+			{BOMRef: "pkg:golang/acme/cmd/server@v1.0.0?type=package", Name: "acme/cmd/server"},
+		}, *applications[1].Components, "shared package must only be included once")
+		assert.Equal(t, []cdx.Property{
+			{Name: "cdx:gomod:binary:name", Value: "server"},
+			{Name: "cdx:gomod:build:env:GOOS", Value: "linux"},
+		}, *applications[1].Properties, "metadata properties must be retained")
+	})
+
+	t.Run("Components", func(t *testing.T) {
+		require.NotNil(t, bom.Components)
+		require.Len(t, *bom.Components, 2)
+
+		a := (*bom.Components)[0]
+		assert.Equal(t, "pkg:golang/a@v1.0.0?type=module", a.BOMRef)
+		assert.Equal(t, []cdx.Property{
+			{Name: "cdx:gomod:build:platform", Value: "linux/amd64"},
+			{Name: "cdx:gomod:build:platform", Value: "linux/arm64"},
+		}, *a.Properties)
+		require.NotNil(t, a.Components)
+		require.Len(t, *a.Components, 2)
+		assert.Equal(t, "a/bar", (*a.Components)[0].Name)
+		assert.Equal(t, "a/foo", (*a.Components)[1].Name)
+
+		assert.Equal(t, "pkg:golang/b@v1.0.0?type=module", (*bom.Components)[1].BOMRef)
+	})
+
+	t.Run("Dependencies", func(t *testing.T) {
+		require.NotNil(t, bom.Dependencies)
+		assert.Equal(t, []cdx.Dependency{
+			{Ref: "acme@v1.0.0", Dependencies: &[]string{"pkg:golang/acme@v1.0.0?type=module#cmd/client", "pkg:golang/acme@v1.0.0?type=module#cmd/server"}},
+			{Ref: "pkg:golang/acme@v1.0.0?type=module#cmd/client", Dependencies: &[]string{"pkg:golang/a@v1.0.0?type=module"}},
+			{Ref: "pkg:golang/a@v1.0.0?type=module", Dependencies: &[]string{"pkg:golang/b@v1.0.0?type=module"}},
+			{Ref: "pkg:golang/acme@v1.0.0?type=module#cmd/server", Dependencies: &[]string{"pkg:golang/a@v1.0.0?type=module"}},
+			{Ref: "pkg:golang/b@v1.0.0?type=module"},
+		}, *bom.Dependencies)
+	})
+
+	t.Run("Compositions", func(t *testing.T) {
+		require.NotNil(t, bom.Compositions)
+		assert.Equal(t, []cdx.Composition{
+			{
+				Aggregate: cdx.CompositionAggregateComplete,
+				Dependencies: &[]cdx.BOMReference{
+					"pkg:golang/acme@v1.0.0?type=module#cmd/client",
+					"pkg:golang/acme@v1.0.0?type=module#cmd/server",
+				},
+			},
+			{
+				Aggregate:    cdx.CompositionAggregateUnknown,
+				Dependencies: &[]cdx.BOMReference{"pkg:golang/b@v1.0.0?type=module"},
+			},
+		}, *bom.Compositions)
+	})
+
+	t.Run("Vulnerabilities", func(t *testing.T) {
+		require.NotNil(t, bom.Vulnerabilities)
+		require.Len(t, *bom.Vulnerabilities, 1)
+
+		vulnerability := (*bom.Vulnerabilities)[0]
+		assert.Nil(t, vulnerability.Analysis, "vulnerability affects the server")
+		assert.Equal(t, []cdx.Property{{Name: "cdx:gomod:vuln:imported", Value: "true"}}, *vulnerability.Properties)
+		assert.Equal(t, []cdx.Affects{{Ref: "pkg:golang/a@v1.0.0?type=module"}}, *vulnerability.Affects)
+	})
+
+	t.Run("NoMainComponent", func(t *testing.T) {
+		_, err := BOMs(product, []*cdx.BOM{client, {}})
+		require.EqualError(t, err, "sbom #2 has no main component")
+	})
+}
@@ -0,0 +1,472 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+// Package policy evaluates the licenses of SBOM components against a license policy.
+//
+// Policies are maintained in a YAML file:
+//
+//	deny:
+//	  - AGPL-*
+//	  - GPL-*
+//	warn:
+//	  - MPL-2.0
+//	allow:
+//	  - Apache-2.0
+//	  - BSD-*
+//	  - MIT
+//	unknown: deny
+//	exceptions:
+//	  - module: github.com/acme/foo
+//	    versions: "< v2.0.0"
+//	    licenses:
+//	      - GPL-3.0-only
+//	    comment: Only used internally
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"gopkg.in/yaml.v3"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/licenseexpr"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
+	modConv "github.com/CycloneDX/cyclonedx-gomod/internal/sbom/convert/module"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/curation"
+)
+
+// Decision is the outcome of evaluating a license against a policy.
+// Decisions are ordered from least to most severe.
+type Decision int
+
+const (
+	Allow Decision = iota
+	Warn
+	Deny
+)
+
+// String implements the fmt.Stringer interface.
+func (d Decision) String() string {
+	switch d {
+	case Allow:
+		return "allow"
+	case Warn:
+		return "warn"
+	case Deny:
+		return "deny"
+	}
+
+	return fmt.Sprintf("Decision(%d)", int(d))
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (d *Decision) UnmarshalYAML(node *yaml.Node) error {
+	switch strings.ToLower(node.Value) {
+	case "allow":
+		*d = Allow
+	case "warn":
+		*d = Warn
+	case "deny":
+		*d = Deny
+	default:
+		return fmt.Errorf("line %d: invalid decision %q (expected allow, warn or deny)", node.Line, node.Value)
+	}
+
+	return nil
+}
+
+// Policy determines which licenses are acceptable.
+//
+// Entries of the Allow, Deny and Warn lists are SPDX license identifiers, optionally
+// with an exception (e.g. "GPL-2.0-only WITH Classpath-exception-2.0"). Identifiers
+// are matched case-insensitively and may contain glob patterns as supported by path.Match.
+// Entries with an exception take precedence over those without. Otherwise, Deny takes
+// precedence over Warn, which in turn takes precedence over Allow.
+type Policy struct {
+	// Allow lists licenses that are acceptable. When not empty,
+	// licenses that are not listed in any list are denied.
+	Allow []string `yaml:"allow,omitempty"`
+
+	// Deny lists licenses that are not acceptable.
+	Deny []string `yaml:"deny,omitempty"`
+
+	// Warn lists licenses that are acceptable, but should be reviewed.
+	Warn []string `yaml:"warn,omitempty"`
+
+	// Unknown is the decision for components without any license. Default is Warn.
+	Unknown *Decision `yaml:"unknown,omitempty"`
+
+	// Exceptions allow licenses for specific modules, regardless of the lists above.
+	Exceptions []Exception `yaml:"exceptions,omitempty"`
+}
+
+// Exception allows licenses for specific modules.
+type Exception struct {
+	// Module is a comma-separated list of glob patterns, matching module path prefixes.
+	// The syntax is the same as that of curation.Curation.
+	Module string `yaml:"module"`
+
+	// Versions optionally restricts the exception to a range of module versions.
+	// The syntax is the same as that of curation.Curation.
+	Versions string `yaml:"versions,omitempty"`
+
+	// Licenses lists the licenses that are allowed for matching modules, using the
+	// same syntax as the lists of Policy. When empty, all licenses are allowed.
+	Licenses []string `yaml:"licenses,omitempty"`
+
+	// Comment optionally explains the exception.
+	Comment string `yaml:"comment,omitempty"`
+}
+
+// Load reads a policy from the file at path.
+func Load(path string) (*Policy, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	policy, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return policy, nil
+}
+
+// Parse reads a policy from r and validates it.
+func Parse(r io.Reader) (*Policy, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var policy Policy
+	if err = decoder.Decode(&policy); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if err = policy.validate(); err != nil {
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+func (p Policy) validate() error {
+	seen := make(map[string]string)
+	for _, list := range []struct {
+		name    string
+		entries []string
+	}{
+		{"allow", p.Allow},
+		{"deny", p.Deny},
+		{"warn", p.Warn},
+	} {
+		for _, entry := range list.entries {
+			if _, err := parseEntry(entry); err != nil {
+				return fmt.Errorf("%s: %w", list.name, err)
+			}
+
+			parsed, _ := parseEntry(entry)
+			key := strings.ToLower(parsed.String())
+			if other, ok := seen[key]; ok {
+				return fmt.Errorf("%s: %q is already listed in %s", list.name, entry, other)
+			}
+			seen[key] = list.name
+		}
+	}
+
+	for i, exception := range p.Exceptions {
+		if strings.TrimSpace(exception.Module) == "" {
+			return fmt.Errorf("exception #%d: module is missing", i+1)
+		}
+		if err := curation.ValidateVersions(exception.Versions); err != nil {
+			return fmt.Errorf("exception #%d: %w", i+1, err)
+		}
+		for _, entry := range exception.Licenses {
+			if _, err := parseEntry(entry); err != nil {
+				return fmt.Errorf("exception #%d: %w", i+1, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseEntry parses a list entry, which must be a single license identifier or pattern,
+// optionally with an exception.
+func parseEntry(entry string) (licenseexpr.License, error) {
+	var license licenseexpr.License
+
+	fields := strings.Fields(entry)
+	switch {
+	case len(fields) == 1:
+		license.ID = fields[0]
+	case len(fields) == 3 && strings.EqualFold(fields[1], "WITH"):
+		license.ID, license.Exception = fields[0], fields[2]
+	default:
+		return licenseexpr.License{}, fmt.Errorf("%q is not a single license", entry)
+	}
+
+	if _, err := path.Match(license.ID, ""); err != nil {
+		return licenseexpr.License{}, fmt.Errorf("invalid pattern %q: %w", entry, err)
+	}
+
+	return license, nil
+}
+
+// matchEntries checks whether any of entries matches license.
+// When withException is true, only entries with an exception are considered,
+// otherwise only entries without one.
+func matchEntries(entries []string, license licenseexpr.License, withException bool) bool {
+	for _, entry := range entries {
+		parsed, err := parseEntry(entry)
+		if err != nil || (parsed.Exception != "") != withException {
+			continue
+		}
+		if withException && !strings.EqualFold(parsed.Exception, license.Exception) {
+			continue
+		}
+		if matched, _ := path.Match(strings.ToLower(parsed.ID), strings.ToLower(license.ID)); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Finding describes a component of which the licenses violate the policy, or require a review.
+type Finding struct {
+	Name     string
+	Version  string
+	Licenses string   // Evaluated licenses, as SPDX license expression
+	Reasons  []string // Licenses that caused the finding, and why
+}
+
+// Result holds the findings of a policy evaluation.
+type Result struct {
+	Violations []Finding
+	Warnings   []Finding
+}
+
+// Evaluate evaluates the licenses of all components of bom against the policy.
+//
+// Only top-level components, i.e. modules, are considered. Packages and files share
+// the licenses of their module, and the main component is the subject of the SBOM itself.
+// The concluded or asserted licenses of a component take precedence over its detected licenses.
+// Multiple licenses of a component all apply, unless they're combined in an expression.
+func (p Policy) Evaluate(bom *cdx.BOM) Result {
+	var result Result
+	if bom == nil || bom.Components == nil {
+		return result
+	}
+
+	for _, component := range *bom.Components {
+		decision, finding := p.evaluateComponent(component)
+		switch decision {
+		case Deny:
+			result.Violations = append(result.Violations, finding)
+		case Warn:
+			result.Warnings = append(result.Warnings, finding)
+		}
+	}
+
+	return result
+}
+
+func (p Policy) evaluateComponent(component cdx.Component) (Decision, Finding) {
+	finding := Finding{
+		Name:    component.Name,
+		Version: component.Version,
+	}
+
+	exceptions := p.exceptionsFor(component)
+
+	expression, err := componentLicenses(component)
+	if err != nil {
+		finding.Reasons = []string{err.Error()}
+		return Deny, finding
+	}
+	if expression == nil {
+		for _, exception := range exceptions {
+			if len(exception.Licenses) == 0 {
+				return Allow, finding
+			}
+		}
+
+		decision := Warn
+		if p.Unknown != nil {
+			decision = *p.Unknown
+		}
+		finding.Reasons = []string{"no license"}
+		return decision, finding
+	}
+
+	finding.Licenses = expression.String()
+
+	var decision Decision
+	decision, finding.Reasons = p.evaluateExpression(expression, exceptions)
+
+	return decision, finding
+}
+
+var replacesPropertyName = sbom.NewProperty(modConv.PropertyReplaces, "").Name
+
+// exceptionsFor returns the exceptions that apply to component.
+// Exceptions for replaced modules apply to their replacements as well.
+func (p Policy) exceptionsFor(component cdx.Component) []Exception {
+	var exceptions []Exception
+	for _, exception := range p.Exceptions {
+		if curation.MatchModule(exception.Module, exception.Versions, component.Name, component.Version) {
+			exceptions = append(exceptions, exception)
+			continue
+		}
+		if component.Properties == nil {
+			continue
+		}
+		for _, property := range *component.Properties {
+			if property.Name != replacesPropertyName {
+				continue
+			}
+			replacedPath, replacedVersion, _ := strings.Cut(property.Value, "@")
+			if curation.MatchModule(exception.Module, exception.Versions, replacedPath, replacedVersion) {
+				exceptions = append(exceptions, exception)
+			}
+		}
+	}
+
+	return exceptions
+}
+
+func (p Policy) evaluateExpression(expression licenseexpr.Expression, exceptions []Exception) (Decision, []string) {
+	switch e := expression.(type) {
+	case licenseexpr.License:
+		return p.evaluateLicense(e, exceptions)
+	case licenseexpr.And:
+		// All licenses apply, so the most severe decision counts
+		decision, reasons := Allow, []string{}
+		for _, operand := range e {
+			operandDecision, operandReasons := p.evaluateExpression(operand, exceptions)
+			decision = max(decision, operandDecision)
+			reasons = append(reasons, operandReasons...)
+		}
+		return decision, reasons
+	case licenseexpr.Or:
+		// Any of the licenses may be chosen, so the least severe decision counts
+		decision, reasons := Deny, []string{}
+		for i, operand := range e {
+			operandDecision, operandReasons := p.evaluateExpression(operand, exceptions)
+			if i == 0 || operandDecision < decision {
+				decision, reasons = operandDecision, operandReasons
+			}
+		}
+		return decision, reasons
+	}
+
+	return Deny, []string{fmt.Sprintf("%s: unsupported expression", expression)}
+}
+
+func (p Policy) evaluateLicense(license licenseexpr.License, exceptions []Exception) (Decision, []string) {
+	for _, exception := range exceptions {
+		if len(exception.Licenses) == 0 ||
+			matchEntries(exception.Licenses, license, true) ||
+			matchEntries(exception.Licenses, license, false) {
+			return Allow, nil
+		}
+	}
+
+	// Entries with an exception are more specific, so they're considered first
+	for _, withException := range []bool{true, false} {
+		if withException && license.Exception == "" {
+			continue
+		}
+		switch {
+		case matchEntries(p.Deny, license, withException):
+			return Deny, []string{license.String() + " is denied"}
+		case matchEntries(p.Warn, license, withException):
+			return Warn, []string{license.String() + " requires review"}
+		case matchEntries(p.Allow, license, withException):
+			return Allow, nil
+		}
+	}
+
+	if len(p.Allow) > 0 {
+		return Deny, []string{license.String() + " is not allowed"}
+	}
+
+	return Allow, nil
+}
+
+// componentLicenses returns the licenses of component as a single expression,
+// or nil if the component has no licenses. Expressions that detected licenses
+// were combined into take precedence over the license evidence.
+func componentLicenses(component cdx.Component) (licenseexpr.Expression, error) {
+	choices := component.Licenses
+	if choices == nil {
+		if expression := sbom.LicenseExpression(component); expression != "" {
+			return licenseexpr.Parse(expression)
+		}
+	}
+	if choices == nil && component.Evidence != nil {
+		choices = component.Evidence.Licenses
+	}
+	if choices == nil {
+		return nil, nil
+	}
+
+	var expressions licenseexpr.And
+	for _, choice := range *choices {
+		switch {
+		case choice.Expression != "":
+			expression, err := licenseexpr.Parse(choice.Expression)
+			if err != nil {
+				return nil, err
+			}
+			expressions = append(expressions, expression)
+		case choice.License != nil && choice.License.ID != "":
+			expressions = append(expressions, licenseexpr.License{ID: choice.License.ID})
+		case choice.License != nil && choice.License.Name != "":
+			// Names are not SPDX identifiers, but may still be listed in the policy
+			expressions = append(expressions, licenseexpr.License{ID: choice.License.Name})
+		}
+	}
+
+	// The same license is commonly detected in multiple files
+	expressions = slices.CompactFunc(expressions, func(a, b licenseexpr.Expression) bool {
+		return a.String() == b.String()
+	})
+
+	switch len(expressions) {
+	case 0:
+		return nil, nil
+	case 1:
+		return expressions[0], nil
+	}
+
+	return expressions, nil
+}
@@ -0,0 +1,219 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		policy, err := Parse(strings.NewReader(`
+deny: [GPL-*]
+warn: [MPL-2.0]
+allow: [MIT, GPL-2.0-only WITH Classpath-exception-2.0]
+unknown: deny
+exceptions:
+  - module: github.com/acme/foo
+    versions: "< v2.0.0"
+    licenses: [GPL-3.0-only]
+    comment: Only used internally
+`))
+		require.NoError(t, err)
+		require.Equal(t, []string{"GPL-*"}, policy.Deny)
+		require.Equal(t, []string{"MPL-2.0"}, policy.Warn)
+		require.Equal(t, []string{"MIT", "GPL-2.0-only WITH Classpath-exception-2.0"}, policy.Allow)
+		require.NotNil(t, policy.Unknown)
+		require.Equal(t, Deny, *policy.Unknown)
+		require.Equal(t, []Exception{
+			{
+				Module:   "github.com/acme/foo",
+				Versions: "< v2.0.0",
+				Licenses: []string{"GPL-3.0-only"},
+				Comment:  "Only used internally",
+			},
+		}, policy.Exceptions)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		policy, err := Parse(strings.NewReader(""))
+		require.NoError(t, err)
+		require.Empty(t, policy.Allow)
+		require.Nil(t, policy.Unknown)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		testCases := map[string]string{
+			"Expression":         "deny: [MIT OR Apache-2.0]\n",
+			"Pattern":            "deny: [\"GPL-[\"]\n",
+			"Duplicate":          "deny: [MIT]\nallow: [mit]\n",
+			"Unknown":            "unknown: maybe\n",
+			"MissingModule":      "exceptions:\n  - licenses: [MIT]\n",
+			"InvalidVersions":    "exceptions:\n  - module: github.com/acme/foo\n    versions: ~v1.2.0\n",
+			"InvalidException":   "exceptions:\n  - module: github.com/acme/foo\n    licenses: [MIT AND Apache-2.0]\n",
+			"UnknownField":       "denied: [MIT]\n",
+			"UnknownNestedField": "exceptions:\n  - module: github.com/acme/foo\n    license: MIT\n",
+		}
+
+		for name, input := range testCases {
+			t.Run(name, func(t *testing.T) {
+				_, err := Parse(strings.NewReader(input))
+				require.Error(t, err)
+			})
+		}
+	})
+}
+
+func component(name, version string, licenses ...cdx.LicenseChoice) cdx.Component {
+	c := cdx.Component{
+		Type:    cdx.ComponentTypeLibrary,
+		Name:    name,
+		Version: version,
+	}
+	if len(licenses) > 0 {
+		c.Licenses = (*cdx.Licenses)(&licenses)
+	}
+
+	return c
+}
+
+func license(id string) cdx.LicenseChoice {
+	return cdx.LicenseChoice{License: &cdx.License{ID: id}}
+}
+
+func expression(expression string) cdx.LicenseChoice {
+	return cdx.LicenseChoice{Expression: expression}
+}
+
+func TestPolicy_Evaluate(t *testing.T) {
+	policy := Policy{
+		Allow: []string{"Apache-2.0", "BSD-*", "MIT", "GPL-2.0-only WITH Classpath-exception-2.0"},
+		Deny:  []string{"AGPL-*", "GPL-*"},
+		Warn:  []string{"MPL-2.0"},
+		Exceptions: []Exception{
+			{Module: "github.com/acme/internal", Licenses: []string{"AGPL-3.0-only"}},
+			{Module: "github.com/acme/legacy", Versions: "< v2.0.0"},
+		},
+	}
+
+	testCases := []struct {
+		name       string
+		component  cdx.Component
+		decision   Decision
+		licenses   string
+		reasonsLen int
+	}{
+		{"Allowed", component("a", "v1.0.0", license("MIT")), Allow, "MIT", 0},
+		{"AllowedPattern", component("a", "v1.0.0", license("BSD-3-Clause")), Allow, "BSD-3-Clause", 0},
+		{"AllowedCaseInsensitive", component("a", "v1.0.0", license("mit")), Allow, "mit", 0},
+		{"Denied", component("a", "v1.0.0", license("GPL-3.0-only")), Deny, "GPL-3.0-only", 1},
+		{"Warned", component("a", "v1.0.0", license("MPL-2.0")), Warn, "MPL-2.0", 1},
+		{"NotAllowed", component("a", "v1.0.0", license("ISC")), Deny, "ISC", 1},
+		{"WithException", component("a", "v1.0.0", expression("GPL-2.0-only WITH Classpath-exception-2.0")), Allow, "GPL-2.0-only WITH Classpath-exception-2.0", 0},
+		{"WithOtherException", component("a", "v1.0.0", expression("GPL-2.0-only WITH GCC-exception-2.0")), Deny, "GPL-2.0-only WITH GCC-exception-2.0", 1},
+		{"Or", component("a", "v1.0.0", expression("GPL-3.0-only OR MIT")), Allow, "GPL-3.0-only OR MIT", 0},
+		{"OrWarned", component("a", "v1.0.0", expression("GPL-3.0-only OR MPL-2.0")), Warn, "GPL-3.0-only OR MPL-2.0", 1},
+		{"And", component("a", "v1.0.0", expression("GPL-3.0-only AND MIT")), Deny, "GPL-3.0-only AND MIT", 1},
+		{"AndWarned", component("a", "v1.0.0", expression("MIT AND MPL-2.0")), Warn, "MIT AND MPL-2.0", 1},
+		{"Nested", component("a", "v1.0.0", expression("(GPL-3.0-only OR MIT) AND (AGPL-3.0-only OR MPL-2.0)")), Warn, "(GPL-3.0-only OR MIT) AND (AGPL-3.0-only OR MPL-2.0)", 1},
+		{"MultipleLicenses", component("a", "v1.0.0", license("MIT"), license("GPL-3.0-only")), Deny, "MIT AND GPL-3.0-only", 1},
+		{"DuplicateLicenses", component("a", "v1.0.0", license("MIT"), license("MIT")), Allow, "MIT", 0},
+		{"InvalidExpression", component("a", "v1.0.0", expression("MIT OR")), Deny, "", 1},
+		{"Unknown", component("a", "v1.0.0"), Warn, "", 1},
+		{"Exception", component("github.com/acme/internal", "v1.0.0", license("AGPL-3.0-only")), Allow, "AGPL-3.0-only", 0},
+		{"ExceptionOtherLicense", component("github.com/acme/internal", "v1.0.0", license("GPL-3.0-only")), Deny, "GPL-3.0-only", 1},
+		{"ExceptionAllLicenses", component("github.com/acme/legacy", "v1.5.0", license("GPL-3.0-only")), Allow, "GPL-3.0-only", 0},
+		{"ExceptionAllLicensesUnknown", component("github.com/acme/legacy", "v1.5.0"), Allow, "", 0},
+		{"ExceptionVersionMismatch", component("github.com/acme/legacy", "v2.0.0", license("GPL-3.0-only")), Deny, "GPL-3.0-only", 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			decision, finding := policy.evaluateComponent(tc.component)
+			require.Equal(t, tc.decision, decision)
+			require.Equal(t, tc.licenses, finding.Licenses)
+			require.Len(t, finding.Reasons, tc.reasonsLen)
+		})
+	}
+
+	t.Run("Evidence", func(t *testing.T) {
+		c := component("a", "v1.0.0")
+		c.Evidence = &cdx.Evidence{Licenses: &cdx.Licenses{license("GPL-3.0-only"), license("MIT")}}
+
+		decision, finding := policy.evaluateComponent(c)
+		require.Equal(t, Deny, decision)
+		require.Equal(t, "GPL-3.0-only AND MIT", finding.Licenses)
+	})
+
+	t.Run("ConcludedOverEvidence", func(t *testing.T) {
+		c := component("a", "v1.0.0", expression("GPL-3.0-only OR MIT"))
+		c.Evidence = &cdx.Evidence{Licenses: &cdx.Licenses{license("GPL-3.0-only"), license("MIT")}}
+
+		decision, _ := policy.evaluateComponent(c)
+		require.Equal(t, Allow, decision)
+	})
+
+	t.Run("DetectedExpressionOverEvidence", func(t *testing.T) {
+		c := component("a", "v1.0.0")
+		c.Evidence = &cdx.Evidence{Licenses: &cdx.Licenses{license("GPL-3.0-only"), license("MIT")}}
+		c.Properties = &[]cdx.Property{{Name: "cdx:gomod:license:expression", Value: "GPL-3.0-only OR MIT"}}
+
+		decision, finding := policy.evaluateComponent(c)
+		require.Equal(t, Allow, decision)
+		require.Equal(t, "GPL-3.0-only OR MIT", finding.Licenses)
+	})
+
+	t.Run("ExceptionForReplacedModule", func(t *testing.T) {
+		c := component("github.com/fork/internal", "v1.0.0", license("AGPL-3.0-only"))
+		c.Properties = &[]cdx.Property{{Name: "cdx:gomod:module:replaces", Value: "github.com/acme/internal@v1.0.0"}}
+
+		decision, _ := policy.evaluateComponent(c)
+		require.Equal(t, Allow, decision)
+	})
+
+	t.Run("NoAllowList", func(t *testing.T) {
+		decision, _ := Policy{Deny: []string{"GPL-*"}}.evaluateComponent(component("a", "v1.0.0", license("ISC")))
+		require.Equal(t, Allow, decision)
+	})
+
+	t.Run("UnknownDenied", func(t *testing.T) {
+		unknown := Deny
+		decision, _ := Policy{Unknown: &unknown}.evaluateComponent(component("a", "v1.0.0"))
+		require.Equal(t, Deny, decision)
+	})
+
+	t.Run("BOM", func(t *testing.T) {
+		main := component("main", "v1.0.0", license("GPL-3.0-only"))
+		bom := &cdx.BOM{
+			Metadata: &cdx.Metadata{Component: &main},
+			Components: &[]cdx.Component{
+				component("a", "v1.0.0", license("MIT")),
+				component("b", "v1.0.0", license("GPL-3.0-only")),
+				component("c", "v1.0.0", license("MPL-2.0")),
+			},
+		}
+
+		result := policy.Evaluate(bom)
+		require.Equal(t, []Finding{{Name: "b", Version: "v1.0.0", Licenses: "GPL-3.0-only", Reasons: []string{"GPL-3.0-only is denied"}}}, result.Violations)
+		require.Equal(t, []Finding{{Name: "c", Version: "v1.0.0", Licenses: "MPL-2.0", Reasons: []string{"MPL-2.0 requires review"}}}, result.Warnings)
+	})
+}
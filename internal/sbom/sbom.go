@@ -26,11 +26,14 @@ import (
 	"fmt"
 	"hash"
 	"io"
+	"maps"
 	"os"
 	"slices"
 	"strings"
 
 	"github.com/rs/zerolog"
+	"github.com/zeebo/blake3"
+	"golang.org/x/crypto/blake2b"
 
 	cdx "github.com/CycloneDX/cyclonedx-go"
 
@@ -60,11 +63,20 @@ func assertComponentLicenses(c *cdx.Component) {
 	}
 
 	if c.Evidence != nil && c.Evidence.Licenses != nil {
-		c.Licenses = c.Evidence.Licenses
+		// Curated licenses take precedence over detected ones,
+		// and expressions combining multiple detected licenses over the individual licenses.
+		if c.Licenses == nil {
+			if expression := LicenseExpression(*c); expression != "" {
+				c.Licenses = &cdx.Licenses{{Expression: expression}}
+			} else {
+				c.Licenses = c.Evidence.Licenses
+			}
+		}
 
-		if c.Evidence.Copyright != nil {
-			c.Evidence.Licenses = nil
-		} else {
+		// Other evidence, like the identity of modules inferred from
+		// symbol tables, must be retained.
+		c.Evidence.Licenses = nil
+		if *c.Evidence == (cdx.Evidence{}) {
 			c.Evidence = nil
 		}
 	}
@@ -104,23 +116,60 @@ func BuildDependencyGraph(modules []gomod.Module) []cdx.Dependency {
 	return depGraph
 }
 
-func BuildToolMetadata(logger zerolog.Logger) (*cdx.Tool, error) { //nolint:staticcheck
+// BuildPackageDependencyGraph builds a dependency graph of the packages of the given modules,
+// based on their imports. Imports of packages that are not part of any module are omitted.
+func BuildPackageDependencyGraph(modules []gomod.Module) []cdx.Dependency {
+	// Packages of replaced modules take the version of the replacement
+	pkgRefs := make(map[string]string)
+	for _, module := range modules {
+		versionModule := module
+		if module.Replace != nil {
+			versionModule = *module.Replace
+		}
+		for _, pkg := range module.Packages {
+			pkgRefs[pkg.ImportPath] = pkg.BOMRef(versionModule)
+		}
+	}
+
+	depGraph := make([]cdx.Dependency, 0, len(pkgRefs))
+	for _, module := range modules {
+		for _, pkg := range module.Packages {
+			cdxDependant := cdx.Dependency{Ref: pkgRefs[pkg.ImportPath]}
+
+			cdxDependencies := make([]string, 0, len(pkg.Imports))
+			for _, importPath := range pkg.Imports {
+				if ref, ok := pkgRefs[importPath]; ok && !slices.Contains(cdxDependencies, ref) {
+					cdxDependencies = append(cdxDependencies, ref)
+				}
+			}
+			if len(cdxDependencies) > 0 {
+				slices.Sort(cdxDependencies)
+				cdxDependant.Dependencies = &cdxDependencies
+			}
+			depGraph = append(depGraph, cdxDependant)
+		}
+	}
+
+	return depGraph
+}
+
+// BuildToolMetadata describes cyclonedx-gomod as the tool that generated a BOM.
+// The executable is hashed using the given algorithms.
+func BuildToolMetadata(logger zerolog.Logger, hashAlgos ...cdx.HashAlgorithm) (*cdx.Tool, error) { //nolint:staticcheck
 	toolExePath, err := os.Executable()
 	if err != nil {
 		return nil, err
 	}
 
-	toolHashes, err := CalculateFileHashes(logger, toolExePath,
-		cdx.HashAlgoMD5, cdx.HashAlgoSHA1, cdx.HashAlgoSHA256, cdx.HashAlgoSHA384, cdx.HashAlgoSHA512)
+	toolHashes, err := CalculateFileHashes(logger, toolExePath, hashAlgos...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate tool hashes: %w", err)
 	}
 
-	return &cdx.Tool{ //nolint:staticcheck
+	tool := cdx.Tool{ //nolint:staticcheck
 		Vendor:  "CycloneDX",
 		Name:    "cyclonedx-gomod",
 		Version: version.Info.Version,
-		Hashes:  &toolHashes,
 		ExternalReferences: &[]cdx.ExternalReference{
 			{
 				Type: cdx.ERTypeVCS,
@@ -131,7 +180,92 @@ func BuildToolMetadata(logger zerolog.Logger) (*cdx.Tool, error) { //nolint:stat
 				URL:  "https://cyclonedx.org",
 			},
 		},
-	}, nil
+	}
+	if len(toolHashes) > 0 {
+		tool.Hashes = &toolHashes
+	}
+
+	return &tool, nil
+}
+
+// hashFuncs maps all supported hash algorithms to constructors of their implementation.
+var hashFuncs = map[cdx.HashAlgorithm]func() hash.Hash{
+	cdx.HashAlgoMD5:         md5.New,  //nolint:gosec // #nosec G401
+	cdx.HashAlgoSHA1:        sha1.New, //nolint:gosec // #nosec G401
+	cdx.HashAlgoSHA256:      sha256.New,
+	cdx.HashAlgoSHA384:      sha512.New384,
+	cdx.HashAlgoSHA512:      sha512.New,
+	cdx.HashAlgoSHA3_256:    func() hash.Hash { return sha3.New256() },
+	cdx.HashAlgoSHA3_384:    func() hash.Hash { return sha3.New384() },
+	cdx.HashAlgoSHA3_512:    func() hash.Hash { return sha3.New512() },
+	cdx.HashAlgoBlake2b_256: func() hash.Hash { return mustBLAKE2b(blake2b.New256(nil)) },
+	cdx.HashAlgoBlake2b_384: func() hash.Hash { return mustBLAKE2b(blake2b.New384(nil)) },
+	cdx.HashAlgoBlake2b_512: func() hash.Hash { return mustBLAKE2b(blake2b.New512(nil)) },
+	cdx.HashAlgoBlake3:      func() hash.Hash { return blake3.New() },
+}
+
+// mustBLAKE2b panics if err is not nil.
+// BLAKE2b constructors only fail for keys that are too long, and we never use a key.
+func mustBLAKE2b(h hash.Hash, err error) hash.Hash {
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+// DefaultHashAlgorithms returns the hash algorithms that are used
+// for files, binaries and the tool, unless configured otherwise.
+func DefaultHashAlgorithms() []cdx.HashAlgorithm {
+	return []cdx.HashAlgorithm{
+		cdx.HashAlgoMD5,
+		cdx.HashAlgoSHA1,
+		cdx.HashAlgoSHA256,
+		cdx.HashAlgoSHA384,
+		cdx.HashAlgoSHA512,
+	}
+}
+
+// SupportedHashAlgorithms returns all hash algorithms supported by CalculateFileHashes.
+func SupportedHashAlgorithms() []cdx.HashAlgorithm {
+	algos := slices.Collect(maps.Keys(hashFuncs))
+	slices.Sort(algos)
+	return algos
+}
+
+// ParseHashAlgorithm parses the name of a supported hash algorithm, as used by CycloneDX.
+// Matching is case-insensitive, and dashes may be omitted (e.g. "sha256" for SHA-256).
+func ParseHashAlgorithm(name string) (cdx.HashAlgorithm, error) {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.ReplaceAll(s, "-", ""))
+	}
+
+	for algo := range hashFuncs {
+		if normalize(string(algo)) == normalize(name) {
+			return algo, nil
+		}
+	}
+
+	supported := make([]string, 0, len(hashFuncs))
+	for _, algo := range SupportedHashAlgorithms() {
+		supported = append(supported, string(algo))
+	}
+
+	return "", fmt.Errorf("unsupported hash algorithm: %s (supported: %s)", name, strings.Join(supported, ", "))
+}
+
+// ValidateHashAlgorithms ensures that all given algorithms are supported by CalculateFileHashes,
+// and that none of them is given more than once.
+func ValidateHashAlgorithms(algos ...cdx.HashAlgorithm) error {
+	for i, algo := range algos {
+		if _, ok := hashFuncs[algo]; !ok {
+			return fmt.Errorf("unsupported hash algorithm: %s", algo)
+		}
+		if slices.Contains(algos[:i], algo) {
+			return fmt.Errorf("duplicate hash algorithm: %s", algo)
+		}
+	}
+
+	return nil
 }
 
 func CalculateFileHashes(logger zerolog.Logger, filePath string, algos ...cdx.HashAlgorithm) ([]cdx.Hash, error) {
@@ -148,27 +282,12 @@ func CalculateFileHashes(logger zerolog.Logger, filePath string, algos ...cdx.Ha
 	hashWriters := make([]io.Writer, 0)
 
 	for _, algo := range algos {
-		var hashWriter hash.Hash
-
-		switch algo { //nolint:exhaustive
-		case cdx.HashAlgoMD5:
-			hashWriter = md5.New() //nolint:gosec // #nosec G401
-		case cdx.HashAlgoSHA1:
-			hashWriter = sha1.New() //nolint:gosec // #nosec G401
-		case cdx.HashAlgoSHA256:
-			hashWriter = sha256.New()
-		case cdx.HashAlgoSHA384:
-			hashWriter = sha512.New384()
-		case cdx.HashAlgoSHA512:
-			hashWriter = sha512.New()
-		case cdx.HashAlgoSHA3_256:
-			hashWriter = hash.Hash(sha3.New256())
-		case cdx.HashAlgoSHA3_512:
-			hashWriter = hash.Hash(sha3.New512())
-		default:
+		newHash, ok := hashFuncs[algo]
+		if !ok {
 			return nil, fmt.Errorf("unsupported hash algorithm: %s", algo)
 		}
 
+		hashWriter := newHash()
 		hashWriters = append(hashWriters, hashWriter)
 		hashMap[algo] = hashWriter
 	}
@@ -205,6 +324,28 @@ func NewProperty(name, value string) cdx.Property {
 	}
 }
 
+// PropertyLicenseExpression records the SPDX license expression that the licenses
+// detected for a component were combined into. As detected licenses are merely evidence,
+// the expression only becomes the component's license when licenses are asserted.
+const PropertyLicenseExpression = "license:expression"
+
+// LicenseExpression returns the value of the PropertyLicenseExpression property of c,
+// or an empty string if c doesn't have such a property.
+func LicenseExpression(c cdx.Component) string {
+	if c.Properties == nil {
+		return ""
+	}
+
+	name := NewProperty(PropertyLicenseExpression, "").Name
+	for _, property := range *c.Properties {
+		if property.Name == name {
+			return property.Value
+		}
+	}
+
+	return ""
+}
+
 func SortProperties(ps []cdx.Property) {
 	slices.SortFunc(ps, func(a, b cdx.Property) int {
 		if a.Name == b.Name {
@@ -214,3 +355,95 @@ func SortProperties(ps []cdx.Property) {
 		return strings.Compare(a.Name, b.Name)
 	})
 }
+
+// ReplaceBOMRef replaces all occurrences of the BOM reference oldRef with newRef,
+// including those in the dependency graph, compositions, vulnerabilities and formulation.
+// References of workflows that were derived from oldRef via GoBuildWorkflowRef are updated as well.
+func ReplaceBOMRef(bom *cdx.BOM, oldRef, newRef string) {
+	replace := func(ref *string) {
+		if *ref == oldRef {
+			*ref = newRef
+		}
+	}
+	replaceAll := func(refs *[]cdx.BOMReference) {
+		if refs == nil {
+			return
+		}
+		for i := range *refs {
+			if string((*refs)[i]) == oldRef {
+				(*refs)[i] = cdx.BOMReference(newRef)
+			}
+		}
+	}
+
+	var replaceComponent func(component *cdx.Component)
+	replaceComponent = func(component *cdx.Component) {
+		replace(&component.BOMRef)
+		if component.Components != nil {
+			for i := range *component.Components {
+				replaceComponent(&(*component.Components)[i])
+			}
+		}
+	}
+
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		replaceComponent(bom.Metadata.Component)
+	}
+	if bom.Components != nil {
+		for i := range *bom.Components {
+			replaceComponent(&(*bom.Components)[i])
+		}
+	}
+
+	if bom.Dependencies != nil {
+		for i := range *bom.Dependencies {
+			dependency := &(*bom.Dependencies)[i]
+			replace(&dependency.Ref)
+			if dependency.Dependencies != nil {
+				for j := range *dependency.Dependencies {
+					replace(&(*dependency.Dependencies)[j])
+				}
+			}
+		}
+	}
+
+	if bom.Compositions != nil {
+		for i := range *bom.Compositions {
+			replaceAll((*bom.Compositions)[i].Assemblies)
+			replaceAll((*bom.Compositions)[i].Dependencies)
+		}
+	}
+
+	if bom.Vulnerabilities != nil {
+		for i := range *bom.Vulnerabilities {
+			if affects := (*bom.Vulnerabilities)[i].Affects; affects != nil {
+				for j := range *affects {
+					replace(&(*affects)[j].Ref)
+				}
+			}
+		}
+	}
+
+	if bom.Formulation != nil {
+		for i := range *bom.Formulation {
+			workflows := (*bom.Formulation)[i].Workflows
+			if workflows == nil {
+				continue
+			}
+			for j := range *workflows {
+				workflow := &(*workflows)[j]
+				if suffix, ok := strings.CutPrefix(workflow.BOMRef, oldRef+"|"); ok {
+					workflow.BOMRef = newRef + "|" + suffix
+					workflow.UID = workflow.BOMRef
+				}
+				if workflow.Outputs != nil {
+					for k := range *workflow.Outputs {
+						if resource := (*workflow.Outputs)[k].Resource; resource != nil {
+							replace(&resource.Ref)
+						}
+					}
+				}
+			}
+		}
+	}
+}
@@ -23,8 +23,94 @@ import (
 	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/gomod"
 )
 
+func TestAssertLicenses(t *testing.T) {
+	t.Run("Evidence", func(t *testing.T) {
+		bom := &cdx.BOM{
+			Components: &[]cdx.Component{
+				{
+					Name: "foo",
+					Evidence: &cdx.Evidence{
+						Licenses: &cdx.Licenses{{License: &cdx.License{ID: "MIT"}}},
+					},
+				},
+			},
+		}
+
+		AssertLicenses(bom)
+		component := (*bom.Components)[0]
+		require.Equal(t, &cdx.Licenses{{License: &cdx.License{ID: "MIT"}}}, component.Licenses)
+		require.Nil(t, component.Evidence)
+	})
+
+	t.Run("OtherEvidence", func(t *testing.T) {
+		identity := &cdx.EvidenceIdentityChoice{Identities: &[]cdx.EvidenceIdentity{{Field: cdx.EvidenceIdentityFieldTypePURL}}}
+		bom := &cdx.BOM{
+			Components: &[]cdx.Component{
+				{
+					Name: "foo",
+					Evidence: &cdx.Evidence{
+						Identity: identity,
+						Licenses: &cdx.Licenses{{License: &cdx.License{ID: "MIT"}}},
+					},
+				},
+			},
+		}
+
+		AssertLicenses(bom)
+		component := (*bom.Components)[0]
+		require.Equal(t, &cdx.Licenses{{License: &cdx.License{ID: "MIT"}}}, component.Licenses)
+		require.Equal(t, &cdx.Evidence{Identity: identity}, component.Evidence)
+	})
+
+	t.Run("DetectedExpression", func(t *testing.T) {
+		bom := &cdx.BOM{
+			Components: &[]cdx.Component{
+				{
+					Name: "foo",
+					Evidence: &cdx.Evidence{
+						Licenses: &cdx.Licenses{
+							{License: &cdx.License{ID: "Apache-2.0"}},
+							{License: &cdx.License{ID: "MIT"}},
+						},
+					},
+					Properties: &[]cdx.Property{NewProperty(PropertyLicenseExpression, "Apache-2.0 OR MIT")},
+				},
+			},
+		}
+
+		AssertLicenses(bom)
+		component := (*bom.Components)[0]
+		require.Equal(t, &cdx.Licenses{{Expression: "Apache-2.0 OR MIT"}}, component.Licenses)
+		require.Nil(t, component.Evidence)
+	})
+
+	t.Run("ConcludedExpression", func(t *testing.T) {
+		bom := &cdx.BOM{
+			Components: &[]cdx.Component{
+				{
+					Name:     "foo",
+					Licenses: &cdx.Licenses{{Expression: "Apache-2.0 OR MIT"}},
+					Evidence: &cdx.Evidence{
+						Licenses: &cdx.Licenses{
+							{License: &cdx.License{ID: "Apache-2.0"}},
+							{License: &cdx.License{ID: "MIT"}},
+						},
+					},
+				},
+			},
+		}
+
+		AssertLicenses(bom)
+		component := (*bom.Components)[0]
+		require.Equal(t, &cdx.Licenses{{Expression: "Apache-2.0 OR MIT"}}, component.Licenses)
+		require.Nil(t, component.Evidence)
+	})
+}
+
 func TestCalculateFileHashes(t *testing.T) {
 	t.Run("AllSupported", func(t *testing.T) {
 		algos := []cdx.HashAlgorithm{
@@ -34,27 +120,35 @@ func TestCalculateFileHashes(t *testing.T) {
 			cdx.HashAlgoSHA384,
 			cdx.HashAlgoSHA512,
 			cdx.HashAlgoSHA3_256,
+			cdx.HashAlgoSHA3_384,
 			cdx.HashAlgoSHA3_512,
+			cdx.HashAlgoBlake2b_256,
+			cdx.HashAlgoBlake2b_384,
+			cdx.HashAlgoBlake2b_512,
+			cdx.HashAlgoBlake3,
 		}
 
 		hashes, err := CalculateFileHashes(zerolog.Nop(), "../../NOTICE", algos...) // TODO: use another file (create a tempfile?)
 		require.NoError(t, err)
-		require.Len(t, hashes, 7)
+		require.Len(t, hashes, 12)
 		require.Equal(t, "90b8bc82c30341e88830b0ea82f18548", hashes[0].Value)
 		require.Equal(t, "8767825dace783fb1570510e21ab84ad59baa39c", hashes[1].Value)
 		require.Equal(t, "02fa11d51d573ee6f4e1133cb4b5c7b8ade1eeadb951875dfc2a67c0122add65", hashes[2].Value)
 		require.Equal(t, "3200f7c24a80080a7d7979aaaad480749b1fc5b07f0609749d47004c7e39265569ed17b2db5eea1f961543cc7a9627f2", hashes[3].Value)
 		require.Equal(t, "afef70a115ee95c3e7d966322898909964399186b9cdd877b5d7ea12352b2b5f8b54902e674875be0fc84affe86d28fdca7893b5e7da45241f3e1e646ab0f32b", hashes[4].Value)
 		require.Equal(t, "436042da3bf8a7b9bebeed1913c8e6ebf3b800aaaa1864690351754ece07caea", hashes[5].Value)
-		require.Equal(t, "cb6b4798adf21d3604dbf089f410edb1d2be31d958d2c859a3bf64a7c3d8b8df29c2218a47e80e026e44ff2932771123a8e5ea9019b18bdce7a0781d4379dd9a", hashes[6].Value)
+		require.Equal(t, "11a2cc80998235c47916b5109e00d2e3beca05c2df1d831a9ebfd238b570f1cb2b078998928e9f957cdf2383aabdee90", hashes[6].Value)
+		require.Equal(t, "cb6b4798adf21d3604dbf089f410edb1d2be31d958d2c859a3bf64a7c3d8b8df29c2218a47e80e026e44ff2932771123a8e5ea9019b18bdce7a0781d4379dd9a", hashes[7].Value)
+		require.Equal(t, "22a03e15441c04951492345ce9edf0ff5279f934ab16422932842708024fe73f", hashes[8].Value)
+		require.Equal(t, "098e7f1f21b202b9d8a269959cd0742c4f4087d423b85a2f0a1255ebca17e253552dc6d5748c50938c083af9a67e5b2e", hashes[9].Value)
+		require.Equal(t, "202777210aa1ace2b8c9be9962344c81952e8525e79fc6b852a9631f8433b5c979a06eed4fa10530de1f4cd53b45fa3df071604246c04d9ef45bdb370b6808b8", hashes[10].Value)
+		require.Equal(t, "55766fcd45633179bce2b108dc9f3012e1573ba14340120d77882f31a99af903", hashes[11].Value)
 	})
 
 	t.Run("UnsupportedAlgorithm", func(t *testing.T) {
 		algos := []cdx.HashAlgorithm{
-			cdx.HashAlgoBlake2b_256,
-			cdx.HashAlgoBlake2b_384,
-			cdx.HashAlgoBlake2b_512,
-			cdx.HashAlgoBlake3,
+			cdx.HashAlgoStreebog256,
+			cdx.HashAlgoStreebog512,
 		}
 
 		for _, algo := range algos {
@@ -73,6 +167,89 @@ func TestCalculateFileHashes(t *testing.T) {
 	})
 }
 
+func TestParseHashAlgorithm(t *testing.T) {
+	testCases := map[string]cdx.HashAlgorithm{
+		"SHA-256":     cdx.HashAlgoSHA256,
+		"sha256":      cdx.HashAlgoSHA256,
+		"sha3-384":    cdx.HashAlgoSHA3_384,
+		"BLAKE2b-512": cdx.HashAlgoBlake2b_512,
+		"blake2b256":  cdx.HashAlgoBlake2b_256,
+		"Blake3":      cdx.HashAlgoBlake3,
+	}
+
+	for name, want := range testCases {
+		t.Run(name, func(t *testing.T) {
+			algo, err := ParseHashAlgorithm(name)
+			require.NoError(t, err)
+			require.Equal(t, want, algo)
+		})
+	}
+
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := ParseHashAlgorithm("Streebog-256")
+		require.ErrorContains(t, err, "unsupported hash algorithm")
+	})
+}
+
+func TestValidateHashAlgorithms(t *testing.T) {
+	require.NoError(t, ValidateHashAlgorithms())
+	require.NoError(t, ValidateHashAlgorithms(SupportedHashAlgorithms()...))
+	require.NoError(t, ValidateHashAlgorithms(DefaultHashAlgorithms()...))
+	require.ErrorContains(t, ValidateHashAlgorithms(cdx.HashAlgoSHA256, cdx.HashAlgoStreebog512), "unsupported hash algorithm")
+	require.ErrorContains(t, ValidateHashAlgorithms(cdx.HashAlgoSHA256, cdx.HashAlgoSHA512, cdx.HashAlgoSHA256), "duplicate hash algorithm")
+}
+
+func TestBuildPackageDependencyGraph(t *testing.T) {
+	modules := []gomod.Module{
+		{
+			Path:    "example.com/app",
+			Version: "v1.0.0",
+			Main:    true,
+			Packages: []gomod.Package{
+				{ImportPath: "example.com/app", Imports: []string{"example.com/app/internal", "example.com/lib", "fmt"}},
+				{ImportPath: "example.com/app/internal", Imports: []string{"example.com/lib/sub", "example.com/lib"}},
+			},
+		},
+		{
+			Path:    "example.com/lib",
+			Version: "v1.2.3",
+			Replace: &gomod.Module{
+				Path:    "example.com/fork",
+				Version: "v1.2.4",
+			},
+			Packages: []gomod.Package{
+				{ImportPath: "example.com/lib"},
+				{ImportPath: "example.com/lib/sub", Imports: []string{"example.com/lib"}},
+			},
+		},
+	}
+
+	dependencies := BuildPackageDependencyGraph(modules)
+	require.Equal(t, []cdx.Dependency{
+		{
+			Ref: "pkg:golang/example.com/app@v1.0.0?type=package",
+			Dependencies: &[]string{
+				"pkg:golang/example.com/app/internal@v1.0.0?type=package",
+				"pkg:golang/example.com/lib@v1.2.4?type=package",
+			},
+		},
+		{
+			Ref: "pkg:golang/example.com/app/internal@v1.0.0?type=package",
+			Dependencies: &[]string{
+				"pkg:golang/example.com/lib/sub@v1.2.4?type=package",
+				"pkg:golang/example.com/lib@v1.2.4?type=package",
+			},
+		},
+		{
+			Ref: "pkg:golang/example.com/lib@v1.2.4?type=package",
+		},
+		{
+			Ref:          "pkg:golang/example.com/lib/sub@v1.2.4?type=package",
+			Dependencies: &[]string{"pkg:golang/example.com/lib@v1.2.4?type=package"},
+		},
+	}, dependencies)
+}
+
 func TestNewProperty(t *testing.T) {
 	property := NewProperty("name", "value")
 	require.Equal(t, "cdx:gomod:name", property.Name)
@@ -101,3 +278,42 @@ func TestSortProperties(t *testing.T) {
 	require.Equal(t, "bar", properties[1].Value)
 	require.Equal(t, "baz", properties[2].Value)
 }
+
+func TestReplaceBOMRef(t *testing.T) {
+	bom := cdx.BOM{
+		Metadata: &cdx.Metadata{
+			Component: &cdx.Component{
+				BOMRef:     "main",
+				Components: &[]cdx.Component{{BOMRef: "main/pkg"}},
+			},
+		},
+		Components: &[]cdx.Component{{BOMRef: "dep"}},
+		Dependencies: &[]cdx.Dependency{
+			{Ref: "main", Dependencies: &[]string{"dep"}},
+			{Ref: "dep"},
+		},
+		Compositions: &[]cdx.Composition{
+			{Aggregate: cdx.CompositionAggregateComplete, Assemblies: &[]cdx.BOMReference{"main"}, Dependencies: &[]cdx.BOMReference{"main", "dep"}},
+		},
+		Vulnerabilities: &[]cdx.Vulnerability{
+			{ID: "GO-0000-0001", Affects: &[]cdx.Affects{{Ref: "main"}}},
+		},
+		Formulation: &[]cdx.Formula{
+			BuildFormula("main", "example.com/main", map[string]string{"GOOS": "linux", "GOARCH": "amd64"}),
+		},
+	}
+
+	ReplaceBOMRef(&bom, "main", "main|app")
+
+	require.Equal(t, "main|app", bom.Metadata.Component.BOMRef)
+	require.Equal(t, "main/pkg", (*bom.Metadata.Component.Components)[0].BOMRef)
+	require.Equal(t, "main|app", (*bom.Dependencies)[0].Ref)
+	require.Equal(t, []string{"dep"}, *(*bom.Dependencies)[0].Dependencies)
+	require.Equal(t, []cdx.BOMReference{"main|app"}, *(*bom.Compositions)[0].Assemblies)
+	require.Equal(t, []cdx.BOMReference{"main|app", "dep"}, *(*bom.Compositions)[0].Dependencies)
+	require.Equal(t, "main|app", (*(*bom.Vulnerabilities)[0].Affects)[0].Ref)
+	workflow := (*(*bom.Formulation)[0].Workflows)[0]
+	require.Equal(t, "main|app|go-build:linux/amd64", workflow.BOMRef)
+	require.Equal(t, "main|app|go-build:linux/amd64", workflow.UID)
+	require.Equal(t, "main|app", (*workflow.Outputs)[0].Resource.Ref)
+}
@@ -0,0 +1,162 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package spdx
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// EncodeJSON writes the given document to writer in the SPDX JSON format.
+func EncodeJSON(writer io.Writer, doc *Document, escapeHTML bool) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(escapeHTML)
+
+	return encoder.Encode(doc)
+}
+
+// EncodeTagValue writes the given document to writer in the SPDX tag-value format.
+// See https://spdx.github.io/spdx-spec/v2.3/conformance/#44-standard-data-format-requirements
+func EncodeTagValue(writer io.Writer, doc *Document) error {
+	w := tagValueWriter{w: bufio.NewWriter(writer)}
+
+	w.tag("SPDXVersion", doc.SPDXVersion)
+	w.tag("DataLicense", doc.DataLicense)
+	w.tag("SPDXID", doc.SPDXID)
+	w.tag("DocumentName", doc.Name)
+	w.tag("DocumentNamespace", doc.DocumentNamespace)
+	for _, creator := range doc.CreationInfo.Creators {
+		w.tag("Creator", creator)
+	}
+	w.tag("Created", doc.CreationInfo.Created)
+
+	// Relationships are grouped with the element they originate from,
+	// except for those originating from the document itself.
+	relationships := make(map[string][]Relationship)
+	for _, rel := range doc.Relationships {
+		relationships[rel.Element] = append(relationships[rel.Element], rel)
+	}
+	w.relationships(relationships[doc.SPDXID])
+
+	files := make(map[string]File, len(doc.Files))
+	for _, file := range doc.Files {
+		files[file.SPDXID] = file
+	}
+
+	for _, pkg := range doc.Packages {
+		w.newline()
+		w.tag("PackageName", pkg.Name)
+		w.tag("SPDXID", pkg.SPDXID)
+		w.optionalTag("PackageVersion", pkg.VersionInfo)
+		w.tag("PackageDownloadLocation", pkg.DownloadLocation)
+		w.tag("FilesAnalyzed", fmt.Sprintf("%t", pkg.FilesAnalyzed))
+		if pkg.VerificationCode != nil {
+			w.tag("PackageVerificationCode", pkg.VerificationCode.Value)
+		}
+		for _, checksum := range pkg.Checksums {
+			w.tag("PackageChecksum", checksum.Algorithm+": "+checksum.Value)
+		}
+		w.tag("PackageLicenseConcluded", pkg.LicenseConcluded)
+		w.tag("PackageLicenseDeclared", pkg.LicenseDeclared)
+		w.textTag("PackageLicenseComments", pkg.LicenseComments)
+		w.tag("PackageCopyrightText", pkg.CopyrightText)
+		w.optionalTag("PrimaryPackagePurpose", pkg.PrimaryPackagePurpose)
+		for _, ref := range pkg.ExternalRefs {
+			w.tag("ExternalRef", strings.Join([]string{ref.Category, ref.Type, ref.Locator}, " "))
+		}
+
+		// Files that belong to a package must directly follow it.
+		for _, fileID := range pkg.HasFiles {
+			file := files[fileID]
+			w.newline()
+			w.tag("FileName", file.FileName)
+			w.tag("SPDXID", file.SPDXID)
+			for _, checksum := range file.Checksums {
+				w.tag("FileChecksum", checksum.Algorithm+": "+checksum.Value)
+			}
+			w.tag("LicenseConcluded", file.LicenseConcluded)
+			w.tag("FileCopyrightText", file.CopyrightText)
+		}
+	}
+
+	for _, pkg := range doc.Packages {
+		w.relationships(relationships[pkg.SPDXID])
+	}
+
+	for _, info := range doc.HasExtractedLicensingInfos {
+		w.newline()
+		w.tag("LicenseID", info.LicenseID)
+		w.textTag("ExtractedText", info.ExtractedText)
+		w.optionalTag("LicenseName", info.Name)
+	}
+
+	if w.err != nil {
+		return w.err
+	}
+
+	return w.w.Flush()
+}
+
+// tagValueWriter writes tag-value pairs and retains the first error that occurred.
+type tagValueWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (t *tagValueWriter) write(s string) {
+	if t.err != nil {
+		return
+	}
+	_, t.err = t.w.WriteString(s)
+}
+
+func (t *tagValueWriter) newline() {
+	t.write("\n")
+}
+
+func (t *tagValueWriter) tag(tag, value string) {
+	t.write(tag + ": " + value + "\n")
+}
+
+func (t *tagValueWriter) optionalTag(tag, value string) {
+	if value != "" {
+		t.tag(tag, value)
+	}
+}
+
+// textTag writes values that may span multiple lines, using the <text> delimiter.
+func (t *tagValueWriter) textTag(tag, value string) {
+	if value != "" {
+		t.tag(tag, "<text>"+value+"</text>")
+	}
+}
+
+func (t *tagValueWriter) relationships(rels []Relationship) {
+	if len(rels) == 0 {
+		return
+	}
+
+	t.newline()
+	for _, rel := range rels {
+		t.tag("Relationship", strings.Join([]string{rel.Element, rel.Type, rel.RelatedElement}, " "))
+	}
+}
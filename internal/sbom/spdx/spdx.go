@@ -0,0 +1,553 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+// Package spdx provides the conversion of CycloneDX BOMs to SPDX 2.3 documents.
+package spdx
+
+import (
+	"crypto/sha1" //nolint:gosec // #nosec G505
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/google/uuid"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
+	modConv "github.com/CycloneDX/cyclonedx-gomod/internal/sbom/convert/module"
+)
+
+// Version is the SPDX specification version of documents produced by this package.
+const Version = "SPDX-2.3"
+
+const (
+	dataLicense    = "CC0-1.0"
+	documentID     = "SPDXRef-DOCUMENT"
+	noAssertion    = "NOASSERTION"
+	namespacePrefx = "https://spdx.org/spdxdocs/"
+)
+
+// See https://spdx.github.io/spdx-spec/v2.3/document-creation-information/
+type Document struct {
+	SPDXVersion                string                   `json:"spdxVersion"`
+	DataLicense                string                   `json:"dataLicense"`
+	SPDXID                     string                   `json:"SPDXID"`
+	Name                       string                   `json:"name"`
+	DocumentNamespace          string                   `json:"documentNamespace"`
+	CreationInfo               CreationInfo             `json:"creationInfo"`
+	DocumentDescribes          []string                 `json:"documentDescribes,omitempty"`
+	Packages                   []Package                `json:"packages,omitempty"`
+	Files                      []File                   `json:"files,omitempty"`
+	HasExtractedLicensingInfos []ExtractedLicensingInfo `json:"hasExtractedLicensingInfos,omitempty"`
+	Relationships              []Relationship           `json:"relationships,omitempty"`
+}
+
+type CreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+// See https://spdx.github.io/spdx-spec/v2.3/package-information/
+type Package struct {
+	SPDXID                string            `json:"SPDXID"`
+	Name                  string            `json:"name"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	VerificationCode      *VerificationCode `json:"packageVerificationCode,omitempty"`
+	Checksums             []Checksum        `json:"checksums,omitempty"`
+	LicenseConcluded      string            `json:"licenseConcluded"`
+	LicenseDeclared       string            `json:"licenseDeclared"`
+	LicenseComments       string            `json:"licenseComments,omitempty"`
+	CopyrightText         string            `json:"copyrightText"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose,omitempty"`
+	ExternalRefs          []ExternalRef     `json:"externalRefs,omitempty"`
+	HasFiles              []string          `json:"hasFiles,omitempty"`
+}
+
+type VerificationCode struct {
+	Value string `json:"packageVerificationCodeValue"`
+}
+
+// See https://spdx.github.io/spdx-spec/v2.3/file-information/
+type File struct {
+	SPDXID           string     `json:"SPDXID"`
+	FileName         string     `json:"fileName"`
+	Checksums        []Checksum `json:"checksums"`
+	LicenseConcluded string     `json:"licenseConcluded"`
+	CopyrightText    string     `json:"copyrightText"`
+}
+
+type Checksum struct {
+	Algorithm string `json:"algorithm"`
+	Value     string `json:"checksumValue"`
+}
+
+type ExternalRef struct {
+	Category string `json:"referenceCategory"`
+	Type     string `json:"referenceType"`
+	Locator  string `json:"referenceLocator"`
+}
+
+// See https://spdx.github.io/spdx-spec/v2.3/other-licensing-information-detected/
+type ExtractedLicensingInfo struct {
+	LicenseID     string `json:"licenseId"`
+	Name          string `json:"name,omitempty"`
+	ExtractedText string `json:"extractedText"`
+}
+
+// See https://spdx.github.io/spdx-spec/v2.3/relationships-between-SPDX-elements/
+type Relationship struct {
+	Element        string `json:"spdxElementId"`
+	Type           string `json:"relationshipType"`
+	RelatedElement string `json:"relatedSpdxElement"`
+}
+
+const (
+	RelationshipContains  = "CONTAINS"
+	RelationshipDependsOn = "DEPENDS_ON"
+	RelationshipDescribes = "DESCRIBES"
+)
+
+// hashAlgorithms maps CycloneDX hash algorithms to their SPDX counterparts.
+var hashAlgorithms = map[cdx.HashAlgorithm]string{
+	cdx.HashAlgoMD5:         "MD5",
+	cdx.HashAlgoSHA1:        "SHA1",
+	cdx.HashAlgoSHA256:      "SHA256",
+	cdx.HashAlgoSHA384:      "SHA384",
+	cdx.HashAlgoSHA512:      "SHA512",
+	cdx.HashAlgoSHA3_256:    "SHA3-256",
+	cdx.HashAlgoSHA3_384:    "SHA3-384",
+	cdx.HashAlgoSHA3_512:    "SHA3-512",
+	cdx.HashAlgoBlake2b_256: "BLAKE2b-256",
+	cdx.HashAlgoBlake2b_384: "BLAKE2b-384",
+	cdx.HashAlgoBlake2b_512: "BLAKE2b-512",
+	cdx.HashAlgoBlake3:      "BLAKE3",
+}
+
+// packagePurposes maps CycloneDX component types to SPDX primary package purposes.
+var packagePurposes = map[cdx.ComponentType]string{
+	cdx.ComponentTypeApplication: "APPLICATION",
+	cdx.ComponentTypeContainer:   "CONTAINER",
+	cdx.ComponentTypeDevice:      "DEVICE",
+	cdx.ComponentTypeFile:        "FILE",
+	cdx.ComponentTypeFirmware:    "FIRMWARE",
+	cdx.ComponentTypeFramework:   "FRAMEWORK",
+	cdx.ComponentTypeLibrary:     "LIBRARY",
+	cdx.ComponentTypeOS:          "OPERATING-SYSTEM",
+}
+
+var invalidIDCharsRegex = regexp.MustCompile(`[^a-zA-Z0-9.\-]+`)
+
+// converter holds the state of a single CycloneDX to SPDX conversion.
+type converter struct {
+	doc       *Document
+	ids       map[string]string // BOM reference -> SPDX ID
+	usedIDs   map[string]struct{}
+	extracted map[string]struct{}
+}
+
+// FromBOM converts a CycloneDX BOM, as produced by cyclonedx-gomod's generators,
+// to an SPDX 2.3 document.
+//
+// Components are represented as packages, except for components of type file,
+// which are represented as files. The dependency graph is represented
+// via DEPENDS_ON relationships, the nesting of components via CONTAINS relationships.
+func FromBOM(bom *cdx.BOM) (*Document, error) {
+	if bom == nil || bom.Metadata == nil || bom.Metadata.Component == nil {
+		return nil, fmt.Errorf("bom has no main component")
+	}
+
+	c := converter{
+		doc: &Document{
+			SPDXVersion: Version,
+			DataLicense: dataLicense,
+			SPDXID:      documentID,
+		},
+		ids:       make(map[string]string),
+		usedIDs:   make(map[string]struct{}),
+		extracted: make(map[string]struct{}),
+	}
+
+	main := bom.Metadata.Component
+	c.doc.Name = main.Name
+	if main.Version != "" {
+		c.doc.Name += "@" + main.Version
+	}
+	c.doc.DocumentNamespace = buildNamespace(bom)
+	c.doc.CreationInfo = buildCreationInfo(bom)
+
+	mainID, err := c.convertComponent(*main, "")
+	if err != nil {
+		return nil, err
+	}
+	c.doc.DocumentDescribes = []string{mainID}
+	c.addRelationship(documentID, RelationshipDescribes, mainID)
+
+	if bom.Components != nil {
+		for i := range *bom.Components {
+			if _, err = c.convertComponent((*bom.Components)[i], ""); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if bom.Dependencies != nil {
+		for _, dependency := range *bom.Dependencies {
+			if dependency.Dependencies == nil {
+				continue
+			}
+
+			dependantID, ok := c.ids[dependency.Ref]
+			if !ok {
+				return nil, fmt.Errorf("dependency graph references unknown component %s", dependency.Ref)
+			}
+			for _, ref := range *dependency.Dependencies {
+				dependencyID, ok := c.ids[ref]
+				if !ok {
+					return nil, fmt.Errorf("dependency graph references unknown component %s", ref)
+				}
+				c.addRelationship(dependantID, RelationshipDependsOn, dependencyID)
+			}
+		}
+	}
+
+	return c.doc, nil
+}
+
+func buildNamespace(bom *cdx.BOM) string {
+	var id uuid.UUID
+	if serial, err := uuid.Parse(bom.SerialNumber); err == nil {
+		id = serial
+	} else {
+		// Without serial number, derive the namespace from the main component,
+		// so that the document remains reproducible.
+		id = uuid.NewSHA1(uuid.NameSpaceURL, []byte(bom.Metadata.Component.PackageURL))
+	}
+
+	name := invalidIDCharsRegex.ReplaceAllString(bom.Metadata.Component.Name, "-")
+	return namespacePrefx + name + "-" + id.String()
+}
+
+func buildCreationInfo(bom *cdx.BOM) CreationInfo {
+	info := CreationInfo{
+		// SPDX requires a creation date. If the BOM doesn't have a timestamp
+		// (e.g. because -notimestamp was used), we fall back to the Unix epoch,
+		// which keeps the document reproducible.
+		Created: time.Unix(0, 0).UTC().Format(time.RFC3339),
+	}
+	if bom.Metadata.Timestamp != "" {
+		if ts, err := time.Parse(time.RFC3339, bom.Metadata.Timestamp); err == nil {
+			info.Created = ts.UTC().Format(time.RFC3339)
+		}
+	}
+
+	if bom.Metadata.Tools != nil && bom.Metadata.Tools.Tools != nil { //nolint:staticcheck
+		for _, tool := range *bom.Metadata.Tools.Tools { //nolint:staticcheck
+			info.Creators = append(info.Creators, fmt.Sprintf("Tool: %s-%s", tool.Name, tool.Version))
+		}
+	}
+	if bom.Metadata.Tools != nil && bom.Metadata.Tools.Components != nil {
+		for _, tool := range *bom.Metadata.Tools.Components {
+			info.Creators = append(info.Creators, fmt.Sprintf("Tool: %s-%s", tool.Name, tool.Version))
+		}
+	}
+	if len(info.Creators) == 0 {
+		info.Creators = []string{"Tool: cyclonedx-gomod"}
+	}
+
+	return info
+}
+
+var licenseSourceCuration = sbom.NewProperty(modConv.PropertyLicenseSource, modConv.LicenseSourceCuration)
+
+// hasProperty checks whether component has a property with the same name and value as property.
+func hasProperty(component cdx.Component, property cdx.Property) bool {
+	return component.Properties != nil && slices.Contains(*component.Properties, property)
+}
+
+// convertComponent converts a component and all of its subcomponents.
+// If parentID is not empty, a CONTAINS relationship is established between the parent and the component.
+func (c *converter) convertComponent(component cdx.Component, parentID string) (string, error) {
+	if component.Type == cdx.ComponentTypeFile {
+		return c.convertFile(component, parentID)
+	}
+
+	pkg := Package{
+		SPDXID:                c.newID(component.BOMRef, "Package-"+component.Name+"-"+component.Version),
+		Name:                  component.Name,
+		VersionInfo:           component.Version,
+		DownloadLocation:      noAssertion,
+		CopyrightText:         noAssertion,
+		PrimaryPackagePurpose: packagePurposes[component.Type],
+		Checksums:             convertHashes(component.Hashes),
+	}
+	pkg.LicenseDeclared, pkg.LicenseConcluded = c.convertLicenses(component)
+	if pkg.LicenseConcluded != noAssertion || pkg.LicenseDeclared != noAssertion {
+		if hasProperty(component, licenseSourceCuration) {
+			pkg.LicenseComments = "License was concluded from a license curation."
+		} else {
+			pkg.LicenseComments = "License was detected by cyclonedx-gomod and may be inaccurate."
+		}
+	}
+	if component.PackageURL != "" {
+		pkg.ExternalRefs = []ExternalRef{
+			{
+				Category: "PACKAGE-MANAGER",
+				Type:     "purl",
+				Locator:  component.PackageURL,
+			},
+		}
+	}
+	if component.ExternalReferences != nil {
+		for _, extRef := range *component.ExternalReferences {
+			if extRef.Type == cdx.ERTypeVCS && strings.HasPrefix(extRef.URL, "https://") {
+				pkg.DownloadLocation = "git+" + extRef.URL + ".git"
+				break
+			}
+		}
+	}
+
+	if component.BOMRef != "" {
+		c.ids[component.BOMRef] = pkg.SPDXID
+	}
+	if parentID != "" {
+		c.addRelationship(parentID, RelationshipContains, pkg.SPDXID)
+	}
+
+	// Packages must be added to the document before their subcomponents,
+	// so we keep track of the index in order to update it afterward.
+	c.doc.Packages = append(c.doc.Packages, pkg)
+	pkgIndex := len(c.doc.Packages) - 1
+
+	if component.Components != nil {
+		var fileSHA1s []string
+		for _, subComponent := range *component.Components {
+			subID, err := c.convertComponent(subComponent, pkg.SPDXID)
+			if err != nil {
+				return "", err
+			}
+			if subComponent.Type != cdx.ComponentTypeFile {
+				continue
+			}
+
+			c.doc.Packages[pkgIndex].HasFiles = append(c.doc.Packages[pkgIndex].HasFiles, subID)
+			for _, checksum := range c.doc.Files[len(c.doc.Files)-1].Checksums {
+				if checksum.Algorithm == "SHA1" {
+					fileSHA1s = append(fileSHA1s, checksum.Value)
+				}
+			}
+		}
+
+		if len(c.doc.Packages[pkgIndex].HasFiles) > 0 {
+			if len(fileSHA1s) != len(c.doc.Packages[pkgIndex].HasFiles) {
+				return "", fmt.Errorf("files of %s are missing SHA-1 hashes", component.Name)
+			}
+			c.doc.Packages[pkgIndex].FilesAnalyzed = true
+			c.doc.Packages[pkgIndex].VerificationCode = &VerificationCode{
+				Value: calculateVerificationCode(fileSHA1s),
+			}
+		}
+	}
+
+	return pkg.SPDXID, nil
+}
+
+func (c *converter) convertFile(component cdx.Component, parentID string) (string, error) {
+	fileName := component.Name
+	if component.Properties != nil {
+		for _, property := range *component.Properties {
+			if property.Name == sbom.PropertyPrefix+":file:path" {
+				fileName = property.Value
+				break
+			}
+		}
+	}
+	fileName = "./" + strings.TrimPrefix(strings.ReplaceAll(fileName, "\\", "/"), "/")
+
+	file := File{
+		SPDXID:           c.newID(component.BOMRef, "File-"+parentID+"-"+component.Name),
+		FileName:         fileName,
+		Checksums:        convertHashes(component.Hashes),
+		LicenseConcluded: noAssertion,
+		CopyrightText:    noAssertion,
+	}
+	if len(file.Checksums) == 0 {
+		return "", fmt.Errorf("file %s has no hashes", component.Name)
+	}
+
+	if component.BOMRef != "" {
+		c.ids[component.BOMRef] = file.SPDXID
+	}
+	if parentID != "" {
+		c.addRelationship(parentID, RelationshipContains, file.SPDXID)
+	}
+	c.doc.Files = append(c.doc.Files, file)
+
+	return file.SPDXID, nil
+}
+
+// convertLicenses converts the licenses of a component to SPDX license expressions,
+// which make up the declared and concluded license of the respective package.
+//
+// Licenses acknowledged as declared, and licenses without acknowledgement, which were
+// asserted by the user (see sbom.AssertLicenses), make up the declared license.
+// Licenses acknowledged as concluded, like curated ones, make up the concluded license.
+// Without such licenses, the concluded license is derived from the license evidence instead.
+// Expressions that detected licenses were combined into take precedence over the evidence.
+func (c *converter) convertLicenses(component cdx.Component) (declared, concluded string) {
+	var declaredLicenses, concludedLicenses []cdx.LicenseChoice
+	if component.Licenses != nil {
+		for _, choice := range *component.Licenses {
+			if licenseAcknowledgement(choice) == cdx.LicenseAcknowledgementConcluded {
+				concludedLicenses = append(concludedLicenses, choice)
+			} else {
+				declaredLicenses = append(declaredLicenses, choice)
+			}
+		}
+	}
+
+	declared = c.convertLicenseChoices(declaredLicenses)
+	switch {
+	case len(concludedLicenses) > 0:
+		concluded = c.convertLicenseChoices(concludedLicenses)
+	case sbom.LicenseExpression(component) != "":
+		concluded = sbom.LicenseExpression(component)
+	case component.Evidence != nil && component.Evidence.Licenses != nil:
+		concluded = c.convertLicenseChoices(*component.Evidence.Licenses)
+	default:
+		concluded = noAssertion
+	}
+
+	return declared, concluded
+}
+
+// licenseAcknowledgement returns the acknowledgement of a license choice,
+// or an empty string if it doesn't have one.
+func licenseAcknowledgement(choice cdx.LicenseChoice) cdx.LicenseAcknowledgement {
+	if choice.License != nil {
+		return choice.License.Acknowledgement
+	}
+	if choice.Acknowledgement != nil {
+		return *choice.Acknowledgement
+	}
+
+	return ""
+}
+
+// convertLicenseChoices converts license choices to a single SPDX license expression,
+// combining multiple choices using the AND operator.
+func (c *converter) convertLicenseChoices(choices []cdx.LicenseChoice) string {
+	terms := make([]string, 0, len(choices))
+	for _, choice := range choices {
+		switch {
+		case choice.Expression != "":
+			terms = append(terms, "("+choice.Expression+")")
+		case choice.License != nil && choice.License.ID != "":
+			terms = append(terms, choice.License.ID)
+		case choice.License != nil && choice.License.Name != "":
+			terms = append(terms, c.addExtractedLicense(*choice.License))
+		}
+	}
+	if len(terms) == 0 {
+		return noAssertion
+	}
+	if len(terms) == 1 {
+		return strings.TrimSuffix(strings.TrimPrefix(terms[0], "("), ")")
+	}
+
+	return strings.Join(terms, " AND ")
+}
+
+func (c *converter) addExtractedLicense(license cdx.License) string {
+	id := "LicenseRef-" + invalidIDCharsRegex.ReplaceAllString(license.Name, "-")
+	if _, ok := c.extracted[id]; ok {
+		return id
+	}
+	c.extracted[id] = struct{}{}
+
+	text := license.Name
+	if license.Text != nil && license.Text.Content != "" {
+		text = license.Text.Content
+	}
+	c.doc.HasExtractedLicensingInfos = append(c.doc.HasExtractedLicensingInfos, ExtractedLicensingInfo{
+		LicenseID:     id,
+		Name:          license.Name,
+		ExtractedText: text,
+	})
+
+	return id
+}
+
+func (c *converter) addRelationship(element, relType, relatedElement string) {
+	c.doc.Relationships = append(c.doc.Relationships, Relationship{
+		Element:        element,
+		Type:           relType,
+		RelatedElement: relatedElement,
+	})
+}
+
+// newID derives a unique SPDX identifier from a BOM reference.
+// If the BOM reference is empty, fallback is used instead.
+func (c *converter) newID(bomRef, fallback string) string {
+	base := bomRef
+	if base == "" {
+		base = fallback
+	}
+	base = strings.TrimPrefix(base, "pkg:")
+	base = strings.Trim(invalidIDCharsRegex.ReplaceAllString(base, "-"), "-")
+
+	id := "SPDXRef-" + base
+	for i := 2; ; i++ {
+		if _, used := c.usedIDs[id]; !used {
+			break
+		}
+		id = fmt.Sprintf("SPDXRef-%s-%d", base, i)
+	}
+	c.usedIDs[id] = struct{}{}
+
+	return id
+}
+
+func convertHashes(hashes *[]cdx.Hash) []Checksum {
+	if hashes == nil {
+		return nil
+	}
+
+	checksums := make([]Checksum, 0, len(*hashes))
+	for _, hash := range *hashes {
+		if algo, ok := hashAlgorithms[hash.Algorithm]; ok {
+			checksums = append(checksums, Checksum{Algorithm: algo, Value: hash.Value})
+		}
+	}
+	if len(checksums) == 0 {
+		return nil
+	}
+
+	return checksums
+}
+
+// calculateVerificationCode calculates the package verification code from the SHA-1 hashes of all files.
+// See https://spdx.github.io/spdx-spec/v2.3/package-information/#79-package-verification-code-field
+func calculateVerificationCode(fileSHA1s []string) string {
+	sorted := slices.Clone(fileSHA1s)
+	slices.Sort(sorted)
+
+	return fmt.Sprintf("%x", sha1.Sum([]byte(strings.Join(sorted, "")))) //nolint:gosec // #nosec G401
+}
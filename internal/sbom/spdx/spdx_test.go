@@ -0,0 +1,274 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package spdx
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
+)
+
+func newTestBOM() *cdx.BOM {
+	bom := cdx.NewBOM()
+	bom.SerialNumber = "urn:uuid:00000000-0000-0000-0000-000000000001"
+	bom.Metadata = &cdx.Metadata{
+		Component: &cdx.Component{
+			BOMRef:     "pkg:golang/example.com/app@v1.0.0?type=module",
+			Type:       cdx.ComponentTypeApplication,
+			Name:       "example.com/app",
+			Version:    "v1.0.0",
+			PackageURL: "pkg:golang/example.com/app@v1.0.0?type=module",
+		},
+	}
+	bom.Components = &[]cdx.Component{
+		{
+			BOMRef:     "pkg:golang/github.com/foo/bar@v1.2.3?type=module",
+			Type:       cdx.ComponentTypeLibrary,
+			Name:       "github.com/foo/bar",
+			Version:    "v1.2.3",
+			PackageURL: "pkg:golang/github.com/foo/bar@v1.2.3?type=module",
+			Hashes:     &[]cdx.Hash{{Algorithm: cdx.HashAlgoSHA256, Value: "abc"}},
+			Evidence: &cdx.Evidence{
+				Licenses: &cdx.Licenses{{License: &cdx.License{ID: "MIT"}}},
+			},
+			ExternalReferences: &[]cdx.ExternalReference{
+				{Type: cdx.ERTypeVCS, URL: "https://github.com/foo/bar"},
+			},
+			Components: &[]cdx.Component{
+				{
+					Type:    cdx.ComponentTypeLibrary,
+					Name:    "github.com/foo/bar/baz",
+					Version: "v1.2.3",
+					Components: &[]cdx.Component{
+						{
+							Type:    cdx.ComponentTypeFile,
+							Name:    "baz.go",
+							Version: "v0.0.0-da39a3ee5e6b",
+							Hashes: &[]cdx.Hash{
+								{Algorithm: cdx.HashAlgoSHA1, Value: "da39a3ee5e6b4b0d3255bfef95601890afd80709"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	bom.Dependencies = &[]cdx.Dependency{
+		{
+			Ref:          "pkg:golang/example.com/app@v1.0.0?type=module",
+			Dependencies: &[]string{"pkg:golang/github.com/foo/bar@v1.2.3?type=module"},
+		},
+		{
+			Ref: "pkg:golang/github.com/foo/bar@v1.2.3?type=module",
+		},
+	}
+
+	return bom
+}
+
+func TestFromBOM(t *testing.T) {
+	t.Run("NoMainComponent", func(t *testing.T) {
+		_, err := FromBOM(cdx.NewBOM())
+		require.Error(t, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		doc, err := FromBOM(newTestBOM())
+		require.NoError(t, err)
+
+		assert.Equal(t, Version, doc.SPDXVersion)
+		assert.Equal(t, "example.com/app@v1.0.0", doc.Name)
+		assert.Equal(t, "https://spdx.org/spdxdocs/example.com-app-00000000-0000-0000-0000-000000000001", doc.DocumentNamespace)
+		assert.Equal(t, "1970-01-01T00:00:00Z", doc.CreationInfo.Created)
+
+		require.Len(t, doc.Packages, 3)
+		assert.Equal(t, "SPDXRef-golang-example.com-app-v1.0.0-type-module", doc.Packages[0].SPDXID)
+		assert.Equal(t, "APPLICATION", doc.Packages[0].PrimaryPackagePurpose)
+
+		lib := doc.Packages[1]
+		assert.Equal(t, "MIT", lib.LicenseConcluded)
+		assert.Equal(t, noAssertion, lib.LicenseDeclared)
+		assert.Equal(t, "License was detected by cyclonedx-gomod and may be inaccurate.", lib.LicenseComments)
+		assert.Equal(t, "git+https://github.com/foo/bar.git", lib.DownloadLocation)
+		assert.Equal(t, []Checksum{{Algorithm: "SHA256", Value: "abc"}}, lib.Checksums)
+		assert.Equal(t, []ExternalRef{{Category: "PACKAGE-MANAGER", Type: "purl", Locator: "pkg:golang/github.com/foo/bar@v1.2.3?type=module"}}, lib.ExternalRefs)
+
+		pkg := doc.Packages[2]
+		assert.True(t, pkg.FilesAnalyzed)
+		require.NotNil(t, pkg.VerificationCode)
+		require.Len(t, doc.Files, 1)
+		assert.Equal(t, []string{doc.Files[0].SPDXID}, pkg.HasFiles)
+		assert.Equal(t, "./baz.go", doc.Files[0].FileName)
+
+		assert.Contains(t, doc.Relationships, Relationship{Element: documentID, Type: RelationshipDescribes, RelatedElement: doc.Packages[0].SPDXID})
+		assert.Contains(t, doc.Relationships, Relationship{Element: doc.Packages[0].SPDXID, Type: RelationshipDependsOn, RelatedElement: lib.SPDXID})
+		assert.Contains(t, doc.Relationships, Relationship{Element: lib.SPDXID, Type: RelationshipContains, RelatedElement: pkg.SPDXID})
+		assert.Contains(t, doc.Relationships, Relationship{Element: pkg.SPDXID, Type: RelationshipContains, RelatedElement: doc.Files[0].SPDXID})
+	})
+
+	t.Run("CuratedLicense", func(t *testing.T) {
+		bom := newTestBOM()
+		(*bom.Components)[0].Properties = &[]cdx.Property{licenseSourceCuration}
+
+		doc, err := FromBOM(bom)
+		require.NoError(t, err)
+
+		require.Len(t, doc.Packages, 3)
+		assert.Equal(t, "MIT", doc.Packages[1].LicenseConcluded)
+		assert.Equal(t, "License was concluded from a license curation.", doc.Packages[1].LicenseComments)
+	})
+
+	t.Run("AssertedLicense", func(t *testing.T) {
+		bom := newTestBOM()
+		sbom.AssertLicenses(bom)
+
+		doc, err := FromBOM(bom)
+		require.NoError(t, err)
+
+		require.Len(t, doc.Packages, 3)
+		assert.Equal(t, "MIT", doc.Packages[1].LicenseDeclared)
+		assert.Equal(t, noAssertion, doc.Packages[1].LicenseConcluded)
+		assert.Equal(t, "License was detected by cyclonedx-gomod and may be inaccurate.", doc.Packages[1].LicenseComments)
+	})
+
+	t.Run("UnknownDependency", func(t *testing.T) {
+		bom := newTestBOM()
+		(*bom.Dependencies)[1].Dependencies = &[]string{"pkg:golang/unknown@v1.0.0?type=module"}
+
+		_, err := FromBOM(bom)
+		require.ErrorContains(t, err, "unknown component")
+	})
+}
+
+func TestConverter_ConvertLicenses(t *testing.T) {
+	newConverter := func() *converter {
+		return &converter{extracted: make(map[string]struct{}), doc: &Document{}}
+	}
+	concludedAcknowledgement := cdx.LicenseAcknowledgementConcluded
+	declaredAcknowledgement := cdx.LicenseAcknowledgementDeclared
+
+	t.Run("Asserted", func(t *testing.T) {
+		c := newConverter()
+		declared, concluded := c.convertLicenses(cdx.Component{
+			Licenses: &cdx.Licenses{
+				{License: &cdx.License{ID: "MIT"}},
+				{License: &cdx.License{Name: "Custom License"}},
+			},
+		})
+		assert.Equal(t, "MIT AND LicenseRef-Custom-License", declared)
+		assert.Equal(t, noAssertion, concluded)
+		require.Len(t, c.doc.HasExtractedLicensingInfos, 1)
+	})
+
+	t.Run("Declared", func(t *testing.T) {
+		declared, concluded := newConverter().convertLicenses(cdx.Component{
+			Licenses: &cdx.Licenses{{Expression: "MIT OR Apache-2.0", Acknowledgement: &declaredAcknowledgement}},
+		})
+		assert.Equal(t, "MIT OR Apache-2.0", declared)
+		assert.Equal(t, noAssertion, concluded)
+	})
+
+	t.Run("Concluded", func(t *testing.T) {
+		declared, concluded := newConverter().convertLicenses(cdx.Component{
+			Licenses: &cdx.Licenses{{License: &cdx.License{ID: "MIT", Acknowledgement: concludedAcknowledgement}}},
+			Evidence: &cdx.Evidence{
+				Licenses: &cdx.Licenses{{License: &cdx.License{ID: "Apache-2.0"}}},
+			},
+		})
+		assert.Equal(t, noAssertion, declared)
+		assert.Equal(t, "MIT", concluded)
+	})
+
+	t.Run("DeclaredAndConcluded", func(t *testing.T) {
+		declared, concluded := newConverter().convertLicenses(cdx.Component{
+			Licenses: &cdx.Licenses{
+				{License: &cdx.License{ID: "Apache-2.0", Acknowledgement: declaredAcknowledgement}},
+				{Expression: "Apache-2.0 OR MIT", Acknowledgement: &concludedAcknowledgement},
+			},
+		})
+		assert.Equal(t, "Apache-2.0", declared)
+		assert.Equal(t, "Apache-2.0 OR MIT", concluded)
+	})
+
+	t.Run("Evidence", func(t *testing.T) {
+		declared, concluded := newConverter().convertLicenses(cdx.Component{
+			Evidence: &cdx.Evidence{
+				Licenses: &cdx.Licenses{{License: &cdx.License{ID: "MIT"}}, {License: &cdx.License{ID: "Apache-2.0"}}},
+			},
+		})
+		assert.Equal(t, noAssertion, declared)
+		assert.Equal(t, "MIT AND Apache-2.0", concluded)
+	})
+
+	t.Run("DetectedExpression", func(t *testing.T) {
+		declared, concluded := newConverter().convertLicenses(cdx.Component{
+			Evidence: &cdx.Evidence{
+				Licenses: &cdx.Licenses{{License: &cdx.License{ID: "MIT"}}, {License: &cdx.License{ID: "Apache-2.0"}}},
+			},
+			Properties: &[]cdx.Property{{Name: "cdx:gomod:license:expression", Value: "MIT OR Apache-2.0"}},
+		})
+		assert.Equal(t, noAssertion, declared)
+		assert.Equal(t, "MIT OR Apache-2.0", concluded)
+	})
+
+	t.Run("None", func(t *testing.T) {
+		declared, concluded := newConverter().convertLicenses(cdx.Component{})
+		assert.Equal(t, noAssertion, declared)
+		assert.Equal(t, noAssertion, concluded)
+	})
+}
+
+func TestCalculateVerificationCode(t *testing.T) {
+	// Order of the hashes must not matter.
+	require.Equal(t,
+		calculateVerificationCode([]string{"a", "b"}),
+		calculateVerificationCode([]string{"b", "a"}))
+}
+
+func TestEncode(t *testing.T) {
+	doc, err := FromBOM(newTestBOM())
+	require.NoError(t, err)
+
+	t.Run("JSON", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, EncodeJSON(buf, doc, true))
+
+		var decoded Document
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+		require.Equal(t, *doc, decoded)
+	})
+
+	t.Run("TagValue", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		require.NoError(t, EncodeTagValue(buf, doc))
+
+		out := buf.String()
+		assert.Contains(t, out, "SPDXVersion: SPDX-2.3\n")
+		assert.Contains(t, out, "PackageName: github.com/foo/bar\n")
+		assert.Contains(t, out, "PackageLicenseConcluded: MIT\n")
+		assert.Contains(t, out, "FileName: ./baz.go\n")
+		assert.Contains(t, out, "ExternalRef: PACKAGE-MANAGER purl pkg:golang/github.com/foo/bar@v1.2.3?type=module\n")
+		assert.Contains(t, out, "Relationship: SPDXRef-DOCUMENT DESCRIBES SPDXRef-golang-example.com-app-v1.0.0-type-module\n")
+	})
+}
@@ -21,7 +21,10 @@ package testutil
 import (
 	"bytes"
 	"fmt"
+	"os"
 	"os/exec"
+	"path"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -53,6 +56,25 @@ func ExtractFixtureArchive(t *testing.T, archivePath string) string {
 	return tmpDir
 }
 
+// BuildGOPATHBinary builds a binary in GOPATH mode, which results in a binary without module information.
+// files maps paths relative to GOPATH/src to their contents, mainPkg is the import path of the package to build.
+func BuildGOPATHBinary(t *testing.T, files map[string]string, mainPkg string) string {
+	gopath := t.TempDir()
+	for name, content := range files {
+		filePath := filepath.Join(gopath, "src", filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0o700))
+		require.NoError(t, os.WriteFile(filePath, []byte(content), 0o600))
+	}
+
+	binaryPath := filepath.Join(gopath, "bin", path.Base(mainPkg))
+	cmd := exec.Command("go", "build", "-o", binaryPath, mainPkg) // #nosec G204
+	cmd.Env = append(os.Environ(), "GO111MODULE=off", "GOPATH="+gopath, "GOFLAGS=")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	return binaryPath
+}
+
 // RequireMatchingPropertyToBeRedacted ensures that a given property is present and its value matched the provided regex.
 //
 // If a property matches, its value is then replaced by the string "REDACTED".
@@ -71,59 +93,61 @@ func RequireMatchingPropertyToBeRedacted(t *testing.T, properties []cdx.Property
 }
 
 // RequireStdlibComponentToBeRedacted ensures that a stdlib component is present and redacts its version.
+// The version of the toolchain component, which is always the same as that of the stdlib component, is redacted as well.
 //
 // Version will be redacted from packages as well, if applicable.
 // If files are expected, their correlating components will be removed and replaced by an empty slice.
 func RequireStdlibComponentToBeRedacted(t *testing.T, bom *cdx.BOM, expectPackages, expectFiles bool) {
-	var (
-		version   string
-		oldBOMRef string
-		newBOMRef string
-		newPURL   string
-	)
+	redactedBOMRefs := make(map[string]string)
+	stdlibFound := false
 
 	for i, component := range *bom.Components {
-		if component.Name == "std" {
-			require.Regexp(t, `^go1\.`, component.Version)
-
-			version = component.Version
-			oldBOMRef = component.BOMRef
-			newBOMRef = strings.ReplaceAll((*bom.Components)[i].BOMRef, version, Redacted)
-			newPURL = strings.ReplaceAll((*bom.Components)[i].PackageURL, version, Redacted)
-
-			(*bom.Components)[i].Version = Redacted
-			(*bom.Components)[i].BOMRef = newBOMRef
-			(*bom.Components)[i].PackageURL = newPURL
-
-			// Redact all packages and files, as they may differ from one go version to another.
-			if component.Components != nil { // Redact version from packages as well
-				for _, component2 := range *(*bom.Components)[i].Components {
-					require.Equal(t, version, component2.Version)
-
-					if expectFiles {
-						require.NotNil(t, component2.Components, "stdlib is missing files")
-					}
-				}
+		if component.Name != "std" && component.Name != "golang.org/toolchain" {
+			continue
+		}
+		require.Regexp(t, `^go1\.`, component.Version)
+
+		version := component.Version
+		newBOMRef := strings.ReplaceAll(component.BOMRef, version, Redacted)
+		redactedBOMRefs[component.BOMRef] = newBOMRef
+
+		(*bom.Components)[i].Version = Redacted
+		(*bom.Components)[i].BOMRef = newBOMRef
+		(*bom.Components)[i].PackageURL = strings.ReplaceAll(component.PackageURL, version, Redacted)
+
+		if component.Type == cdx.ComponentTypeApplication {
+			continue // Toolchain
+		}
+		stdlibFound = true
 
-				// Use an empty slice instead of nil, in order for this modification
-				// to be somewhat visible in the snapshot file.
-				(*bom.Components)[i].Components = &[]cdx.Component{}
-			} else if expectPackages {
-				t.Fatalf("stdlib is missing packages")
+		// Redact all packages and files, as they may differ from one go version to another.
+		if component.Components != nil { // Redact version from packages as well
+			for _, component2 := range *(*bom.Components)[i].Components {
+				require.Equal(t, version, component2.Version)
+
+				if expectFiles {
+					require.NotNil(t, component2.Components, "stdlib is missing files")
+				}
 			}
-			break
+
+			// Use an empty slice instead of nil, in order for this modification
+			// to be somewhat visible in the snapshot file.
+			(*bom.Components)[i].Components = &[]cdx.Component{}
+		} else if expectPackages {
+			t.Fatalf("stdlib is missing packages")
 		}
 	}
-	if newPURL == "" && newBOMRef == "" {
+	if !stdlibFound {
 		t.Fatalf("stdlib component not found")
 	}
 
 	for i, dependency := range *bom.Dependencies {
-		if dependency.Ref == oldBOMRef { // Dependant
+		if newBOMRef, ok := redactedBOMRefs[dependency.Ref]; ok { // Dependant
 			(*bom.Dependencies)[i].Ref = newBOMRef
-		} else if dependency.Dependencies != nil { // Dependencies
+		}
+		if dependency.Dependencies != nil { // Dependencies
 			for j, dependency2 := range *(*bom.Dependencies)[i].Dependencies {
-				if dependency2 == oldBOMRef {
+				if newBOMRef, ok := redactedBOMRefs[dependency2]; ok {
 					(*(*bom.Dependencies)[i].Dependencies)[j] = newBOMRef
 				}
 			}
@@ -132,42 +156,98 @@ func RequireStdlibComponentToBeRedacted(t *testing.T, bom *cdx.BOM, expectPackag
 }
 
 func RequireVolatilePURLQualifiersToBeRedacted(t *testing.T, bom *cdx.BOM) {
-	if bom.Metadata.Component != nil && bom.Metadata.Component.PackageURL != "" {
-		purl, err := packageurl.FromString(bom.Metadata.Component.PackageURL)
-		require.NoError(t, err)
-
-		qualifierMap := purl.Qualifiers.Map()
-		for k := range qualifierMap {
-			switch k {
-			case "goarch":
-				qualifierMap[k] = Redacted
-			case "goos":
-				qualifierMap[k] = Redacted
+	if bom.Metadata.Component != nil {
+		redactVolatilePURLQualifiers(t, bom.Metadata.Component)
+
+		// Main components of merged SBOMs are nested
+		if bom.Metadata.Component.Components != nil {
+			for i := range *bom.Metadata.Component.Components {
+				redactVolatilePURLQualifiers(t, &(*bom.Metadata.Component.Components)[i])
 			}
 		}
+	}
 
-		purl.Qualifiers = packageurl.QualifiersFromMap(qualifierMap)
-		bom.Metadata.Component.PackageURL = purl.String()
+	for i := range *bom.Components {
+		redactVolatilePURLQualifiers(t, &(*bom.Components)[i])
 	}
+}
 
-	for i, component := range *bom.Components {
-		if component.PackageURL != "" {
-			purl, err := packageurl.FromString(component.PackageURL)
-			require.NoError(t, err)
-
-			qualifierMap := purl.Qualifiers.Map()
-			for k := range qualifierMap {
-				switch k {
-				case "goarch":
-					qualifierMap[k] = Redacted
-				case "goos":
-					qualifierMap[k] = Redacted
-				}
-			}
+func redactVolatilePURLQualifiers(t *testing.T, component *cdx.Component) {
+	if component.PackageURL == "" {
+		return
+	}
+
+	purl, err := packageurl.FromString(component.PackageURL)
+	require.NoError(t, err)
+
+	qualifierMap := purl.Qualifiers.Map()
+	for k := range qualifierMap {
+		switch k {
+		case "goarch":
+			qualifierMap[k] = Redacted
+		case "goos":
+			qualifierMap[k] = Redacted
+		}
+	}
+
+	purl.Qualifiers = packageurl.QualifiersFromMap(qualifierMap)
+	component.PackageURL = purl.String()
+}
+
+// RequireVolatileBuildEnvironmentToBeRedacted redacts parts of the formulation
+// that depend on the environment the tests are executed in.
+// The set of environment variables differs between platforms (e.g. GOAMD64 vs. GOARM64),
+// so only GOARCH and GOOS are retained.
+func RequireVolatileBuildEnvironmentToBeRedacted(t *testing.T, bom *cdx.BOM) {
+	if bom.Formulation == nil {
+		return
+	}
+
+	for i := range *bom.Formulation {
+		formula := &(*bom.Formulation)[i]
+		if formula.Workflows == nil {
+			continue
+		}
+
+		for j := range *formula.Workflows {
+			redactVolatileBuildEnvironment(t, &(*formula.Workflows)[j])
+		}
+	}
+}
+
+func redactVolatileBuildEnvironment(t *testing.T, workflow *cdx.Workflow) {
+	if ref, _, found := strings.Cut(workflow.BOMRef, "|go-build:"); found {
+		workflow.BOMRef = ref + "|go-build:" + Redacted
+	}
+	if ref, _, found := strings.Cut(workflow.UID, "|go-build:"); found {
+		workflow.UID = ref + "|go-build:" + Redacted
+	}
+
+	if workflow.Inputs == nil {
+		return
+	}
+
+	for i := range *workflow.Inputs {
+		input := &(*workflow.Inputs)[i]
+		if input.EnvironmentVars == nil {
+			continue
+		}
 
-			purl.Qualifiers = packageurl.QualifiersFromMap(qualifierMap)
-			(*bom.Components)[i].PackageURL = purl.String()
+		redacted := make(cdx.EnvironmentVariables, 0, 2)
+		for _, envVar := range *input.EnvironmentVars {
+			if envVar.Property == nil {
+				continue
+			}
+			switch envVar.Property.Name {
+			case "GOARCH", "GOOS":
+				redacted = append(redacted, cdx.EnvironmentVariableChoice{
+					Property: &cdx.Property{Name: envVar.Property.Name, Value: Redacted},
+				})
+			}
 		}
+		require.Len(t, redacted, 2, "GOARCH and GOOS must be part of the build environment")
+
+		input.EnvironmentVars = &redacted
 	}
 }
 
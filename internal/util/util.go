@@ -50,6 +50,22 @@ func IsSubPath(subject, path string) (bool, error) {
 	return true, nil
 }
 
+// IsSameDir checks if a and b refer to the same directory.
+// Unlike a lexical comparison, this accounts for symlinks and relative paths.
+func IsSameDir(a, b string) bool {
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(aInfo, bInfo)
+}
+
 func ParseSpecVersion(specVersion string) (sv cdx.SpecVersion, err error) {
 	switch specVersion {
 	case cdx.SpecVersion1_0.String():
@@ -19,6 +19,7 @@ package util
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -33,3 +34,14 @@ func TestFileExists(t *testing.T) {
 	defer os.Remove(tmpFile.Name())
 	require.True(t, FileExists(tmpFile.Name()))
 }
+
+func TestIsSameDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	assert.True(t, IsSameDir(tmpDir, tmpDir+string(os.PathSeparator)+"."))
+	assert.False(t, IsSameDir(tmpDir, os.TempDir()))
+	assert.False(t, IsSameDir(tmpDir, "doesNotExist"))
+
+	link := filepath.Join(t.TempDir(), "link")
+	require.NoError(t, os.Symlink(tmpDir, link))
+	assert.True(t, IsSameDir(tmpDir, link))
+}
@@ -0,0 +1,189 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+// Package vuln provides access to local copies of the Go vulnerability database.
+package vuln
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog"
+)
+
+// StdlibModulePath is the module path used by the vulnerability database for the Go standard library.
+const StdlibModulePath = "stdlib"
+
+// ErrNoDatabase indicates that a given path is not a valid vulnerability database.
+var ErrNoDatabase = errors.New("not a go vulnerability database")
+
+// Database is a local copy of a Go vulnerability database,
+// using the same layout as https://vuln.go.dev.
+// See https://go.dev/security/vuln/database#api
+type Database struct {
+	dir     string
+	modules map[string][]string // module path -> IDs of entries affecting the module
+}
+
+// See https://go.dev/security/vuln/database#index-modules-json
+type moduleIndexEntry struct {
+	Path  string `json:"path"`
+	Vulns []struct {
+		ID string `json:"id"`
+	} `json:"vulns"`
+}
+
+// OpenDatabase loads the module index of the vulnerability database in dir.
+// Entries themselves are only read when they're needed.
+func OpenDatabase(logger zerolog.Logger, dir string) (*Database, error) {
+	logger.Debug().
+		Str("dir", dir).
+		Msg("opening vulnerability database")
+
+	indexFile, err := os.Open(filepath.Join(dir, "index", "modules.json"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNoDatabase
+		}
+		return nil, fmt.Errorf("failed to open module index: %w", err)
+	}
+	defer indexFile.Close()
+
+	var index []moduleIndexEntry
+	err = json.NewDecoder(indexFile).Decode(&index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode module index: %w", err)
+	}
+
+	db := Database{
+		dir:     dir,
+		modules: make(map[string][]string, len(index)),
+	}
+	for _, module := range index {
+		for _, vuln := range module.Vulns {
+			db.modules[module.Path] = append(db.modules[module.Path], vuln.ID)
+		}
+	}
+
+	return &db, nil
+}
+
+// ModuleEntries returns all entries of the database that affect the module with the given path.
+// Withdrawn entries are omitted.
+func (db Database) ModuleEntries(modulePath string) ([]Entry, error) {
+	ids := db.modules[modulePath]
+	entries := make([]Entry, 0, len(ids))
+
+	for _, id := range ids {
+		entry, err := db.entry(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load entry %s: %w", id, err)
+		}
+		if entry.Withdrawn != "" {
+			continue
+		}
+
+		entries = append(entries, *entry)
+	}
+
+	return entries, nil
+}
+
+func (db Database) entry(id string) (*Entry, error) {
+	entryFile, err := os.Open(filepath.Join(db.dir, "ID", id+".json"))
+	if err != nil {
+		return nil, err
+	}
+	defer entryFile.Close()
+
+	var entry Entry
+	err = json.NewDecoder(entryFile).Decode(&entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// Entry is a vulnerability in OSV format, with Go-specific extensions.
+// See https://go.dev/security/vuln/database#schema
+type Entry struct {
+	ID               string            `json:"id"`
+	Modified         string            `json:"modified"`
+	Published        string            `json:"published"`
+	Withdrawn        string            `json:"withdrawn"`
+	Aliases          []string          `json:"aliases"`
+	Summary          string            `json:"summary"`
+	Details          string            `json:"details"`
+	Affected         []Affected        `json:"affected"`
+	References       []Reference       `json:"references"`
+	Credits          []Credit          `json:"credits"`
+	DatabaseSpecific *DatabaseSpecific `json:"database_specific"`
+}
+
+type Affected struct {
+	Module            AffectedModule     `json:"package"`
+	Ranges            []Range            `json:"ranges"`
+	EcosystemSpecific *EcosystemSpecific `json:"ecosystem_specific"`
+}
+
+type AffectedModule struct {
+	Path      string `json:"name"`
+	Ecosystem string `json:"ecosystem"`
+}
+
+type Range struct {
+	Type   string       `json:"type"` // always SEMVER for the Go vulnerability database
+	Events []RangeEvent `json:"events"`
+}
+
+// RangeEvent describes a version at which a module becomes affected (Introduced) or unaffected (Fixed).
+// Versions are semantic versions without "v" prefix, "0" denotes the very first version.
+type RangeEvent struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+}
+
+type EcosystemSpecific struct {
+	Packages []AffectedPackage `json:"imports"`
+}
+
+// AffectedPackage describes a package containing vulnerable code.
+// Empty GOOS and GOARCH lists mean that all platforms are affected.
+type AffectedPackage struct {
+	Path    string   `json:"path"`
+	GOOS    []string `json:"goos"`
+	GOARCH  []string `json:"goarch"`
+	Symbols []string `json:"symbols"`
+}
+
+type Reference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type Credit struct {
+	Name string `json:"name"`
+}
+
+type DatabaseSpecific struct {
+	URL string `json:"url"`
+}
@@ -0,0 +1,110 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package vuln
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+// writeDatabase writes a vulnerability database containing the given entries to a temporary directory.
+func writeDatabase(t *testing.T, entries ...Entry) string {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "index"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "ID"), 0o755))
+
+	modules := make(map[string][]string)
+	var modulePaths []string
+	for _, entry := range entries {
+		for _, affected := range entry.Affected {
+			if _, ok := modules[affected.Module.Path]; !ok {
+				modulePaths = append(modulePaths, affected.Module.Path)
+			}
+			modules[affected.Module.Path] = append(modules[affected.Module.Path], entry.ID)
+		}
+
+		entryJSON, err := json.Marshal(entry)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "ID", entry.ID+".json"), entryJSON, 0o600))
+	}
+
+	index := make([]map[string]any, 0, len(modulePaths))
+	for _, path := range modulePaths {
+		var vulns []map[string]string
+		for _, id := range modules[path] {
+			vulns = append(vulns, map[string]string{"id": id})
+		}
+		index = append(index, map[string]any{"path": path, "vulns": vulns})
+	}
+
+	indexJSON, err := json.Marshal(index)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index", "modules.json"), indexJSON, 0o600))
+
+	return dir
+}
+
+func TestOpenDatabase(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		dir := writeDatabase(t, Entry{
+			ID:       "GO-0000-0001",
+			Affected: []Affected{{Module: AffectedModule{Path: "example.com/foo"}}},
+		})
+
+		db, err := OpenDatabase(zerolog.Nop(), dir)
+		require.NoError(t, err)
+		require.Equal(t, []string{"GO-0000-0001"}, db.modules["example.com/foo"])
+	})
+
+	t.Run("NoDatabase", func(t *testing.T) {
+		db, err := OpenDatabase(zerolog.Nop(), t.TempDir())
+		require.ErrorIs(t, err, ErrNoDatabase)
+		require.Nil(t, db)
+	})
+}
+
+func TestDatabase_ModuleEntries(t *testing.T) {
+	dir := writeDatabase(t,
+		Entry{
+			ID:       "GO-0000-0001",
+			Affected: []Affected{{Module: AffectedModule{Path: "example.com/foo"}}},
+		},
+		Entry{
+			ID:        "GO-0000-0002",
+			Withdrawn: "2024-01-01T00:00:00Z",
+			Affected:  []Affected{{Module: AffectedModule{Path: "example.com/foo"}}},
+		},
+	)
+
+	db, err := OpenDatabase(zerolog.Nop(), dir)
+	require.NoError(t, err)
+
+	entries, err := db.ModuleEntries("example.com/foo")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "GO-0000-0001", entries[0].ID)
+
+	entries, err = db.ModuleEntries("example.com/bar")
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
@@ -0,0 +1,346 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package vuln
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/rs/zerolog"
+	"golang.org/x/mod/semver"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/gomod"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
+)
+
+const (
+	// PropertyImported denotes whether any of the affected packages is imported ("true" or "false").
+	PropertyImported = "vuln:imported"
+	// PropertyImportedPackage names an affected package that is imported.
+	PropertyImportedPackage = "vuln:imported:package"
+	// PropertyAffectedSymbol names a vulnerable symbol of an imported package, in "package.Symbol" notation.
+	PropertyAffectedSymbol = "vuln:affected:symbol"
+)
+
+// Scan matches the given modules against the vulnerability database and
+// returns a CycloneDX vulnerability for each database entry that affects at least one of them.
+//
+// For every vulnerability it is recorded whether the affected packages are imported,
+// based on the packages of the modules. This requires the modules to have been loaded
+// for the target platform, as denoted by goos and goarch. Symbol-level reachability is
+// not analyzed, affected symbols of imported packages are listed instead.
+func Scan(logger zerolog.Logger, db *Database, modules []gomod.Module, goos, goarch string) ([]cdx.Vulnerability, error) {
+	vulnMap := make(map[string]*cdx.Vulnerability)
+
+	for i := range modules {
+		module := modules[i]
+		if module.Replace != nil {
+			// Packages are associated with the replaced module
+			module = *module.Replace
+			module.Packages = modules[i].Packages
+		}
+
+		if module.Local {
+			logger.Debug().
+				Str("module", module.Coordinates()).
+				Str("reason", "local module").
+				Msg("skipping vulnerability scan")
+			continue
+		}
+
+		dbPath, version := module.Path, module.Version
+		if module.Path == gomod.StdlibModulePath {
+			dbPath, version = StdlibModulePath, goVersionToSemver(module.Version)
+		}
+		if !semver.IsValid(version) {
+			logger.Debug().
+				Str("module", module.Coordinates()).
+				Str("reason", "no valid version").
+				Msg("skipping vulnerability scan")
+			continue
+		}
+
+		entries, err := db.ModuleEntries(dbPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load vulnerabilities of %s: %w", module.Path, err)
+		}
+
+		for _, entry := range entries {
+			for _, affected := range entry.Affected {
+				if affected.Module.Path != dbPath {
+					continue
+				}
+
+				isAffected, fixed := affectsVersion(affected.Ranges, version)
+				if !isAffected {
+					continue
+				}
+
+				logger.Debug().
+					Str("module", module.Coordinates()).
+					Str("vuln", entry.ID).
+					Msg("module is affected by vulnerability")
+
+				vuln, ok := vulnMap[entry.ID]
+				if !ok {
+					vuln = convertEntry(entry)
+					vulnMap[entry.ID] = vuln
+				}
+				addAffectedModule(vuln, module, affected, fixed, goos, goarch)
+			}
+		}
+	}
+
+	vulns := make([]cdx.Vulnerability, 0, len(vulnMap))
+	for _, vuln := range vulnMap {
+		finalizeAnalysis(vuln)
+		vulns = append(vulns, *vuln)
+	}
+	slices.SortFunc(vulns, func(a, b cdx.Vulnerability) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return vulns, nil
+}
+
+func convertEntry(entry Entry) *cdx.Vulnerability {
+	source := cdx.Source{
+		Name: "Go Vulnerability Database",
+		URL:  "https://pkg.go.dev/vuln/" + entry.ID,
+	}
+	if entry.DatabaseSpecific != nil && entry.DatabaseSpecific.URL != "" {
+		source.URL = entry.DatabaseSpecific.URL
+	}
+
+	vuln := cdx.Vulnerability{
+		BOMRef:      entry.ID,
+		ID:          entry.ID,
+		Source:      &source,
+		Description: entry.Summary,
+		Detail:      entry.Details,
+		Published:   entry.Published,
+		Updated:     entry.Modified,
+		Properties:  &[]cdx.Property{},
+	}
+
+	if len(entry.Aliases) > 0 {
+		references := make([]cdx.VulnerabilityReference, 0, len(entry.Aliases))
+		for _, alias := range entry.Aliases {
+			references = append(references, cdx.VulnerabilityReference{
+				ID:     alias,
+				Source: aliasSource(alias),
+			})
+		}
+		vuln.References = &references
+	}
+
+	if len(entry.References) > 0 {
+		advisories := make([]cdx.Advisory, 0, len(entry.References))
+		for _, reference := range entry.References {
+			advisories = append(advisories, cdx.Advisory{URL: reference.URL})
+		}
+		vuln.Advisories = &advisories
+	}
+
+	if len(entry.Credits) > 0 {
+		individuals := make([]cdx.OrganizationalContact, 0, len(entry.Credits))
+		for _, credit := range entry.Credits {
+			individuals = append(individuals, cdx.OrganizationalContact{Name: credit.Name})
+		}
+		vuln.Credits = &cdx.Credits{Individuals: &individuals}
+	}
+
+	return &vuln
+}
+
+func aliasSource(alias string) *cdx.Source {
+	switch {
+	case strings.HasPrefix(alias, "CVE-"):
+		return &cdx.Source{Name: "NVD", URL: "https://nvd.nist.gov/vuln/detail/" + alias}
+	case strings.HasPrefix(alias, "GHSA-"):
+		return &cdx.Source{Name: "GitHub", URL: "https://github.com/advisories/" + alias}
+	}
+
+	return nil
+}
+
+// addAffectedModule records module as being affected by vuln, and adds the
+// imported packages and their symbols that are affected on the target platform.
+func addAffectedModule(vuln *cdx.Vulnerability, module gomod.Module, affected Affected, fixed, goos, goarch string) {
+	affects := cdx.Affects{
+		Ref: module.BOMRef(),
+		Range: &[]cdx.AffectedVersions{
+			{Version: module.Version, Status: cdx.VulnerabilityStatusAffected},
+		},
+	}
+	if vuln.Affects == nil {
+		vuln.Affects = &[]cdx.Affects{affects}
+	} else {
+		*vuln.Affects = append(*vuln.Affects, affects)
+	}
+
+	if fixed != "" {
+		recommendation := fmt.Sprintf("Upgrade %s to v%s", module.Path, fixed)
+		if module.Path == gomod.StdlibModulePath {
+			recommendation = fmt.Sprintf("Upgrade Go to go%s", fixed)
+		}
+		if vuln.Recommendation == "" {
+			vuln.Recommendation = recommendation
+		} else {
+			vuln.Recommendation += "; " + recommendation
+		}
+	}
+
+	var affectedPackages []AffectedPackage
+	if affected.EcosystemSpecific != nil {
+		affectedPackages = affected.EcosystemSpecific.Packages
+	}
+
+	properties := vuln.Properties
+	if len(affectedPackages) == 0 {
+		// The entire module is affected
+		if len(module.Packages) > 0 {
+			*properties = append(*properties, sbom.NewProperty(PropertyImported, "true"))
+		}
+		return
+	}
+
+	for _, affectedPackage := range affectedPackages {
+		if !appliesToPlatform(affectedPackage, goos, goarch) {
+			continue
+		}
+		if !slices.ContainsFunc(module.Packages, func(pkg gomod.Package) bool { return pkg.ImportPath == affectedPackage.Path }) {
+			continue
+		}
+
+		*properties = append(*properties,
+			sbom.NewProperty(PropertyImported, "true"),
+			sbom.NewProperty(PropertyImportedPackage, affectedPackage.Path))
+		for _, symbol := range affectedPackage.Symbols {
+			*properties = append(*properties, sbom.NewProperty(PropertyAffectedSymbol, affectedPackage.Path+"."+symbol))
+		}
+	}
+}
+
+// finalizeAnalysis deduplicates the properties of vuln and, if none of
+// the affected packages are imported, marks it as not affecting the application.
+func finalizeAnalysis(vuln *cdx.Vulnerability) {
+	properties := *vuln.Properties
+	sbom.SortProperties(properties)
+	properties = slices.Compact(properties)
+
+	if !slices.Contains(properties, sbom.NewProperty(PropertyImported, "true")) {
+		properties = append([]cdx.Property{sbom.NewProperty(PropertyImported, "false")}, properties...)
+		vuln.Analysis = &cdx.VulnerabilityAnalysis{
+			State:         cdx.IASNotAffected,
+			Justification: cdx.IAJCodeNotPresent,
+			Detail:        "None of the affected packages are imported for the target platform.",
+		}
+	}
+
+	vuln.Properties = &properties
+}
+
+func appliesToPlatform(pkg AffectedPackage, goos, goarch string) bool {
+	return (len(pkg.GOOS) == 0 || slices.Contains(pkg.GOOS, goos)) &&
+		(len(pkg.GOARCH) == 0 || slices.Contains(pkg.GOARCH, goarch))
+}
+
+// affectsVersion determines whether version (with "v" prefix) is within any of the given ranges.
+// If it is, the lowest version in which the vulnerability is fixed is returned as well (without "v" prefix).
+//
+// See https://ossf.github.io/osv-schema/#evaluation
+func affectsVersion(ranges []Range, version string) (bool, string) {
+	for _, r := range ranges {
+		if r.Type != "SEMVER" {
+			continue
+		}
+
+		events := slices.Clone(r.Events)
+		slices.SortStableFunc(events, func(a, b RangeEvent) int {
+			return semver.Compare(a.semver(), b.semver())
+		})
+
+		affected := false
+		fixed := ""
+		for _, event := range events {
+			switch {
+			case event.Introduced != "":
+				if event.Introduced == "0" || semver.Compare(version, "v"+event.Introduced) >= 0 {
+					affected = true
+				}
+			case event.Fixed != "":
+				if semver.Compare(version, "v"+event.Fixed) >= 0 {
+					affected = false
+				} else if fixed == "" {
+					fixed = event.Fixed
+				}
+			case event.LastAffected != "":
+				if semver.Compare(version, "v"+event.LastAffected) > 0 {
+					affected = false
+				}
+			}
+		}
+
+		if affected {
+			return true, fixed
+		}
+	}
+
+	return false, ""
+}
+
+func (e RangeEvent) semver() string {
+	switch {
+	case e.Introduced == "0":
+		return "v0.0.0-0" // lowest possible version
+	case e.Introduced != "":
+		return "v" + e.Introduced
+	case e.Fixed != "":
+		return "v" + e.Fixed
+	}
+
+	return "v" + e.LastAffected
+}
+
+var goVersionRegex = regexp.MustCompile(`^go(\d+)\.(\d+)(?:\.(\d+))?(?:(beta|rc)(\d+))?$`)
+
+// goVersionToSemver converts a Go version like go1.21.5 or go1.22rc1
+// to a semantic version as used by the vulnerability database.
+// An empty string is returned for versions that can't be converted.
+func goVersionToSemver(goVersion string) string {
+	matches := goVersionRegex.FindStringSubmatch(goVersion)
+	if matches == nil {
+		return ""
+	}
+
+	patch := matches[3]
+	if patch == "" {
+		patch = "0"
+	}
+
+	version := fmt.Sprintf("v%s.%s.%s", matches[1], matches[2], patch)
+	if matches[4] != "" {
+		version += "-" + matches[4] + "." + matches[5]
+	}
+
+	return version
+}
@@ -0,0 +1,156 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package vuln
+
+import (
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/gomod"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
+)
+
+func TestScan(t *testing.T) {
+	dir := writeDatabase(t,
+		Entry{
+			ID: "GO-0000-0001",
+			Affected: []Affected{{
+				Module: AffectedModule{Path: "example.com/fork"},
+				Ranges: []Range{{Type: "SEMVER", Events: []RangeEvent{{Introduced: "0"}, {Fixed: "1.0.1"}}}},
+				EcosystemSpecific: &EcosystemSpecific{Packages: []AffectedPackage{
+					{Path: "example.com/fork/a", Symbols: []string{"Foo"}},
+					{Path: "example.com/fork/b"},
+				}},
+			}},
+		},
+		Entry{
+			ID: "GO-0000-0002",
+			Affected: []Affected{{
+				Module: AffectedModule{Path: StdlibModulePath},
+				Ranges: []Range{{Type: "SEMVER", Events: []RangeEvent{{Introduced: "1.21.0"}, {Fixed: "1.21.5"}}}},
+				EcosystemSpecific: &EcosystemSpecific{Packages: []AffectedPackage{
+					{Path: "net/http", GOOS: []string{"windows"}},
+				}},
+			}},
+		},
+		Entry{
+			ID: "GO-0000-0003",
+			Affected: []Affected{{
+				Module: AffectedModule{Path: "example.com/local"},
+				Ranges: []Range{{Type: "SEMVER", Events: []RangeEvent{{Introduced: "0"}}}},
+			}},
+		},
+	)
+	db, err := OpenDatabase(zerolog.Nop(), dir)
+	require.NoError(t, err)
+
+	modules := []gomod.Module{
+		{
+			Path:    "example.com/lib",
+			Version: "v1.0.0",
+			Replace: &gomod.Module{
+				Path:    "example.com/fork",
+				Version: "v1.0.0",
+			},
+			Packages: []gomod.Package{{ImportPath: "example.com/fork/a"}},
+		},
+		{
+			Path:     gomod.StdlibModulePath,
+			Version:  "go1.21.4",
+			Packages: []gomod.Package{{ImportPath: "net/http"}},
+		},
+		{
+			Path:    "example.com/local",
+			Version: "v1.0.0",
+			Local:   true,
+		},
+	}
+
+	vulns, err := Scan(zerolog.Nop(), db, modules, "linux", "amd64")
+	require.NoError(t, err)
+	require.Len(t, vulns, 2)
+
+	assert.Equal(t, "GO-0000-0001", vulns[0].ID)
+	assert.Equal(t, "pkg:golang/example.com/fork@v1.0.0?type=module", (*vulns[0].Affects)[0].Ref)
+	assert.Equal(t, "Upgrade example.com/fork to v1.0.1", vulns[0].Recommendation)
+	assert.Nil(t, vulns[0].Analysis)
+	assert.Equal(t, []cdx.Property{
+		sbom.NewProperty(PropertyAffectedSymbol, "example.com/fork/a.Foo"),
+		sbom.NewProperty(PropertyImported, "true"),
+		sbom.NewProperty(PropertyImportedPackage, "example.com/fork/a"),
+	}, *vulns[0].Properties)
+
+	assert.Equal(t, "GO-0000-0002", vulns[1].ID)
+	assert.Equal(t, "Upgrade Go to go1.21.5", vulns[1].Recommendation)
+	require.NotNil(t, vulns[1].Analysis)
+	assert.Equal(t, cdx.IASNotAffected, vulns[1].Analysis.State)
+	assert.Equal(t, []cdx.Property{sbom.NewProperty(PropertyImported, "false")}, *vulns[1].Properties)
+}
+
+func TestAffectsVersion(t *testing.T) {
+	ranges := []Range{{
+		Type: "SEMVER",
+		Events: []RangeEvent{
+			{Introduced: "1.5.0"},
+			{Fixed: "1.2.0"},
+			{Introduced: "0"},
+			{Fixed: "1.6.0"},
+		},
+	}}
+
+	testCases := []struct {
+		version  string
+		affected bool
+		fixed    string
+	}{
+		{version: "v0.0.0-20210101000000-abcdefabcdef", affected: true, fixed: "1.2.0"},
+		{version: "v1.1.9", affected: true, fixed: "1.2.0"},
+		{version: "v1.2.0", affected: false},
+		{version: "v1.5.0", affected: true, fixed: "1.6.0"},
+		{version: "v1.6.0", affected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.version, func(t *testing.T) {
+			affected, fixed := affectsVersion(ranges, tc.version)
+			require.Equal(t, tc.affected, affected)
+			require.Equal(t, tc.fixed, fixed)
+		})
+	}
+
+	t.Run("LastAffected", func(t *testing.T) {
+		lastAffected := []Range{{Type: "SEMVER", Events: []RangeEvent{{Introduced: "0"}, {LastAffected: "1.0.0"}}}}
+
+		affected, _ := affectsVersion(lastAffected, "v1.0.0")
+		require.True(t, affected)
+		affected, _ = affectsVersion(lastAffected, "v1.0.1")
+		require.False(t, affected)
+	})
+}
+
+func TestGoVersionToSemver(t *testing.T) {
+	assert.Equal(t, "v1.21.5", goVersionToSemver("go1.21.5"))
+	assert.Equal(t, "v1.20.0", goVersionToSemver("go1.20"))
+	assert.Equal(t, "v1.22.0-rc.1", goVersionToSemver("go1.22rc1"))
+	assert.Equal(t, "v1.19.0-beta.1", goVersionToSemver("go1.19beta1"))
+	assert.Equal(t, "", goVersionToSemver("devel go1.23-abcdef"))
+}
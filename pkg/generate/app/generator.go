@@ -18,9 +18,12 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
 	"slices"
 	"strings"
 
@@ -28,32 +31,52 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
+	"github.com/CycloneDX/cyclonedx-gomod/internal/cache"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/gocmd"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/gomod"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
 	modConv "github.com/CycloneDX/cyclonedx-gomod/internal/sbom/convert/module"
 	pkgConv "github.com/CycloneDX/cyclonedx-gomod/internal/sbom/convert/pkg"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/vuln"
 	"github.com/CycloneDX/cyclonedx-gomod/pkg/generate"
 	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/curation"
 )
 
 type generator struct {
 	logger zerolog.Logger
 
-	includeFiles    bool
-	includePackages bool
-	includePaths    bool
-	includeStdlib   bool
-	licenseDetector licensedetect.Detector
-	mainDir         string
-	moduleDir       string
-	shortPURLs      bool
+	cache              *cache.Cache
+	cacheDir           string
+	hashAlgorithms     []cdx.HashAlgorithm
+	includeFiles       bool
+	includePackageDeps bool
+	includePackages    bool
+	includePaths       bool
+	includeStdlib      bool
+	licenseCurations   *curation.Curations
+	licenseDetector    licensedetect.Detector
+	parallelism        int
+	mainDir            string
+	moduleDir          string
+	platforms          []Platform
+	shortPURLs         bool
+	vulnDBDir          string
+
+	goos       string
+	goarch     string
+	buildTags  []string
+	cgoEnabled *bool
+	env        []string
 }
 
-func NewGenerator(moduleDir string, opts ...Option) (generate.Generator, error) {
+// NewGenerator returns a generator that is capable of generating BOMs for Go applications.
+func NewGenerator(moduleDir string, opts ...Option) (generate.ContextGenerator, error) {
 	g := generator{
-		logger:    log.Logger,
-		moduleDir: moduleDir,
+		logger:         log.Logger,
+		moduleDir:      moduleDir,
+		hashAlgorithms: sbom.DefaultHashAlgorithms(),
+		parallelism:    runtime.GOMAXPROCS(0),
 	}
 
 	var err error
@@ -68,7 +91,58 @@ func NewGenerator(moduleDir string, opts ...Option) (generate.Generator, error)
 
 // Generate implements the generate.Generator interface.
 func (g generator) Generate() (*cdx.BOM, error) {
-	modules, err := gomod.LoadModulesFromPackages(g.logger, g.moduleDir, g.mainDir)
+	return g.GenerateContext(context.Background())
+}
+
+// GenerateContext implements the generate.ContextGenerator interface.
+func (g generator) GenerateContext(ctx context.Context) (*cdx.BOM, error) {
+	var err error
+	g.cache, err = cache.Open(ctx, g.logger, g.cacheDir)
+	if err != nil {
+		g.logger.Warn().Err(err).Msg("failed to open cache, continuing without it")
+	}
+	g.licenseDetector = g.cache.LicenseDetector(g.licenseDetector)
+
+	if len(g.platforms) > 0 {
+		return g.generateForPlatforms(ctx)
+	}
+
+	return g.generate(ctx, g.commandEnv())
+}
+
+// generateForPlatforms generates a BOM for each target platform
+// and merges them into a single one.
+func (g generator) generateForPlatforms(ctx context.Context) (*cdx.BOM, error) {
+	if g.licenseDetector != nil {
+		g.licenseDetector = newMemoizingDetector(g.licenseDetector)
+	}
+
+	merger := newPlatformMerger()
+	for _, platform := range g.platforms {
+		g.logger.Debug().
+			Str("platform", platform.String()).
+			Msg("generating bom for platform")
+
+		bom, err := g.generate(ctx, append(g.commandEnv(), platform.env()...))
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate bom for platform %s: %w", platform, err)
+		}
+
+		merger.add(platform, bom)
+	}
+
+	return merger.bom(), nil
+}
+
+// generate generates a BOM for the application.
+// env holds additional environment variables for the go command.
+func (g generator) generate(ctx context.Context, env []string) (*cdx.BOM, error) {
+	goEnv, err := gocmd.GetEnv(ctx, g.logger, env...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get go environment: %w", err)
+	}
+
+	modules, err := gomod.LoadModulesFromPackages(ctx, g.logger, g.moduleDir, g.mainDir, env...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load modules: %w", err)
 	}
@@ -86,6 +160,7 @@ func (g generator) Generate() (*cdx.BOM, error) {
 	for i, module := range modules {
 		if module.Path == gomod.StdlibModulePath {
 			if g.includeStdlib {
+				modules = append(modules, gomod.StdlibModules(module)[1:]...)
 				modules[appModuleIndex].Dependencies = append(modules[appModuleIndex].Dependencies, &modules[i])
 				break
 			} else {
@@ -97,7 +172,7 @@ func (g generator) Generate() (*cdx.BOM, error) {
 
 	// Dependencies need to be applied prior to determining the main
 	// module's version, because `go mod graph` omits that version.
-	err = gomod.ApplyModuleGraph(g.logger, g.moduleDir, modules)
+	err = gomod.ApplyModuleGraph(ctx, g.logger, g.moduleDir, modules)
 	if err != nil {
 		return nil, fmt.Errorf("failed to apply module graph: %w", err)
 	}
@@ -107,34 +182,36 @@ func (g generator) Generate() (*cdx.BOM, error) {
 		return nil, fmt.Errorf("failed to determine version of main module: %w", err)
 	}
 
+	// When the application module is part of a workspace, packages
+	// of other workspace modules are reported as main modules, too.
+	gomod.MarkWorkspaceModules(modules, modules[appModuleIndex].Dir)
+	gomod.ResolveWorkspaceModuleVersions(g.logger, modules)
+
 	mainComponent, err := modConv.ToComponent(g.logger, modules[appModuleIndex],
 		modConv.WithComponentType(cdx.ComponentTypeApplication),
-		modConv.WithLicenses(g.licenseDetector),
+		modConv.WithLicenses(ctx, g.licenseDetector, g.licenseCurations),
 		modConv.WithShortPURL(g.shortPURLs),
 		modConv.WithPackages(g.includePackages,
-			pkgConv.WithFiles(g.includeFiles, g.includePaths),
+			pkgConv.WithFiles(g.includeFiles, g.includePaths, g.cache, g.hashAlgorithms...),
 			pkgConv.WithShortPURL(g.shortPURLs)),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert main module: %w", err)
 	}
 
-	buildProperties, err := g.createBuildProperties()
-	if err != nil {
-		return nil, err
-	}
+	buildProperties := g.createBuildProperties(goEnv)
 	if mainComponent.Properties == nil {
 		mainComponent.Properties = &buildProperties
 	} else {
 		*mainComponent.Properties = append(*mainComponent.Properties, buildProperties...)
 	}
 
-	components, err := modConv.ToComponents(g.logger, modules,
-		modConv.WithLicenses(g.licenseDetector),
-		modConv.WithModuleHashes(),
+	components, err := modConv.ToComponents(g.logger, modules, g.parallelism,
+		modConv.WithLicenses(ctx, g.licenseDetector, g.licenseCurations),
+		modConv.WithModuleHashes(g.cache),
 		modConv.WithShortPURL(g.shortPURLs),
 		modConv.WithPackages(g.includePackages,
-			pkgConv.WithFiles(g.includeFiles, g.includePaths),
+			pkgConv.WithFiles(g.includeFiles, g.includePaths, g.cache, g.hashAlgorithms...),
 			pkgConv.WithShortPURL(g.shortPURLs)),
 	)
 	if err != nil {
@@ -143,6 +220,9 @@ func (g generator) Generate() (*cdx.BOM, error) {
 	components = append(components[0:appModuleIndex], components[appModuleIndex+1:]...)
 
 	dependencies := sbom.BuildDependencyGraph(modules)
+	if g.includePackages && g.includePackageDeps {
+		dependencies = append(dependencies, sbom.BuildPackageDependencyGraph(modules)...)
+	}
 
 	bom := cdx.NewBOM()
 	bom.Metadata = &cdx.Metadata{
@@ -151,14 +231,76 @@ func (g generator) Generate() (*cdx.BOM, error) {
 	bom.Components = &components
 	bom.Dependencies = &dependencies
 
+	if g.vulnDBDir != "" {
+		vulnerabilities, err := g.scanVulnerabilities(modules, goEnv)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan for vulnerabilities: %w", err)
+		}
+		bom.Vulnerabilities = &vulnerabilities
+	}
+
 	err = g.includeAppPathInMainComponentPURL(bom)
 	if err != nil {
 		return nil, fmt.Errorf("failed to enrich bom with app details: %w", err)
 	}
 
+	bom.Formulation = &[]cdx.Formula{
+		sbom.BuildFormula(bom.Metadata.Component.BOMRef, g.buildTarget(), createBuildSettings(goEnv)),
+	}
+
+	// Package URLs are created with the goos and goarch of the current process,
+	// which is not necessarily the platform we're generating the BOM for.
+	setComponentPlatformQualifiers(bom, &Platform{OS: goEnv["GOOS"], Arch: goEnv["GOARCH"]})
+
 	return bom, nil
 }
 
+// scanVulnerabilities matches modules against the vulnerability database in g.vulnDBDir.
+// Modules must have been loaded with goEnv, because their packages determine whether
+// a vulnerability affects the application.
+func (g generator) scanVulnerabilities(modules []gomod.Module, goEnv map[string]string) ([]cdx.Vulnerability, error) {
+	db, err := vuln.OpenDatabase(g.logger, g.vulnDBDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vulnerability database: %w", err)
+	}
+
+	return vuln.Scan(g.logger, db, modules, goEnv["GOOS"], goEnv["GOARCH"])
+}
+
+// commandEnv assembles the environment variables that are passed to the go command,
+// in order to apply the configured build constraints.
+func (g generator) commandEnv() []string {
+	env := slices.Clone(g.env)
+
+	if g.goos != "" {
+		env = append(env, "GOOS="+g.goos)
+	}
+	if g.goarch != "" {
+		env = append(env, "GOARCH="+g.goarch)
+	}
+	if g.cgoEnabled != nil {
+		if *g.cgoEnabled {
+			env = append(env, "CGO_ENABLED=1")
+		} else {
+			env = append(env, "CGO_ENABLED=0")
+		}
+	}
+	if len(g.buildTags) > 0 {
+		// Build tags are passed via GOFLAGS, so they need to be merged with flags that
+		// have already been set. Explicitly provided environment variables take precedence.
+		goflags := os.Getenv("GOFLAGS")
+		for _, e := range g.env {
+			if value, ok := strings.CutPrefix(e, "GOFLAGS="); ok {
+				goflags = value
+			}
+		}
+
+		env = append(env, "GOFLAGS="+setTagsInGoFlags(goflags, g.buildTags))
+	}
+
+	return env
+}
+
 var buildEnv = []string{
 	"CGO_ENABLED",
 	"GOARCH",
@@ -166,12 +308,7 @@ var buildEnv = []string{
 	"GOVERSION",
 }
 
-func (g generator) createBuildProperties() (properties []cdx.Property, err error) {
-	env, err := gocmd.GetEnv(g.logger)
-	if err != nil {
-		return nil, err
-	}
-
+func (g generator) createBuildProperties(env map[string]string) (properties []cdx.Property) {
 	for _, buildEnvKey := range buildEnv {
 		buildEnvVal, ok := env[buildEnvKey]
 		if !ok {
@@ -197,6 +334,79 @@ func (g generator) createBuildProperties() (properties []cdx.Property, err error
 	return
 }
 
+// buildSettingsEnv lists the environment variables that affect the output of go build.
+// It mirrors the ones recorded in the build information of binaries.
+var buildSettingsEnv = []string{
+	"CGO_ENABLED",
+	"GO386",
+	"GOAMD64",
+	"GOARCH",
+	"GOARM",
+	"GOARM64",
+	"GOEXPERIMENT",
+	"GOFIPS140",
+	"GOMIPS",
+	"GOMIPS64",
+	"GOOS",
+	"GOPPC64",
+	"GORISCV64",
+	"GOWASM",
+}
+
+// cgoBuildSettingsEnv lists the environment variables that only affect builds with cgo enabled.
+var cgoBuildSettingsEnv = []string{
+	"CGO_CFLAGS",
+	"CGO_CPPFLAGS",
+	"CGO_CXXFLAGS",
+	"CGO_LDFLAGS",
+}
+
+// createBuildSettings assembles the settings for building the application, in the notation
+// of debug/buildinfo. They're composed of the go environment and the flags in GOFLAGS.
+func createBuildSettings(env map[string]string) map[string]string {
+	settings := make(map[string]string)
+
+	keys := buildSettingsEnv
+	if env["CGO_ENABLED"] == "1" {
+		keys = append(slices.Clone(keys), cgoBuildSettingsEnv...)
+	}
+	for _, key := range keys {
+		if value := env[key]; value != "" {
+			settings[key] = value
+		}
+	}
+
+	for _, field := range strings.Fields(env["GOFLAGS"]) {
+		flag, value, ok := strings.Cut(field, "=")
+		if !ok {
+			value = "true"
+		}
+		settings[flag] = value
+	}
+
+	return settings
+}
+
+// buildTarget returns the package to build, relative to the module directory.
+func (g generator) buildTarget() string {
+	mainDir := path.Clean(filepath.ToSlash(g.mainDir))
+	if mainDir == "." || strings.HasPrefix(mainDir, "../") || path.IsAbs(mainDir) {
+		return mainDir
+	}
+
+	return "./" + mainDir
+}
+
+// setTagsInGoFlags replaces all -tags flags in goflags with the given tags.
+func setTagsInGoFlags(goflags string, tags []string) string {
+	fields := slices.DeleteFunc(strings.Fields(goflags), func(field string) bool {
+		return strings.HasPrefix(field, "-tags=")
+	})
+	fields = append(fields, "-tags="+strings.Join(tags, ","))
+
+	return strings.Join(fields, " ")
+}
+
 func parseTagsFromGoFlags(goflags string) (tags []string) {
 	fields := strings.Fields(goflags)
 
@@ -272,6 +482,20 @@ func (g generator) includeAppPathInMainComponentPURL(bom *cdx.BOM) error {
 				break
 			}
 		}
+
+		// Update references of vulnerabilities affecting the main component
+		if bom.Vulnerabilities != nil {
+			for i := range *bom.Vulnerabilities {
+				if (*bom.Vulnerabilities)[i].Affects == nil {
+					continue
+				}
+				for j, affects := range *(*bom.Vulnerabilities)[i].Affects {
+					if affects.Ref == oldBOMRef {
+						(*(*bom.Vulnerabilities)[i].Affects)[j].Ref = newBOMRef
+					}
+				}
+			}
+		}
 	}
 
 	return nil
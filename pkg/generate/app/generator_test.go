@@ -18,8 +18,10 @@
 package app
 
 import (
+	"context"
 	"errors"
 	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
 
@@ -29,6 +31,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/CycloneDX/cyclonedx-gomod/internal/gocmd"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/testutil"
 	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/local"
 )
@@ -73,6 +76,7 @@ func TestGenerator_Generate(t *testing.T) {
 		testutil.RequireValidSBOM(t, bom, cyclonedx.BOMFileFormatJSON)
 
 		testutil.RequireVolatilePURLQualifiersToBeRedacted(t, bom)
+		testutil.RequireVolatileBuildEnvironmentToBeRedacted(t, bom)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:CGO_ENABLED", `(0|1)`)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOARCH", runtime.GOARCH)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOOS", runtime.GOOS)
@@ -98,6 +102,7 @@ func TestGenerator_Generate(t *testing.T) {
 		testutil.RequireValidSBOM(t, bom, cyclonedx.BOMFileFormatJSON)
 
 		testutil.RequireVolatilePURLQualifiersToBeRedacted(t, bom)
+		testutil.RequireVolatileBuildEnvironmentToBeRedacted(t, bom)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:CGO_ENABLED", `(0|1)`)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOARCH", runtime.GOARCH)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOOS", runtime.GOOS)
@@ -122,6 +127,7 @@ func TestGenerator_Generate(t *testing.T) {
 		testutil.RequireValidSBOM(t, bom, cyclonedx.BOMFileFormatJSON)
 
 		testutil.RequireVolatilePURLQualifiersToBeRedacted(t, bom)
+		testutil.RequireVolatileBuildEnvironmentToBeRedacted(t, bom)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:CGO_ENABLED", `(0|1)`)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOARCH", runtime.GOARCH)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOOS", runtime.GOOS)
@@ -130,6 +136,51 @@ func TestGenerator_Generate(t *testing.T) {
 		testutil.RequireMatchingSBOMSnapshot(t, snapShooter, bom, cyclonedx.BOMFileFormatJSON)
 	})
 
+	t.Run("SimpleWithPackageDependencies", func(t *testing.T) {
+		fixturePath := testutil.ExtractFixtureArchive(t, "../testdata/simple.tar.gz")
+
+		g, err := NewGenerator(fixturePath,
+			WithIncludePackageDependencies(true),
+			WithIncludePackages(true),
+			WithLogger(testutil.SilentLogger))
+		require.NoError(t, err)
+
+		bom, err := g.Generate()
+		require.NoError(t, err)
+
+		testutil.RequireValidSBOM(t, bom, cyclonedx.BOMFileFormatJSON)
+
+		testutil.RequireVolatilePURLQualifiersToBeRedacted(t, bom)
+		testutil.RequireVolatileBuildEnvironmentToBeRedacted(t, bom)
+		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:CGO_ENABLED", `(0|1)`)
+		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOARCH", runtime.GOARCH)
+		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOOS", runtime.GOOS)
+		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOVERSION", `^go1\.`)
+		testutil.RequireMatchingSBOMSnapshot(t, snapShooter, bom, cyclonedx.BOMFileFormatJSON)
+	})
+
+	t.Run("SimpleWithVulnerabilities", func(t *testing.T) {
+		fixturePath := testutil.ExtractFixtureArchive(t, "../testdata/simple.tar.gz")
+
+		g, err := NewGenerator(fixturePath,
+			WithVulnerabilityDatabase("../testdata/vulndb"),
+			WithLogger(testutil.SilentLogger))
+		require.NoError(t, err)
+
+		bom, err := g.Generate()
+		require.NoError(t, err)
+
+		testutil.RequireValidSBOM(t, bom, cyclonedx.BOMFileFormatJSON)
+
+		testutil.RequireVolatilePURLQualifiersToBeRedacted(t, bom)
+		testutil.RequireVolatileBuildEnvironmentToBeRedacted(t, bom)
+		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:CGO_ENABLED", `(0|1)`)
+		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOARCH", runtime.GOARCH)
+		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOOS", runtime.GOOS)
+		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOVERSION", `^go1\.`)
+		testutil.RequireMatchingSBOMSnapshot(t, snapShooter, bom, cyclonedx.BOMFileFormatJSON)
+	})
+
 	t.Run("SimpleMultiCommandPURL", func(t *testing.T) {
 		fixturePath := testutil.ExtractFixtureArchive(t, "../testdata/simple-multi-command.tar.gz")
 
@@ -146,6 +197,7 @@ func TestGenerator_Generate(t *testing.T) {
 		testutil.RequireValidSBOM(t, bom, cyclonedx.BOMFileFormatJSON)
 
 		testutil.RequireVolatilePURLQualifiersToBeRedacted(t, bom)
+		testutil.RequireVolatileBuildEnvironmentToBeRedacted(t, bom)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:CGO_ENABLED", `(0|1)`)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOARCH", runtime.GOARCH)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOOS", runtime.GOOS)
@@ -170,6 +222,7 @@ func TestGenerator_Generate(t *testing.T) {
 		testutil.RequireValidSBOM(t, bom, cyclonedx.BOMFileFormatJSON)
 
 		testutil.RequireVolatilePURLQualifiersToBeRedacted(t, bom)
+		testutil.RequireVolatileBuildEnvironmentToBeRedacted(t, bom)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:CGO_ENABLED", `(0|1)`)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOARCH", runtime.GOARCH)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOOS", runtime.GOOS)
@@ -193,6 +246,7 @@ func TestGenerator_Generate(t *testing.T) {
 		testutil.RequireValidSBOM(t, bom, cyclonedx.BOMFileFormatJSON)
 
 		testutil.RequireVolatilePURLQualifiersToBeRedacted(t, bom)
+		testutil.RequireVolatileBuildEnvironmentToBeRedacted(t, bom)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:CGO_ENABLED", `(0|1)`)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOARCH", runtime.GOARCH)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOOS", runtime.GOOS)
@@ -218,6 +272,7 @@ func TestGenerator_Generate(t *testing.T) {
 		testutil.RequireValidSBOM(t, bom, cyclonedx.BOMFileFormatJSON)
 
 		testutil.RequireVolatilePURLQualifiersToBeRedacted(t, bom)
+		testutil.RequireVolatileBuildEnvironmentToBeRedacted(t, bom)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:CGO_ENABLED", `(0|1)`)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOARCH", runtime.GOARCH)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOOS", runtime.GOOS)
@@ -242,6 +297,7 @@ func TestGenerator_Generate(t *testing.T) {
 		testutil.RequireValidSBOM(t, bom, cyclonedx.BOMFileFormatJSON)
 
 		testutil.RequireVolatilePURLQualifiersToBeRedacted(t, bom)
+		testutil.RequireVolatileBuildEnvironmentToBeRedacted(t, bom)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:CGO_ENABLED", `(0|1)`)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOARCH", runtime.GOARCH)
 		testutil.RequireMatchingPropertyToBeRedacted(t, *bom.Metadata.Component.Properties, "cdx:gomod:build:env:GOOS", runtime.GOOS)
@@ -265,8 +321,10 @@ func TestGenerator_CreateBuildProperties(t *testing.T) {
 		}()
 	}
 
-	properties, err := g.createBuildProperties()
+	env, err := gocmd.GetEnv(context.Background(), zerolog.Nop())
 	require.NoError(t, err)
+
+	properties := g.createBuildProperties(env)
 	require.Len(t, properties, 6)
 
 	expectedCgoEnabled := "1" // Cgo is enabled per default
@@ -281,3 +339,102 @@ func TestGenerator_CreateBuildProperties(t *testing.T) {
 	assert.Contains(t, properties, cyclonedx.Property{Name: "cdx:gomod:build:tag", Value: "foo"})
 	assert.Contains(t, properties, cyclonedx.Property{Name: "cdx:gomod:build:tag", Value: "bar"})
 }
+
+func TestCreateBuildSettings(t *testing.T) {
+	t.Run("CgoDisabled", func(t *testing.T) {
+		settings := createBuildSettings(map[string]string{
+			"CGO_ENABLED": "0",
+			"CGO_CFLAGS":  "-O2 -g",
+			"GOAMD64":     "v1",
+			"GOARCH":      "amd64",
+			"GOARM":       "",
+			"GOFLAGS":     "-mod=readonly -trimpath -ldflags=-s -tags=foo,bar",
+			"GOOS":        "linux",
+			"GOPATH":      "/home/dev/go",
+		})
+
+		require.Equal(t, map[string]string{
+			"-ldflags":    "-s",
+			"-mod":        "readonly",
+			"-tags":       "foo,bar",
+			"-trimpath":   "true",
+			"CGO_ENABLED": "0",
+			"GOAMD64":     "v1",
+			"GOARCH":      "amd64",
+			"GOOS":        "linux",
+		}, settings)
+	})
+
+	t.Run("CgoEnabled", func(t *testing.T) {
+		settings := createBuildSettings(map[string]string{
+			"CGO_ENABLED": "1",
+			"CGO_CFLAGS":  "-O2 -g",
+			"CGO_LDFLAGS": "",
+		})
+
+		require.Equal(t, map[string]string{
+			"CGO_ENABLED": "1",
+			"CGO_CFLAGS":  "-O2 -g",
+		}, settings)
+	})
+}
+
+func TestGenerator_BuildTarget(t *testing.T) {
+	require.Equal(t, ".", generator{}.buildTarget())
+	require.Equal(t, ".", generator{mainDir: "."}.buildTarget())
+	require.Equal(t, "./cmd/app", generator{mainDir: filepath.Join("cmd", "app")}.buildTarget())
+	require.Equal(t, "./cmd/app", generator{mainDir: "./cmd/app/"}.buildTarget())
+}
+
+func TestGenerator_CommandEnv(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		require.Empty(t, generator{}.commandEnv())
+	})
+
+	t.Run("BuildConstraints", func(t *testing.T) {
+		t.Setenv("GOFLAGS", "-mod=readonly -tags=foo")
+
+		cgoEnabled := false
+		g := generator{
+			goos:       "windows",
+			goarch:     "arm64",
+			buildTags:  []string{"bar", "baz"},
+			cgoEnabled: &cgoEnabled,
+			env:        []string{"GOPRIVATE=example.com"},
+		}
+
+		require.Equal(t, []string{
+			"GOPRIVATE=example.com",
+			"GOOS=windows",
+			"GOARCH=arm64",
+			"CGO_ENABLED=0",
+			"GOFLAGS=-mod=readonly -tags=bar,baz",
+		}, g.commandEnv())
+	})
+
+	t.Run("GOFLAGSFromEnvOption", func(t *testing.T) {
+		g := generator{
+			buildTags: []string{"foo"},
+			env:       []string{"GOFLAGS=-trimpath"},
+		}
+
+		require.Equal(t, []string{"GOFLAGS=-trimpath", "GOFLAGS=-trimpath -tags=foo"}, g.commandEnv())
+	})
+
+	t.Run("ReflectedInBuildProperties", func(t *testing.T) {
+		g := generator{
+			logger:    zerolog.Nop(),
+			goos:      "windows",
+			goarch:    "arm64",
+			buildTags: []string{"foo"},
+		}
+
+		env, err := gocmd.GetEnv(context.Background(), zerolog.Nop(), g.commandEnv()...)
+		require.NoError(t, err)
+
+		properties := g.createBuildProperties(env)
+		assert.Contains(t, properties, cyclonedx.Property{Name: "cdx:gomod:build:env:GOARCH", Value: "arm64"})
+		assert.Contains(t, properties, cyclonedx.Property{Name: "cdx:gomod:build:env:GOOS", Value: "windows"})
+		assert.Contains(t, properties, cyclonedx.Property{Name: "cdx:gomod:build:tag", Value: "foo"})
+	})
+}
@@ -18,13 +18,96 @@
 package app
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/rs/zerolog"
 
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
 	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/curation"
 )
 
 type Option func(g *generator) error
 
+// WithBuildTags sets the build tags to consider when loading packages.
+//
+// Tags are passed to the go command via GOFLAGS. Existing -tags flags in GOFLAGS are replaced.
+func WithBuildTags(tags ...string) Option {
+	return func(g *generator) error {
+		g.buildTags = tags
+		return nil
+	}
+}
+
+// WithCacheDir enables a persistent cache for module hashes, file hashes and license detection results
+// in the given directory, which speeds up repeated runs considerably.
+// Results for modules are only cached if they're located in the module cache.
+//
+// Default is "", which disables the cache.
+func WithCacheDir(dir string) Option {
+	return func(g *generator) error {
+		g.cacheDir = dir
+		return nil
+	}
+}
+
+// WithCGOEnabled toggles cgo. When not set, the default of the go command applies.
+func WithCGOEnabled(enable bool) Option {
+	return func(g *generator) error {
+		g.cgoEnabled = &enable
+		return nil
+	}
+}
+
+// WithEnv sets additional environment variables for the go command, in "key=value" form.
+//
+// Environment variables set by other options, like GOOS, take precedence.
+func WithEnv(env ...string) Option {
+	return func(g *generator) error {
+		for _, e := range env {
+			if !strings.Contains(e, "=") {
+				return fmt.Errorf("invalid environment variable \"%s\": expected key=value", e)
+			}
+		}
+		g.env = append(g.env, env...)
+		return nil
+	}
+}
+
+// WithGOARCH sets the target architecture.
+// When not set, the GOARCH of the go command applies.
+func WithGOARCH(goarch string) Option {
+	return func(g *generator) error {
+		g.goarch = goarch
+		return nil
+	}
+}
+
+// WithGOOS sets the target operating system.
+// When not set, the GOOS of the go command applies.
+func WithGOOS(goos string) Option {
+	return func(g *generator) error {
+		g.goos = goos
+		return nil
+	}
+}
+
+// WithHashAlgorithms sets the algorithms used to calculate hashes of files.
+// When no algorithms are given, no hashes will be calculated.
+// Default is MD5, SHA-1, SHA-256, SHA-384 and SHA-512.
+func WithHashAlgorithms(algos ...cdx.HashAlgorithm) Option {
+	return func(g *generator) error {
+		if err := sbom.ValidateHashAlgorithms(algos...); err != nil {
+			return err
+		}
+		g.hashAlgorithms = algos
+		return nil
+	}
+}
+
 // WithIncludeFiles toggles the inclusion of files.
 // Has no effect when packages are not included as well.
 func WithIncludeFiles(enable bool) Option {
@@ -34,6 +117,15 @@ func WithIncludeFiles(enable bool) Option {
 	}
 }
 
+// WithIncludePackageDependencies toggles the inclusion of dependencies between packages,
+// as derived from their imports. Has no effect when packages are not included as well.
+func WithIncludePackageDependencies(enable bool) Option {
+	return func(g *generator) error {
+		g.includePackageDeps = enable
+		return nil
+	}
+}
+
 // WithIncludePackages toggles the inclusion of packages.
 func WithIncludePackages(enable bool) Option {
 	return func(g *generator) error {
@@ -63,6 +155,16 @@ func WithIncludeStdlib(enable bool) Option {
 	}
 }
 
+// WithLicenseCurations sets license curations, which take precedence over detected licenses.
+//
+// When nil, no curations are applied. Default is nil.
+func WithLicenseCurations(curations *curation.Curations) Option {
+	return func(g *generator) error {
+		g.licenseCurations = curations
+		return nil
+	}
+}
+
 // WithLicenseDetector sets the license detector.
 //
 // When nil, no license detection will be performed. Default is nil.
@@ -89,6 +191,37 @@ func WithMainDir(dir string) Option {
 	}
 }
 
+// WithParallelism sets the number of modules that are processed concurrently
+// when detecting licenses and calculating hashes.
+// Default is the value of runtime.GOMAXPROCS.
+func WithParallelism(parallelism int) Option {
+	return func(g *generator) error {
+		if parallelism < 1 {
+			return fmt.Errorf("parallelism must be at least 1, got %d", parallelism)
+		}
+		g.parallelism = parallelism
+		return nil
+	}
+}
+
+// WithPlatforms enables the generation of a single BOM for multiple target platforms.
+//
+// Packages are loaded once per platform, and the results are merged. Components
+// are annotated with the platforms they apply to, and their package URLs
+// will not contain goos and goarch qualifiers.
+//
+// The platforms override the GOOS and GOARCH set via WithGOOS, WithGOARCH or WithEnv.
+func WithPlatforms(platforms ...Platform) Option {
+	return func(g *generator) error {
+		for _, platform := range platforms {
+			if !slices.Contains(g.platforms, platform) {
+				g.platforms = append(g.platforms, platform)
+			}
+		}
+		return nil
+	}
+}
+
 // WithShortPURLS toggles the use of short PURLs without query parameters.
 func WithShortPURLS(enable bool) Option {
 	return func(g *generator) error {
@@ -96,3 +229,14 @@ func WithShortPURLS(enable bool) Option {
 		return nil
 	}
 }
+
+// WithVulnerabilityDatabase enables the reporting of vulnerabilities, based on the local copy
+// of a Go vulnerability database in dir. The database must have the layout of https://vuln.go.dev.
+//
+// For each vulnerability it is recorded whether any of the affected packages is imported by the application.
+func WithVulnerabilityDatabase(dir string) Option {
+	return func(g *generator) error {
+		g.vulnDBDir = dir
+		return nil
+	}
+}
@@ -21,12 +21,81 @@ import (
 	"os"
 	"testing"
 
+	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/curation"
 	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/local"
 )
 
+func TestWithBuildTags(t *testing.T) {
+	g := &generator{}
+	err := WithBuildTags("foo", "bar")(g)
+	require.NoError(t, err)
+	require.Equal(t, []string{"foo", "bar"}, g.buildTags)
+}
+
+func TestWithCGOEnabled(t *testing.T) {
+	g := &generator{}
+	err := WithCGOEnabled(false)(g)
+	require.NoError(t, err)
+	require.NotNil(t, g.cgoEnabled)
+	require.False(t, *g.cgoEnabled)
+}
+
+func TestWithCacheDir(t *testing.T) {
+	g := &generator{}
+	err := WithCacheDir("/tmp/cache")(g)
+	require.NoError(t, err)
+	require.Equal(t, "/tmp/cache", g.cacheDir)
+}
+
+func TestWithEnv(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		g := &generator{}
+		err := WithEnv("GOPRIVATE=example.com", "GOFLAGS=")(g)
+		require.NoError(t, err)
+		require.Equal(t, []string{"GOPRIVATE=example.com", "GOFLAGS="}, g.env)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		g := &generator{}
+		err := WithEnv("GOPRIVATE")(g)
+		require.ErrorContains(t, err, "expected key=value")
+	})
+}
+
+func TestWithGOARCH(t *testing.T) {
+	g := &generator{}
+	err := WithGOARCH("arm64")(g)
+	require.NoError(t, err)
+	require.Equal(t, "arm64", g.goarch)
+}
+
+func TestWithGOOS(t *testing.T) {
+	g := &generator{}
+	err := WithGOOS("windows")(g)
+	require.NoError(t, err)
+	require.Equal(t, "windows", g.goos)
+}
+
+func TestWithHashAlgorithms(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		g := &generator{}
+		err := WithHashAlgorithms(cdx.HashAlgoSHA3_256, cdx.HashAlgoBlake3)(g)
+		require.NoError(t, err)
+		require.Equal(t, []cdx.HashAlgorithm{cdx.HashAlgoSHA3_256, cdx.HashAlgoBlake3}, g.hashAlgorithms)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		g := &generator{}
+		err := WithHashAlgorithms(cdx.HashAlgoStreebog256)(g)
+		require.Error(t, err)
+		require.Nil(t, g.hashAlgorithms)
+	})
+}
+
 func TestWithIncludeFiles(t *testing.T) {
 	g := &generator{includeFiles: false}
 	err := WithIncludeFiles(true)(g)
@@ -48,6 +117,17 @@ func TestWithIncludeStdlib(t *testing.T) {
 	require.True(t, g.includeStdlib)
 }
 
+func TestWithLicenseCurations(t *testing.T) {
+	curations := &curation.Curations{
+		Curations: []curation.Curation{{Module: "github.com/acme/foo", License: "MIT"}},
+	}
+
+	g := &generator{}
+	err := WithLicenseCurations(curations)(g)
+	require.NoError(t, err)
+	require.Same(t, curations, g.licenseCurations)
+}
+
 func TestWithLicenseDetector(t *testing.T) {
 	detector := local.NewDetector(zerolog.Nop(), local.DefaultMinDetectionConfidence)
 
@@ -64,3 +144,19 @@ func TestWithLogger(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, logger, g.logger)
 }
+
+func TestWithParallelism(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		g := &generator{parallelism: 1}
+		err := WithParallelism(4)(g)
+		require.NoError(t, err)
+		require.Equal(t, 4, g.parallelism)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		g := &generator{parallelism: 1}
+		err := WithParallelism(0)(g)
+		require.Error(t, err)
+		require.Equal(t, 1, g.parallelism)
+	})
+}
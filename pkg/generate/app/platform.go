@@ -0,0 +1,482 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/vuln"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect"
+)
+
+// Platform is a target platform of an application.
+type Platform struct {
+	OS   string // value for GOOS
+	Arch string // value for GOARCH
+}
+
+// ParsePlatform parses a platform in GOOS/GOARCH notation, e.g. "linux/amd64".
+func ParsePlatform(s string) (Platform, error) {
+	goos, goarch, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok || goos == "" || goarch == "" || strings.Contains(goarch, "/") {
+		return Platform{}, fmt.Errorf("invalid platform \"%s\": expected GOOS/GOARCH", s)
+	}
+
+	return Platform{OS: goos, Arch: goarch}, nil
+}
+
+func (p Platform) String() string {
+	return p.OS + "/" + p.Arch
+}
+
+func (p Platform) env() []string {
+	return []string{"GOOS=" + p.OS, "GOARCH=" + p.Arch}
+}
+
+const (
+	propertyPlatform           = "build:platform"
+	propertyPlatformDependency = "build:platform:dependency"
+)
+
+// platformMerger merges BOMs that have been generated for
+// multiple platforms into a single BOM.
+//
+// Components and dependency edges are annotated with the platforms they apply to.
+type platformMerger struct {
+	main         *mergedComponent
+	components   []*mergedComponent
+	componentMap map[string]*mergedComponent
+	dependants   []string
+	edges        map[string]map[string][]Platform // dependant -> dependency -> platforms
+	vulns        []*mergedVulnerability
+	vulnMap      map[string]*mergedVulnerability
+	formulation  []cdx.Formula
+}
+
+type mergedComponent struct {
+	component  cdx.Component
+	platforms  []Platform
+	children   []*mergedComponent
+	childMap   map[string]*mergedComponent
+	properties []cdx.Property // properties common to all platforms
+}
+
+func newPlatformMerger() *platformMerger {
+	return &platformMerger{
+		componentMap: make(map[string]*mergedComponent),
+		edges:        make(map[string]map[string][]Platform),
+		vulnMap:      make(map[string]*mergedVulnerability),
+	}
+}
+
+// add adds a BOM that was generated for the given platform.
+func (m *platformMerger) add(platform Platform, bom *cdx.BOM) {
+	if m.main == nil {
+		m.main = newMergedComponent(*bom.Metadata.Component)
+	}
+	m.main.merge(platform, *bom.Metadata.Component)
+
+	if bom.Components != nil {
+		for _, component := range *bom.Components {
+			key := componentKey(component)
+			merged, ok := m.componentMap[key]
+			if !ok {
+				merged = newMergedComponent(component)
+				m.componentMap[key] = merged
+				m.components = append(m.components, merged)
+			}
+			merged.merge(platform, component)
+		}
+	}
+
+	if bom.Dependencies != nil {
+		for _, dependency := range *bom.Dependencies {
+			dependencies, ok := m.edges[dependency.Ref]
+			if !ok {
+				dependencies = make(map[string][]Platform)
+				m.edges[dependency.Ref] = dependencies
+				m.dependants = append(m.dependants, dependency.Ref)
+			}
+			if dependency.Dependencies == nil {
+				continue
+			}
+			for _, ref := range *dependency.Dependencies {
+				dependencies[ref] = append(dependencies[ref], platform)
+			}
+		}
+	}
+
+	if bom.Vulnerabilities != nil {
+		for _, vulnerability := range *bom.Vulnerabilities {
+			merged, ok := m.vulnMap[vulnerability.ID]
+			if !ok {
+				merged = &mergedVulnerability{vulnerability: vulnerability}
+				m.vulnMap[vulnerability.ID] = merged
+				m.vulns = append(m.vulns, merged)
+			}
+			merged.merge(platform, vulnerability)
+		}
+	}
+
+	// Workflows are specific to the platform already
+	if bom.Formulation != nil {
+		m.formulation = append(m.formulation, *bom.Formulation...)
+	}
+}
+
+// bom assembles the merged BOM.
+func (m *platformMerger) bom() *cdx.BOM {
+	m.annotateDependencies(m.main)
+	for _, component := range m.components {
+		m.annotateDependencies(component)
+	}
+
+	slices.SortStableFunc(m.components, func(a, b *mergedComponent) int {
+		return strings.Compare(a.component.Name, b.component.Name)
+	})
+
+	components := make([]cdx.Component, 0, len(m.components))
+	for _, component := range m.components {
+		components = append(components, component.build())
+	}
+
+	dependencies := make([]cdx.Dependency, 0, len(m.dependants))
+	for _, ref := range m.dependants {
+		dependsOn := make([]string, 0, len(m.edges[ref]))
+		for dependencyRef := range m.edges[ref] {
+			dependsOn = append(dependsOn, dependencyRef)
+		}
+		slices.Sort(dependsOn)
+
+		dependency := cdx.Dependency{Ref: ref}
+		if len(dependsOn) > 0 {
+			dependency.Dependencies = &dependsOn
+		}
+		dependencies = append(dependencies, dependency)
+	}
+
+	bom := cdx.NewBOM()
+	bom.Metadata = &cdx.Metadata{
+		Component: new(cdx.Component),
+	}
+	*bom.Metadata.Component = m.main.build()
+	bom.Components = &components
+	bom.Dependencies = &dependencies
+
+	if len(m.vulns) > 0 {
+		slices.SortStableFunc(m.vulns, func(a, b *mergedVulnerability) int {
+			return strings.Compare(a.vulnerability.ID, b.vulnerability.ID)
+		})
+
+		vulnerabilities := make([]cdx.Vulnerability, 0, len(m.vulns))
+		for _, vulnerability := range m.vulns {
+			vulnerabilities = append(vulnerabilities, vulnerability.build())
+		}
+		bom.Vulnerabilities = &vulnerabilities
+	}
+
+	if len(m.formulation) > 0 {
+		bom.Formulation = &m.formulation
+	}
+
+	return bom
+}
+
+// annotateDependencies records all dependency edges of component that don't apply
+// to all platforms of the component itself. CycloneDX doesn't support properties
+// on dependencies, so they're recorded on the dependant component instead.
+func (m *platformMerger) annotateDependencies(component *mergedComponent) {
+	dependencies := m.edges[component.component.BOMRef]
+
+	refs := make([]string, 0, len(dependencies))
+	for ref := range dependencies {
+		refs = append(refs, ref)
+	}
+	slices.Sort(refs)
+
+	for _, ref := range refs {
+		platforms := dependencies[ref]
+		if len(platforms) == len(component.platforms) {
+			continue
+		}
+
+		platformNames := make([]string, len(platforms))
+		for i := range platforms {
+			platformNames[i] = platforms[i].String()
+		}
+
+		component.properties = append(component.properties,
+			sbom.NewProperty(propertyPlatformDependency, ref+" "+strings.Join(platformNames, ",")))
+	}
+}
+
+func newMergedComponent(component cdx.Component) *mergedComponent {
+	merged := mergedComponent{
+		component: component,
+		childMap:  make(map[string]*mergedComponent),
+	}
+	if component.Properties != nil {
+		merged.properties = slices.Clone(*component.Properties)
+	}
+
+	return &merged
+}
+
+func (c *mergedComponent) merge(platform Platform, component cdx.Component) {
+	c.platforms = append(c.platforms, platform)
+
+	// Properties that differ between platforms, like build:env:GOOS, are dropped.
+	c.properties = slices.DeleteFunc(c.properties, func(property cdx.Property) bool {
+		return component.Properties == nil || !slices.Contains(*component.Properties, property)
+	})
+
+	if component.Components == nil {
+		return
+	}
+
+	for _, child := range *component.Components {
+		key := componentKey(child)
+		merged, ok := c.childMap[key]
+		if !ok {
+			merged = newMergedComponent(child)
+			c.childMap[key] = merged
+			c.children = append(c.children, merged)
+		}
+		merged.merge(platform, child)
+	}
+}
+
+// build returns the merged component, including all of its (merged) children.
+func (c *mergedComponent) build() cdx.Component {
+	component := c.component
+	component.PackageURL = setPlatformQualifiers(component.PackageURL, nil)
+
+	properties := slices.Clone(c.properties)
+	for _, platform := range c.platforms {
+		properties = append(properties, sbom.NewProperty(propertyPlatform, platform.String()))
+	}
+	sbom.SortProperties(properties)
+	component.Properties = &properties
+
+	if len(c.children) > 0 {
+		slices.SortStableFunc(c.children, func(a, b *mergedComponent) int {
+			return strings.Compare(a.component.Name, b.component.Name)
+		})
+
+		children := make([]cdx.Component, 0, len(c.children))
+		for _, child := range c.children {
+			children = append(children, child.build())
+		}
+		component.Components = &children
+	}
+
+	return component
+}
+
+type mergedVulnerability struct {
+	vulnerability cdx.Vulnerability
+	platforms     []Platform
+	affects       []cdx.Affects
+	properties    []cdx.Property
+	affected      bool // whether the vulnerability affects the application on any platform
+}
+
+func (v *mergedVulnerability) merge(platform Platform, vulnerability cdx.Vulnerability) {
+	v.platforms = append(v.platforms, platform)
+
+	if vulnerability.Affects != nil {
+		for _, affects := range *vulnerability.Affects {
+			if !slices.ContainsFunc(v.affects, func(a cdx.Affects) bool { return a.Ref == affects.Ref }) {
+				v.affects = append(v.affects, affects)
+			}
+		}
+	}
+	if vulnerability.Properties != nil {
+		v.properties = append(v.properties, *vulnerability.Properties...)
+	}
+	if vulnerability.Analysis == nil {
+		v.affected = true
+	}
+}
+
+// build returns the merged vulnerability. Imported packages and affected symbols of all platforms
+// are combined. The vulnerability is only considered not to affect the application, if that's the
+// case for all platforms.
+func (v *mergedVulnerability) build() cdx.Vulnerability {
+	vulnerability := v.vulnerability
+
+	properties := slices.Clone(v.properties)
+	if v.affected {
+		properties = slices.DeleteFunc(properties, func(property cdx.Property) bool {
+			return property == sbom.NewProperty(vuln.PropertyImported, "false")
+		})
+		vulnerability.Analysis = nil
+	}
+	for _, platform := range v.platforms {
+		properties = append(properties, sbom.NewProperty(propertyPlatform, platform.String()))
+	}
+	sbom.SortProperties(properties)
+	properties = slices.Compact(properties)
+	vulnerability.Properties = &properties
+
+	affects := slices.Clone(v.affects)
+	vulnerability.Affects = &affects
+
+	return vulnerability
+}
+
+// componentKey returns the key by which components are identified when merging.
+// Packages and files don't have a BOM reference, but their names are unique within their parent.
+func componentKey(component cdx.Component) string {
+	if component.BOMRef != "" {
+		return component.BOMRef
+	}
+
+	return component.Name
+}
+
+// setPlatformQualifiers replaces the values of the goos and goarch qualifiers of a package URL.
+// When platform is nil, the qualifiers are removed instead, as a component of a
+// multi-platform BOM is not specific to a single platform.
+//
+// The package URL is intentionally not parsed and re-encoded, because that
+// would normalize it (e.g. lowercase the namespace of golang PURLs).
+func setPlatformQualifiers(purl string, platform *Platform) string {
+	purl, subpath, hasSubpath := strings.Cut(purl, "#")
+	base, qualifiers, hasQualifiers := strings.Cut(purl, "?")
+
+	if hasQualifiers {
+		var kept []string
+		for _, qualifier := range strings.Split(qualifiers, "&") {
+			key, _, _ := strings.Cut(qualifier, "=")
+			switch {
+			case key == "goos" && platform != nil:
+				kept = append(kept, "goos="+platform.OS)
+			case key == "goarch" && platform != nil:
+				kept = append(kept, "goarch="+platform.Arch)
+			case key != "goos" && key != "goarch":
+				kept = append(kept, qualifier)
+			}
+		}
+		if len(kept) > 0 {
+			base += "?" + strings.Join(kept, "&")
+		}
+	}
+	if hasSubpath {
+		base += "#" + subpath
+	}
+
+	return base
+}
+
+// setComponentPlatformQualifiers applies setPlatformQualifiers to all components of a BOM.
+func setComponentPlatformQualifiers(bom *cdx.BOM, platform *Platform) {
+	var visit func(*cdx.Component)
+	visit = func(component *cdx.Component) {
+		component.PackageURL = setPlatformQualifiers(component.PackageURL, platform)
+		if component.Components != nil {
+			for i := range *component.Components {
+				visit(&(*component.Components)[i])
+			}
+		}
+	}
+
+	if bom.Metadata != nil && bom.Metadata.Component != nil {
+		visit(bom.Metadata.Component)
+	}
+	if bom.Components != nil {
+		for i := range *bom.Components {
+			visit(&(*bom.Components)[i])
+		}
+	}
+}
+
+// memoizingDetector caches the results of a license detector,
+// so that detection runs only once per module when generating for multiple platforms.
+// Detections of different modules run concurrently, while concurrent detections
+// of the same module wait for the first one to complete.
+type memoizingDetector struct {
+	detector licensedetect.Detector
+	results  map[string]*memoizedLicenses
+	mutex    sync.Mutex
+}
+
+type memoizedLicenses struct {
+	done       chan struct{} // Closed once detection has completed
+	licenses   []cdx.License
+	expression string
+	err        error
+}
+
+func newMemoizingDetector(detector licensedetect.Detector) *memoizingDetector {
+	return &memoizingDetector{
+		detector: detector,
+		results:  make(map[string]*memoizedLicenses),
+	}
+}
+
+// Detect implements the licensedetect.Detector interface.
+func (d *memoizingDetector) Detect(modulePath, moduleVersion, moduleDir string) ([]cdx.License, error) {
+	return d.DetectContext(context.Background(), modulePath, moduleVersion, moduleDir)
+}
+
+// DetectContext implements the licensedetect.ContextDetector interface.
+func (d *memoizingDetector) DetectContext(ctx context.Context, modulePath, moduleVersion, moduleDir string) ([]cdx.License, error) {
+	licenses, _, err := d.DetectExpression(ctx, modulePath, moduleVersion, moduleDir)
+	return licenses, err
+}
+
+// DetectExpression implements the licensedetect.ExpressionDetector interface.
+func (d *memoizingDetector) DetectExpression(ctx context.Context, modulePath, moduleVersion, moduleDir string) ([]cdx.License, string, error) {
+	key := modulePath + "@" + moduleVersion + ":" + moduleDir
+
+	d.mutex.Lock()
+	result, ok := d.results[key]
+	if !ok {
+		result = &memoizedLicenses{done: make(chan struct{})}
+		d.results[key] = result
+	}
+	d.mutex.Unlock()
+
+	if ok {
+		select {
+		case <-result.done:
+			return result.licenses, result.expression, result.err
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		}
+	}
+
+	result.licenses, result.expression, result.err = licensedetect.DetectExpression(ctx, d.detector, modulePath, moduleVersion, moduleDir)
+	if result.err != nil {
+		// Don't memoize failures, subsequent detections may succeed
+		d.mutex.Lock()
+		delete(d.results, key)
+		d.mutex.Unlock()
+	}
+	close(result.done)
+
+	return result.licenses, result.expression, result.err
+}
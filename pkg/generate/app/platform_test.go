@@ -0,0 +1,232 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package app
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePlatform(t *testing.T) {
+	platform, err := ParsePlatform("linux/amd64")
+	require.NoError(t, err)
+	require.Equal(t, Platform{OS: "linux", Arch: "amd64"}, platform)
+	require.Equal(t, "linux/amd64", platform.String())
+
+	for _, invalid := range []string{"", "linux", "linux/", "/amd64", "linux/amd64/v2"} {
+		_, err = ParsePlatform(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestSetPlatformQualifiers(t *testing.T) {
+	t.Run("Remove", func(t *testing.T) {
+		assert.Equal(t, "pkg:golang/example.com/foo@v1.0.0?type=module",
+			setPlatformQualifiers("pkg:golang/example.com/foo@v1.0.0?goarch=amd64&goos=linux&type=module", nil))
+		assert.Equal(t, "pkg:golang/example.com/Foo@v1.0.0?type=module#cmd/foo",
+			setPlatformQualifiers("pkg:golang/example.com/Foo@v1.0.0?goarch=amd64&goos=linux&type=module#cmd/foo", nil))
+		assert.Equal(t, "pkg:golang/example.com/foo@v1.0.0",
+			setPlatformQualifiers("pkg:golang/example.com/foo@v1.0.0?goarch=amd64&goos=linux", nil))
+		assert.Empty(t, setPlatformQualifiers("", nil))
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		platform := &Platform{OS: "windows", Arch: "arm64"}
+		assert.Equal(t, "pkg:golang/example.com/foo@v1.0.0?goarch=arm64&goos=windows&type=module#cmd/foo",
+			setPlatformQualifiers("pkg:golang/example.com/foo@v1.0.0?goarch=amd64&goos=linux&type=module#cmd/foo", platform))
+		assert.Equal(t, "pkg:golang/example.com/foo@v1.0.0",
+			setPlatformQualifiers("pkg:golang/example.com/foo@v1.0.0", platform))
+	})
+}
+
+func TestPlatformMerger(t *testing.T) {
+	linux := Platform{OS: "linux", Arch: "amd64"}
+	windows := Platform{OS: "windows", Arch: "amd64"}
+
+	newBOM := func(platform Platform, windowsOnly bool) *cdx.BOM {
+		bom := cdx.NewBOM()
+		bom.Metadata = &cdx.Metadata{
+			Component: &cdx.Component{
+				BOMRef:     "app",
+				Name:       "app",
+				PackageURL: "pkg:golang/app@v1.0.0?goarch=amd64&goos=" + platform.OS + "&type=module",
+				Properties: &[]cdx.Property{
+					{Name: "cdx:gomod:build:env:GOOS", Value: platform.OS},
+					{Name: "cdx:gomod:build:env:GOVERSION", Value: "go1.22.0"},
+				},
+				Components: &[]cdx.Component{
+					{Name: "app", Components: &[]cdx.Component{{Name: "main_" + platform.OS + ".go"}}},
+				},
+			},
+		}
+		components := []cdx.Component{{BOMRef: "common", Name: "common"}}
+		dependsOn := []string{"common"}
+		if windowsOnly {
+			components = append(components, cdx.Component{BOMRef: "windows", Name: "windows"})
+			dependsOn = append(dependsOn, "windows")
+		}
+		bom.Components = &components
+		bom.Dependencies = &[]cdx.Dependency{
+			{Ref: "app", Dependencies: &dependsOn},
+			{Ref: "common"},
+		}
+		return bom
+	}
+
+	merger := newPlatformMerger()
+	merger.add(linux, newBOM(linux, false))
+	merger.add(windows, newBOM(windows, true))
+	bom := merger.bom()
+
+	main := bom.Metadata.Component
+	assert.Equal(t, "pkg:golang/app@v1.0.0?type=module", main.PackageURL)
+	assert.Equal(t, []cdx.Property{
+		{Name: "cdx:gomod:build:env:GOVERSION", Value: "go1.22.0"},
+		{Name: "cdx:gomod:build:platform", Value: "linux/amd64"},
+		{Name: "cdx:gomod:build:platform", Value: "windows/amd64"},
+		{Name: "cdx:gomod:build:platform:dependency", Value: "windows windows/amd64"},
+	}, *main.Properties)
+
+	// Files of packages must be merged as well
+	require.Len(t, *main.Components, 1)
+	files := *(*main.Components)[0].Components
+	require.Len(t, files, 2)
+	assert.Equal(t, "main_linux.go", files[0].Name)
+	assert.Contains(t, *files[0].Properties, cdx.Property{Name: "cdx:gomod:build:platform", Value: "linux/amd64"})
+	assert.NotContains(t, *files[0].Properties, cdx.Property{Name: "cdx:gomod:build:platform", Value: "windows/amd64"})
+
+	require.Len(t, *bom.Components, 2)
+	assert.Len(t, *(*bom.Components)[0].Properties, 2)
+	assert.Equal(t, []cdx.Property{{Name: "cdx:gomod:build:platform", Value: "windows/amd64"}}, *(*bom.Components)[1].Properties)
+
+	require.Len(t, *bom.Dependencies, 2)
+	assert.Equal(t, []string{"common", "windows"}, *(*bom.Dependencies)[0].Dependencies)
+}
+
+func TestPlatformMerger_Vulnerabilities(t *testing.T) {
+	linux := Platform{OS: "linux", Arch: "amd64"}
+	windows := Platform{OS: "windows", Arch: "amd64"}
+
+	newBOM := func(imported bool) *cdx.BOM {
+		vulnerability := cdx.Vulnerability{
+			ID:      "GO-0000-0001",
+			Affects: &[]cdx.Affects{{Ref: "lib"}},
+		}
+		if imported {
+			vulnerability.Properties = &[]cdx.Property{
+				{Name: "cdx:gomod:vuln:imported", Value: "true"},
+				{Name: "cdx:gomod:vuln:imported:package", Value: "lib/windows"},
+			}
+		} else {
+			vulnerability.Properties = &[]cdx.Property{{Name: "cdx:gomod:vuln:imported", Value: "false"}}
+			vulnerability.Analysis = &cdx.VulnerabilityAnalysis{State: cdx.IASNotAffected}
+		}
+
+		bom := cdx.NewBOM()
+		bom.Metadata = &cdx.Metadata{Component: &cdx.Component{BOMRef: "app", Name: "app"}}
+		bom.Vulnerabilities = &[]cdx.Vulnerability{vulnerability}
+		return bom
+	}
+
+	t.Run("AffectedOnSomePlatforms", func(t *testing.T) {
+		merger := newPlatformMerger()
+		merger.add(linux, newBOM(false))
+		merger.add(windows, newBOM(true))
+		bom := merger.bom()
+
+		require.NotNil(t, bom.Vulnerabilities)
+		require.Len(t, *bom.Vulnerabilities, 1)
+		vulnerability := (*bom.Vulnerabilities)[0]
+		assert.Nil(t, vulnerability.Analysis)
+		assert.Len(t, *vulnerability.Affects, 1)
+		assert.Equal(t, []cdx.Property{
+			{Name: "cdx:gomod:build:platform", Value: "linux/amd64"},
+			{Name: "cdx:gomod:build:platform", Value: "windows/amd64"},
+			{Name: "cdx:gomod:vuln:imported", Value: "true"},
+			{Name: "cdx:gomod:vuln:imported:package", Value: "lib/windows"},
+		}, *vulnerability.Properties)
+	})
+
+	t.Run("AffectedOnNoPlatform", func(t *testing.T) {
+		merger := newPlatformMerger()
+		merger.add(linux, newBOM(false))
+		merger.add(windows, newBOM(false))
+		bom := merger.bom()
+
+		vulnerability := (*bom.Vulnerabilities)[0]
+		require.NotNil(t, vulnerability.Analysis)
+		assert.Equal(t, cdx.IASNotAffected, vulnerability.Analysis.State)
+		assert.Contains(t, *vulnerability.Properties, cdx.Property{Name: "cdx:gomod:vuln:imported", Value: "false"})
+	})
+}
+
+type blockingDetector struct {
+	started chan string
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (d *blockingDetector) Detect(modulePath, _, _ string) ([]cdx.License, error) {
+	d.calls.Add(1)
+	d.started <- modulePath
+	<-d.release
+
+	return []cdx.License{{ID: "MIT"}}, nil
+}
+
+func TestMemoizingDetector(t *testing.T) {
+	detector := &blockingDetector{
+		started: make(chan string, 4),
+		release: make(chan struct{}),
+	}
+	memoizing := newMemoizingDetector(detector)
+
+	var wg sync.WaitGroup
+	for _, modulePath := range []string{"a", "b", "a", "b"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			licenses, err := memoizing.Detect(modulePath, "v1.0.0", "/"+modulePath)
+			assert.NoError(t, err)
+			assert.Equal(t, []cdx.License{{ID: "MIT"}}, licenses)
+		}()
+	}
+
+	// Both modules must be detected concurrently
+	for range 2 {
+		select {
+		case <-detector.started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("detections of different modules did not run concurrently")
+		}
+	}
+	close(detector.release)
+	wg.Wait()
+
+	require.Equal(t, int32(2), detector.calls.Load())
+
+	_, err := memoizing.Detect("a", "v1.0.0", "/a")
+	require.NoError(t, err)
+	require.Equal(t, int32(2), detector.calls.Load())
+}
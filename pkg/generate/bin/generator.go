@@ -18,8 +18,12 @@
 package bin
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"path"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
@@ -28,28 +32,40 @@ import (
 	"github.com/rs/zerolog/log"
 	"golang.org/x/mod/module"
 
+	"github.com/CycloneDX/cyclonedx-gomod/internal/cache"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/gobinary"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/gomod"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
 	modConv "github.com/CycloneDX/cyclonedx-gomod/internal/sbom/convert/module"
+	pkgConv "github.com/CycloneDX/cyclonedx-gomod/internal/sbom/convert/pkg"
 	"github.com/CycloneDX/cyclonedx-gomod/pkg/generate"
 	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/curation"
 )
 
 type generator struct {
 	logger zerolog.Logger
 
-	binaryPath      string
-	includeStdlib   bool
-	licenseDetector licensedetect.Detector
-	versionOverride string
-	shortPURLs      bool
+	cache            *cache.Cache
+	cacheDir         string
+	binaryPath       string
+	hashAlgorithms   []cdx.HashAlgorithm
+	includePackages  bool
+	includeStdlib    bool
+	licenseCurations *curation.Curations
+	licenseDetector  licensedetect.Detector
+	parallelism      int
+	versionOverride  string
+	shortPURLs       bool
 }
 
 // NewGenerator returns a generator that is capable of generating BOMs from Go module binaries.
-func NewGenerator(binaryPath string, opts ...Option) (generate.Generator, error) {
+func NewGenerator(binaryPath string, opts ...Option) (generate.ContextGenerator, error) {
 	g := generator{
-		logger:     log.Logger,
-		binaryPath: binaryPath,
+		logger:         log.Logger,
+		binaryPath:     binaryPath,
+		hashAlgorithms: sbom.DefaultHashAlgorithms(),
+		parallelism:    runtime.GOMAXPROCS(0),
 	}
 
 	var err error
@@ -64,20 +80,43 @@ func NewGenerator(binaryPath string, opts ...Option) (generate.Generator, error)
 
 // Generate implements the generate.Generator interface.
 func (g generator) Generate() (*cdx.BOM, error) {
-	bi, err := gomod.LoadBuildInfo(g.binaryPath)
+	return g.GenerateContext(context.Background())
+}
+
+// GenerateContext implements the generate.ContextGenerator interface.
+func (g generator) GenerateContext(ctx context.Context) (*cdx.BOM, error) {
+	var err error
+	g.cache, err = cache.Open(ctx, g.logger, g.cacheDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load build info: %w", err)
-	} else if bi.Main == nil {
-		return nil, fmt.Errorf("failed to parse any modules from %s", g.binaryPath)
+		g.logger.Warn().Err(err).Msg("failed to open cache, continuing without it")
+	}
+	g.licenseDetector = g.cache.LicenseDetector(g.licenseDetector)
+
+	bi, err := gomod.LoadBuildInfo(g.binaryPath)
+	if err != nil || bi.Main == nil || bi.Main.Path == "" {
+		// Binaries built in GOPATH mode, with Go versions prior to 1.13, or with their
+		// build information removed don't contain any modules. They can still be inferred
+		// from the symbol table, albeit with less confidence.
+		g.logger.Warn().
+			AnErr("reason", err).
+			Msg("binary contains no module information, inferring modules from symbol table")
+
+		var inferErr error
+		bi, inferErr = gomod.InferBuildInfo(g.binaryPath)
+		if inferErr != nil {
+			if err != nil {
+				return nil, fmt.Errorf("failed to load build info: %w", errors.Join(err, inferErr))
+			}
+			return nil, fmt.Errorf("failed to infer build info: %w", inferErr)
+		}
 	}
 
 	modules := append([]gomod.Module{*bi.Main}, bi.Deps...)
 
-	if g.includeStdlib {
-		modules = append(modules, gomod.Module{
-			Path:    gomod.StdlibModulePath,
-			Version: bi.GoVersion,
-		})
+	// The Go version may be unknown for binaries without build information
+	if g.includeStdlib && bi.GoVersion != "" {
+		stdlibModule := gomod.NewStdlibModule(bi.GoVersion, bi.Settings["GOFIPS140"])
+		modules = append(modules, gomod.StdlibModules(stdlibModule)...)
 	}
 
 	if g.versionOverride != "" {
@@ -90,9 +129,16 @@ func (g generator) Generate() (*cdx.BOM, error) {
 		}
 	}
 
+	if g.includePackages {
+		err = gomod.ApplyLinkedPackages(g.binaryPath, bi.Path, modules)
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine linked packages: %w", err)
+		}
+	}
+
 	if g.licenseDetector != nil {
 		// Before we can resolve licenses, we have to download the modules first
-		err = g.downloadModules(modules)
+		err = g.downloadModules(ctx, modules)
 		if err != nil {
 			return nil, fmt.Errorf("failed to download modules: %w", err)
 		}
@@ -100,18 +146,28 @@ func (g generator) Generate() (*cdx.BOM, error) {
 
 	main, err := modConv.ToComponent(g.logger, modules[0],
 		modConv.WithComponentType(cdx.ComponentTypeApplication),
-		modConv.WithLicenses(g.licenseDetector),
-		modConv.WithShortPURL(g.shortPURLs))
+		modConv.WithLicenses(ctx, g.licenseDetector, g.licenseCurations),
+		modConv.WithShortPURL(g.shortPURLs),
+		modConv.WithPackages(g.includePackages,
+			pkgConv.WithShortPURL(g.shortPURLs)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert main module: %w", err)
 	}
-	components, err := modConv.ToComponents(g.logger, modules[1:],
-		modConv.WithLicenses(g.licenseDetector),
-		modConv.WithShortPURL(g.shortPURLs))
+	components, err := modConv.ToComponents(g.logger, modules[1:], g.parallelism,
+		modConv.WithLicenses(ctx, g.licenseDetector, g.licenseCurations),
+		modConv.WithShortPURL(g.shortPURLs),
+		modConv.WithPackages(g.includePackages,
+			pkgConv.WithShortPURL(g.shortPURLs)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert modules: %w", err)
 	}
+
+	// Binaries built with cgo may link against system libraries like glibc or OpenSSL
+	libraries := g.buildLinkedLibraryComponents()
+	components = append(components, libraries...)
+
 	dependencies := sbom.BuildDependencyGraph(modules)
+	dependencies = addLinkedLibraryDependencies(dependencies, main.BOMRef, libraries)
 	compositions := buildCompositions(main, components)
 
 	binaryProperties, err := g.buildBinaryProperties(g.binaryPath, bi)
@@ -130,9 +186,79 @@ func (g generator) Generate() (*cdx.BOM, error) {
 
 	g.includeAppPathInMainComponentPURL(bi, bom)
 
+	// Binaries built in GOPATH mode or with Go versions prior to 1.18 don't record any build settings
+	if len(bi.Settings) > 0 {
+		target := bi.Path
+		if target == "command-line-arguments" {
+			target = "" // Built from a list of .go files, which are unknown
+		}
+		bom.Formulation = &[]cdx.Formula{
+			sbom.BuildFormula(bom.Metadata.Component.BOMRef, target, bi.Settings),
+		}
+	}
+
 	return bom, nil
 }
 
+// PropertyLinkedLibrary marks components of shared libraries that a binary is dynamically linked against.
+// Its value is the library as referenced by the binary, which may be a path in case of Mach-O binaries.
+const PropertyLinkedLibrary = "binary:linked-library"
+
+// buildLinkedLibraryComponents builds components for the shared libraries that the binary links against.
+// Failing to determine them is not considered fatal, as modules are the main concern of the BOM.
+func (g generator) buildLinkedLibraryComponents() []cdx.Component {
+	libraries, err := gobinary.LinkedLibraries(g.binaryPath)
+	if err != nil {
+		g.logger.Warn().Err(err).Msg("failed to determine linked libraries")
+		return nil
+	}
+
+	components := make([]cdx.Component, 0, len(libraries))
+	for _, library := range libraries {
+		g.logger.Debug().Str("library", library).Msg("adding linked library")
+
+		components = append(components, cdx.Component{
+			BOMRef: "linked-library:" + library,
+			Type:   cdx.ComponentTypeLibrary,
+			Name:   path.Base(library),
+			Scope:  cdx.ScopeRequired,
+			Properties: &[]cdx.Property{
+				sbom.NewProperty(PropertyLinkedLibrary, library),
+			},
+		})
+	}
+
+	return components
+}
+
+// addLinkedLibraryDependencies makes the linked libraries direct dependencies of the main component.
+func addLinkedLibraryDependencies(dependencies []cdx.Dependency, mainRef string, libraries []cdx.Component) []cdx.Dependency {
+	if len(libraries) == 0 {
+		return dependencies
+	}
+
+	for i := range dependencies {
+		if dependencies[i].Ref != mainRef {
+			continue
+		}
+
+		var refs []string
+		if dependencies[i].Dependencies != nil {
+			refs = append(refs, *dependencies[i].Dependencies...)
+		}
+		for _, library := range libraries {
+			refs = append(refs, library.BOMRef)
+		}
+		dependencies[i].Dependencies = &refs
+	}
+
+	for _, library := range libraries {
+		dependencies = append(dependencies, cdx.Dependency{Ref: library.BOMRef})
+	}
+
+	return dependencies
+}
+
 // buildPseudoVersion builds a pseudo version for the main module.
 // Requires that the binary was built with Go 1.18+ and the build
 // settings include VCS information.
@@ -162,7 +288,9 @@ func buildPseudoVersion(bi *gomod.BuildInfo) (string, error) {
 func (g generator) buildBinaryProperties(binaryPath string, bi *gomod.BuildInfo) ([]cdx.Property, error) {
 	properties := []cdx.Property{
 		sbom.NewProperty("binary:name", filepath.Base(binaryPath)),
-		sbom.NewProperty("build:env:GOVERSION", bi.GoVersion),
+	}
+	if bi.GoVersion != "" {
+		properties = append(properties, sbom.NewProperty("build:env:GOVERSION", bi.GoVersion))
 	}
 
 	if len(bi.Settings) > 0 {
@@ -188,8 +316,7 @@ func (g generator) buildBinaryProperties(binaryPath string, bi *gomod.BuildInfo)
 		}
 	}
 
-	binaryHashes, err := sbom.CalculateFileHashes(g.logger, binaryPath,
-		cdx.HashAlgoMD5, cdx.HashAlgoSHA1, cdx.HashAlgoSHA256, cdx.HashAlgoSHA384, cdx.HashAlgoSHA512)
+	binaryHashes, err := sbom.CalculateFileHashes(g.logger, binaryPath, g.hashAlgorithms...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to calculate binary hashes: %w", err)
 	}
@@ -227,11 +354,11 @@ func buildCompositions(mainComponent *cdx.Component, components []cdx.Component)
 	return compositions
 }
 
-func (g generator) downloadModules(modules []gomod.Module) error {
+func (g generator) downloadModules(ctx context.Context, modules []gomod.Module) error {
 	modulesToDownload := make([]gomod.Module, 0)
 	for i := range modules {
-		if modules[i].Path == gomod.StdlibModulePath {
-			continue // We can't download the stdlib
+		if modules[i].Path == gomod.StdlibModulePath || modules[i].Toolchain {
+			continue // We can't download the stdlib and toolchain
 		}
 
 		// When modules are replaced, only download the replacement.
@@ -242,7 +369,7 @@ func (g generator) downloadModules(modules []gomod.Module) error {
 		}
 	}
 
-	downloads, err := gomod.Download(g.logger, modulesToDownload)
+	downloads, err := gomod.Download(ctx, g.logger, modulesToDownload)
 	if err != nil {
 		return err
 	}
@@ -69,6 +69,20 @@ func TestGenerator_Generate(t *testing.T) {
 		testutil.RequireValidSBOM(t, bom, cyclonedx.BOMFileFormatJSON)
 	})
 
+	t.Run("SimpleWithPackages", func(t *testing.T) {
+		g, err := NewGenerator("../testdata/simple",
+			WithIncludePackages(true),
+			WithLogger(testutil.SilentLogger))
+		require.NoError(t, err)
+
+		bom, err := g.Generate()
+		require.NoError(t, err)
+
+		testutil.RequireVolatilePURLQualifiersToBeRedacted(t, bom)
+		testutil.RequireMatchingSBOMSnapshot(t, snapShooter, bom, cyclonedx.BOMFileFormatJSON)
+		testutil.RequireValidSBOM(t, bom, cyclonedx.BOMFileFormatJSON)
+	})
+
 	t.Run("Simple1.18", func(t *testing.T) {
 		g, err := NewGenerator("../testdata/simple1.18",
 			WithLicenseDetector(local.NewDetector(zerolog.Nop(), local.DefaultMinDetectionConfidence)),
@@ -82,4 +96,33 @@ func TestGenerator_Generate(t *testing.T) {
 		testutil.RequireMatchingSBOMSnapshot(t, snapShooter, bom, cyclonedx.BOMFileFormatJSON)
 		testutil.RequireValidSBOM(t, bom, cyclonedx.BOMFileFormatJSON)
 	})
+
+	t.Run("WithoutModuleInformation", func(t *testing.T) {
+		binaryPath := testutil.BuildGOPATHBinary(t, map[string]string{
+			"example.com/lib/greet/greet.go":  "package greet\n\n//go:noinline\nfunc Hello() string { return \"hello\" }\n",
+			"example.com/app/cmd/app/main.go": "package main\n\nimport \"example.com/lib/greet\"\n\nfunc main() { println(greet.Hello()) }\n",
+		}, "example.com/app/cmd/app")
+
+		g, err := NewGenerator(binaryPath,
+			WithIncludeStdlib(true),
+			WithLogger(testutil.SilentLogger))
+		require.NoError(t, err)
+
+		bom, err := g.Generate()
+		require.NoError(t, err)
+
+		require.Equal(t, "example.com/app", bom.Metadata.Component.Name)
+		require.Equal(t, "pkg:golang/example.com/app?type=module#cmd/app", bom.Metadata.Component.BOMRef)
+		require.NotNil(t, bom.Metadata.Component.Evidence)
+		require.NotNil(t, bom.Components)
+		require.Len(t, *bom.Components, 3)
+		require.Equal(t, "example.com/lib", (*bom.Components)[0].Name)
+		require.NotNil(t, (*bom.Components)[0].Evidence)
+		require.NotNil(t, (*bom.Components)[0].Evidence.Identity)
+		require.Equal(t, "std", (*bom.Components)[1].Name)
+		require.Nil(t, (*bom.Components)[1].Evidence)
+		require.Equal(t, cyclonedx.ComponentTypeApplication, (*bom.Components)[2].Type)
+		require.Regexp(t, `^pkg:golang/golang.org/toolchain@go[^?]+$`, (*bom.Components)[2].BOMRef)
+		testutil.RequireValidSBOM(t, bom, cyclonedx.BOMFileFormatJSON)
+	})
 }
@@ -18,15 +18,57 @@
 package bin
 
 import (
+	"fmt"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/rs/zerolog"
 
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
 	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/curation"
 )
 
 // Option allows for customization of the generator using the
 // functional options pattern.
 type Option func(g *generator) error
 
+// WithCacheDir enables a persistent cache for license detection results
+// in the given directory, which speeds up repeated runs considerably.
+// Results for modules are only cached if they're located in the module cache.
+//
+// Default is "", which disables the cache.
+func WithCacheDir(dir string) Option {
+	return func(g *generator) error {
+		g.cacheDir = dir
+		return nil
+	}
+}
+
+// WithHashAlgorithms sets the algorithms used to calculate hashes of the binary.
+// When no algorithms are given, no hashes will be calculated.
+// Default is MD5, SHA-1, SHA-256, SHA-384 and SHA-512.
+func WithHashAlgorithms(algos ...cdx.HashAlgorithm) Option {
+	return func(g *generator) error {
+		if err := sbom.ValidateHashAlgorithms(algos...); err != nil {
+			return err
+		}
+		g.hashAlgorithms = algos
+		return nil
+	}
+}
+
+// WithIncludePackages toggles the inclusion of packages.
+//
+// Because build information doesn't list packages, they're read from
+// the binary's symbol table. Only packages that were actually linked
+// into the binary are included.
+func WithIncludePackages(enable bool) Option {
+	return func(g *generator) error {
+		g.includePackages = enable
+		return nil
+	}
+}
+
 // WithIncludeStdlib toggles the inclusion of a std component
 // representing the Go standard library in the generated BOM.
 //
@@ -39,6 +81,16 @@ func WithIncludeStdlib(enable bool) Option {
 	}
 }
 
+// WithLicenseCurations sets license curations, which take precedence over detected licenses.
+//
+// When nil, no curations are applied. Default is nil.
+func WithLicenseCurations(curations *curation.Curations) Option {
+	return func(g *generator) error {
+		g.licenseCurations = curations
+		return nil
+	}
+}
+
 // WithLicenseDetector sets the license detector.
 //
 // Because Go does not embed license information in binaries,
@@ -62,6 +114,19 @@ func WithLogger(logger zerolog.Logger) Option {
 	}
 }
 
+// WithParallelism sets the number of modules that are processed concurrently
+// when detecting licenses and calculating hashes.
+// Default is the value of runtime.GOMAXPROCS.
+func WithParallelism(parallelism int) Option {
+	return func(g *generator) error {
+		if parallelism < 1 {
+			return fmt.Errorf("parallelism must be at least 1, got %d", parallelism)
+		}
+		g.parallelism = parallelism
+		return nil
+	}
+}
+
 // WithVersionOverride overrides the version of the main component.
 //
 // This is useful in cases where a BOM is generated from development-
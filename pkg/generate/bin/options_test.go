@@ -21,12 +21,44 @@ import (
 	"os"
 	"testing"
 
+	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/curation"
 	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/local"
 )
 
+func TestWithCacheDir(t *testing.T) {
+	g := &generator{}
+	err := WithCacheDir("/tmp/cache")(g)
+	require.NoError(t, err)
+	require.Equal(t, "/tmp/cache", g.cacheDir)
+}
+
+func TestWithHashAlgorithms(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		g := &generator{}
+		err := WithHashAlgorithms(cdx.HashAlgoSHA3_256, cdx.HashAlgoBlake3)(g)
+		require.NoError(t, err)
+		require.Equal(t, []cdx.HashAlgorithm{cdx.HashAlgoSHA3_256, cdx.HashAlgoBlake3}, g.hashAlgorithms)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		g := &generator{}
+		err := WithHashAlgorithms(cdx.HashAlgoStreebog256)(g)
+		require.Error(t, err)
+		require.Nil(t, g.hashAlgorithms)
+	})
+}
+
+func TestWithIncludePackages(t *testing.T) {
+	g := &generator{includePackages: false}
+	err := WithIncludePackages(true)(g)
+	require.NoError(t, err)
+	require.True(t, g.includePackages)
+}
+
 func TestWithIncludeStdlib(t *testing.T) {
 	g := &generator{includeStdlib: false}
 	err := WithIncludeStdlib(true)(g)
@@ -34,6 +66,17 @@ func TestWithIncludeStdlib(t *testing.T) {
 	require.True(t, g.includeStdlib)
 }
 
+func TestWithLicenseCurations(t *testing.T) {
+	curations := &curation.Curations{
+		Curations: []curation.Curation{{Module: "github.com/acme/foo", License: "MIT"}},
+	}
+
+	g := &generator{}
+	err := WithLicenseCurations(curations)(g)
+	require.NoError(t, err)
+	require.Same(t, curations, g.licenseCurations)
+}
+
 func TestWithLicenseDetector(t *testing.T) {
 	detector := local.NewDetector(zerolog.Nop(), local.DefaultMinDetectionConfidence)
 
@@ -51,6 +94,22 @@ func TestWithLogger(t *testing.T) {
 	require.Equal(t, logger, g.logger)
 }
 
+func TestWithParallelism(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		g := &generator{parallelism: 1}
+		err := WithParallelism(4)(g)
+		require.NoError(t, err)
+		require.Equal(t, 4, g.parallelism)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		g := &generator{parallelism: 1}
+		err := WithParallelism(0)(g)
+		require.Error(t, err)
+		require.Equal(t, 1, g.parallelism)
+	})
+}
+
 func TestWithVersionOverride(t *testing.T) {
 	g := &generator{versionOverride: ""}
 	err := WithVersionOverride("v1.0.0")(g)
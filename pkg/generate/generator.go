@@ -18,7 +18,11 @@
 // Package generate exposes cyclonedx-gomod's SBOM generation capabilities.
 package generate
 
-import cdx "github.com/CycloneDX/cyclonedx-go"
+import (
+	"context"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+)
 
 // Generator is the interface that provides abstraction for multiple BOM generation strategies.
 //
@@ -40,3 +44,14 @@ import cdx "github.com/CycloneDX/cyclonedx-go"
 type Generator interface {
 	Generate() (*cdx.BOM, error)
 }
+
+// ContextGenerator is a Generator that supports cancellation.
+//
+// GenerateContext behaves like Generate, but aborts generation as soon as
+// possible when ctx is canceled or its deadline is exceeded. This includes
+// invocations of the go command (e.g. to download modules) and license detection.
+// The returned error wraps ctx.Err() in that case.
+type ContextGenerator interface {
+	Generator
+	GenerateContext(ctx context.Context) (*cdx.BOM, error)
+}
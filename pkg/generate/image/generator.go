@@ -0,0 +1,207 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package image
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"runtime"
+	"strings"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/package-url/packageurl-go"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/image"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom/merge"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/generate"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/generate/bin"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/curation"
+)
+
+const (
+	PropertyImagePath        = "image:path"
+	PropertyImageLayerDigest = "image:layer:digest"
+	PropertyImagePlatform    = "image:platform"
+)
+
+type generator struct {
+	logger zerolog.Logger
+
+	cacheDir         string
+	imagePath        string
+	hashAlgorithms   []cdx.HashAlgorithm
+	includeStdlib    bool
+	licenseCurations *curation.Curations
+	licenseDetector  licensedetect.Detector
+	parallelism      int
+	platform         string
+	shortPURLs       bool
+}
+
+// NewGenerator returns a generator that is capable of generating BOMs
+// for the Go binaries in container images.
+//
+// imagePath may point to a tarball written by "docker save",
+// or to an OCI image layout (either as directory or tarball).
+func NewGenerator(imagePath string, opts ...Option) (generate.ContextGenerator, error) {
+	g := generator{
+		logger:         log.Logger,
+		imagePath:      imagePath,
+		hashAlgorithms: sbom.DefaultHashAlgorithms(),
+		parallelism:    runtime.GOMAXPROCS(0),
+	}
+
+	var err error
+	for _, opt := range opts {
+		if err = opt(&g); err != nil {
+			return nil, err
+		}
+	}
+
+	return &g, nil
+}
+
+// Generate implements the generate.Generator interface.
+func (g generator) Generate() (*cdx.BOM, error) {
+	return g.GenerateContext(context.Background())
+}
+
+// GenerateContext implements the generate.ContextGenerator interface.
+func (g generator) GenerateContext(ctx context.Context) (*cdx.BOM, error) {
+	img, err := image.Extract(g.logger, g.imagePath, g.platform)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract image: %w", err)
+	}
+	defer img.Close()
+
+	if len(img.Binaries) == 0 {
+		return nil, fmt.Errorf("no go binaries found in %s", g.imagePath)
+	}
+
+	boms := make([]*cdx.BOM, 0, len(img.Binaries))
+	for _, binary := range img.Binaries {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		g.logger.Debug().
+			Str("path", binary.Path).
+			Str("layer", binary.LayerDigest).
+			Msg("generating sbom for binary")
+
+		bom, err := g.generateForBinary(ctx, binary)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+
+			g.logger.Warn().
+				Err(err).
+				Str("path", binary.Path).
+				Msg("failed to generate sbom for binary")
+			continue
+		}
+		boms = append(boms, bom)
+	}
+	if len(boms) == 0 {
+		return nil, fmt.Errorf("failed to generate sboms for any of the binaries in %s", g.imagePath)
+	}
+
+	bom, err := merge.BOMs(g.buildImageComponent(img), boms)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge sboms: %w", err)
+	}
+
+	return bom, nil
+}
+
+// generateForBinary generates a BOM for a single binary of the image.
+// The binary's location within the image is recorded alongside the properties of the binary itself.
+func (g generator) generateForBinary(ctx context.Context, binary image.Binary) (*cdx.BOM, error) {
+	binGenerator, err := bin.NewGenerator(binary.File,
+		bin.WithLogger(g.logger),
+		bin.WithCacheDir(g.cacheDir),
+		bin.WithHashAlgorithms(g.hashAlgorithms...),
+		bin.WithIncludeStdlib(g.includeStdlib),
+		bin.WithLicenseCurations(g.licenseCurations),
+		bin.WithLicenseDetector(g.licenseDetector),
+		bin.WithParallelism(g.parallelism),
+		bin.WithShortPURLS(g.shortPURLs))
+	if err != nil {
+		return nil, err
+	}
+
+	bom, err := binGenerator.GenerateContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var properties []cdx.Property
+	if bom.Metadata.Properties != nil {
+		properties = *bom.Metadata.Properties
+	}
+	properties = append(properties,
+		sbom.NewProperty(PropertyImagePath, binary.Path),
+		sbom.NewProperty(PropertyImageLayerDigest, binary.LayerDigest))
+	bom.Metadata.Properties = &properties
+
+	return bom, nil
+}
+
+func (g generator) buildImageComponent(img *image.Image) cdx.Component {
+	component := cdx.Component{
+		BOMRef:  img.Name,
+		Type:    cdx.ComponentTypeContainer,
+		Name:    img.Name,
+		Version: img.Tag,
+	}
+	if component.Version != "" {
+		component.BOMRef += ":" + component.Version
+	}
+
+	if img.Digest != "" {
+		var qualifiers packageurl.Qualifiers
+		if !g.shortPURLs {
+			qualifierMap := make(map[string]string)
+			if strings.Contains(img.Name, "/") {
+				qualifierMap["repository_url"] = img.Name
+			}
+			if img.Tag != "" {
+				qualifierMap["tag"] = img.Tag
+			}
+			qualifiers = packageurl.QualifiersFromMap(qualifierMap)
+		}
+
+		component.PackageURL = packageurl.NewPackageURL(packageurl.TypeOCI, "",
+			strings.ToLower(path.Base(img.Name)), img.Digest, qualifiers, "").ToString()
+		component.BOMRef = component.PackageURL
+	}
+
+	if img.Platform != "" {
+		component.Properties = &[]cdx.Property{
+			sbom.NewProperty(PropertyImagePlatform, img.Platform),
+		}
+	}
+
+	return component
+}
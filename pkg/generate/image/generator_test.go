@@ -0,0 +1,119 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/CycloneDX/cyclonedx-go"
+	"github.com/bradleyjkemp/cupaloy/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/testutil"
+)
+
+func TestNewGenerator(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		g, err := NewGenerator("")
+		require.NoError(t, err)
+		require.NotNil(t, g)
+	})
+
+	t.Run("OptionError", func(t *testing.T) {
+		failOption := func(g *generator) error {
+			return errors.New("test")
+		}
+
+		g, err := NewGenerator("", failOption)
+		require.Nil(t, g)
+		require.Error(t, err)
+		require.Equal(t, "test", err.Error())
+	})
+}
+
+type tarEntry struct {
+	name    string
+	content []byte
+}
+
+// buildTar builds a tar archive of executable files.
+func buildTar(t *testing.T, entries ...tarEntry) []byte {
+	buf := new(bytes.Buffer)
+	tarWriter := tar.NewWriter(buf)
+	for _, entry := range entries {
+		require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: entry.name, Mode: 0o755, Size: int64(len(entry.content))}))
+		_, err := tarWriter.Write(entry.content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tarWriter.Close())
+
+	return buf.Bytes()
+}
+
+func TestGenerator_Generate(t *testing.T) {
+	testutil.SkipIfShort(t)
+
+	snapShooter := cupaloy.NewDefaultConfig().
+		WithOptions(cupaloy.SnapshotSubdirectory("./testdata/snapshots"))
+
+	t.Run("DockerArchive", func(t *testing.T) {
+		simple, err := os.ReadFile("../testdata/simple")
+		require.NoError(t, err)
+		simple118, err := os.ReadFile("../testdata/simple1.18")
+		require.NoError(t, err)
+
+		imagePath := filepath.Join(t.TempDir(), "image.tar")
+		require.NoError(t, os.WriteFile(imagePath, buildTar(t,
+			tarEntry{"manifest.json", []byte(`[{"Config":"config.json","RepoTags":["example.com/acme/simple:v1.0.0"],"Layers":["layer1/layer.tar","layer2/layer.tar"]}]`)},
+			tarEntry{"config.json", []byte(`{"os":"linux","architecture":"amd64","rootfs":{"diff_ids":["sha256:1111","sha256:2222"]}}`)},
+			tarEntry{"layer1/layer.tar", buildTar(t, tarEntry{"usr/bin/simple", simple})},
+			tarEntry{"layer2/layer.tar", buildTar(t, tarEntry{"usr/local/bin/simple1.18", simple118})},
+		), 0o600))
+
+		g, err := NewGenerator(imagePath,
+			WithIncludeStdlib(true),
+			WithLogger(testutil.SilentLogger))
+		require.NoError(t, err)
+
+		bom, err := g.Generate()
+		require.NoError(t, err)
+
+		testutil.RequireVolatilePURLQualifiersToBeRedacted(t, bom)
+		testutil.RequireMatchingSBOMSnapshot(t, snapShooter, bom, cyclonedx.BOMFileFormatJSON)
+		testutil.RequireValidSBOM(t, bom, cyclonedx.BOMFileFormatJSON)
+	})
+
+	t.Run("NoGoBinaries", func(t *testing.T) {
+		imagePath := filepath.Join(t.TempDir(), "image.tar")
+		require.NoError(t, os.WriteFile(imagePath, buildTar(t,
+			tarEntry{"manifest.json", []byte(`[{"Config":"config.json","RepoTags":["example.com/acme/empty:v1.0.0"],"Layers":[]}]`)},
+			tarEntry{"config.json", []byte(`{}`)},
+		), 0o600))
+
+		g, err := NewGenerator(imagePath, WithLogger(testutil.SilentLogger))
+		require.NoError(t, err)
+
+		_, err = g.Generate()
+		require.ErrorContains(t, err, "no go binaries found")
+	})
+}
@@ -0,0 +1,128 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package image
+
+import (
+	"fmt"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/rs/zerolog"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/curation"
+)
+
+// Option allows for customization of the generator using the
+// functional options pattern.
+type Option func(g *generator) error
+
+// WithCacheDir enables a persistent cache for license detection results
+// in the given directory, which speeds up repeated runs considerably.
+// Results for modules are only cached if they're located in the module cache.
+//
+// Default is "", which disables the cache.
+func WithCacheDir(dir string) Option {
+	return func(g *generator) error {
+		g.cacheDir = dir
+		return nil
+	}
+}
+
+// WithHashAlgorithms sets the algorithms used to calculate hashes of binaries found in the image.
+// When no algorithms are given, no hashes will be calculated.
+// Default is MD5, SHA-1, SHA-256, SHA-384 and SHA-512.
+func WithHashAlgorithms(algos ...cdx.HashAlgorithm) Option {
+	return func(g *generator) error {
+		if err := sbom.ValidateHashAlgorithms(algos...); err != nil {
+			return err
+		}
+		g.hashAlgorithms = algos
+		return nil
+	}
+}
+
+// WithIncludeStdlib toggles the inclusion of a std component
+// representing the Go standard library in the generated BOM.
+func WithIncludeStdlib(enable bool) Option {
+	return func(g *generator) error {
+		g.includeStdlib = enable
+		return nil
+	}
+}
+
+// WithLicenseCurations sets license curations, which take precedence over detected licenses.
+//
+// When nil, no curations are applied. Default is nil.
+func WithLicenseCurations(curations *curation.Curations) Option {
+	return func(g *generator) error {
+		g.licenseCurations = curations
+		return nil
+	}
+}
+
+// WithLicenseDetector sets the license detector.
+//
+// Like for single binaries, performing license detection requires
+// downloading of the modules embedded in the image's binaries.
+//
+// When nil, no license detection will be performed. Default is nil.
+func WithLicenseDetector(detector licensedetect.Detector) Option {
+	return func(g *generator) error {
+		g.licenseDetector = detector
+		return nil
+	}
+}
+
+// WithLogger overrides the default logger of the generator.
+func WithLogger(logger zerolog.Logger) Option {
+	return func(g *generator) error {
+		g.logger = logger
+		return nil
+	}
+}
+
+// WithParallelism sets the number of modules that are processed concurrently
+// when detecting licenses and calculating hashes.
+// Default is the value of runtime.GOMAXPROCS.
+func WithParallelism(parallelism int) Option {
+	return func(g *generator) error {
+		if parallelism < 1 {
+			return fmt.Errorf("parallelism must be at least 1, got %d", parallelism)
+		}
+		g.parallelism = parallelism
+		return nil
+	}
+}
+
+// WithPlatform selects the platform (in os/arch[/variant] notation)
+// to generate the BOM for, if the image is available for multiple platforms.
+func WithPlatform(platform string) Option {
+	return func(g *generator) error {
+		g.platform = platform
+		return nil
+	}
+}
+
+// WithShortPURLS toggles the use of short PURLs without query parameters.
+func WithShortPURLS(enable bool) Option {
+	return func(g *generator) error {
+		g.shortPURLs = enable
+		return nil
+	}
+}
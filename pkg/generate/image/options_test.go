@@ -0,0 +1,108 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package image
+
+import (
+	"testing"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/curation"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/local"
+)
+
+func TestWithCacheDir(t *testing.T) {
+	g := &generator{}
+	err := WithCacheDir("/tmp/cache")(g)
+	require.NoError(t, err)
+	require.Equal(t, "/tmp/cache", g.cacheDir)
+}
+
+func TestWithHashAlgorithms(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		g := &generator{}
+		err := WithHashAlgorithms(cdx.HashAlgoSHA3_256, cdx.HashAlgoBlake3)(g)
+		require.NoError(t, err)
+		require.Equal(t, []cdx.HashAlgorithm{cdx.HashAlgoSHA3_256, cdx.HashAlgoBlake3}, g.hashAlgorithms)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		g := &generator{}
+		err := WithHashAlgorithms(cdx.HashAlgoStreebog256)(g)
+		require.Error(t, err)
+		require.Nil(t, g.hashAlgorithms)
+	})
+}
+
+func TestWithIncludeStdlib(t *testing.T) {
+	g := &generator{includeStdlib: false}
+	err := WithIncludeStdlib(true)(g)
+	require.NoError(t, err)
+	require.True(t, g.includeStdlib)
+}
+
+func TestWithLicenseCurations(t *testing.T) {
+	curations := &curation.Curations{
+		Curations: []curation.Curation{{Module: "github.com/acme/foo", License: "MIT"}},
+	}
+
+	g := &generator{}
+	err := WithLicenseCurations(curations)(g)
+	require.NoError(t, err)
+	require.Same(t, curations, g.licenseCurations)
+}
+
+func TestWithLicenseDetector(t *testing.T) {
+	g := &generator{}
+	detector := local.NewDetector(zerolog.Nop(), local.DefaultMinDetectionConfidence)
+	err := WithLicenseDetector(detector)(g)
+	require.NoError(t, err)
+	require.Equal(t, detector, g.licenseDetector)
+}
+
+func TestWithParallelism(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		g := &generator{parallelism: 1}
+		err := WithParallelism(4)(g)
+		require.NoError(t, err)
+		require.Equal(t, 4, g.parallelism)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		g := &generator{parallelism: 1}
+		err := WithParallelism(0)(g)
+		require.Error(t, err)
+		require.Equal(t, 1, g.parallelism)
+	})
+}
+
+func TestWithPlatform(t *testing.T) {
+	g := &generator{}
+	err := WithPlatform("linux/arm64")(g)
+	require.NoError(t, err)
+	require.Equal(t, "linux/arm64", g.platform)
+}
+
+func TestWithShortPURLS(t *testing.T) {
+	g := &generator{shortPURLs: false}
+	err := WithShortPURLS(true)(g)
+	require.NoError(t, err)
+	require.True(t, g.shortPURLs)
+}
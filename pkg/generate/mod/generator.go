@@ -18,38 +18,52 @@
 package mod
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"go/version"
 	"io"
+	"path/filepath"
+	"runtime"
 
 	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/mod/modfile"
 
+	"github.com/CycloneDX/cyclonedx-gomod/internal/cache"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/gocmd"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/gomod"
 	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
 	modConv "github.com/CycloneDX/cyclonedx-gomod/internal/sbom/convert/module"
 	"github.com/CycloneDX/cyclonedx-gomod/pkg/generate"
 	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/curation"
 )
 
 type generator struct {
 	logger zerolog.Logger
 
-	moduleDir       string
-	componentType   cdx.ComponentType
-	includeStdlib   bool
-	includeTest     bool
-	licenseDetector licensedetect.Detector
-	shortPURLs      bool
+	cache            *cache.Cache
+	cacheDir         string
+	moduleDir        string
+	componentType    cdx.ComponentType
+	includeStdlib    bool
+	includeTest      bool
+	includeTools     bool
+	licenseCurations *curation.Curations
+	licenseDetector  licensedetect.Detector
+	parallelism      int
+	offline          bool
+	shortPURLs       bool
 }
 
 // NewGenerator returns a generator that is capable of generating BOMs for Go modules.
-func NewGenerator(moduleDir string, opts ...Option) (generate.Generator, error) {
+func NewGenerator(moduleDir string, opts ...Option) (generate.ContextGenerator, error) {
 	g := generator{
 		logger:        log.Logger,
 		moduleDir:     moduleDir,
+		parallelism:   runtime.GOMAXPROCS(0),
 		componentType: cdx.ComponentTypeApplication,
 	}
 
@@ -65,17 +79,37 @@ func NewGenerator(moduleDir string, opts ...Option) (generate.Generator, error)
 
 // Generate implements the generate.Generator interface.
 func (g generator) Generate() (*cdx.BOM, error) {
+	return g.GenerateContext(context.Background())
+}
+
+// GenerateContext implements the generate.ContextGenerator interface.
+func (g generator) GenerateContext(ctx context.Context) (*cdx.BOM, error) {
+	if g.offline {
+		return g.generateOffline(ctx)
+	}
+
+	var err error
+	g.cache, err = cache.Open(ctx, g.logger, g.cacheDir)
+	if err != nil {
+		g.logger.Warn().Err(err).Msg("failed to open cache, continuing without it")
+	}
+	g.licenseDetector = g.cache.LicenseDetector(g.licenseDetector)
+
 	// Cheap trick to make Go download all required modules in the module graph
 	// without modifying go.sum (as `go mod download` would do).
-	err := gocmd.ModWhy(g.logger, g.moduleDir, []string{"github.com/CycloneDX/cyclonedx-go"}, io.Discard)
+	err = gocmd.ModWhy(ctx, g.logger, g.moduleDir, []string{"github.com/CycloneDX/cyclonedx-go"}, io.Discard)
 	if err != nil {
 		return nil, fmt.Errorf("failed to download modules: %w", err)
 	}
 
-	modules, err := gomod.GetVendoredModules(g.logger, g.moduleDir, g.includeTest)
+	if gomod.IsWorkspace(g.moduleDir) {
+		return g.generateForWorkspace(ctx)
+	}
+
+	modules, err := gomod.GetVendoredModules(ctx, g.logger, g.moduleDir, g.includeTest, g.includeTools)
 	if err != nil {
 		if errors.Is(err, gomod.ErrNotVendoring) {
-			modules, err = gomod.LoadModules(g.logger, g.moduleDir, g.includeTest)
+			modules, err = gomod.LoadModules(ctx, g.logger, g.moduleDir, g.includeTest, g.includeTools)
 			if err != nil {
 				return nil, fmt.Errorf("failed to collect modules: %w", err)
 			}
@@ -84,17 +118,32 @@ func (g generator) Generate() (*cdx.BOM, error) {
 		}
 	}
 
+	// When the module is part of a workspace, the other workspace modules
+	// are reported as main modules, too. Make sure ours comes first.
+	gomod.MarkWorkspaceModules(modules, g.moduleDir)
+	inWorkspace := false
+	for i := range modules {
+		if modules[i].Workspace {
+			inWorkspace = true
+		} else if modules[i].Main && i > 0 {
+			mainModule := modules[i]
+			copy(modules[1:i+1], modules[:i])
+			modules[0] = mainModule
+			break
+		}
+	}
+
 	if g.includeStdlib {
-		stdlibModule, err := gomod.LoadStdlibModule(g.logger)
+		stdlibModule, err := gomod.LoadStdlibModule(ctx, g.logger)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load stdlib module: %w", err)
 		}
 
 		modules[0].Dependencies = append(modules[0].Dependencies, stdlibModule)
-		modules = append(modules, *stdlibModule)
+		modules = append(modules, gomod.StdlibModules(*stdlibModule)...)
 	}
 
-	err = gomod.ApplyModuleGraph(g.logger, g.moduleDir, modules)
+	err = gomod.ApplyModuleGraph(ctx, g.logger, g.moduleDir, modules)
 	if err != nil {
 		return nil, fmt.Errorf("failed to apply module graph: %w", err)
 	}
@@ -103,18 +152,24 @@ func (g generator) Generate() (*cdx.BOM, error) {
 	if err != nil {
 		g.logger.Warn().Err(err).Msg("failed to determine version of main module")
 	}
+	if inWorkspace {
+		gomod.ResolveWorkspaceModuleVersions(g.logger, modules)
+
+		// The workspace may contain modules that our main module doesn't depend on.
+		modules = gomod.PruneUnreachableModules(modules, &modules[0])
+	}
 
 	main, err := modConv.ToComponent(g.logger, modules[0],
 		modConv.WithComponentType(g.componentType),
-		modConv.WithLicenses(g.licenseDetector),
+		modConv.WithLicenses(ctx, g.licenseDetector, g.licenseCurations),
 		modConv.WithShortPURL(g.shortPURLs),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert main module: %w", err)
 	}
-	components, err := modConv.ToComponents(g.logger, modules[1:],
-		modConv.WithLicenses(g.licenseDetector),
-		modConv.WithModuleHashes(),
+	components, err := modConv.ToComponents(g.logger, modules[1:], g.parallelism,
+		modConv.WithLicenses(ctx, g.licenseDetector, g.licenseCurations),
+		modConv.WithModuleHashes(g.cache),
 		modConv.WithShortPURL(g.shortPURLs),
 	)
 	if err != nil {
@@ -131,3 +186,207 @@ func (g generator) Generate() (*cdx.BOM, error) {
 
 	return bom, nil
 }
+
+// generateForWorkspace generates a BOM for the Go workspace in g.moduleDir.
+//
+// The workspace itself is represented by the main component. All modules
+// of the workspace are direct dependencies of it, and have their own
+// dependency subtree.
+func (g generator) generateForWorkspace(ctx context.Context) (*cdx.BOM, error) {
+	workspace, err := gomod.LoadWorkspace(g.logger, g.moduleDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workspace: %w", err)
+	}
+
+	modules, err := gomod.LoadModules(ctx, g.logger, workspace.Dir, g.includeTest, g.includeTools)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect modules: %w", err)
+	}
+	gomod.MarkWorkspaceModules(modules, "")
+
+	var stdlibModule *gomod.Module
+	if g.includeStdlib {
+		stdlibModule, err = gomod.LoadStdlibModule(ctx, g.logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load stdlib module: %w", err)
+		}
+
+		modules = append(modules, gomod.StdlibModules(*stdlibModule)...)
+	}
+
+	err = gomod.ApplyModuleGraph(ctx, g.logger, workspace.Dir, modules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply module graph: %w", err)
+	}
+	gomod.ResolveWorkspaceModuleVersions(g.logger, modules)
+
+	// There is no module path for the workspace itself, so we
+	// fall back to the name of the directory containing go.work.
+	workspaceModule := gomod.Module{
+		Path: filepath.Base(workspace.Dir),
+		Dir:  workspace.Dir,
+		Main: true,
+	}
+	workspaceModule.Version, err = gomod.GetModuleVersion(g.logger, workspace.Dir)
+	if err != nil {
+		g.logger.Warn().Err(err).Msg("failed to determine version of workspace")
+	}
+
+	main, err := modConv.ToComponent(g.logger, workspaceModule,
+		modConv.WithComponentType(g.componentType),
+		modConv.WithLicenses(ctx, g.licenseDetector, g.licenseCurations),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert workspace: %w", err)
+	}
+	// The workspace is not a module, so it can't be referred to by module PURL.
+	main.BOMRef = "workspace:" + workspaceModule.Coordinates()
+	main.PackageURL = ""
+	main.ExternalReferences = nil
+
+	components, err := modConv.ToComponents(g.logger, modules, g.parallelism,
+		modConv.WithLicenses(ctx, g.licenseDetector, g.licenseCurations),
+		modConv.WithModuleHashes(g.cache),
+		modConv.WithShortPURL(g.shortPURLs),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert modules: %w", err)
+	}
+
+	mainDependencies := make([]string, 0, len(workspace.Use)+1)
+	for i := range modules {
+		if modules[i].Workspace {
+			mainDependencies = append(mainDependencies, modules[i].BOMRef())
+		}
+	}
+	if stdlibModule != nil {
+		mainDependencies = append(mainDependencies, stdlibModule.BOMRef())
+	}
+
+	dependencies := []cdx.Dependency{
+		{
+			Ref:          main.BOMRef,
+			Dependencies: &mainDependencies,
+		},
+	}
+	dependencies = append(dependencies, sbom.BuildDependencyGraph(modules)...)
+
+	bom := cdx.NewBOM()
+	bom.Metadata = &cdx.Metadata{
+		Component: main,
+	}
+	bom.Components = &components
+	bom.Dependencies = &dependencies
+
+	return bom, nil
+}
+
+// generateOffline generates a BOM from the go.mod and go.sum files in g.moduleDir,
+// without invoking the go command.
+//
+// Only the requirements of the main module are known, so the dependency graph is
+// limited to the main module's direct dependencies. Compositions are used to denote
+// which parts of the graph are incomplete.
+func (g generator) generateOffline(ctx context.Context) (*cdx.BOM, error) {
+	modFile, err := gomod.ReadModFile(g.moduleDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.mod: %w", err)
+	}
+	sums, err := gomod.ReadSumFile(g.moduleDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.sum: %w", err)
+	}
+
+	modules, err := gomod.ModulesFromModFile(g.logger, g.moduleDir, modFile, sums)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect modules: %w", err)
+	}
+	if !g.includeTest {
+		g.logger.Warn().Msg("test-only modules can't be told apart in offline mode, including all requirements")
+	}
+	if !g.includeTools && len(modFile.Tool) > 0 {
+		g.logger.Warn().Msg("tool-only modules can't be told apart in offline mode, including all requirements")
+	}
+
+	if g.includeStdlib {
+		stdlibModule := gomod.StdlibModuleFromModFile(modFile)
+		if stdlibModule != nil {
+			modules[0].Dependencies = append(modules[0].Dependencies, stdlibModule)
+			modules = append(modules, gomod.StdlibModules(*stdlibModule)...)
+		} else {
+			g.logger.Warn().Msg("go.mod declares no go version, omitting stdlib module")
+		}
+	}
+
+	modules[0].Version, err = gomod.GetModuleVersion(g.logger, modules[0].Dir)
+	if err != nil {
+		g.logger.Warn().Err(err).Msg("failed to determine version of main module")
+	} else if gomod.IsRetracted(modFile, modules[0].Version) {
+		g.logger.Warn().
+			Str("version", modules[0].Version).
+			Msg("version of main module is retracted")
+	}
+
+	main, err := modConv.ToComponent(g.logger, modules[0],
+		modConv.WithComponentType(g.componentType),
+		modConv.WithLicenses(ctx, g.licenseDetector, g.licenseCurations),
+		modConv.WithShortPURL(g.shortPURLs),
+		modConv.WithPlatformIndependentPURL(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert main module: %w", err)
+	}
+	components, err := modConv.ToComponents(g.logger, modules[1:], g.parallelism,
+		modConv.WithLicenses(ctx, g.licenseDetector, g.licenseCurations),
+		modConv.WithShortPURL(g.shortPURLs),
+		modConv.WithPlatformIndependentPURL(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert modules: %w", err)
+	}
+	dependencies := sbom.BuildDependencyGraph(modules)
+	compositions := buildOfflineCompositions(modFile, main, components)
+
+	bom := cdx.NewBOM()
+	bom.Metadata = &cdx.Metadata{
+		Component: main,
+	}
+	bom.Components = &components
+	bom.Dependencies = &dependencies
+	bom.Compositions = &compositions
+
+	return bom, nil
+}
+
+func buildOfflineCompositions(modFile *modfile.File, mainComponent *cdx.Component, components []cdx.Component) []cdx.Composition {
+	compositions := make([]cdx.Composition, 0, 2)
+
+	// As of Go 1.17, go.mod lists all modules that provide packages to the main module,
+	// and the go command refuses to build if direct requirements are missing.
+	// Before that, requirements may be omitted if they're implied by other modules.
+	mainAggregate := cdx.CompositionAggregateIncomplete
+	if modFile.Go != nil && version.Compare("go"+modFile.Go.Version, "go1.17") >= 0 {
+		mainAggregate = cdx.CompositionAggregateComplete
+	}
+	compositions = append(compositions, cdx.Composition{
+		Aggregate: mainAggregate,
+		Dependencies: &[]cdx.BOMReference{
+			cdx.BOMReference(mainComponent.BOMRef),
+		},
+	})
+
+	// Requirements of the dependencies are declared in their own go.mod files,
+	// which are not available without the module cache.
+	if len(components) > 0 {
+		dependencyRefs := make([]cdx.BOMReference, 0, len(components))
+		for _, component := range components {
+			dependencyRefs = append(dependencyRefs, cdx.BOMReference(component.BOMRef))
+		}
+		compositions = append(compositions, cdx.Composition{
+			Aggregate:    cdx.CompositionAggregateIncomplete,
+			Dependencies: &dependencyRefs,
+		})
+	}
+
+	return compositions
+}
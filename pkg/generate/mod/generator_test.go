@@ -18,7 +18,10 @@
 package mod
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -75,6 +78,30 @@ func TestGenerator_Generate(t *testing.T) {
 		testutil.RequireMatchingSBOMSnapshot(t, snapShooter, bom, cyclonedx.BOMFileFormatJSON)
 	})
 
+	t.Run("SimpleWithCache", func(t *testing.T) {
+		fixturePath := testutil.ExtractFixtureArchive(t, "../testdata/simple.tar.gz")
+		cacheDir := t.TempDir()
+
+		generate := func(cacheDir string) *cyclonedx.BOM {
+			g, err := NewGenerator(fixturePath,
+				WithCacheDir(cacheDir),
+				WithLicenseDetector(local.NewDetector(zerolog.Nop(), local.DefaultMinDetectionConfidence)),
+				WithLogger(testutil.SilentLogger))
+			require.NoError(t, err)
+
+			bom, err := g.Generate()
+			require.NoError(t, err)
+			return bom
+		}
+
+		uncachedBOM := generate("")
+		require.Equal(t, uncachedBOM, generate(cacheDir)) // Cold
+		entries, err := os.ReadDir(filepath.Join(cacheDir, "v2"))
+		require.NoError(t, err)
+		require.NotEmpty(t, entries)
+		require.Equal(t, uncachedBOM, generate(cacheDir)) // Warm
+	})
+
 	t.Run("SimpleMultiCommand", func(t *testing.T) {
 		fixturePath := testutil.ExtractFixtureArchive(t, "../testdata/simple-multi-command.tar.gz")
 
@@ -113,6 +140,75 @@ func TestGenerator_Generate(t *testing.T) {
 		testutil.RequireMatchingSBOMSnapshot(t, snapShooter, bom, cyclonedx.BOMFileFormatJSON)
 	})
 
+	t.Run("SimpleTools", func(t *testing.T) {
+		fixturePath := testutil.ExtractFixtureArchive(t, "../testdata/simple-tools.tar.gz")
+
+		g, err := NewGenerator(filepath.Join(fixturePath, "app"),
+			WithIncludeToolModules(true),
+			WithLogger(testutil.SilentLogger))
+		require.NoError(t, err)
+
+		bom, err := g.Generate()
+		require.NoError(t, err)
+
+		testutil.RequireValidSBOM(t, bom, cyclonedx.BOMFileFormatJSON)
+
+		testutil.RequireVolatilePURLQualifiersToBeRedacted(t, bom)
+		testutil.RequireMatchingSBOMSnapshot(t, snapShooter, bom, cyclonedx.BOMFileFormatJSON)
+	})
+
+	t.Run("SimpleWithoutTools", func(t *testing.T) {
+		fixturePath := testutil.ExtractFixtureArchive(t, "../testdata/simple-tools.tar.gz")
+
+		g, err := NewGenerator(filepath.Join(fixturePath, "app"),
+			WithLogger(testutil.SilentLogger))
+		require.NoError(t, err)
+
+		bom, err := g.Generate()
+		require.NoError(t, err)
+
+		require.NotNil(t, bom.Components)
+		require.Len(t, *bom.Components, 1)
+		require.Equal(t, "github.com/google/uuid", (*bom.Components)[0].Name)
+	})
+
+	t.Run("SimpleOffline", func(t *testing.T) {
+		fixturePath := testutil.ExtractFixtureArchive(t, "../testdata/simple.tar.gz")
+		t.Setenv("PATH", "") // The go command must not be invoked
+
+		logs := new(bytes.Buffer)
+		g, err := NewGenerator(fixturePath,
+			WithIncludeStdlib(true),
+			WithLogger(zerolog.New(logs).Level(zerolog.WarnLevel)),
+			WithOffline(true))
+		require.NoError(t, err)
+
+		bom, err := g.Generate()
+		require.NoError(t, err)
+
+		// Test-only modules are included, even though they weren't requested
+		require.Contains(t, logs.String(), `"level":"warn","message":"test-only modules can't be told apart in offline mode, including all requirements"`)
+
+		testutil.RequireValidSBOM(t, bom, cyclonedx.BOMFileFormatJSON)
+		testutil.RequireMatchingSBOMSnapshot(t, snapShooter, bom, cyclonedx.BOMFileFormatJSON)
+	})
+
+	t.Run("SimpleLocalOffline", func(t *testing.T) {
+		fixturePath := testutil.ExtractFixtureArchive(t, "../testdata/simple-local.tar.gz")
+		t.Setenv("PATH", "")
+
+		g, err := NewGenerator(filepath.Join(fixturePath, "local"),
+			WithLogger(testutil.SilentLogger),
+			WithOffline(true))
+		require.NoError(t, err)
+
+		bom, err := g.Generate()
+		require.NoError(t, err)
+
+		testutil.RequireValidSBOM(t, bom, cyclonedx.BOMFileFormatJSON)
+		testutil.RequireMatchingSBOMSnapshot(t, snapShooter, bom, cyclonedx.BOMFileFormatJSON)
+	})
+
 	// Test with a "simple" module with only a few dependencies,
 	// but as a subdirectory of a Git repository. The expectation is that the
 	// (pseudo-) version is inherited from the repository of the parent dir.
@@ -179,4 +275,70 @@ func TestGenerator_Generate(t *testing.T) {
 		testutil.RequireStdlibComponentToBeRedacted(t, bom, false, false)
 		testutil.RequireMatchingSBOMSnapshot(t, snapShooter, bom, cyclonedx.BOMFileFormatJSON)
 	})
+	// Test with a Go workspace, consisting of two modules.
+	// app depends on lib, and a local replacement is declared in go.work.
+	//
+	// workspace/
+	// |-+ go.work
+	// |-+ app/
+	// |-+ lib/
+	// |-+ errors/
+	t.Run("SimpleWorkspace", func(t *testing.T) {
+		fixturePath := testutil.ExtractFixtureArchive(t, "../testdata/simple-workspace.tar.gz")
+
+		g, err := NewGenerator(fixturePath,
+			WithIncludeStdlib(true),
+			WithLicenseDetector(local.NewDetector(zerolog.Nop(), local.DefaultMinDetectionConfidence)),
+			WithLogger(testutil.SilentLogger))
+		require.NoError(t, err)
+
+		bom, err := g.Generate()
+		require.NoError(t, err)
+
+		testutil.RequireValidSBOM(t, bom, cyclonedx.BOMFileFormatJSON)
+
+		// Name of the main component is derived from the (random) fixture directory
+		bom.Metadata.Component.Name = testutil.Redacted
+		bom.Metadata.Component.BOMRef = "workspace:" + testutil.Redacted
+		(*bom.Dependencies)[0].Ref = bom.Metadata.Component.BOMRef
+
+		testutil.RequireVolatilePURLQualifiersToBeRedacted(t, bom)
+		testutil.RequireStdlibComponentToBeRedacted(t, bom, false, false)
+		testutil.RequireMatchingSBOMSnapshot(t, snapShooter, bom, cyclonedx.BOMFileFormatJSON)
+	})
+
+	t.Run("SimpleWorkspaceModule", func(t *testing.T) {
+		fixturePath := testutil.ExtractFixtureArchive(t, "../testdata/simple-workspace.tar.gz")
+
+		g, err := NewGenerator(filepath.Join(fixturePath, "lib"),
+			WithIncludeStdlib(true),
+			WithLicenseDetector(local.NewDetector(zerolog.Nop(), local.DefaultMinDetectionConfidence)),
+			WithLogger(testutil.SilentLogger))
+		require.NoError(t, err)
+
+		bom, err := g.Generate()
+		require.NoError(t, err)
+
+		testutil.RequireValidSBOM(t, bom, cyclonedx.BOMFileFormatJSON)
+
+		testutil.RequireVolatilePURLQualifiersToBeRedacted(t, bom)
+		testutil.RequireStdlibComponentToBeRedacted(t, bom, false, false)
+		testutil.RequireMatchingSBOMSnapshot(t, snapShooter, bom, cyclonedx.BOMFileFormatJSON)
+	})
+}
+
+func TestGenerator_GenerateContext(t *testing.T) {
+	t.Run("Canceled", func(t *testing.T) {
+		fixturePath := testutil.ExtractFixtureArchive(t, "../testdata/simple.tar.gz")
+
+		g, err := NewGenerator(fixturePath, WithLogger(testutil.SilentLogger))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		bom, err := g.GenerateContext(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Nil(t, bom)
+	})
 }
@@ -18,16 +18,31 @@
 package mod
 
 import (
+	"fmt"
+
 	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/rs/zerolog"
 
 	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect"
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/curation"
 )
 
 // Option allows for customization of the generator using the
 // functional options pattern.
 type Option func(g *generator) error
 
+// WithCacheDir enables a persistent cache for module hashes and license detection results
+// in the given directory, which speeds up repeated runs considerably.
+// Results for modules are only cached if they're located in the module cache.
+//
+// Default is "", which disables the cache.
+func WithCacheDir(dir string) Option {
+	return func(g *generator) error {
+		g.cacheDir = dir
+		return nil
+	}
+}
+
 // WithComponentType overrides the type of the main component.
 // Default is ComponentTypeApplication.
 func WithComponentType(ctype cdx.ComponentType) Option {
@@ -57,6 +72,25 @@ func WithIncludeTestModules(enable bool) Option {
 	}
 }
 
+// WithIncludeToolModules toggles the inclusion of modules that are only required
+// by tools, as declared by tool directives in go.mod.
+func WithIncludeToolModules(enable bool) Option {
+	return func(g *generator) error {
+		g.includeTools = enable
+		return nil
+	}
+}
+
+// WithLicenseCurations sets license curations, which take precedence over detected licenses.
+//
+// When nil, no curations are applied. Default is nil.
+func WithLicenseCurations(curations *curation.Curations) Option {
+	return func(g *generator) error {
+		g.licenseCurations = curations
+		return nil
+	}
+}
+
 // WithLicenseDetector sets the license detector.
 //
 // When nil, no license detection will be performed. Default is nil.
@@ -75,6 +109,35 @@ func WithLogger(logger zerolog.Logger) Option {
 	}
 }
 
+// WithOffline toggles offline mode, in which modules are read from the go.mod and
+// go.sum files of the module instead of being loaded with the go command.
+// Neither the module cache nor network access is required in this mode.
+//
+// Because the requirements of dependencies are unknown, the dependency graph
+// only includes direct dependencies of the main module, which is reflected
+// in the compositions of the generated BOM. Test-only and tool-only modules
+// can't be identified and are always included, regardless of WithIncludeTestModules
+// and WithIncludeToolModules. A warning is logged when they would have been excluded.
+func WithOffline(enable bool) Option {
+	return func(g *generator) error {
+		g.offline = enable
+		return nil
+	}
+}
+
+// WithParallelism sets the number of modules that are processed concurrently
+// when detecting licenses and calculating hashes.
+// Default is the value of runtime.GOMAXPROCS.
+func WithParallelism(parallelism int) Option {
+	return func(g *generator) error {
+		if parallelism < 1 {
+			return fmt.Errorf("parallelism must be at least 1, got %d", parallelism)
+		}
+		g.parallelism = parallelism
+		return nil
+	}
+}
+
 // WithShortPURLS toggles the use of short PURLs without query parameters.
 func WithShortPURLS(enable bool) Option {
 	return func(g *generator) error {
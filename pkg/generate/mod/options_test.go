@@ -25,9 +25,17 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/curation"
 	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect/local"
 )
 
+func TestWithCacheDir(t *testing.T) {
+	g := &generator{}
+	err := WithCacheDir("/tmp/cache")(g)
+	require.NoError(t, err)
+	require.Equal(t, "/tmp/cache", g.cacheDir)
+}
+
 func TestWithComponentType(t *testing.T) {
 	g := &generator{componentType: cdx.ComponentTypeContainer}
 	err := WithComponentType(cdx.ComponentTypeDevice)(g)
@@ -49,6 +57,17 @@ func TestWithIncludeTestModules(t *testing.T) {
 	require.True(t, g.includeTest)
 }
 
+func TestWithLicenseCurations(t *testing.T) {
+	curations := &curation.Curations{
+		Curations: []curation.Curation{{Module: "github.com/acme/foo", License: "MIT"}},
+	}
+
+	g := &generator{}
+	err := WithLicenseCurations(curations)(g)
+	require.NoError(t, err)
+	require.Same(t, curations, g.licenseCurations)
+}
+
 func TestWithLicenseDetector(t *testing.T) {
 	detector := local.NewDetector(zerolog.Nop(), local.DefaultMinDetectionConfidence)
 
@@ -65,3 +84,19 @@ func TestWithLogger(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, logger, g.logger)
 }
+
+func TestWithParallelism(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		g := &generator{parallelism: 1}
+		err := WithParallelism(4)(g)
+		require.NoError(t, err)
+		require.Equal(t, 4, g.parallelism)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		g := &generator{parallelism: 1}
+		err := WithParallelism(0)(g)
+		require.Error(t, err)
+		require.Equal(t, 1, g.parallelism)
+	})
+}
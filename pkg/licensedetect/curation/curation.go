@@ -0,0 +1,234 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+// Package curation provides license curations, which assert the licenses of modules
+// whose licenses are detected incorrectly, or can't be detected at all.
+//
+// Curations are maintained in a YAML file:
+//
+//	curations:
+//	  - module: github.com/acme/foo
+//	    versions: ">= v1.2.0, < v2.0.0"
+//	    license: Apache-2.0 OR MIT
+//	    comment: Dual-licensed, see README.md
+//	  - module: github.com/acme/*
+//	    license: LicenseRef-Acme
+package curation
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	"gopkg.in/yaml.v3"
+
+	"github.com/CycloneDX/cyclonedx-gomod/internal/licenseexpr"
+)
+
+// DefaultFileName is the conventional name of curation files.
+const DefaultFileName = ".cyclonedx-gomod-licenses.yaml"
+
+// Curations is a list of license curations.
+type Curations struct {
+	Curations []Curation `yaml:"curations"`
+}
+
+// Curation asserts the license of modules.
+type Curation struct {
+	// Module is a comma-separated list of glob patterns, matching module path prefixes.
+	// The syntax is the same as that of the GOPRIVATE environment variable,
+	// e.g. "github.com/acme" matches github.com/acme/foo as well.
+	Module string `yaml:"module"`
+
+	// Versions optionally restricts the curation to a range of module versions.
+	// It is a comma-separated list of comparisons, all of which must be satisfied,
+	// e.g. ">= v1.2.0, < v2.0.0". Supported operators are =, !=, <, <=, > and >=.
+	Versions string `yaml:"versions,omitempty"`
+
+	// License is the SPDX license identifier or expression that applies to matching modules.
+	License string `yaml:"license"`
+
+	// Comment optionally explains the curation.
+	Comment string `yaml:"comment,omitempty"`
+}
+
+// operators are the supported operators of version comparisons.
+// Operators that are prefixes of others must come last.
+var operators = []string{"!=", "<=", ">=", "=", "<", ">"}
+
+type constraint struct {
+	operator string
+	version  string
+}
+
+// Load reads curations from the file at path.
+func Load(path string) (*Curations, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	curations, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return curations, nil
+}
+
+// Parse reads curations from r and validates them.
+func Parse(r io.Reader) (*Curations, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var curations Curations
+	if err = decoder.Decode(&curations); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	for i := range curations.Curations {
+		if err = curations.Curations[i].validate(); err != nil {
+			return nil, fmt.Errorf("curation #%d: %w", i+1, err)
+		}
+	}
+
+	return &curations, nil
+}
+
+func (c Curation) validate() error {
+	if strings.TrimSpace(c.Module) == "" {
+		return fmt.Errorf("module is missing")
+	}
+	if strings.TrimSpace(c.License) == "" {
+		return fmt.Errorf("license is missing")
+	}
+	if _, err := licenseexpr.Parse(c.License); err != nil {
+		return err
+	}
+
+	return ValidateVersions(c.Versions)
+}
+
+// ValidateVersions checks whether versions is a valid list of version comparisons,
+// as used in the Versions field of Curation.
+func ValidateVersions(versions string) error {
+	_, err := parseConstraints(versions)
+	return err
+}
+
+// parseConstraints parses a comma-separated list of version comparisons.
+func parseConstraints(versions string) ([]constraint, error) {
+	if strings.TrimSpace(versions) == "" {
+		return nil, nil
+	}
+
+	var constraints []constraint
+	for _, comparison := range strings.Split(versions, ",") {
+		comparison = strings.TrimSpace(comparison)
+
+		operator := "="
+		for _, candidate := range operators {
+			if strings.HasPrefix(comparison, candidate) {
+				operator = candidate
+				break
+			}
+		}
+		version := strings.TrimSpace(strings.TrimPrefix(comparison, operator))
+		if !semver.IsValid(version) {
+			return nil, fmt.Errorf("invalid version comparison %q: %q is not a valid semantic version", comparison, version)
+		}
+
+		constraints = append(constraints, constraint{operator: operator, version: version})
+	}
+
+	return constraints, nil
+}
+
+// Matches checks whether the curation applies to the module with the given path and version.
+func (c Curation) Matches(path, version string) bool {
+	return MatchModule(c.Module, c.Versions, path, version)
+}
+
+// MatchModule checks whether the module with the given path and version is matched
+// by patterns and versions, which have the same syntax as the Module and Versions fields of Curation.
+// Version constraints never match modules without a valid version,
+// and invalid version constraints never match at all.
+func MatchModule(patterns, versions, path, version string) bool {
+	if !module.MatchPrefixPatterns(patterns, path) {
+		return false
+	}
+
+	constraints, err := parseConstraints(versions)
+	if err != nil {
+		return false
+	}
+	if len(constraints) > 0 && !semver.IsValid(version) {
+		return false
+	}
+
+	for _, constraint := range constraints {
+		cmp := semver.Compare(version, constraint.version)
+
+		var satisfied bool
+		switch constraint.operator {
+		case "=":
+			satisfied = cmp == 0
+		case "!=":
+			satisfied = cmp != 0
+		case "<":
+			satisfied = cmp < 0
+		case "<=":
+			satisfied = cmp <= 0
+		case ">":
+			satisfied = cmp > 0
+		case ">=":
+			satisfied = cmp >= 0
+		}
+		if !satisfied {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Lookup returns the first curation that applies to the module with the given path and version.
+// It may be called on a nil *Curations, in which case no curation applies.
+func (c *Curations) Lookup(path, version string) (Curation, bool) {
+	if c == nil {
+		return Curation{}, false
+	}
+
+	for _, curation := range c.Curations {
+		if curation.Matches(path, version) {
+			return curation, true
+		}
+	}
+
+	return Curation{}, false
+}
@@ -0,0 +1,136 @@
+// This file is part of CycloneDX GoMod
+//
+// Licensed under the Apache License, Version 2.0 (the “License”);
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an “AS IS” BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) OWASP Foundation. All Rights Reserved.
+
+package curation
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		curations, err := Parse(strings.NewReader(`
+curations:
+  - module: github.com/acme/foo
+    versions: ">= v1.2.0, < v2.0.0"
+    license: Apache-2.0 OR MIT
+    comment: Dual-licensed
+  - module: github.com/acme/*
+    license: LicenseRef-Acme
+`))
+		require.NoError(t, err)
+		require.Equal(t, []Curation{
+			{
+				Module:   "github.com/acme/foo",
+				Versions: ">= v1.2.0, < v2.0.0",
+				License:  "Apache-2.0 OR MIT",
+				Comment:  "Dual-licensed",
+			},
+			{
+				Module:  "github.com/acme/*",
+				License: "LicenseRef-Acme",
+			},
+		}, curations.Curations)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		curations, err := Parse(strings.NewReader(""))
+		require.NoError(t, err)
+		require.Empty(t, curations.Curations)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		testCases := map[string]string{
+			"MissingModule":   "curations:\n  - license: MIT\n",
+			"MissingLicense":  "curations:\n  - module: github.com/acme/foo\n",
+			"InvalidLicense":  "curations:\n  - module: github.com/acme/foo\n    license: MIT OR\n",
+			"InvalidVersions": "curations:\n  - module: github.com/acme/foo\n    license: MIT\n    versions: ~v1.2.0\n",
+			"UnknownField":    "curations:\n  - module: github.com/acme/foo\n    license: MIT\n    licence: MIT\n",
+		}
+
+		for name, input := range testCases {
+			t.Run(name, func(t *testing.T) {
+				_, err := Parse(strings.NewReader(input))
+				require.Error(t, err)
+			})
+		}
+	})
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultFileName)
+	require.NoError(t, os.WriteFile(path, []byte("curations:\n  - module: github.com/acme/foo\n    license: MIT\n"), 0o600))
+
+	curations, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, curations.Curations, 1)
+
+	_, err = Load(filepath.Join(t.TempDir(), DefaultFileName))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestCuration_Matches(t *testing.T) {
+	curation := Curation{Module: "github.com/acme/*", Versions: ">= v1.2.0, < v2.0.0, != v1.3.0", License: "MIT"}
+
+	require.True(t, curation.Matches("github.com/acme/foo", "v1.2.0"))
+	require.True(t, curation.Matches("github.com/acme/foo/bar", "v1.9.9"))
+	require.True(t, curation.Matches("github.com/acme/foo", "v1.4.0-20210101000000-abcdefabcdef"))
+	require.False(t, curation.Matches("github.com/acme/foo", "v1.3.0"))
+	require.False(t, curation.Matches("github.com/acme/foo", "v1.1.0"))
+	require.False(t, curation.Matches("github.com/acme/foo", "v2.0.0"))
+	require.False(t, curation.Matches("github.com/acme/foo", ""))
+	require.False(t, curation.Matches("github.com/acme", "v1.2.0"))
+	require.False(t, curation.Matches("github.com/other/foo", "v1.2.0"))
+
+	curation = Curation{Module: "github.com/acme/foo,github.com/acme/bar", License: "MIT"}
+	require.True(t, curation.Matches("github.com/acme/foo", ""))
+	require.True(t, curation.Matches("github.com/acme/bar/v2", "v2.0.0"))
+	require.False(t, curation.Matches("github.com/acme/baz", "v1.0.0"))
+
+	curation = Curation{Module: "github.com/acme/foo", Versions: "v1.0.0", License: "MIT"}
+	require.True(t, curation.Matches("github.com/acme/foo", "v1.0.0"))
+	require.False(t, curation.Matches("github.com/acme/foo", "v1.0.1"))
+}
+
+func TestCurations_Lookup(t *testing.T) {
+	curations := &Curations{
+		Curations: []Curation{
+			{Module: "github.com/acme/foo", Versions: "< v2.0.0", License: "MIT"},
+			{Module: "github.com/acme", License: "Apache-2.0"},
+		},
+	}
+
+	curation, ok := curations.Lookup("github.com/acme/foo", "v1.0.0")
+	require.True(t, ok)
+	require.Equal(t, "MIT", curation.License)
+
+	curation, ok = curations.Lookup("github.com/acme/foo/v2", "v2.0.0")
+	require.True(t, ok)
+	require.Equal(t, "Apache-2.0", curation.License)
+
+	_, ok = curations.Lookup("github.com/other/foo", "v1.0.0")
+	require.False(t, ok)
+
+	var nilCurations *Curations
+	_, ok = nilCurations.Lookup("github.com/acme/foo", "v1.0.0")
+	require.False(t, ok)
+}
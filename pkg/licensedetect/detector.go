@@ -18,12 +18,73 @@
 // Package licensedetect exposes cyclonedx-gomod's license detection functionality.
 package licensedetect
 
-import cdx "github.com/CycloneDX/cyclonedx-go"
+import (
+	"context"
+
+	cdx "github.com/CycloneDX/cyclonedx-go"
+)
 
 // Detector is the interface that provides abstraction for license detection strategies.
 //
 // Detectors are provided with a module's path, version, and local directory (in Go's module cache).
 // The latter may be empty, if the module has not been downloaded to the module cache.
+// Generators may detect licenses of multiple modules concurrently,
+// so implementations must be safe for concurrent use.
 type Detector interface {
 	Detect(path, version, dir string) ([]cdx.License, error)
 }
+
+// ContextDetector is a Detector that supports cancellation.
+type ContextDetector interface {
+	Detector
+	DetectContext(ctx context.Context, path, version, dir string) ([]cdx.License, error)
+}
+
+// ExpressionDetector is a Detector that is able to tell how the licenses it detects relate to each other.
+type ExpressionDetector interface {
+	ContextDetector
+
+	// DetectExpression detects licenses like DetectContext does.
+	// Additionally, it returns an SPDX license expression that combines the detected licenses,
+	// or an empty string if their relationship can't be determined.
+	DetectExpression(ctx context.Context, path, version, dir string) ([]cdx.License, string, error)
+}
+
+// CacheableDetector is a Detector whose results may be cached across runs.
+type CacheableDetector interface {
+	Detector
+
+	// CacheKey returns a key for the result of detecting licenses of a module.
+	// The key must change whenever the result may change, e.g. because the files
+	// the detector inspects or the detector's configuration changed.
+	CacheKey(path, version, dir string) (string, error)
+}
+
+// DetectContext detects licenses using the given detector.
+// If detector implements ContextDetector, ctx is passed on to it.
+// Otherwise, ctx is only checked before detection is started.
+func DetectContext(ctx context.Context, detector Detector, path, version, dir string) ([]cdx.License, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if contextDetector, ok := detector.(ContextDetector); ok {
+		return contextDetector.DetectContext(ctx, path, version, dir)
+	}
+
+	return detector.Detect(path, version, dir)
+}
+
+// DetectExpression detects licenses and an SPDX license expression combining them using the given detector.
+// If detector doesn't implement ExpressionDetector, the expression is always empty.
+func DetectExpression(ctx context.Context, detector Detector, path, version, dir string) ([]cdx.License, string, error) {
+	if expressionDetector, ok := detector.(ExpressionDetector); ok {
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+		return expressionDetector.DetectExpression(ctx, path, version, dir)
+	}
+
+	licenses, err := DetectContext(ctx, detector, path, version, dir)
+	return licenses, "", err
+}
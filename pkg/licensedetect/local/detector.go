@@ -19,15 +19,27 @@
 package local
 
 import (
+	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
 
 	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/go-enry/go-license-detector/v4/licensedb"
 	"github.com/go-enry/go-license-detector/v4/licensedb/filer"
 	"github.com/rs/zerolog"
 
+	"github.com/CycloneDX/cyclonedx-gomod/internal/sbom"
 	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect"
 )
 
@@ -51,65 +63,370 @@ func NewDetector(logger zerolog.Logger, minDetectionConfidence float32) licensed
 	}
 }
 
+// licenseDirRegex matches names of directories that are searched for license files,
+// in addition to the module's root directory. It mirrors the logic of licensedb.Detect.
+var licenseDirRegex = regexp.MustCompile(`^(li[cs]en[cs]e(s?)|legal|copy(left|right|ing)|unlicense|l?gpl([-_ v]?)(\d\.?\d)?|bsd|mit|apache)$`)
+
+// licenseFileRegex matches names of files that licensedb considers to be license files.
+var licenseFileRegex = regexp.MustCompile(`^(|.*[-_. ])(li[cs]en[cs]e(s?)|legal|copy(left|right|ing)|unlicense|l?gpl([-_ v]?)(\d\.?\d)?|bsd|mit|apache)(|[-_. ].*)$`)
+
+// readmeFileRegex matches names of README files, which commonly state how a module is licensed.
+var readmeFileRegex = regexp.MustCompile(`^readme(|\.md|\.rst|\.html|\.txt)$`)
+
+// choiceRegex matches statements saying that licensees may choose between multiple licenses.
+var choiceRegex = regexp.MustCompile(`(?i)\b(dual[\s-]+licen[cs]ed|(licen[cs]ed|available)\s+under\s+(the\s+terms\s+of\s+)?either|at\s+your\s+(option|choice|discretion))\b`)
+
+// laterVersionRegex matches the remainder of a GPL-style "(at your option) any later version" clause,
+// which allows choosing between versions of the same license, not between different licenses.
+var laterVersionRegex = regexp.MustCompile(`(?i)^\W*any\s+later\s+version`)
+
+// spdxIdentifierRegex matches SPDX license identifier tags, as used in file headers.
+// Delimiters that follow the expression, like the end of a comment or a code span, are not matched.
+var spdxIdentifierRegex = regexp.MustCompile(`SPDX-License-Identifier:[ \t]*([A-Za-z0-9.+:()-]+(?:[ \t]+[A-Za-z0-9.+:()-]+)*)`)
+
+// CacheKey implements the licensedetect.CacheableDetector interface.
+//
+// Only the files visited by walkFiles are inspected during detection,
+// so the key is derived from their paths, sizes and modification times.
+func (d detector) CacheKey(path, version, dir string) (string, error) {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s@%s\n%s\n%g\n", path, version, dir, d.minDetectionConfidence)
+
+	err := walkFiles(dir, func(relPath string, entry fs.DirEntry, _ bool) error {
+		return writeFileInfo(hash, relPath, entry)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("local:%x", hash.Sum(nil)), nil
+}
+
+func writeFileInfo(w io.Writer, relPath string, entry fs.DirEntry) error {
+	info, err := entry.Info()
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s\t%d\t%d\n", relPath, info.Size(), info.ModTime().UnixNano())
+	return err
+}
+
+// walkFiles calls fn for every file that may be inspected during detection, in lexical order.
+//
+// Root files are all files in the module's root directory and in license directories within it,
+// which is what licensedb.Detect inspects. Additionally, license files in all other directories
+// of the module are visited. Directories that are ignored by the Go toolchain, as well as vendor
+// and testdata directories and nested modules, are skipped.
+func walkFiles(dir string, fn func(relPath string, entry fs.DirEntry, root bool) error) error {
+	return filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if filePath == dir {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		name := strings.ToLower(entry.Name())
+
+		if entry.IsDir() {
+			if name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+				return fs.SkipDir
+			}
+			if _, err = os.Stat(filepath.Join(filePath, "go.mod")); err == nil {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		parentDir, _ := pathpkg.Split(relPath)
+		if parentDir == "" || (strings.Count(relPath, "/") == 1 && licenseDirRegex.MatchString(strings.ToLower(strings.TrimSuffix(parentDir, "/")))) {
+			return fn(relPath, entry, true)
+		}
+		if licenseFileRegex.MatchString(name) && pathpkg.Ext(name) != ".go" {
+			return fn(relPath, entry, false)
+		}
+
+		return nil
+	})
+}
+
 // Detect implements the licensedetect.Detector interface.
 func (d detector) Detect(path, version, dir string) ([]cdx.License, error) {
-	licensesFiler, err := filer.FromDirectory(dir)
+	return d.DetectContext(context.Background(), path, version, dir)
+}
+
+// DetectContext implements the licensedetect.ContextDetector interface.
+func (d detector) DetectContext(ctx context.Context, path, version, dir string) ([]cdx.License, error) {
+	licenses, _, err := d.DetectExpression(ctx, path, version, dir)
+	return licenses, err
+}
+
+// match is a license that was detected in a file.
+type match struct {
+	license    string
+	file       string // Relative to the module's directory
+	confidence float32
+}
+
+// DetectExpression implements the licensedetect.ExpressionDetector interface.
+//
+// Every license that was detected in a file with sufficient confidence is returned,
+// along with the name of the file and the confidence as properties. Licenses of the
+// module's root directory are detected using licensedb, licenses in subdirectories
+// are detected in the license files within them.
+//
+// Detection of a single directory can't be interrupted, but ctx is checked before each directory.
+func (d detector) DetectExpression(ctx context.Context, path, version, dir string) ([]cdx.License, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var rootFiles []string
+	subdirFiles := make(map[string][]string)
+	err := walkFiles(dir, func(relPath string, _ fs.DirEntry, root bool) error {
+		if root {
+			rootFiles = append(rootFiles, relPath)
+		} else {
+			subdir, name := pathpkg.Split(relPath)
+			subdir = strings.TrimSuffix(subdir, "/")
+			subdirFiles[subdir] = append(subdirFiles[subdir], name)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
+	module := fmt.Sprintf("%s@%s", path, version)
+
+	rootFiler, err := filer.FromDirectory(dir)
+	if err != nil {
+		return nil, "", err
+	}
+	rootMatches, err := d.detectMatches(module, rootFiler)
+	if err != nil {
+		return nil, "", err
+	}
+
+	subdirs := make([]string, 0, len(subdirFiles))
+	for subdir := range subdirFiles {
+		subdirs = append(subdirs, subdir)
+	}
+	sort.Strings(subdirs)
+
+	var subdirMatches []match
+	for _, subdir := range subdirs {
+		if err = ctx.Err(); err != nil {
+			return nil, "", err
+		}
+
+		subdirFiler, err := filer.FromDirectory(filepath.Join(dir, filepath.FromSlash(subdir)))
+		if err != nil {
+			return nil, "", err
+		}
+		matches, err := d.detectMatches(module, licenseFilesFiler{Filer: subdirFiler, files: subdirFiles[subdir]})
+		if err != nil {
+			return nil, "", err
+		}
+		for _, m := range matches {
+			m.file = pathpkg.Join(subdir, m.file)
+			subdirMatches = append(subdirMatches, m)
+		}
+	}
+
+	matches := slices.Concat(rootMatches, subdirMatches)
+	licenses := make([]cdx.License, 0, len(matches))
+	for _, m := range matches {
+		d.logger.Debug().
+			Str("module", module).
+			Str("license", m.license).
+			Str("file", m.file).
+			Float32("confidence", m.confidence).
+			Msg("license detected")
+
+		licenses = append(licenses, cdx.License{
+			ID: m.license,
+			Properties: &[]cdx.Property{
+				sbom.NewProperty("license:confidence", strconv.FormatFloat(float64(m.confidence), 'f', 2, 32)),
+				sbom.NewProperty("license:file", m.file),
+			},
+		})
+	}
+
+	expression := d.expression(module, dir, rootFiles, rootMatches, subdirMatches)
+
+	return licenses, expression, nil
+}
+
+// detectMatches detects licenses in the files provided by licensesFiler,
+// and returns the best match for every file, given it has sufficient confidence.
+func (d detector) detectMatches(module string, licensesFiler filer.Filer) ([]match, error) {
 	detectedLicenses, err := licensedb.Detect(licensesFiler)
 	if err != nil {
 		if errors.Is(err, licensedb.ErrNoLicenseFound) {
-			return []cdx.License{}, nil
+			return nil, nil
 		}
 		return nil, err
 	}
 
-	// Eventually we'll get multiple matches with equal confidence.
-	// In order to at least make the output deterministic, we sort
-	// the license names before iterating over the matches.
-	// Maps are not sorted, so we need a temporary slice for this.
-	detectedLicenseNames := make([]string, 0, len(detectedLicenses))
-	for license := range detectedLicenses {
-		detectedLicenseNames = append(detectedLicenseNames, license)
+	// A single file usually matches multiple, similar licenses.
+	// Only the best match is of interest, with ties being broken by
+	// the license name, in order to make the output deterministic.
+	bestMatches := make(map[string]match)
+	for license, detectedLicense := range detectedLicenses {
+		for file, confidence := range detectedLicense.Files {
+			best, ok := bestMatches[file]
+			if !ok || confidence > best.confidence || (confidence == best.confidence && license < best.license) {
+				bestMatches[file] = match{license: license, file: file, confidence: confidence}
+			}
+		}
 	}
-	sort.Slice(detectedLicenseNames, func(i, j int) bool {
-		return detectedLicenseNames[i] < detectedLicenseNames[j]
-	})
 
-	// Select the best match based on the highest confidence
-	var detectedLicense string
-	var detectedLicenseConfidence float32
-	for _, license := range detectedLicenseNames {
-		if detectedLicenses[license].Confidence > detectedLicenseConfidence {
-			detectedLicense = license
-			detectedLicenseConfidence = detectedLicenses[license].Confidence
+	matches := make([]match, 0, len(bestMatches))
+	for _, m := range bestMatches {
+		if m.confidence < d.minDetectionConfidence {
+			d.logger.Debug().
+				Str("module", module).
+				Str("license", m.license).
+				Str("file", m.file).
+				Float32("confidence", m.confidence).
+				Float32("minConfidence", d.minDetectionConfidence).
+				Msg("detection confidence for license is too low")
+			continue
 		}
+		matches = append(matches, m)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].file < matches[j].file
+	})
+
+	return matches, nil
+}
+
+// licenseFilesFiler is a filer.Filer that only lists the given files of a directory.
+type licenseFilesFiler struct {
+	filer.Filer
+	files []string
+}
+
+// ReadDir implements the filer.Filer interface.
+func (f licenseFilesFiler) ReadDir(path string) ([]filer.File, error) {
+	if path != "" {
+		return nil, nil
+	}
+
+	files := make([]filer.File, 0, len(f.files))
+	for _, file := range f.files {
+		files = append(files, filer.File{Name: file})
+	}
+
+	return files, nil
+}
+
+// expression builds an SPDX license expression from the detected licenses,
+// as long as the module states how they relate to each other.
+//
+// Licenses of the root directory are combined using the expression of an SPDX license identifier
+// in one of the root's license or README files, if present. Otherwise, multiple licenses are
+// only considered a choice, if one of those files says so. Licenses of subdirectories
+// apply in addition to those of the root directory.
+func (d detector) expression(module, dir string, rootFiles []string, rootMatches, subdirMatches []match) string {
+	rootLicenses := distinctLicenses(rootMatches)
+	subdirLicenses := distinctLicenses(subdirMatches)
+	if len(rootLicenses)+len(subdirLicenses) < 2 {
+		return ""
+	}
+
+	// Files that matched a license are excluded when looking for a choice,
+	// as license texts themselves may contain phrases like "at your option".
+	matchedFiles := make(map[string]bool, len(rootMatches))
+	for _, m := range rootMatches {
+		matchedFiles[m.file] = true
 	}
 
-	if detectedLicense == "" {
-		return []cdx.License{}, nil
+	var declaredExpression string
+	var statesChoice bool
+	for _, file := range rootFiles {
+		name := strings.ToLower(pathpkg.Base(file))
+		if !licenseFileRegex.MatchString(name) && !readmeFileRegex.MatchString(name) {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			d.logger.Debug().Err(err).Str("module", module).Str("file", file).Msg("failed to read file")
+			continue
+		}
+
+		if declaredExpression == "" {
+			for _, submatch := range spdxIdentifierRegex.FindAllSubmatch(content, -1) {
+				if expression := strings.TrimRight(string(submatch[1]), " -"); strings.Contains(expression, " AND ") || strings.Contains(expression, " OR ") {
+					declaredExpression = expression
+					break
+				}
+			}
+		}
+		if !matchedFiles[file] && !statesChoice {
+			statesChoice = containsChoice(content)
+		}
 	}
-	if detectedLicenseConfidence < d.minDetectionConfidence {
+
+	var rootExpression string
+	switch {
+	case declaredExpression != "":
+		rootExpression = declaredExpression
+	case len(rootLicenses) == 1:
+		rootExpression = rootLicenses[0]
+	case len(rootLicenses) > 1 && statesChoice:
+		rootExpression = strings.Join(rootLicenses, " OR ")
+	default:
 		d.logger.Debug().
-			Str("module", fmt.Sprintf("%s@%s", path, version)).
-			Str("license", detectedLicense).
-			Float32("confidence", detectedLicenseConfidence).
-			Float32("minConfidence", d.minDetectionConfidence).
-			Msg("detection confidence for license is too low")
-		return []cdx.License{}, nil
-	}
-
-	d.logger.Debug().
-		Str("module", fmt.Sprintf("%s@%s", path, version)).
-		Str("license", detectedLicense).
-		Float32("confidence", detectedLicenseConfidence).
-		Msg("license detected")
-
-	return []cdx.License{
-		{
-			ID: detectedLicense,
-		},
-	}, nil
+			Str("module", module).
+			Strs("licenses", append(rootLicenses, subdirLicenses...)).
+			Msg("unable to determine how detected licenses relate to each other")
+		return ""
+	}
+
+	terms := []string{rootExpression}
+	if strings.Contains(rootExpression, " OR ") && len(subdirLicenses) > 0 {
+		terms[0] = "(" + rootExpression + ")"
+	}
+	for _, license := range subdirLicenses {
+		if license != rootExpression {
+			terms = append(terms, license)
+		}
+	}
+	if len(terms) == 1 && !strings.Contains(rootExpression, " ") {
+		return ""
+	}
+
+	return strings.Join(terms, " AND ")
+}
+
+// containsChoice checks whether content states that licensees may choose between multiple licenses.
+func containsChoice(content []byte) bool {
+	for _, loc := range choiceRegex.FindAllIndex(content, -1) {
+		if !laterVersionRegex.Match(content[loc[1]:]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// distinctLicenses returns the sorted, distinct licenses of matches.
+func distinctLicenses(matches []match) []string {
+	licenses := make([]string, 0, len(matches))
+	for _, m := range matches {
+		licenses = append(licenses, m.license)
+	}
+	sort.Strings(licenses)
+
+	return slices.Compact(licenses)
 }
@@ -18,11 +18,17 @@
 package local
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
+	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/CycloneDX/cyclonedx-gomod/pkg/licensedetect"
 )
 
 func TestDetector_Detect(t *testing.T) {
@@ -31,6 +37,8 @@ func TestDetector_Detect(t *testing.T) {
 		require.NoError(t, err)
 		require.Len(t, licenses, 1)
 		assert.Equal(t, "Apache-2.0", licenses[0].ID)
+		require.NotNil(t, licenses[0].Properties)
+		assert.Contains(t, *licenses[0].Properties, cdx.Property{Name: "cdx:gomod:license:file", Value: "LICENSE"})
 	})
 
 	t.Run("NoLicenseDetected", func(t *testing.T) {
@@ -39,3 +47,176 @@ func TestDetector_Detect(t *testing.T) {
 		require.Empty(t, licenses)
 	})
 }
+
+func TestDetector_DetectContext(t *testing.T) {
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		detector := NewDetector(zerolog.Nop(), DefaultMinDetectionConfidence).(licensedetect.ContextDetector)
+		licenses, err := detector.DetectContext(ctx, "path", "version", "../../../")
+		require.ErrorIs(t, err, context.Canceled)
+		require.Nil(t, licenses)
+	})
+}
+
+// writeModule writes a module with the given files to a temporary directory.
+// Contents of the form "@name" are replaced by the contents of the respective license text.
+func writeModule(t *testing.T, files map[string]string) string {
+	apacheText, err := os.ReadFile("../../../LICENSE")
+	require.NoError(t, err)
+	mitText, err := os.ReadFile("./testdata/MIT.txt")
+	require.NoError(t, err)
+	texts := map[string]string{
+		"@Apache-2.0": string(apacheText),
+		"@MIT":        string(mitText),
+	}
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if text, ok := texts[content]; ok {
+			content = text
+		}
+
+		filePath := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0o700))
+		require.NoError(t, os.WriteFile(filePath, []byte(content), 0o600))
+	}
+
+	return dir
+}
+
+func TestDetector_DetectExpression(t *testing.T) {
+	detector := NewDetector(zerolog.Nop(), DefaultMinDetectionConfidence).(licensedetect.ExpressionDetector)
+
+	detectExpression := func(t *testing.T, dir string) ([]string, string) {
+		licenses, expression, err := detector.DetectExpression(context.Background(), "path", "version", dir)
+		require.NoError(t, err)
+
+		matches := make([]string, 0, len(licenses))
+		for _, license := range licenses {
+			require.NotNil(t, license.Properties)
+			for _, property := range *license.Properties {
+				if property.Name == "cdx:gomod:license:file" {
+					matches = append(matches, property.Value+": "+license.ID)
+				}
+			}
+		}
+
+		return matches, expression
+	}
+
+	t.Run("Single", func(t *testing.T) {
+		matches, expression := detectExpression(t, writeModule(t, map[string]string{
+			"LICENSE": "@MIT",
+		}))
+		require.Equal(t, []string{"LICENSE: MIT"}, matches)
+		require.Empty(t, expression)
+	})
+
+	t.Run("Choice", func(t *testing.T) {
+		matches, expression := detectExpression(t, writeModule(t, map[string]string{
+			"LICENSE-APACHE": "@Apache-2.0",
+			"LICENSE-MIT":    "@MIT",
+			"README.md":      "# Foo\n\n## License\n\nLicensed under either of Apache License, Version 2.0 or MIT license at your option.\n",
+		}))
+		require.Equal(t, []string{"LICENSE-APACHE: Apache-2.0", "LICENSE-MIT: MIT"}, matches)
+		require.Equal(t, "Apache-2.0 OR MIT", expression)
+	})
+
+	t.Run("SPDXIdentifier", func(t *testing.T) {
+		matches, expression := detectExpression(t, writeModule(t, map[string]string{
+			"COPYING.md":     "`SPDX-License-Identifier: Apache-2.0 AND MIT`\n\nSome files are licensed under MIT, all others under Apache-2.0.\n",
+			"LICENSE.APACHE": "@Apache-2.0",
+			"LICENSE.MIT":    "@MIT",
+		}))
+		require.Equal(t, []string{"LICENSE.APACHE: Apache-2.0", "LICENSE.MIT: MIT"}, matches)
+		require.Equal(t, "Apache-2.0 AND MIT", expression)
+	})
+
+	t.Run("NoStatement", func(t *testing.T) {
+		matches, expression := detectExpression(t, writeModule(t, map[string]string{
+			"LICENSE-APACHE": "@Apache-2.0",
+			"LICENSE-MIT":    "@MIT",
+		}))
+		require.Equal(t, []string{"LICENSE-APACHE: Apache-2.0", "LICENSE-MIT: MIT"}, matches)
+		require.Empty(t, expression)
+	})
+
+	t.Run("Subdirectory", func(t *testing.T) {
+		matches, expression := detectExpression(t, writeModule(t, map[string]string{
+			"LICENSE":                          "@MIT",
+			"internal/third_party/foo/LICENSE": "@Apache-2.0",
+			"internal/third_party/foo/foo.go":  "package foo",
+			"internal/license.go":              "package internal",
+			"nested/go.mod":                    "module example.com/nested",
+			"nested/LICENSE":                   "@Apache-2.0",
+			"testdata/LICENSE":                 "@Apache-2.0",
+			"vendor/example.com/bar/LICENSE":   "@Apache-2.0",
+		}))
+		require.Equal(t, []string{"LICENSE: MIT", "internal/third_party/foo/LICENSE: Apache-2.0"}, matches)
+		require.Equal(t, "MIT AND Apache-2.0", expression)
+	})
+
+	t.Run("ChoiceAndSubdirectory", func(t *testing.T) {
+		matches, expression := detectExpression(t, writeModule(t, map[string]string{
+			"LICENSE-APACHE":      "@Apache-2.0",
+			"LICENSE-MIT":         "@MIT",
+			"README":              "This project is dual-licensed under Apache-2.0 and MIT.",
+			"third_party/LICENSE": "@MIT",
+		}))
+		require.Equal(t, []string{"LICENSE-APACHE: Apache-2.0", "LICENSE-MIT: MIT", "third_party/LICENSE: MIT"}, matches)
+		require.Equal(t, "(Apache-2.0 OR MIT) AND MIT", expression)
+	})
+}
+
+func TestContainsChoice(t *testing.T) {
+	require.True(t, containsChoice([]byte("Licensed under either of Apache-2.0 or MIT, at your option.")))
+	require.True(t, containsChoice([]byte("This project is dual-licensed under the MIT and Apache licenses.")))
+	require.True(t, containsChoice([]byte("It is available under the terms of either the MIT or the BSD license.")))
+	require.False(t, containsChoice([]byte("either version 2 of the License, or\n(at your option) any later version.")))
+	require.False(t, containsChoice([]byte("Licensed under the MIT license.")))
+}
+
+func TestDetector_CacheKey(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "LICENSE"), []byte("license"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "licenses"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "licenses", "MIT.txt"), []byte("mit"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "internal"), 0o700))
+
+	detector := NewDetector(zerolog.Nop(), DefaultMinDetectionConfidence).(licensedetect.CacheableDetector)
+	cacheKey := func() string {
+		key, err := detector.CacheKey("path", "version", dir)
+		require.NoError(t, err)
+		return key
+	}
+
+	key := cacheKey()
+	require.Equal(t, key, cacheKey())
+
+	// Only license files are inspected in other directories
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "internal", "foo.go"), []byte("package internal"), 0o600))
+	require.Equal(t, key, cacheKey())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "licenses", "MIT.txt"), []byte("mit license"), 0o600))
+	require.NotEqual(t, key, cacheKey())
+	key = cacheKey()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("readme"), 0o600))
+	require.NotEqual(t, key, cacheKey())
+	key = cacheKey()
+
+	// License files in subdirectories are inspected as well
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "internal", "LICENSE"), []byte("license"), 0o600))
+	require.NotEqual(t, key, cacheKey())
+	key = cacheKey()
+
+	otherDetector := NewDetector(zerolog.Nop(), 0.5).(licensedetect.CacheableDetector)
+	otherKey, err := otherDetector.CacheKey("path", "version", dir)
+	require.NoError(t, err)
+	require.NotEqual(t, key, otherKey)
+
+	_, err = detector.CacheKey("path", "version", filepath.Join(dir, "doesnotexist"))
+	require.Error(t, err)
+}